@@ -495,6 +495,11 @@ func (in *NamespacedVerifierStatus) DeepCopy() *NamespacedVerifierStatus {
 func (in *PluginSource) DeepCopyInto(out *PluginSource) {
 	*out = *in
 	in.AuthProvider.DeepCopyInto(&out.AuthProvider)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(PluginVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSource.
@@ -507,6 +512,22 @@ func (in *PluginSource) DeepCopy() *PluginSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginVerification) DeepCopyInto(out *PluginVerification) {
+	*out = *in
+	in.Verifier.DeepCopyInto(&out.Verifier)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginVerification.
+func (in *PluginVerification) DeepCopy() *PluginVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
@@ -21,8 +21,6 @@ limitations under the License.
 package v1alpha1
 
 import (
-	unsafe "unsafe"
-
 	unversioned "github.com/ratify-project/ratify/api/unversioned"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
@@ -75,11 +73,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*unversioned.PluginSource)(nil), (*PluginSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(a.(*unversioned.PluginSource), b.(*PluginSource), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*PolicyList)(nil), (*unversioned.PolicyList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_PolicyList_To_unversioned_PolicyList(a.(*PolicyList), b.(*unversioned.PolicyList), scope)
 	}); err != nil {
@@ -165,6 +158,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*unversioned.PluginSource)(nil), (*PluginSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(a.(*unversioned.PluginSource), b.(*PluginSource), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*unversioned.PolicySpec)(nil), (*PolicySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_unversioned_PolicySpec_To_v1alpha1_PolicySpec(a.(*unversioned.PolicySpec), b.(*PolicySpec), scope)
 	}); err != nil {
@@ -336,14 +334,10 @@ func Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(in *PluginSource,
 func autoConvert_unversioned_PluginSource_To_v1alpha1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	// WARNING: in.Verification requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_unversioned_PluginSource_To_v1alpha1_PluginSource is an autogenerated conversion function.
-func Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
-	return autoConvert_unversioned_PluginSource_To_v1alpha1_PluginSource(in, out, s)
-}
-
 func autoConvert_v1alpha1_Policy_To_unversioned_Policy(in *Policy, out *unversioned.Policy, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_PolicySpec_To_unversioned_PolicySpec(&in.Spec, &out.Spec, s); err != nil {
@@ -517,7 +511,15 @@ func Convert_unversioned_StoreList_To_v1alpha1_StoreList(in *unversioned.StoreLi
 func autoConvert_v1alpha1_StoreSpec_To_unversioned_StoreSpec(in *StoreSpec, out *unversioned.StoreSpec, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Address = in.Address
-	out.Source = (*unversioned.PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(unversioned.PluginSource)
+		if err := Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -531,7 +533,15 @@ func autoConvert_unversioned_StoreSpec_To_v1alpha1_StoreSpec(in *unversioned.Sto
 	out.Name = in.Name
 	// WARNING: in.Version requires manual conversion: does not exist in peer-type
 	out.Address = in.Address
-	out.Source = (*PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(PluginSource)
+		if err := Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -630,7 +640,15 @@ func autoConvert_v1alpha1_VerifierSpec_To_unversioned_VerifierSpec(in *VerifierS
 	out.Name = in.Name
 	out.ArtifactTypes = in.ArtifactTypes
 	out.Address = in.Address
-	out.Source = (*unversioned.PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(unversioned.PluginSource)
+		if err := Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -646,7 +664,15 @@ func autoConvert_unversioned_VerifierSpec_To_v1alpha1_VerifierSpec(in *unversion
 	// WARNING: in.Version requires manual conversion: does not exist in peer-type
 	out.ArtifactTypes = in.ArtifactTypes
 	out.Address = in.Address
-	out.Source = (*PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(PluginSource)
+		if err := Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
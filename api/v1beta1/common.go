@@ -27,4 +27,20 @@ type PluginSource struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// AuthProvider to use to authenticate to the OCI Artifact source, optional
 	AuthProvider runtime.RawExtension `json:"authProvider,omitempty"`
+
+	// Verification of the OCI Artifact before the plugin is installed, optional
+	Verification *PluginVerification `json:"verification,omitempty"`
+}
+
+// PluginVerification defines how the plugin artifact is verified before the plugin is installed
+type PluginVerification struct {
+	// Digest of the plugin artifact manifest that must match the resolved artifact, optional
+	Digest string `json:"digest,omitempty"`
+
+	// Skip explicitly installs the plugin without verification, which is refused if neither digest, verifier nor skip is configured, optional
+	Skip bool `json:"skip,omitempty"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// Verifier is the configuration of a built-in notation or cosign verifier used to verify the signature of the plugin artifact, optional
+	Verifier runtime.RawExtension `json:"verifier,omitempty"`
 }
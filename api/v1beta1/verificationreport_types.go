@@ -0,0 +1,109 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VerificationReportSpec defines the image of running pods reported by a
+// VerificationReport
+type VerificationReportSpec struct {
+	// Important: Run "make manifests" to regenerate code after modifying this file
+
+	// Reference of the image by digest
+	Subject string `json:"subject"`
+	// References of the image in the specs of the pods running it
+	// +optional
+	Images []string `json:"images,omitempty"`
+}
+
+// VerificationFailure describes a failing verifier report of the subject or of
+// a nested artifact
+type VerificationFailure struct {
+	// Name of the verifier
+	// +optional
+	Verifier string `json:"verifier,omitempty"`
+	// Subject verified by the verifier
+	// +optional
+	Subject string `json:"subject,omitempty"`
+	// Digest of the referrer verified by the verifier
+	// +optional
+	ReferenceDigest string `json:"referencedigest,omitempty"`
+	// Artifact type of the referrer verified by the verifier
+	// +optional
+	ArtifactType string `json:"artifacttype,omitempty"`
+	// Message of the verifier report
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Reason of the verifier error
+	// +optional
+	ErrorReason string `json:"errorreason,omitempty"`
+}
+
+// VerificationReportStatus defines the result of the last verification of the
+// image
+type VerificationReportStatus struct {
+	// Important: Run "make manifests" to regenerate code after modifying this file
+
+	// Is successful if the image passed the policy in the last verification
+	IsSuccess bool `json:"issuccess"`
+	// Error message if the image could not be verified
+	// +optional
+	Error string `json:"error,omitempty"`
+	// Truncated error message if the message is too long
+	// +optional
+	BriefError string `json:"brieferror,omitempty"`
+	// The time stamp of the last verification
+	// +optional
+	LastVerifiedTime *metav1.Time `json:"lastverifiedtime,omitempty"`
+	// The time stamp of the last change of IsSuccess
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lasttransitiontime,omitempty"`
+	// Failing verifier reports of the last verification
+	// +optional
+	Failures []VerificationFailure `json:"failures,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope="Namespaced"
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Subject",type=string,JSONPath=`.spec.subject`
+// +kubebuilder:printcolumn:name="IsSuccess",type=boolean,JSONPath=`.status.issuccess`
+// +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.brieferror`
+// +kubebuilder:printcolumn:name="LastVerifiedTime",type=date,JSONPath=`.status.lastverifiedtime`
+// VerificationReport is the Schema for the verificationreports API
+type VerificationReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VerificationReportSpec   `json:"spec,omitempty"`
+	Status VerificationReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// VerificationReportList contains a list of VerificationReport
+type VerificationReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VerificationReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VerificationReport{}, &VerificationReportList{})
+}
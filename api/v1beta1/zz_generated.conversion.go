@@ -286,6 +286,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PluginVerification)(nil), (*unversioned.PluginVerification)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PluginVerification_To_unversioned_PluginVerification(a.(*PluginVerification), b.(*unversioned.PluginVerification), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*unversioned.PluginVerification)(nil), (*PluginVerification)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_PluginVerification_To_v1beta1_PluginVerification(a.(*unversioned.PluginVerification), b.(*PluginVerification), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Policy)(nil), (*unversioned.Policy)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_Policy_To_unversioned_Policy(a.(*Policy), b.(*unversioned.Policy), scope)
 	}); err != nil {
@@ -1044,6 +1054,7 @@ func Convert_unversioned_NamespacedVerifierStatus_To_v1beta1_NamespacedVerifierS
 func autoConvert_v1beta1_PluginSource_To_unversioned_PluginSource(in *PluginSource, out *unversioned.PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	out.Verification = (*unversioned.PluginVerification)(unsafe.Pointer(in.Verification))
 	return nil
 }
 
@@ -1055,6 +1066,7 @@ func Convert_v1beta1_PluginSource_To_unversioned_PluginSource(in *PluginSource,
 func autoConvert_unversioned_PluginSource_To_v1beta1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	out.Verification = (*PluginVerification)(unsafe.Pointer(in.Verification))
 	return nil
 }
 
@@ -1063,6 +1075,30 @@ func Convert_unversioned_PluginSource_To_v1beta1_PluginSource(in *unversioned.Pl
 	return autoConvert_unversioned_PluginSource_To_v1beta1_PluginSource(in, out, s)
 }
 
+func autoConvert_v1beta1_PluginVerification_To_unversioned_PluginVerification(in *PluginVerification, out *unversioned.PluginVerification, s conversion.Scope) error {
+	out.Digest = in.Digest
+	out.Skip = in.Skip
+	out.Verifier = in.Verifier
+	return nil
+}
+
+// Convert_v1beta1_PluginVerification_To_unversioned_PluginVerification is an autogenerated conversion function.
+func Convert_v1beta1_PluginVerification_To_unversioned_PluginVerification(in *PluginVerification, out *unversioned.PluginVerification, s conversion.Scope) error {
+	return autoConvert_v1beta1_PluginVerification_To_unversioned_PluginVerification(in, out, s)
+}
+
+func autoConvert_unversioned_PluginVerification_To_v1beta1_PluginVerification(in *unversioned.PluginVerification, out *PluginVerification, s conversion.Scope) error {
+	out.Digest = in.Digest
+	out.Skip = in.Skip
+	out.Verifier = in.Verifier
+	return nil
+}
+
+// Convert_unversioned_PluginVerification_To_v1beta1_PluginVerification is an autogenerated conversion function.
+func Convert_unversioned_PluginVerification_To_v1beta1_PluginVerification(in *unversioned.PluginVerification, out *PluginVerification, s conversion.Scope) error {
+	return autoConvert_unversioned_PluginVerification_To_v1beta1_PluginVerification(in, out, s)
+}
+
 func autoConvert_v1beta1_Policy_To_unversioned_Policy(in *Policy, out *unversioned.Policy, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1beta1_PolicySpec_To_unversioned_PolicySpec(&in.Spec, &out.Spec, s); err != nil {
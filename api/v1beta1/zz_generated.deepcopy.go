@@ -593,6 +593,11 @@ func (in *NamespacedVerifierStatus) DeepCopy() *NamespacedVerifierStatus {
 func (in *PluginSource) DeepCopyInto(out *PluginSource) {
 	*out = *in
 	in.AuthProvider.DeepCopyInto(&out.AuthProvider)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(PluginVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSource.
@@ -605,6 +610,22 @@ func (in *PluginSource) DeepCopy() *PluginSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginVerification) DeepCopyInto(out *PluginVerification) {
+	*out = *in
+	in.Verifier.DeepCopyInto(&out.Verifier)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginVerification.
+func (in *PluginVerification) DeepCopy() *PluginVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
@@ -790,6 +811,128 @@ func (in *StoreStatus) DeepCopy() *StoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationFailure) DeepCopyInto(out *VerificationFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationFailure.
+func (in *VerificationFailure) DeepCopy() *VerificationFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationReport) DeepCopyInto(out *VerificationReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationReport.
+func (in *VerificationReport) DeepCopy() *VerificationReport {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VerificationReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationReportList) DeepCopyInto(out *VerificationReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VerificationReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationReportList.
+func (in *VerificationReportList) DeepCopy() *VerificationReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VerificationReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationReportSpec) DeepCopyInto(out *VerificationReportSpec) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationReportSpec.
+func (in *VerificationReportSpec) DeepCopy() *VerificationReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationReportStatus) DeepCopyInto(out *VerificationReportStatus) {
+	*out = *in
+	if in.LastVerifiedTime != nil {
+		in, out := &in.LastVerifiedTime, &out.LastVerifiedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]VerificationFailure, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationReportStatus.
+func (in *VerificationReportStatus) DeepCopy() *VerificationReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Verifier) DeepCopyInto(out *Verifier) {
 	*out = *in
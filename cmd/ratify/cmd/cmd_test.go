@@ -81,3 +81,12 @@ func TestShowRefManifest(t *testing.T) {
 		t.Errorf("error expected")
 	}
 }
+
+func TestExport_MissingParameters(t *testing.T) {
+	if err := export(exportCmdOptions{output: "bundle.tar"}); err == nil {
+		t.Fatalf("export() expected error for missing subject")
+	}
+	if err := export(exportCmdOptions{subject: "localhost:5000/net-monitor:v1"}); err == nil {
+		t.Fatalf("export() expected error for missing output")
+	}
+}
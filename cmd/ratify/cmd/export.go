@@ -0,0 +1,134 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ratify-project/ratify/config"
+	"github.com/ratify-project/ratify/internal/logger"
+	sf "github.com/ratify-project/ratify/pkg/referrerstore/factory"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportUse = "export"
+)
+
+type exportCmdOptions struct {
+	configFilePath string
+	subject        string
+	output         string
+}
+
+func NewCmdExport(argv ...string) *cobra.Command {
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Export a subject and its referrers to an OCI layout directory
+  %[1]s export -c ./config.json -s myregistry/myrepo@sha256:34343 -o ./bundle
+
+  # Export a subject and its referrers to an oci-archive tarball
+  %[1]s export -c ./config.json -s myregistry/myrepo:v1 -o ./bundle.tar`, strings.Join(argv, " "))
+
+	var opts exportCmdOptions
+
+	cmd := &cobra.Command{
+		Use:     exportUse,
+		Short:   "Export a subject and its referrers to an OCI layout",
+		Example: eg,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return export(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVarP(&opts.subject, "subject", "s", "", "Subject Reference")
+	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
+	flags.StringVarP(&opts.output, "output", "o", "", "Output OCI layout directory, or oci-archive tarball if the path ends with .tar")
+	return cmd
+}
+
+func export(opts exportCmdOptions) error {
+	if opts.subject == "" {
+		return errors.New("subject parameter is required")
+	}
+	if opts.output == "" {
+		return errors.New("output parameter is required")
+	}
+
+	subRef, err := utils.ParseSubjectReference(opts.subject)
+	if err != nil {
+		return err
+	}
+
+	cf, err := config.Load(opts.configFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := logger.InitLogConfig(cf.LoggerConfig); err != nil {
+		return err
+	}
+
+	stores, err := sf.CreateStoresFromConfig(cf.StoresConfig, config.GetDefaultPluginPath())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	subjectDesc, err := su.ResolveSubjectDescriptor(ctx, &stores, subRef)
+	if err != nil {
+		return err
+	}
+	subRef.Digest = subjectDesc.Digest
+
+	layoutPath := opts.output
+	isArchive := strings.HasSuffix(opts.output, archiveExtension)
+	if isArchive {
+		// the layout is written to a temporary directory and then archived
+		if layoutPath, err = os.MkdirTemp("", "ratify-export-"); err != nil {
+			return fmt.Errorf("failed to create the temporary OCI layout: %w", err)
+		}
+		defer os.RemoveAll(layoutPath)
+	}
+
+	exporter, err := newBundleExporter(layoutPath)
+	if err != nil {
+		return err
+	}
+	if err := exporter.exportSubject(ctx, stores, subRef, subjectDesc.Descriptor); err != nil {
+		return err
+	}
+
+	if isArchive {
+		if err := writeLayoutArchive(layoutPath, opts.output); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Exported %s with %d referrers (%d manifests, %d blobs) to %s\n", subRef.Original, exporter.referrers, exporter.manifests, exporter.blobs, opts.output)
+	return nil
+}
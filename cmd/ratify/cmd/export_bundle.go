@@ -0,0 +1,369 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+)
+
+const (
+	archiveExtension        = ".tar"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// bundleExporter copies a subject and its referrer graph into an OCI layout
+type bundleExporter struct {
+	target *ocitarget.Store
+	// exported records the descriptors of the content copied to the layout
+	exported map[godigest.Digest]oci.Descriptor
+	// referrersIndex records the referrers of each subject of the graph,
+	// written to the layout as referrers indexes following the tag schema
+	referrersIndex map[godigest.Digest][]oci.Descriptor
+	// subjects keeps the order in which subjects were walked so that the
+	// referrers indexes are written deterministically
+	subjects  []godigest.Digest
+	referrers int
+	manifests int
+	blobs     int
+}
+
+func newBundleExporter(layoutPath string) (*bundleExporter, error) {
+	target, err := ocitarget.New(layoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OCI layout at %s: %w", layoutPath, err)
+	}
+	return &bundleExporter{
+		target:         target,
+		exported:       make(map[godigest.Digest]oci.Descriptor),
+		referrersIndex: make(map[godigest.Digest][]oci.Descriptor),
+	}, nil
+}
+
+// exportSubject copies the subject manifest from the first store able to
+// provide it, then the referrer graph of the subject found in every store.
+// The subject is tagged in the layout with the tag of the reference if any.
+func (e *bundleExporter) exportSubject(ctx context.Context, stores []referrerstore.ReferrerStore, subRef common.Reference, subjectDesc oci.Descriptor) error {
+	var err error
+	for _, store := range stores {
+		if subjectDesc, err = e.copyManifest(ctx, store, subRef, subjectDesc, false); err == nil {
+			break
+		}
+		logger.GetLogger(ctx, logOpt).Warnf("failed to export subject %s from store %s: %v", subRef.Original, store.Name(), err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export subject %s from any store: %w", subRef.Original, err)
+	}
+	if subRef.Tag != "" {
+		if err := e.target.Tag(ctx, subjectDesc, subRef.Tag); err != nil {
+			return fmt.Errorf("failed to tag subject %s: %w", subRef.Original, err)
+		}
+	}
+
+	for _, store := range stores {
+		if err := e.exportReferrers(ctx, store, subRef, subjectDesc); err != nil {
+			return err
+		}
+	}
+	return e.writeReferrersIndexes(ctx)
+}
+
+// exportReferrers walks the referrers of the subject in the store the same
+// way discover does, and copies every referrer manifest and blob
+func (e *bundleExporter) exportReferrers(ctx context.Context, store referrerstore.ReferrerStore, subRef common.Reference, subjectDesc oci.Descriptor) error {
+	var continuationToken string
+	for {
+		lr, err := store.ListReferrers(ctx, subRef, nil, continuationToken, &ocispecs.SubjectDescriptor{Descriptor: subjectDesc})
+		if err != nil {
+			return fmt.Errorf("failed to get referrers list from subject %s: %w", subRef.Original, err)
+		}
+
+		for _, ref := range lr.Referrers {
+			desc := ref.Descriptor
+			if desc.ArtifactType == "" {
+				desc.ArtifactType = ref.ArtifactType
+			}
+			if e.hasReferrer(subjectDesc.Digest, desc.Digest) {
+				continue
+			}
+			referrerRef := common.Reference{
+				Path:     subRef.Path,
+				Digest:   desc.Digest,
+				Original: fmt.Sprintf("%s@%s", subRef.Path, desc.Digest),
+			}
+			_, walked := e.exported[desc.Digest]
+			exportedDesc, err := e.copyManifest(ctx, store, referrerRef, desc, true)
+			if err != nil {
+				return fmt.Errorf("failed to export referrer %s of %s: %w", desc.Digest, subRef.Original, err)
+			}
+			desc.MediaType, desc.Size = exportedDesc.MediaType, exportedDesc.Size
+			e.addReferrer(subjectDesc.Digest, desc)
+			if walked {
+				// the referrers of a manifest are only walked once to
+				// avoid cycles in the graph
+				continue
+			}
+			if err := e.exportReferrers(ctx, store, referrerRef, desc); err != nil {
+				return err
+			}
+		}
+
+		continuationToken = lr.NextToken
+		if continuationToken == "" {
+			return nil
+		}
+	}
+}
+
+func (e *bundleExporter) hasReferrer(subject godigest.Digest, referrer godigest.Digest) bool {
+	for _, desc := range e.referrersIndex[subject] {
+		if desc.Digest == referrer {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *bundleExporter) addReferrer(subject godigest.Digest, referrer oci.Descriptor) {
+	if _, ok := e.referrersIndex[subject]; !ok {
+		e.subjects = append(e.subjects, subject)
+	}
+	e.referrersIndex[subject] = append(e.referrersIndex[subject], referrer)
+	e.referrers++
+}
+
+// copyManifest copies the manifest to the layout and returns the descriptor
+// of the copied manifest. If withContent is set, the blobs and child
+// manifests of the manifest are copied as well.
+func (e *bundleExporter) copyManifest(ctx context.Context, store referrerstore.ReferrerStore, subRef common.Reference, desc oci.Descriptor, withContent bool) (oci.Descriptor, error) {
+	if exported, ok := e.exported[desc.Digest]; ok {
+		return exported, nil
+	}
+	manifestBytes, err := fetchManifest(ctx, store, subRef, desc)
+	if err != nil {
+		return oci.Descriptor{}, err
+	}
+	if err := verifyContent(desc.Digest, manifestBytes); err != nil {
+		return oci.Descriptor{}, err
+	}
+	if desc.MediaType == "" {
+		desc.MediaType = manifestMediaType(manifestBytes)
+	}
+	exported, err := e.push(ctx, desc, manifestBytes)
+	if err != nil {
+		return oci.Descriptor{}, err
+	}
+	e.manifests++
+	if !withContent {
+		return exported, nil
+	}
+
+	manifests, blobs, err := manifestContent(desc.MediaType, manifestBytes)
+	if err != nil {
+		return oci.Descriptor{}, fmt.Errorf("failed to parse manifest %s: %w", desc.Digest, err)
+	}
+	for _, blob := range blobs {
+		if _, ok := e.exported[blob.Digest]; ok {
+			continue
+		}
+		blobBytes, err := store.GetBlobContent(ctx, subRef, blob.Digest)
+		if err != nil {
+			return oci.Descriptor{}, fmt.Errorf("failed to fetch blob %s: %w", blob.Digest, err)
+		}
+		if err := verifyContent(blob.Digest, blobBytes); err != nil {
+			return oci.Descriptor{}, err
+		}
+		if _, err := e.push(ctx, blob, blobBytes); err != nil {
+			return oci.Descriptor{}, err
+		}
+		e.blobs++
+	}
+	for _, manifest := range manifests {
+		if _, err := e.copyManifest(ctx, store, subRef, manifest, true); err != nil {
+			return oci.Descriptor{}, err
+		}
+	}
+	return exported, nil
+}
+
+// push writes the content to the layout unless it already exists there
+func (e *bundleExporter) push(ctx context.Context, desc oci.Descriptor, content []byte) (oci.Descriptor, error) {
+	desc = oci.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: int64(len(content))}
+	exists, err := e.target.Exists(ctx, desc)
+	if err != nil {
+		return oci.Descriptor{}, fmt.Errorf("failed to check content %s in the OCI layout: %w", desc.Digest, err)
+	}
+	if !exists {
+		if err := e.target.Push(ctx, desc, bytes.NewReader(content)); err != nil {
+			return oci.Descriptor{}, fmt.Errorf("failed to write content %s to the OCI layout: %w", desc.Digest, err)
+		}
+	}
+	e.exported[desc.Digest] = desc
+	return desc, nil
+}
+
+// writeReferrersIndexes writes a referrers index for every subject of the
+// graph tagged following the referrers tag schema, so that referrers not
+// declaring their subject, such as Cosign signatures attached by tag, can be
+// discovered from the layout
+func (e *bundleExporter) writeReferrersIndexes(ctx context.Context) error {
+	for _, subject := range e.subjects {
+		index := oci.Index{
+			MediaType: oci.MediaTypeImageIndex,
+			Manifests: e.referrersIndex[subject],
+		}
+		index.SchemaVersion = 2
+		indexBytes, err := json.Marshal(index)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the referrers index of %s: %w", subject, err)
+		}
+		indexDesc := oci.Descriptor{
+			MediaType: oci.MediaTypeImageIndex,
+			Digest:    godigest.FromBytes(indexBytes),
+			Size:      int64(len(indexBytes)),
+		}
+		if _, err := e.push(ctx, indexDesc, indexBytes); err != nil {
+			return err
+		}
+		if err := e.target.Tag(ctx, indexDesc, strings.Replace(subject.String(), ":", "-", 1)); err != nil {
+			return fmt.Errorf("failed to tag the referrers index of %s: %w", subject, err)
+		}
+	}
+	return nil
+}
+
+// fetchManifest returns the manifest as stored. Stores that cannot return
+// manifests are asked for the manifest as a blob.
+func fetchManifest(ctx context.Context, store referrerstore.ReferrerStore, subRef common.Reference, desc oci.Descriptor) ([]byte, error) {
+	if manifestStore, ok := store.(referrerstore.ManifestStore); ok {
+		return manifestStore.GetManifestContent(ctx, subRef, desc)
+	}
+	return store.GetBlobContent(ctx, subRef, desc.Digest)
+}
+
+// verifyContent checks that the content matches the digest so that the
+// exported bundle keeps the digests signed in the referrers
+func verifyContent(expected godigest.Digest, content []byte) error {
+	if err := expected.Validate(); err != nil {
+		return fmt.Errorf("invalid digest %s: %w", expected, err)
+	}
+	if actual := expected.Algorithm().FromBytes(content); actual != expected {
+		return fmt.Errorf("content digest %s does not match the expected digest %s", actual, expected)
+	}
+	return nil
+}
+
+// manifestMediaType returns the media type declared by the manifest
+func manifestMediaType(manifestBytes []byte) string {
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+	}
+	_ = json.Unmarshal(manifestBytes, &manifest)
+	return manifest.MediaType
+}
+
+// manifestContent returns the child manifests and the blobs of the manifest.
+// The subject of the manifest is not part of its content.
+func manifestContent(mediaType string, manifestBytes []byte) ([]oci.Descriptor, []oci.Descriptor, error) {
+	switch {
+	case mediaType == oci.MediaTypeImageManifest || mediaType == dockerManifestMediaType:
+		var manifest oci.Manifest
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return nil, nil, err
+		}
+		return nil, append([]oci.Descriptor{manifest.Config}, manifest.Layers...), nil
+	case mediaType == ocispecs.MediaTypeArtifactManifest:
+		var manifest ocispecs.ReferenceManifest
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return nil, nil, err
+		}
+		return nil, manifest.Blobs, nil
+	case ocispecs.IsImageIndex(mediaType):
+		var index oci.Index
+		if err := json.Unmarshal(manifestBytes, &index); err != nil {
+			return nil, nil, err
+		}
+		return index.Manifests, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported manifest media type %s", mediaType)
+	}
+}
+
+// writeLayoutArchive writes the OCI layout directory as an oci-archive tarball
+func writeLayoutArchive(layoutPath string, archivePath string) (err error) {
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive %s: %w", archivePath, err)
+	}
+	defer func() {
+		if closeErr := archive.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	tw := tar.NewWriter(archive)
+	err = filepath.WalkDir(layoutPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == layoutPath {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(layoutPath, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", archivePath, err)
+	}
+	return tw.Close()
+}
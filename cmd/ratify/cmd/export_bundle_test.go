@@ -0,0 +1,253 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"oras.land/oras-go/v2/content"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+)
+
+// manifestTestStore is a memory store returning manifests as stored
+type manifestTestStore struct {
+	*mocks.MemoryTestStore
+	rawManifests map[godigest.Digest][]byte
+}
+
+func (s *manifestTestStore) GetManifestContent(_ context.Context, _ common.Reference, desc oci.Descriptor) ([]byte, error) {
+	if manifest, ok := s.rawManifests[desc.Digest]; ok {
+		return manifest, nil
+	}
+	return nil, fmt.Errorf("manifest %s not found", desc.Digest)
+}
+
+type exportTestGraph struct {
+	subject      oci.Descriptor
+	signature    oci.Descriptor
+	sbom         oci.Descriptor
+	sbomSig      oci.Descriptor
+	subjectLayer godigest.Digest
+	rawManifests map[godigest.Digest][]byte
+	blobs        map[godigest.Digest][]byte
+}
+
+func (g *exportTestGraph) addBlob(content string) oci.Descriptor {
+	blob := []byte(content)
+	desc := oci.Descriptor{MediaType: "application/octet-stream", Digest: godigest.FromBytes(blob), Size: int64(len(blob))}
+	g.blobs[desc.Digest] = blob
+	return desc
+}
+
+func (g *exportTestGraph) addManifest(t *testing.T, artifactType string, layer string, subject *oci.Descriptor) oci.Descriptor {
+	t.Helper()
+	manifest := oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       g.addBlob("{}"),
+		Layers:       []oci.Descriptor{g.addBlob(layer)},
+		Subject:      subject,
+	}
+	manifest.SchemaVersion = 2
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	desc := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, ArtifactType: artifactType, Digest: godigest.FromBytes(manifestBytes), Size: int64(len(manifestBytes))}
+	g.rawManifests[desc.Digest] = manifestBytes
+	return desc
+}
+
+// newExportTestGraph creates an image signed and with a signed SBOM
+func newExportTestGraph(t *testing.T) *exportTestGraph {
+	t.Helper()
+	g := &exportTestGraph{rawManifests: map[godigest.Digest][]byte{}, blobs: map[godigest.Digest][]byte{}}
+	g.subject = g.addManifest(t, "", "image layer", nil)
+	g.subjectLayer = godigest.FromString("image layer")
+	g.signature = g.addManifest(t, mocks.SignatureArtifactType, "image signature", &g.subject)
+	g.sbom = g.addManifest(t, mocks.SbomArtifactType, "sbom", &g.subject)
+	g.sbomSig = g.addManifest(t, mocks.SignatureArtifactType, "sbom signature", &g.sbom)
+	return g
+}
+
+func (g *exportTestGraph) memoryStore() *mocks.MemoryTestStore {
+	toReferrers := func(descs ...oci.Descriptor) []ocispecs.ReferenceDescriptor {
+		referrers := []ocispecs.ReferenceDescriptor{}
+		for _, desc := range descs {
+			referrers = append(referrers, ocispecs.ReferenceDescriptor{Descriptor: desc, ArtifactType: desc.ArtifactType})
+		}
+		return referrers
+	}
+	return &mocks.MemoryTestStore{
+		Subjects: map[godigest.Digest]*ocispecs.SubjectDescriptor{
+			g.subject.Digest: {Descriptor: g.subject},
+		},
+		Referrers: map[godigest.Digest][]ocispecs.ReferenceDescriptor{
+			g.subject.Digest: toReferrers(g.signature, g.sbom),
+			g.sbom.Digest:    toReferrers(g.sbomSig),
+		},
+		Blobs: map[godigest.Digest][]byte{},
+	}
+}
+
+// manifestStore returns a store able to return manifests as stored
+func (g *exportTestGraph) manifestStore() referrerstore.ReferrerStore {
+	store := g.memoryStore()
+	for d, blob := range g.blobs {
+		store.Blobs[d] = blob
+	}
+	return &manifestTestStore{MemoryTestStore: store, rawManifests: g.rawManifests}
+}
+
+// blobStore returns a store returning manifests as blobs only
+func (g *exportTestGraph) blobStore() referrerstore.ReferrerStore {
+	store := g.memoryStore()
+	for d, blob := range g.blobs {
+		store.Blobs[d] = blob
+	}
+	for d, manifest := range g.rawManifests {
+		store.Blobs[d] = manifest
+	}
+	return store
+}
+
+func testSubjectReference(subject oci.Descriptor) common.Reference {
+	return common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Tag:      "v1",
+		Digest:   subject.Digest,
+		Original: "localhost:5000/net-monitor:v1",
+	}
+}
+
+// verifyExportedLayout checks that the subject is tagged and the referrer
+// graph can be discovered from the layout
+func verifyExportedLayout(t *testing.T, layout *ocitarget.ReadOnlyStore, g *exportTestGraph) {
+	t.Helper()
+	ctx := context.Background()
+	subjectDesc, err := layout.Resolve(ctx, "v1")
+	if err != nil || subjectDesc.Digest != g.subject.Digest {
+		t.Fatalf("failed to resolve the exported subject: %v", err)
+	}
+
+	wantReferrers := map[godigest.Digest][]godigest.Digest{
+		g.subject.Digest: {g.signature.Digest, g.sbom.Digest},
+		g.sbom.Digest:    {g.sbomSig.Digest},
+	}
+	for subject, want := range wantReferrers {
+		indexDesc, err := layout.Resolve(ctx, strings.Replace(subject.String(), ":", "-", 1))
+		if err != nil {
+			t.Fatalf("failed to resolve the referrers index of %s: %v", subject, err)
+		}
+		indexBytes, err := content.FetchAll(ctx, layout, indexDesc)
+		if err != nil {
+			t.Fatalf("failed to fetch the referrers index of %s: %v", subject, err)
+		}
+		var index oci.Index
+		if err := json.Unmarshal(indexBytes, &index); err != nil {
+			t.Fatalf("failed to parse the referrers index of %s: %v", subject, err)
+		}
+		if len(index.Manifests) != len(want) {
+			t.Fatalf("referrers index of %s has %d manifests, want %d", subject, len(index.Manifests), len(want))
+		}
+		for i, desc := range index.Manifests {
+			if desc.Digest != want[i] || desc.ArtifactType == "" || desc.Size == 0 {
+				t.Fatalf("unexpected referrer %+v of %s", desc, subject)
+			}
+		}
+	}
+
+	for d, blob := range g.blobs {
+		exists, err := layout.Exists(ctx, oci.Descriptor{Digest: d, Size: int64(len(blob))})
+		if err != nil {
+			t.Fatalf("failed to check blob %s: %v", d, err)
+		}
+		// only the manifest of the subject is exported, not its layers
+		if want := d != g.subjectLayer; exists != want {
+			t.Fatalf("blob %s exported = %t, want %t", d, exists, want)
+		}
+	}
+}
+
+// TestExportSubject tests exporting the referrer graph to a layout directory
+// and to a tarball, from stores returning manifests as stored or as blobs
+func TestExportSubject(t *testing.T) {
+	g := newExportTestGraph(t)
+	for name, store := range map[string]referrerstore.ReferrerStore{
+		"manifest store": g.manifestStore(),
+		"blob store":     g.blobStore(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			layoutPath := filepath.Join(t.TempDir(), "bundle")
+			exporter, err := newBundleExporter(layoutPath)
+			if err != nil {
+				t.Fatalf("newBundleExporter() unexpected error: %v", err)
+			}
+			if err := exporter.exportSubject(ctx, []referrerstore.ReferrerStore{store}, testSubjectReference(g.subject), g.subject); err != nil {
+				t.Fatalf("exportSubject() unexpected error: %v", err)
+			}
+			if exporter.referrers != 3 || exporter.manifests != 4 {
+				t.Fatalf("exportSubject() exported %d referrers and %d manifests, want 3 and 4", exporter.referrers, exporter.manifests)
+			}
+
+			layout, err := ocitarget.NewFromFS(ctx, os.DirFS(layoutPath))
+			if err != nil {
+				t.Fatalf("failed to read the exported layout: %v", err)
+			}
+			verifyExportedLayout(t, layout, g)
+
+			archivePath := filepath.Join(t.TempDir(), "bundle.tar")
+			if err := writeLayoutArchive(layoutPath, archivePath); err != nil {
+				t.Fatalf("writeLayoutArchive() unexpected error: %v", err)
+			}
+			archive, err := ocitarget.NewFromTar(ctx, archivePath)
+			if err != nil {
+				t.Fatalf("failed to read the exported archive: %v", err)
+			}
+			verifyExportedLayout(t, archive, g)
+		})
+	}
+}
+
+// TestExportSubject_DigestMismatch tests that content not matching its digest
+// is not exported
+func TestExportSubject_DigestMismatch(t *testing.T) {
+	g := newExportTestGraph(t)
+	store := g.blobStore().(*mocks.MemoryTestStore)
+	store.Blobs[g.sbomSig.Digest] = []byte("tampered")
+
+	exporter, err := newBundleExporter(filepath.Join(t.TempDir(), "bundle"))
+	if err != nil {
+		t.Fatalf("newBundleExporter() unexpected error: %v", err)
+	}
+	err = exporter.exportSubject(context.Background(), []referrerstore.ReferrerStore{store}, testSubjectReference(g.subject), g.subject)
+	if err == nil || !strings.Contains(err.Error(), "does not match the expected digest") {
+		t.Fatalf("exportSubject() error = %v, want digest mismatch", err)
+	}
+}
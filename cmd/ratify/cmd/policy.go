@@ -0,0 +1,412 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ratify-project/ratify/pkg/controllers/utils"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	"github.com/ratify-project/ratify/pkg/policyprovider/policyquery"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	policyUse     = "policy"
+	policyTestUse = "test"
+
+	regoPolicyExtension = ".rego"
+	// explainOff disables the evaluation trace
+	explainOff = "off"
+)
+
+var explainModes = []string{policyquery.ExplainNotes, policyquery.ExplainFails, policyquery.ExplainFull, explainOff}
+
+type policyTestCmdOptions struct {
+	policyPath string
+	inputPath  string
+	subject    string
+	testFiles  []string
+	explain    string
+}
+
+// policyTestFile is a table of policy test cases, usually kept next to the
+// policy in version control and run in CI
+type policyTestFile struct {
+	// Policy is the path of the policy, relative to the test file. It is
+	// overridden by the --policy flag.
+	Policy string           `yaml:"policy,omitempty"`
+	Tests  []policyTestCase `yaml:"tests"`
+}
+
+// policyTestCase evaluates the policy against the verifier reports read from
+// Input or given inline with VerifierReports
+type policyTestCase struct {
+	Name string `yaml:"name"`
+	// Input is the path of a verifier reports fixture, relative to the test file
+	Input string `yaml:"input,omitempty"`
+	// Subject selects the subject of fixtures with results of several subjects
+	Subject         string        `yaml:"subject,omitempty"`
+	VerifierReports []interface{} `yaml:"verifierReports,omitempty"`
+	// Want is the expected decision of the policy
+	Want bool `yaml:"want"`
+}
+
+func NewCmdPolicy(argv ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   policyUse,
+		Short: "Evaluate policies offline",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(NewCmdPolicyTest(argv...))
+	return cmd
+}
+
+func NewCmdPolicyTest(argv ...string) *cobra.Command {
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Evaluate a Rego policy against recorded verifier reports
+  %[1]s test -p ./policy.rego -i ./verification-response.json
+
+  # Evaluate the Rego policy of a Policy resource with the full evaluation trace
+  %[1]s test -p ./policy.yaml -i ./verification-response.json --explain full
+
+  # Run the test cases of a test file
+  %[1]s test -f ./policy_test.yaml`, strings.Join(argv, " "))
+
+	var opts policyTestCmdOptions
+
+	cmd := &cobra.Command{
+		Use:     policyTestUse,
+		Short:   "Test a Rego policy against recorded verifier reports",
+		Example: eg,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return policyTest(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVarP(&opts.policyPath, "policy", "p", "", "Rego policy file or Policy resource YAML")
+	flags.StringVarP(&opts.inputPath, "input", "i", "", "Verifier reports fixture, such as a recorded verification response")
+	flags.StringVarP(&opts.subject, "subject", "s", "", "Subject of the verifier reports if the fixture has results of several subjects")
+	flags.StringArrayVarP(&opts.testFiles, "file", "f", nil, "Test file with policy test cases")
+	flags.StringVar(&opts.explain, "explain", policyquery.ExplainNotes, fmt.Sprintf("Evaluation trace to print, one of %s", strings.Join(explainModes, "|")))
+	return cmd
+}
+
+func policyTest(w io.Writer, opts policyTestCmdOptions) error {
+	if !isExplainMode(opts.explain) {
+		return fmt.Errorf("unsupported explain mode %s, supported modes are %s", opts.explain, strings.Join(explainModes, "|"))
+	}
+	if opts.inputPath == "" && len(opts.testFiles) == 0 {
+		return errors.New("input or test file parameter is required")
+	}
+
+	if opts.inputPath != "" {
+		if opts.policyPath == "" {
+			return errors.New("policy parameter is required")
+		}
+		return evaluatePolicyInput(w, opts)
+	}
+
+	failed, total := 0, 0
+	for _, testFile := range opts.testFiles {
+		fileFailed, fileTotal, err := runPolicyTestFile(w, testFile, opts.policyPath, opts.explain)
+		if err != nil {
+			return err
+		}
+		failed += fileFailed
+		total += fileTotal
+	}
+	fmt.Fprintf(w, "\n%d of %d policy tests passed\n", total-failed, total)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d policy tests failed", failed, total)
+	}
+	return nil
+}
+
+// evaluatePolicyInput prints the decision of the policy for the fixture
+func evaluatePolicyInput(w io.Writer, opts policyTestCmdOptions) error {
+	explainer, err := loadPolicyExplainer(opts.policyPath)
+	if err != nil {
+		return err
+	}
+	verifierReports, err := loadVerifierReports(opts.inputPath, opts.subject)
+	if err != nil {
+		return err
+	}
+	result, trace, err := explainPolicy(explainer, verifierReports, opts.explain)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Decision: %s\n", policyDecision(result))
+	writeTrace(w, trace)
+	return nil
+}
+
+// runPolicyTestFile runs the test cases of the test file and returns the
+// number of failed and total test cases
+func runPolicyTestFile(w io.Writer, testFilePath string, policyPath string, explain string) (int, int, error) {
+	content, err := os.ReadFile(testFilePath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read test file %s: %w", testFilePath, err)
+	}
+	var testFile policyTestFile
+	if err := yaml.Unmarshal(content, &testFile); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse test file %s: %w", testFilePath, err)
+	}
+	baseDir := filepath.Dir(testFilePath)
+	if policyPath == "" {
+		if testFile.Policy == "" {
+			return 0, 0, fmt.Errorf("no policy is set in test file %s or with the policy parameter", testFilePath)
+		}
+		policyPath = resolveTestPath(baseDir, testFile.Policy)
+	}
+	explainer, err := loadPolicyExplainer(policyPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fmt.Fprintf(w, "%s (%s)\n", testFilePath, policyPath)
+	failed := 0
+	for i, testCase := range testFile.Tests {
+		name := testCase.Name
+		if name == "" {
+			name = fmt.Sprintf("test %d", i+1)
+		}
+		verifierReports := testCase.VerifierReports
+		if testCase.Input != "" {
+			if verifierReports, err = loadVerifierReports(resolveTestPath(baseDir, testCase.Input), testCase.Subject); err != nil {
+				return 0, 0, fmt.Errorf("test %s: %w", name, err)
+			}
+		}
+		if verifierReports == nil {
+			verifierReports = []interface{}{}
+		}
+
+		result, trace, err := explainPolicy(explainer, normalizeReports(verifierReports), explain)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "  ERROR  %s: %v\n", name, err)
+		case result != testCase.Want:
+			failed++
+			fmt.Fprintf(w, "  FAIL   %s: got %s, want %s\n", name, policyDecision(result), policyDecision(testCase.Want))
+			writeTrace(w, trace)
+		default:
+			fmt.Fprintf(w, "  PASS   %s\n", name)
+		}
+	}
+	return failed, len(testFile.Tests), nil
+}
+
+func resolveTestPath(baseDir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// loadPolicyExplainer creates the policy provider the same way the Policy
+// controller does from a Rego file or a Policy resource
+func loadPolicyExplainer(policyPath string) (policyprovider.PolicyExplainer, error) {
+	content, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", policyPath, err)
+	}
+
+	policyType := pt.RegoPolicy
+	var parameters []byte
+	if filepath.Ext(policyPath) == regoPolicyExtension {
+		if parameters, err = json.Marshal(map[string]string{"policy": string(content)}); err != nil {
+			return nil, err
+		}
+	} else {
+		var resource struct {
+			Kind string `yaml:"kind"`
+			Spec struct {
+				Type       string                 `yaml:"type"`
+				Parameters map[string]interface{} `yaml:"parameters"`
+			} `yaml:"spec"`
+		}
+		if err := yaml.Unmarshal(content, &resource); err != nil {
+			return nil, fmt.Errorf("failed to parse policy resource %s: %w", policyPath, err)
+		}
+		if resource.Kind != "Policy" && resource.Kind != "NamespacedPolicy" {
+			return nil, fmt.Errorf("policy %s must be a .rego file or a Policy resource", policyPath)
+		}
+		if resource.Spec.Type != "" {
+			policyType = resource.Spec.Type
+		}
+		if parameters, err = json.Marshal(resource.Spec.Parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse policy parameters of %s: %w", policyPath, err)
+		}
+	}
+
+	provider, err := utils.SpecToPolicyEnforcer(parameters, policyType)
+	if err != nil {
+		return nil, err
+	}
+	explainer, ok := provider.(policyprovider.PolicyExplainer)
+	if !ok {
+		return nil, fmt.Errorf("policy %s of type %s cannot be tested, only Rego policies are supported", policyPath, policyType)
+	}
+	return explainer, nil
+}
+
+// loadVerifierReports reads the verifier reports of a fixture. The fixture
+// is a verification response or verify result with verifierReports, a list
+// of verifier reports, or an external data provider response with the
+// results of one or more subjects.
+func loadVerifierReports(path string, subject string) ([]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verifier reports %s: %w", path, err)
+	}
+	var fixture interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse verifier reports %s: %w", path, err)
+	}
+	verifierReports, err := fixtureReports(fixture, subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifier reports %s: %w", path, err)
+	}
+	return verifierReports, nil
+}
+
+func fixtureReports(fixture interface{}, subject string) ([]interface{}, error) {
+	switch value := fixture.(type) {
+	case []interface{}:
+		return value, nil
+	case map[string]interface{}:
+		if reports, ok := value["verifierReports"]; ok {
+			verifierReports, ok := reports.([]interface{})
+			if !ok {
+				return nil, errors.New("verifierReports must be a list")
+			}
+			return verifierReports, nil
+		}
+		if response, ok := value["response"].(map[string]interface{}); ok {
+			return providerResponseReports(response, subject)
+		}
+		if _, ok := value["items"]; ok {
+			return providerResponseReports(value, subject)
+		}
+	}
+	return nil, errors.New("no verifierReports found")
+}
+
+// providerResponseReports returns the verifier reports of the subject from the
+// items of an external data provider response
+func providerResponseReports(response map[string]interface{}, subject string) ([]interface{}, error) {
+	items, _ := response["items"].([]interface{})
+	var matched []map[string]interface{}
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := itemMap["key"].(string)
+		if subject == "" || key == subject || strings.HasSuffix(key, "]"+subject) {
+			matched = append(matched, itemMap)
+		}
+	}
+	switch {
+	case len(matched) == 0:
+		return nil, fmt.Errorf("no result found for subject %q", subject)
+	case len(matched) > 1:
+		return nil, errors.New("the response has results of several subjects, select one with the subject parameter")
+	}
+	if errMessage, _ := matched[0]["error"].(string); errMessage != "" {
+		return nil, fmt.Errorf("the response of subject %v is an error: %s", matched[0]["key"], errMessage)
+	}
+	return fixtureReports(matched[0]["value"], subject)
+}
+
+// normalizeReports converts the verifier reports decoded from YAML to the
+// JSON representation evaluated by the server
+func normalizeReports(verifierReports []interface{}) []interface{} {
+	reportsBytes, err := json.Marshal(verifierReports)
+	if err != nil {
+		return verifierReports
+	}
+	var normalized []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(reportsBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&normalized); err != nil {
+		return verifierReports
+	}
+	return normalized
+}
+
+func explainPolicy(explainer policyprovider.PolicyExplainer, verifierReports []interface{}, explain string) (bool, string, error) {
+	explainMode := explain
+	if explain == explainOff {
+		explainMode = policyquery.ExplainNotes
+	}
+	result, trace, err := explainer.ExplainOverallVerifyResult(context.Background(), verifierReports, explainMode)
+	if explain == explainOff {
+		trace = ""
+	}
+	return result, trace, err
+}
+
+func isExplainMode(explain string) bool {
+	for _, mode := range explainModes {
+		if explain == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func policyDecision(result bool) string {
+	if result {
+		return "allow"
+	}
+	return "deny"
+}
+
+func writeTrace(w io.Writer, trace string) {
+	trace = strings.TrimRight(trace, "\n")
+	if trace == "" {
+		return
+	}
+	fmt.Fprintln(w, "Trace:")
+	for _, line := range strings.Split(trace, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
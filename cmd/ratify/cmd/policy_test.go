@@ -0,0 +1,254 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ratify-project/ratify/pkg/policyprovider/policyquery"
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"
+)
+
+const (
+	testRegoPolicy = `package ratify.policy
+default valid := false
+valid {
+  trace("checking verifier reports")
+  count(input.verifierReports) > 0
+  not failed_verify
+}
+failed_verify {
+  input.verifierReports[_].isSuccess == false
+}`
+
+	testPolicyResource = `apiVersion: config.ratify.deislabs.io/v1beta1
+kind: Policy
+metadata:
+  name: ratify-policy
+spec:
+  type: rego-policy
+  parameters:
+    passthroughEnabled: false
+    policy: |
+      package ratify.policy
+      default valid := false
+      valid {
+        count(input.verifierReports) > 0
+      }
+`
+
+	testVerificationResponse = `{
+  "version": "0.2.0",
+  "isSuccess": true,
+  "verifierReports": [{"subject": "localhost:5000/net-monitor:v1", "isSuccess": true, "name": "notation"}]
+}`
+
+	testProviderResponse = `{
+  "apiVersion": "externaldata.gatekeeper.sh/v1beta1",
+  "kind": "ProviderResponse",
+  "response": {
+    "items": [
+      {"key": "localhost:5000/net-monitor:v1", "value": {"isSuccess": true, "verifierReports": [{"isSuccess": true}]}},
+      {"key": "localhost:5000/net-monitor:v2", "value": {"isSuccess": false, "verifierReports": [{"isSuccess": false}]}},
+      {"key": "localhost:5000/net-monitor:v3", "error": "failed to resolve the subject"}
+    ]
+  }
+}`
+
+	testPolicyTestFile = `policy: policy.rego
+tests:
+  - name: signed image
+    input: response.json
+    want: true
+  - name: unsigned image
+    input: provider-response.json
+    subject: localhost:5000/net-monitor:v2
+    want: false
+  - name: inline reports
+    verifierReports:
+      - isSuccess: true
+        name: notation
+    want: true
+  - name: no reports
+    want: false
+`
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadVerifierReports(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name      string
+		fixture   string
+		subject   string
+		wantCount int
+		wantErr   string
+	}{
+		{
+			name:      "verification response",
+			fixture:   testVerificationResponse,
+			wantCount: 1,
+		},
+		{
+			name:      "list of verifier reports",
+			fixture:   `[{"isSuccess": true}, {"isSuccess": false}]`,
+			wantCount: 2,
+		},
+		{
+			name:      "provider response with subject",
+			fixture:   testProviderResponse,
+			subject:   "localhost:5000/net-monitor:v2",
+			wantCount: 1,
+		},
+		{
+			name:    "provider response without subject",
+			fixture: testProviderResponse,
+			wantErr: "select one with the subject parameter",
+		},
+		{
+			name:    "provider response with unknown subject",
+			fixture: testProviderResponse,
+			subject: "localhost:5000/net-monitor:v4",
+			wantErr: "no result found",
+		},
+		{
+			name:    "provider response with error",
+			fixture: testProviderResponse,
+			subject: "localhost:5000/net-monitor:v3",
+			wantErr: "failed to resolve the subject",
+		},
+		{
+			name:    "no verifier reports",
+			fixture: `{"isSuccess": true}`,
+			wantErr: "no verifierReports found",
+		},
+		{
+			name:    "invalid json",
+			fixture: `{`,
+			wantErr: "failed to parse verifier reports",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestFile(t, dir, fmt.Sprintf("fixture-%d.json", i), tc.fixture)
+			reports, err := loadVerifierReports(path, tc.subject)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("loadVerifierReports() error = %v, want %s", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadVerifierReports() unexpected error: %v", err)
+			}
+			if len(reports) != tc.wantCount {
+				t.Fatalf("loadVerifierReports() returned %d reports, want %d", len(reports), tc.wantCount)
+			}
+		})
+	}
+}
+
+func TestPolicyTest_Input(t *testing.T) {
+	dir := t.TempDir()
+	input := writeTestFile(t, dir, "response.json", testVerificationResponse)
+
+	for _, policyPath := range []string{
+		writeTestFile(t, dir, "policy.rego", testRegoPolicy),
+		writeTestFile(t, dir, "policy.yaml", testPolicyResource),
+	} {
+		var out bytes.Buffer
+		if err := policyTest(&out, policyTestCmdOptions{policyPath: policyPath, inputPath: input, explain: policyquery.ExplainNotes}); err != nil {
+			t.Fatalf("policyTest() unexpected error for %s: %v", policyPath, err)
+		}
+		if !strings.Contains(out.String(), "Decision: allow") {
+			t.Fatalf("policyTest() output for %s = %q, want allow decision", policyPath, out.String())
+		}
+	}
+}
+
+func TestPolicyTest_TestFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "policy.rego", testRegoPolicy)
+	writeTestFile(t, dir, "response.json", testVerificationResponse)
+	writeTestFile(t, dir, "provider-response.json", testProviderResponse)
+	testFile := writeTestFile(t, dir, "policy_test.yaml", testPolicyTestFile)
+
+	var out bytes.Buffer
+	if err := policyTest(&out, policyTestCmdOptions{testFiles: []string{testFile}, explain: explainOff}); err != nil {
+		t.Fatalf("policyTest() unexpected error: %v, output: %s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "4 of 4 policy tests passed") {
+		t.Fatalf("policyTest() output = %q, want all tests passed", out.String())
+	}
+
+	// a test expecting the opposite decision fails
+	failingTestFile := writeTestFile(t, dir, "failing_test.yaml", strings.Replace(testPolicyTestFile, "want: false\n", "want: true\n", 1))
+	out.Reset()
+	err := policyTest(&out, policyTestCmdOptions{testFiles: []string{failingTestFile}, explain: explainOff})
+	if err == nil || !strings.Contains(err.Error(), "1 of 4 policy tests failed") {
+		t.Fatalf("policyTest() error = %v, want 1 failed test", err)
+	}
+	if !strings.Contains(out.String(), "FAIL   unsigned image: got deny, want allow") {
+		t.Fatalf("policyTest() output = %q, want failed test", out.String())
+	}
+}
+
+func TestPolicyTest_InvalidParameters(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    policyTestCmdOptions
+		wantErr string
+	}{
+		{
+			name:    "no input",
+			opts:    policyTestCmdOptions{policyPath: "policy.rego", explain: explainOff},
+			wantErr: "input or test file parameter is required",
+		},
+		{
+			name:    "no policy",
+			opts:    policyTestCmdOptions{inputPath: "response.json", explain: explainOff},
+			wantErr: "policy parameter is required",
+		},
+		{
+			name:    "unsupported explain mode",
+			opts:    policyTestCmdOptions{inputPath: "response.json", explain: "verbose"},
+			wantErr: "unsupported explain mode",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policyTest(&bytes.Buffer{}, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("policyTest() error = %v, want %s", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -55,6 +55,8 @@ func New(use, short string) *cobra.Command {
 	root.AddCommand(NewCmdDiscover(use, discoverUse))
 	root.AddCommand(NewCmdVersion(use, versionUse))
 	root.AddCommand(NewCmdResolve(use, resolveUse))
+	root.AddCommand(NewCmdExport(use, exportUse))
+	root.AddCommand(NewCmdPolicy(use, policyUse))
 
 	root.PersistentFlags().BoolVarP(&enableDebug, "debug", "d", false, "Enable debug mode. If enabled, set logger level to debug")
 	return root
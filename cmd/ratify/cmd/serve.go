@@ -24,6 +24,9 @@ import (
 	"github.com/ratify-project/ratify/httpserver"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/cache"
+	"github.com/ratify-project/ratify/pkg/cache/dapr"
+	"github.com/ratify-project/ratify/pkg/controllers/audit"
+	"github.com/ratify-project/ratify/pkg/controllers/prewarm"
 	"github.com/ratify-project/ratify/pkg/manager"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,6 +39,8 @@ const (
 type serveCmdOptions struct {
 	configFilePath    string
 	httpServerAddress string
+	grpcServerAddress string
+	webhookAllowList  []string
 	certDirectory     string
 	caCertFile        string
 	enableCrdManager  bool
@@ -48,6 +53,13 @@ type serveCmdOptions struct {
 	metricsType       string
 	metricsPort       int
 	healthPort        string
+	prewarmEnabled    bool
+	prewarmOptions    prewarm.Options
+	auditEnabled      bool
+	auditOptions      audit.Options
+	// kmpKubernetesSecretEnabled watches Secrets and ConfigMaps for
+	// kubernetesSecret key management providers
+	kmpKubernetesSecretEnabled bool
 }
 
 func NewCmdServe(_ ...string) *cobra.Command {
@@ -66,6 +78,8 @@ func NewCmdServe(_ ...string) *cobra.Command {
 	flags := cmd.Flags()
 
 	flags.StringVar(&opts.httpServerAddress, "http", "", "HTTP Address")
+	flags.StringVar(&opts.grpcServerAddress, "grpc", "", "gRPC Address of the experimental verification service, not served if empty")
+	flags.StringSliceVar(&opts.webhookAllowList, "webhook-allow-list", nil, "Hosts, or URL prefixes with scheme, that verification jobs may notify. Webhooks must resolve to public addresses if empty")
 	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
 	flags.StringVar(&opts.certDirectory, "cert-dir", "", "Path to ratify certs")
 	flags.StringVar(&opts.caCertFile, "ca-cert-file", "", "Path to CA cert file")
@@ -79,10 +93,29 @@ func NewCmdServe(_ ...string) *cobra.Command {
 	flags.StringVar(&opts.metricsType, "metrics-type", httpserver.DefaultMetricsType, fmt.Sprintf("Metrics exporter type to use (default: %s)", httpserver.DefaultMetricsType))
 	flags.IntVar(&opts.metricsPort, "metrics-port", httpserver.DefaultMetricsPort, fmt.Sprintf("Metrics exporter port to use (default: %d)", httpserver.DefaultMetricsPort))
 	flags.StringVar(&opts.healthPort, "health-port", httpserver.DefaultHealthPort, fmt.Sprintf("Health port to use (default: %s)", httpserver.DefaultHealthPort))
+	flags.BoolVar(&opts.prewarmEnabled, "cache-prewarm-enabled", false, "Pre-warm the cache with the images of Deployments, StatefulSets, DaemonSets and Jobs in crd mode (default: false)")
+	flags.StringSliceVar(&opts.prewarmOptions.Repositories, "cache-prewarm-repositories", nil, "Registries or repositories to pre-warm the cache for, all images are pre-warmed if empty")
+	flags.DurationVar(&opts.prewarmOptions.CacheTTL, "cache-prewarm-ttl", 0, "Cache TTL of pre-warmed verification results, at most and by default the cache TTL")
+	flags.DurationVar(&opts.prewarmOptions.Window, "cache-prewarm-window", prewarm.DefaultWindow, fmt.Sprintf("Duration after a change of the pod template of a workload during which its images are pre-warmed (default: %s)", prewarm.DefaultWindow))
+	flags.Float64Var(&opts.prewarmOptions.QPS, "cache-prewarm-qps", prewarm.DefaultQPS, fmt.Sprintf("Maximum verifications per second to pre-warm the cache (default: %d)", prewarm.DefaultQPS))
+	flags.IntVar(&opts.prewarmOptions.Burst, "cache-prewarm-burst", prewarm.DefaultBurst, fmt.Sprintf("Maximum burst of verifications to pre-warm the cache (default: %d)", prewarm.DefaultBurst))
+	flags.BoolVar(&opts.prewarmOptions.NamespacedKeys, "cache-prewarm-namespaced-keys", false, "Pre-warm the cache in the namespace of the workloads for constraint templates sending namespaced keys, such as the multi-tenancy template (default: false)")
+	flags.BoolVar(&opts.auditEnabled, "audit-enabled", false, "Periodically verify the images of running pods and record the results in VerificationReports in crd mode. Enables leader election so that only one replica audits (default: false)")
+	flags.DurationVar(&opts.auditOptions.Interval, "audit-interval", audit.DefaultInterval, fmt.Sprintf("Interval between audits of running images (default: %s)", audit.DefaultInterval))
+	flags.StringSliceVar(&opts.auditOptions.Namespaces, "audit-namespaces", nil, "Namespaces to audit the running images of, all namespaces are audited if empty")
+	flags.Float64Var(&opts.auditOptions.QPS, "audit-qps", audit.DefaultQPS, fmt.Sprintf("Maximum verifications per second of the audit (default: %d)", audit.DefaultQPS))
+	flags.IntVar(&opts.auditOptions.Burst, "audit-burst", audit.DefaultBurst, fmt.Sprintf("Maximum burst of verifications of the audit (default: %d)", audit.DefaultBurst))
+	flags.BoolVar(&opts.kmpKubernetesSecretEnabled, "kmp-kubernetes-secret-enabled", false, "Watch Secrets and ConfigMaps to refresh the kubernetesSecret key management providers referencing them in crd mode (default: false)")
 	return cmd
 }
 
 func serve(opts serveCmdOptions) error {
+	if opts.prewarmEnabled && (!opts.cacheEnabled || !opts.enableCrdManager) {
+		return fmt.Errorf("cache pre-warming requires both the cache and the crd manager to be enabled")
+	}
+	if opts.auditEnabled && !opts.enableCrdManager {
+		return fmt.Errorf("audit requires the crd manager to be enabled")
+	}
 	if opts.cacheEnabled {
 		// initialize global cache of specified type
 		if _, err := cache.NewCacheProvider(context.TODO(), opts.cacheType, opts.cacheName, opts.cacheSize); err != nil {
@@ -102,8 +135,21 @@ func serve(opts serveCmdOptions) error {
 	if opts.enableCrdManager {
 		certRotatorReady := make(chan struct{})
 		logrus.Infof("starting crd manager")
-		go manager.StartManager(certRotatorReady, opts.healthPort)
-		manager.StartServer(opts.httpServerAddress, opts.configFilePath, opts.certDirectory, opts.caCertFile, opts.cacheTTL, opts.metricsEnabled, opts.metricsType, opts.metricsPort, certRotatorReady)
+		var prewarmer *prewarm.Prewarmer
+		if opts.prewarmEnabled {
+			// pre-warmed entries must not outlive the entries of the verify handler
+			if opts.prewarmOptions.CacheTTL <= 0 || opts.prewarmOptions.CacheTTL > opts.cacheTTL {
+				opts.prewarmOptions.CacheTTL = opts.cacheTTL
+			}
+			opts.prewarmOptions.SharedCache = opts.cacheType == dapr.DaprCacheType
+			prewarmer = prewarm.NewPrewarmer(opts.prewarmOptions)
+		}
+		var auditOptions *audit.Options
+		if opts.auditEnabled {
+			auditOptions = &opts.auditOptions
+		}
+		go manager.StartManager(certRotatorReady, opts.healthPort, opts.configFilePath, prewarmer, auditOptions, opts.kmpKubernetesSecretEnabled)
+		manager.StartServer(opts.httpServerAddress, opts.grpcServerAddress, opts.configFilePath, opts.certDirectory, opts.caCertFile, opts.webhookAllowList, opts.cacheTTL, opts.metricsEnabled, opts.metricsType, opts.metricsPort, prewarmer, certRotatorReady)
 
 		return nil
 	}
@@ -118,6 +164,8 @@ func serve(opts serveCmdOptions) error {
 		if err != nil {
 			return err
 		}
+		server.GRPCAddress = opts.grpcServerAddress
+		server.WebhookAllowList = opts.webhookAllowList
 		logrus.Infof("starting server at" + opts.httpServerAddress)
 		if err := server.Run(nil); err != nil {
 			return err
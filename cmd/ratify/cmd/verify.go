@@ -18,11 +18,14 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/ratify-project/ratify/config"
 	"github.com/ratify-project/ratify/internal/constants"
 	"github.com/ratify-project/ratify/internal/logger"
-	e "github.com/ratify-project/ratify/pkg/executor"
 	ef "github.com/ratify-project/ratify/pkg/executor/core"
 	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
 	sf "github.com/ratify-project/ratify/pkg/referrerstore/factory"
@@ -42,17 +45,37 @@ var logOpt = logger.Option{
 type verifyCmdOptions struct {
 	configFilePath string
 	subject        string
+	subjectsFile   string
 	artifactTypes  []string
 	silentMode     bool
+	output         string
+	concurrency    int
+	tracePath      string
 }
 
-func NewCmdVerify(_ ...string) *cobra.Command {
+func NewCmdVerify(argv ...string) *cobra.Command {
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Verify a subject
+  %[1]s verify -c ./config.json -s myregistry/myrepo@sha256:34343
+
+  # Verify the subjects listed in a file, one per line, and output a table
+  %[1]s verify -c ./config.json --subjectsFile ./subjects.txt -o table
+
+  # Verify the images of rendered Kubernetes manifests read from stdin and output SARIF
+  helm template ./chart | %[1]s verify -c ./config.json --subjectsFile - -o sarif
+
+  # Verify a subject and write the trace of every executor decision to a file
+  %[1]s verify -c ./config.json -s myregistry/myrepo@sha256:34343 --trace ./trace.json`, strings.Join(argv, " "))
+
 	var opts verifyCmdOptions
 
 	cmd := &cobra.Command{
 		Use:     verifyUse,
 		Short:   "Verify a subject",
-		Example: "sample example",
+		Example: eg,
 		Args:    cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return verify(opts)
@@ -62,18 +85,35 @@ func NewCmdVerify(_ ...string) *cobra.Command {
 	flags := cmd.Flags()
 
 	flags.StringVarP(&opts.subject, "subject", "s", "", "Subject Reference")
+	flags.StringVar(&opts.subjectsFile, "subjectsFile", "", "File listing the subjects to verify, one per line, or Kubernetes manifests to extract image references from. Use - to read from stdin. The command fails if any subject fails the verification")
 	flags.StringVarP(&opts.configFilePath, "config", "c", "", "Config File Path")
 	flags.StringArrayVarP(&opts.artifactTypes, "artifactType", "t", nil, "artifact type to filter")
 	flags.BoolVar(&opts.silentMode, "silent", false, "Silent output")
+	flags.StringVarP(&opts.output, "output", "o", outputFormatJSON, fmt.Sprintf("Output format, one of %s", strings.Join(outputFormats, ", ")))
+	flags.IntVar(&opts.concurrency, "concurrency", defaultVerifyConcurrency, "Maximum number of subjects verified concurrently")
+	flags.StringVar(&opts.tracePath, "trace", "", "File to write the verification trace of each subject to as JSON. Use - to write to stderr")
 	return cmd
 }
 
 func verify(opts verifyCmdOptions) error {
-	if opts.subject == "" {
-		return errors.New("subject parameter is required")
+	if opts.subject == "" && opts.subjectsFile == "" {
+		return errors.New("subject or subjectsFile parameter is required")
+	}
+	// options left unset keep the behavior of verifying a single subject
+	if opts.output == "" {
+		opts.output = outputFormatJSON
+	}
+	if opts.concurrency == 0 {
+		opts.concurrency = defaultVerifyConcurrency
+	}
+	if !slices.Contains(outputFormats, opts.output) {
+		return fmt.Errorf("unsupported output format %s, supported formats are %s", opts.output, strings.Join(outputFormats, ", "))
+	}
+	if opts.concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", opts.concurrency)
 	}
 
-	subRef, err := utils.ParseSubjectReference(opts.subject)
+	subjects, err := loadSubjects(opts.subject, opts.subjectsFile, os.Stdin)
 	if err != nil {
 		return err
 	}
@@ -87,8 +127,14 @@ func verify(opts verifyCmdOptions) error {
 		return err
 	}
 
-	if subRef.Digest == "" {
-		logger.GetLogger(context.Background(), logOpt).Warn(taggedReferenceWarning)
+	for _, subject := range subjects {
+		subRef, err := utils.ParseSubjectReference(subject)
+		if err != nil {
+			return err
+		}
+		if subRef.Digest == "" {
+			logger.GetLogger(context.Background(), logOpt).Warnf("%s: %s", subject, taggedReferenceWarning)
+		}
 	}
 
 	stores, err := sf.CreateStoresFromConfig(cf.StoresConfig, config.GetDefaultPluginPath())
@@ -116,20 +162,32 @@ func verify(opts verifyCmdOptions) error {
 		Config:         &cf.ExecutorConfig,
 	}
 
-	verifyParameters := e.VerifyParameters{
-		Subject:        opts.subject,
-		ReferenceTypes: opts.artifactTypes,
+	results := verifySubjects(context.Background(), executor, subjects, opts.artifactTypes, opts.concurrency, opts.tracePath != "")
+	if opts.tracePath != "" {
+		if err := writeVerifyTraces(opts.tracePath, results); err != nil {
+			return err
+		}
 	}
 
-	result, err := executor.VerifySubject(context.Background(), verifyParameters)
-
-	if err != nil {
-		return err
+	// a single subject passed with --subject keeps the original JSON output
+	// and exit code, which only reports errors of the verification itself and
+	// not a failed policy evaluation
+	if opts.subjectsFile == "" && len(results) == 1 && opts.output == outputFormatJSON {
+		if results[0].err != nil {
+			return results[0].err
+		}
+		if !opts.silentMode {
+			return PrintJSON(results[0].Result)
+		}
+		return nil
 	}
 
 	if !opts.silentMode {
-		return PrintJSON(result)
+		if err := writeVerifyResults(os.Stdout, opts.output, results); err != nil {
+			return err
+		}
 	}
-
-	return nil
+	// subject lists and the report formats fail the command if any subject
+	// failed the verification so that CI pipelines can gate on the exit code
+	return verifyResultsError(results)
 }
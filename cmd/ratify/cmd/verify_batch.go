@@ -0,0 +1,238 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultVerifyConcurrency = 4
+	stdinSubjectsFile        = "-"
+	stderrTraceFile          = "-"
+)
+
+// subjectVerifyResult is the verification result of a single subject
+type subjectVerifyResult struct {
+	Subject   string              `json:"subject"`
+	IsSuccess bool                `json:"isSuccess"`
+	Error     string              `json:"error,omitempty"`
+	Result    *types.VerifyResult `json:"result,omitempty"`
+	err       error
+	trace     *trace.Trace
+}
+
+// subjectVerifier verifies a single subject, implemented by the executor
+type subjectVerifier interface {
+	VerifySubject(ctx context.Context, verifyParameters e.VerifyParameters) (types.VerifyResult, error)
+}
+
+// loadSubjects returns the subject passed with --subject followed by the
+// subjects read from the subjects file, without duplicates
+func loadSubjects(subject string, subjectsFile string, stdin io.Reader) ([]string, error) {
+	subjects := []string{}
+	if subject != "" {
+		subjects = append(subjects, subject)
+	}
+	if subjectsFile != "" {
+		var content []byte
+		var err error
+		if subjectsFile == stdinSubjectsFile {
+			content, err = io.ReadAll(stdin)
+		} else {
+			content, err = os.ReadFile(subjectsFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subjects from %s: %w", subjectsFile, err)
+		}
+		subjects = append(subjects, parseSubjects(content)...)
+	}
+
+	seen := make(map[string]bool)
+	unique := []string{}
+	for _, s := range subjects {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("no subject found in %s", subjectsFile)
+	}
+	return unique, nil
+}
+
+// parseSubjects returns the image references of the content if it contains
+// Kubernetes manifests, or the non-empty lines of the content otherwise.
+// Lines starting with # are comments.
+func parseSubjects(content []byte) []string {
+	if images, isManifest := extractImages(content); isManifest {
+		return images
+	}
+	subjects := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		subjects = append(subjects, line)
+	}
+	return subjects
+}
+
+// extractImages returns the container images referenced by the YAML
+// documents of the content, such as Kubernetes manifests or Helm rendered
+// templates. It returns false if the content has no YAML object.
+func extractImages(content []byte) ([]string, bool) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	isManifest := false
+	images := []string{}
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, false
+		}
+		if _, ok := document.(map[string]interface{}); ok {
+			isManifest = true
+		}
+		images = append(images, findImages(document)...)
+	}
+	return images, isManifest
+}
+
+// findImages walks the YAML node and returns the values of the image fields
+func findImages(node interface{}) []string {
+	images := []string{}
+	switch value := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		// sort the keys so that the images are returned in a stable order
+		sort.Strings(keys)
+		for _, key := range keys {
+			if image, ok := value[key].(string); ok && key == "image" {
+				if image = strings.TrimSpace(image); image != "" {
+					images = append(images, image)
+				}
+				continue
+			}
+			images = append(images, findImages(value[key])...)
+		}
+	case []interface{}:
+		for _, item := range value {
+			images = append(images, findImages(item)...)
+		}
+	}
+	return images
+}
+
+// verifySubjects verifies the subjects with at most concurrency subjects
+// verified at the same time. The results are in the order of the subjects.
+// If withTrace is true, the trace of the verification is recorded in the
+// result of each subject.
+func verifySubjects(ctx context.Context, executor subjectVerifier, subjects []string, artifactTypes []string, concurrency int, withTrace bool) []subjectVerifyResult {
+	results := make([]subjectVerifyResult, len(subjects))
+	eg := errgroup.Group{}
+	eg.SetLimit(concurrency)
+	for i, subject := range subjects {
+		i, subject := i, subject
+		eg.Go(func() error {
+			subjectCtx := ctx
+			var verificationTrace *trace.Trace
+			if withTrace {
+				verificationTrace = trace.New(subject, "")
+				subjectCtx = trace.NewContext(ctx, verificationTrace)
+			}
+			result, err := executor.VerifySubject(subjectCtx, e.VerifyParameters{
+				Subject:        subject,
+				ReferenceTypes: artifactTypes,
+			})
+			if verificationTrace != nil {
+				verificationTrace.Finish()
+			}
+			results[i] = subjectVerifyResult{Subject: subject, err: err, trace: verificationTrace}
+			if err != nil {
+				results[i].Error = err.Error()
+				return nil
+			}
+			results[i].IsSuccess = result.IsSuccess
+			results[i].Result = &result
+			return nil
+		})
+	}
+	// the goroutines never return an error, failures are recorded in the results
+	_ = eg.Wait()
+	return results
+}
+
+// writeVerifyTraces writes the traces of the results as a JSON list to the
+// file, or to stderr if the path is -
+func writeVerifyTraces(path string, results []subjectVerifyResult) error {
+	traces := make([]*trace.Trace, 0, len(results))
+	for _, result := range results {
+		if result.trace != nil {
+			traces = append(traces, result.trace)
+		}
+	}
+	traceBytes, err := json.MarshalIndent(traces, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the verification traces: %w", err)
+	}
+	traceBytes = append(traceBytes, '\n')
+	if path == stderrTraceFile {
+		_, err = os.Stderr.Write(traceBytes)
+		return err
+	}
+	if err := os.WriteFile(path, traceBytes, 0600); err != nil {
+		return fmt.Errorf("failed to write the verification traces to %s: %w", path, err)
+	}
+	return nil
+}
+
+// verifyResultsError returns an error if any subject failed the verification
+func verifyResultsError(results []subjectVerifyResult) error {
+	failed := 0
+	for _, result := range results {
+		if !result.IsSuccess {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("verification failed for %d of %d subjects", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const testManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: net-monitor
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: registry.example.io/init:v1
+      containers:
+        - name: net-monitor
+          image: registry.example.io/net-monitor:v1
+---
+# Source: chart/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: registry.example.io/cleanup@sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4
+            - name: sidecar
+              image: registry.example.io/net-monitor:v1
+`
+
+type fakeSubjectVerifier struct {
+	mu          sync.Mutex
+	running     int
+	maxRunning  int
+	failures    map[string]bool
+	errSubjects map[string]bool
+}
+
+func (f *fakeSubjectVerifier) VerifySubject(ctx context.Context, verifyParameters e.VerifyParameters) (types.VerifyResult, error) {
+	trace.Record(ctx, trace.Event{Type: trace.EventSubjectResolved, Subject: verifyParameters.Subject})
+	f.mu.Lock()
+	f.running++
+	if f.running > f.maxRunning {
+		f.maxRunning = f.running
+	}
+	f.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+
+	if f.errSubjects[verifyParameters.Subject] {
+		return types.VerifyResult{}, errors.New("failed to resolve subject")
+	}
+	isSuccess := !f.failures[verifyParameters.Subject]
+	return types.VerifyResult{
+		IsSuccess: isSuccess,
+		VerifierReports: []interface{}{
+			vr.VerifierResult{
+				Subject:         verifyParameters.Subject,
+				IsSuccess:       isSuccess,
+				VerifierName:    "notation",
+				VerifierType:    "notation",
+				ArtifactType:    "application/vnd.cncf.notary.signature",
+				ReferenceDigest: "sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4",
+				Message:         "Notation signature verification",
+			},
+		},
+	}, nil
+}
+
+func TestParseSubjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "subject list",
+			content: "# release images\nregistry.example.io/a:v1\n\n  registry.example.io/b@sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4  \n",
+			want:    []string{"registry.example.io/a:v1", "registry.example.io/b@sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4"},
+		},
+		{
+			name:    "kubernetes manifests",
+			content: testManifests,
+			want: []string{
+				"registry.example.io/net-monitor:v1",
+				"registry.example.io/init:v1",
+				"registry.example.io/cleanup@sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4",
+				"registry.example.io/net-monitor:v1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSubjects([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseSubjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSubjects(t *testing.T) {
+	subjects, err := loadSubjects("registry.example.io/init:v1", "-", strings.NewReader(testManifests))
+	if err != nil {
+		t.Fatalf("loadSubjects() unexpected error: %v", err)
+	}
+	want := []string{
+		"registry.example.io/init:v1",
+		"registry.example.io/net-monitor:v1",
+		"registry.example.io/cleanup@sha256:17490f904cf278d4314a1ccba407fc8fd00fb45303589b8cc7f5174ac35554f4",
+	}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Fatalf("loadSubjects() = %v, want %v", subjects, want)
+	}
+
+	if _, err := loadSubjects("", "-", strings.NewReader("# no subjects\n")); err == nil {
+		t.Fatalf("loadSubjects() expected error for empty subjects file")
+	}
+	if _, err := loadSubjects("", "./not-exist.txt", nil); err == nil {
+		t.Fatalf("loadSubjects() expected error for missing subjects file")
+	}
+}
+
+func TestVerifySubjects(t *testing.T) {
+	subjects := []string{"a:v1", "b:v1", "c:v1", "d:v1", "e:v1", "f:v1"}
+	fake := &fakeSubjectVerifier{
+		failures:    map[string]bool{"b:v1": true},
+		errSubjects: map[string]bool{"c:v1": true},
+	}
+	results := verifySubjects(context.Background(), fake, subjects, nil, 2, false)
+
+	if fake.maxRunning > 2 {
+		t.Fatalf("verifySubjects() verified %d subjects concurrently, want at most 2", fake.maxRunning)
+	}
+	for i, result := range results {
+		if result.Subject != subjects[i] {
+			t.Fatalf("verifySubjects() result %d subject = %s, want %s", i, result.Subject, subjects[i])
+		}
+	}
+	if results[0].IsSuccess != true || results[1].IsSuccess != false || results[2].Error == "" {
+		t.Fatalf("verifySubjects() unexpected results: %+v", results)
+	}
+	if err := verifyResultsError(results); err == nil || !strings.Contains(err.Error(), "2 of 6") {
+		t.Fatalf("verifyResultsError() = %v, want failure of 2 of 6 subjects", err)
+	}
+	if err := verifyResultsError(results[:1]); err != nil {
+		t.Fatalf("verifyResultsError() unexpected error: %v", err)
+	}
+}
+
+func TestVerifySubjects_Trace(t *testing.T) {
+	subjects := []string{"a:v1", "b:v1"}
+	fake := &fakeSubjectVerifier{errSubjects: map[string]bool{"b:v1": true}}
+
+	results := verifySubjects(context.Background(), fake, subjects, nil, 2, false)
+	for _, result := range results {
+		if result.trace != nil {
+			t.Fatalf("verifySubjects() recorded a trace for %s without tracing", result.Subject)
+		}
+	}
+
+	results = verifySubjects(context.Background(), fake, subjects, nil, 2, true)
+	tracePath := filepath.Join(t.TempDir(), "trace.json")
+	if err := writeVerifyTraces(tracePath, results); err != nil {
+		t.Fatalf("writeVerifyTraces() unexpected error: %v", err)
+	}
+	traceBytes, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatalf("failed to read traces: %v", err)
+	}
+	var traces []struct {
+		Subject string        `json:"subject"`
+		Events  []trace.Event `json:"events"`
+	}
+	if err := json.Unmarshal(traceBytes, &traces); err != nil {
+		t.Fatalf("failed to parse traces: %v", err)
+	}
+	if len(traces) != len(subjects) {
+		t.Fatalf("writeVerifyTraces() wrote %d traces, want %d", len(traces), len(subjects))
+	}
+	for i, subjectTrace := range traces {
+		if subjectTrace.Subject != subjects[i] || len(subjectTrace.Events) != 1 || subjectTrace.Events[0].Subject != subjects[i] {
+			t.Fatalf("unexpected trace %+v of subject %s", subjectTrace, subjects[i])
+		}
+	}
+}
+
+func TestWriteVerifyResults(t *testing.T) {
+	fake := &fakeSubjectVerifier{
+		failures:    map[string]bool{"b:v1": true},
+		errSubjects: map[string]bool{"c:v1": true},
+	}
+	results := verifySubjects(context.Background(), fake, []string{"a:v1", "b:v1", "c:v1"}, nil, 1, false)
+	results = append(results, subjectVerifyResult{
+		Subject: "d:v1",
+		Result:  &types.VerifyResult{IsSuccess: false, VerifierReports: []interface{}{}},
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeVerifyResults(&buf, outputFormatJSON, results); err != nil {
+			t.Fatalf("writeVerifyResults() unexpected error: %v", err)
+		}
+		var decoded []subjectVerifyResult
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("failed to decode json output: %v", err)
+		}
+		if len(decoded) != 4 || decoded[2].Error == "" {
+			t.Fatalf("unexpected json output: %s", buf.String())
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeVerifyResults(&buf, outputFormatTable, results); err != nil {
+			t.Fatalf("writeVerifyResults() unexpected error: %v", err)
+		}
+		output := buf.String()
+		for _, want := range []string{"SUBJECT", "PASS", "FAIL", "ERROR", policyRuleID, "1 of 4 subjects passed verification"} {
+			if !strings.Contains(output, want) {
+				t.Fatalf("table output does not contain %s: %s", want, output)
+			}
+		}
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeVerifyResults(&buf, outputFormatSARIF, results); err != nil {
+			t.Fatalf("writeVerifyResults() unexpected error: %v", err)
+		}
+		var log struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Results []struct {
+					RuleID string `json:"ruleId"`
+					Level  string `json:"level"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatalf("failed to decode sarif output: %v", err)
+		}
+		if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 4 {
+			t.Fatalf("unexpected sarif output: %s", buf.String())
+		}
+		errorsCount := 0
+		for _, result := range log.Runs[0].Results {
+			if result.Level == "error" {
+				errorsCount++
+			}
+		}
+		if errorsCount != 3 {
+			t.Fatalf("expected 3 sarif errors, got %d", errorsCount)
+		}
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeVerifyResults(&buf, outputFormatJUnit, results); err != nil {
+			t.Fatalf("writeVerifyResults() unexpected error: %v", err)
+		}
+		var suites junitTestSuites
+		if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+			t.Fatalf("failed to decode junit output: %v", err)
+		}
+		if suites.Tests != 4 || suites.Failures != 2 || suites.Errors != 1 || len(suites.Suites) != 4 {
+			t.Fatalf("unexpected junit output: %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,320 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/owenrumney/go-sarif/v2/sarif"
+	"github.com/ratify-project/ratify/internal/version"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	outputFormatJSON  = "json"
+	outputFormatTable = "table"
+	outputFormatSARIF = "sarif"
+	outputFormatJUnit = "junit"
+
+	sarifToolName = "ratify"
+	sarifToolURI  = "https://ratify.dev"
+	// verifyRuleID is the rule of the results of subjects that could not be verified
+	verifyRuleID = "verify"
+	// policyRuleID is the rule of the results of subjects failing the policy
+	// without any failed verifier report
+	policyRuleID = "policy"
+)
+
+var outputFormats = []string{outputFormatJSON, outputFormatTable, outputFormatSARIF, outputFormatJUnit}
+
+// verifierReportRow is a flattened verifier report of a subject
+type verifierReportRow struct {
+	Subject         string
+	VerifierName    string
+	VerifierType    string
+	ArtifactType    string
+	ReferenceDigest string
+	IsSuccess       bool
+	IsError         bool
+	Message         string
+}
+
+// name returns the name identifying the verified artifact of the row
+func (r verifierReportRow) name() string {
+	if r.ReferenceDigest == "" {
+		return r.VerifierName
+	}
+	return fmt.Sprintf("%s %s@%s", r.VerifierName, r.ArtifactType, r.ReferenceDigest)
+}
+
+// writeVerifyResults writes the verification results in the output format
+func writeVerifyResults(w io.Writer, format string, results []subjectVerifyResult) error {
+	switch format {
+	case outputFormatTable:
+		return writeTable(w, results)
+	case outputFormatSARIF:
+		return writeSARIF(w, results)
+	case outputFormatJUnit:
+		return writeJUnit(w, results)
+	default:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+}
+
+// reportRows flattens the verifier reports of the subject, including nested
+// reports. A row is added for subjects that could not be verified or that
+// failed the policy without a failed verifier report.
+func reportRows(result subjectVerifyResult) []verifierReportRow {
+	if result.Error != "" {
+		return []verifierReportRow{{
+			Subject:      result.Subject,
+			VerifierName: verifyRuleID,
+			IsError:      true,
+			Message:      result.Error,
+		}}
+	}
+	rows := []verifierReportRow{}
+	if result.Result != nil {
+		for _, report := range result.Result.VerifierReports {
+			rows = append(rows, reportToRows(result.Subject, report)...)
+		}
+	}
+	if !result.IsSuccess {
+		hasFailure := false
+		for _, row := range rows {
+			hasFailure = hasFailure || !row.IsSuccess
+		}
+		if !hasFailure {
+			rows = append(rows, verifierReportRow{
+				Subject:      result.Subject,
+				VerifierName: policyRuleID,
+				Message:      "the subject does not satisfy the policy",
+			})
+		}
+	}
+	return rows
+}
+
+func reportToRows(subject string, report interface{}) []verifierReportRow {
+	switch r := report.(type) {
+	case vr.VerifierResult:
+		return verifierResultToRows(subject, r)
+	case *vr.VerifierResult:
+		return verifierResultToRows(subject, *r)
+	case types.NestedVerifierReport:
+		rows := []verifierReportRow{}
+		for _, verifierReport := range r.VerifierReports {
+			rows = append(rows, nestedVerifierResultToRow(subject, r, verifierReport))
+		}
+		for _, nested := range r.NestedReports {
+			rows = append(rows, reportToRows(subject, nested)...)
+		}
+		return rows
+	default:
+		// reports of unknown types are decoded as verifier results
+		var verifierResult vr.VerifierResult
+		reportBytes, err := json.Marshal(report)
+		if err != nil || json.Unmarshal(reportBytes, &verifierResult) != nil {
+			return nil
+		}
+		return verifierResultToRows(subject, verifierResult)
+	}
+}
+
+// verifierResultToRows converts the verifier result of the config policy and
+// its nested results to rows
+func verifierResultToRows(subject string, result vr.VerifierResult) []verifierReportRow {
+	rows := []verifierReportRow{{
+		Subject:         subject,
+		VerifierName:    firstNonEmpty(result.VerifierName, result.Name),
+		VerifierType:    firstNonEmpty(result.VerifierType, result.Type),
+		ArtifactType:    result.ArtifactType,
+		ReferenceDigest: result.ReferenceDigest,
+		IsSuccess:       result.IsSuccess,
+		Message:         reportMessage(result.Message, result.ErrorReason),
+	}}
+	for _, nested := range result.NestedResults {
+		rows = append(rows, verifierResultToRows(subject, nested)...)
+	}
+	return rows
+}
+
+// nestedVerifierResultToRow converts a verifier result of the rego policy
+// nested report to a row
+func nestedVerifierResultToRow(subject string, report types.NestedVerifierReport, result vt.VerifierResult) verifierReportRow {
+	return verifierReportRow{
+		Subject:         subject,
+		VerifierName:    firstNonEmpty(result.VerifierName, result.Name),
+		VerifierType:    firstNonEmpty(result.VerifierType, result.Type),
+		ArtifactType:    report.ArtifactType,
+		ReferenceDigest: report.ReferenceDigest,
+		IsSuccess:       result.IsSuccess,
+		Message:         reportMessage(result.Message, result.ErrorReason),
+	}
+}
+
+func reportMessage(message, errorReason string) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", message, errorReason))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// writeTable writes the verifier reports as a human readable table
+func writeTable(w io.Writer, results []subjectVerifyResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SUBJECT\tRESULT\tVERIFIER\tARTIFACT TYPE\tREFERENCE\tMESSAGE")
+	passed := 0
+	for _, result := range results {
+		if result.IsSuccess {
+			passed++
+		}
+		for _, row := range reportRows(result) {
+			status := "PASS"
+			if row.IsError {
+				status = "ERROR"
+			} else if !row.IsSuccess {
+				status = "FAIL"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", row.Subject, status, row.VerifierName, row.ArtifactType, row.ReferenceDigest, row.Message)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d of %d subjects passed verification\n", passed, len(results))
+	return err
+}
+
+// writeSARIF writes the verifier reports as a SARIF log with a result per
+// verifier report. Failed reports are errors, passed reports are passes.
+func writeSARIF(w io.Writer, results []subjectVerifyResult) error {
+	report, err := sarif.New(sarif.Version210)
+	if err != nil {
+		return err
+	}
+	run := sarif.NewRunWithInformationURI(sarifToolName, sarifToolURI)
+	if version.GitTag != "" {
+		run.Tool.Driver.WithVersion(version.GitTag)
+	}
+	for _, result := range results {
+		for _, row := range reportRows(result) {
+			run.AddRule(row.VerifierName).WithDescription(fmt.Sprintf("Ratify %s verification", row.VerifierName))
+			sarifResult := run.CreateResultForRule(row.VerifierName)
+			if row.IsSuccess {
+				sarifResult.WithKind("pass").WithLevel("none")
+			} else {
+				sarifResult.WithKind("fail").WithLevel("error")
+			}
+			message := row.Message
+			if message == "" {
+				message = fmt.Sprintf("%s verification of %s", row.VerifierName, row.Subject)
+			}
+			fullyQualifiedName := row.Subject
+			if row.ReferenceDigest != "" {
+				fullyQualifiedName = fmt.Sprintf("%s -> %s@%s", row.Subject, row.ArtifactType, row.ReferenceDigest)
+			}
+			sarifResult.WithMessage(sarif.NewTextMessage(message)).AddLocation(
+				sarif.NewLocation().WithLogicalLocations([]*sarif.LogicalLocation{
+					sarif.NewLogicalLocation().WithName(row.Subject).WithFullyQualifiedName(fullyQualifiedName).WithKind("resource"),
+				}))
+		}
+	}
+	report.AddRun(run)
+	return report.PrettyWrite(w)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the verifier reports as JUnit XML with a test suite per
+// subject and a test case per verifier report
+func writeJUnit(w io.Writer, results []subjectVerifyResult) error {
+	suites := junitTestSuites{Name: sarifToolName}
+	for _, result := range results {
+		suite := junitTestSuite{Name: result.Subject}
+		for _, row := range reportRows(result) {
+			testCase := junitTestCase{Name: row.name(), Classname: row.Subject}
+			switch {
+			case row.IsError:
+				testCase.Error = &junitFailure{Message: row.Message, Type: row.VerifierName, Text: row.Message}
+				suite.Errors++
+			case !row.IsSuccess:
+				testCase.Failure = &junitFailure{Message: row.Message, Type: row.VerifierType, Text: row.Message}
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -19,16 +19,23 @@ import (
 	"os"
 
 	"github.com/ratify-project/ratify/cmd/ratify/cmd"
-	_ "github.com/ratify-project/ratify/pkg/cache/dapr"                  // register dapr cache
-	_ "github.com/ratify-project/ratify/pkg/cache/ristretto"             // register ristretto cache
-	_ "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy" // register configpolicy policy provider
-	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"   // register regopolicy policy provider
-	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"          // register oras referrer store
-	_ "github.com/ratify-project/ratify/pkg/verifier/cosign"             // register cosign verifier
-	_ "github.com/ratify-project/ratify/pkg/verifier/notation"           // register notation verifier
+	_ "github.com/ratify-project/ratify/pkg/cache/dapr"      // register dapr cache
+	_ "github.com/ratify-project/ratify/pkg/cache/ristretto" // register ristretto cache
+	"github.com/ratify-project/ratify/pkg/common/plugin"
+	_ "github.com/ratify-project/ratify/pkg/common/plugin/artifactverifier" // register plugin artifact verifier
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"    // register configpolicy policy provider
+	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"      // register regopolicy policy provider
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/ocilayout"        // register oci layout referrer store
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"             // register oras referrer store
+	_ "github.com/ratify-project/ratify/pkg/verifier/cosign"                // register cosign verifier
+	_ "github.com/ratify-project/ratify/pkg/verifier/notation"              // register notation verifier
+	_ "github.com/ratify-project/ratify/pkg/verifier/provenance"            // register provenance verifier
 )
 
 func main() {
+	// sandboxed plugins are executed through ratify itself
+	plugin.RunSandbox()
+
 	if err := cmd.Root.Execute(); err != nil {
 		os.Exit(1)
 	}
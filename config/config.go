@@ -126,6 +126,10 @@ func Load(configFilePath string) (Config, error) {
 		return config, fmt.Errorf("unable to unmarshal config body: %w", err)
 	}
 
+	if err = config.ExecutorConfig.Validate(); err != nil {
+		return config, fmt.Errorf("invalid executor config: %w", err)
+	}
+
 	if config.fileHash, err = getFileHash(body); err != nil {
 		return config, fmt.Errorf("error getting configuration file hash error: %w", err)
 	}
@@ -143,6 +143,25 @@ func TestLoad_InvalidConfigFile(t *testing.T) {
 	}
 }
 
+func TestLoad_InvalidMaxNestedDepth(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "test-config")
+	if err != nil {
+		t.Fatalf("temp dir creation failed %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fileName := filepath.Join(tmpDir, ConfigFileName)
+	content := []byte(`{"executor": { "maxNestedDepth": 0 }}`)
+	err = os.WriteFile(fileName, content, 0600)
+	if err != nil {
+		t.Fatalf("config file creation failed %v", err)
+	}
+
+	if _, err = Load(fileName); err == nil {
+		t.Fatalf("loading config is expected to fail for a non-positive maxNestedDepth")
+	}
+}
+
 func TestLoad_ComputeHash(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "test-config")
 	if err != nil {
@@ -40,6 +40,22 @@ var (
 		Description: `Generic error returned when the executor fails to perform an operation. Please check the error details for more information.`,
 	})
 
+	// ErrorCodeVerificationLimitExceeded is returned when the executor stops
+	// traversing the referrer graph because a configured limit is reached.
+	ErrorCodeVerificationLimitExceeded = Register("errcode", ErrorDescriptor{
+		Value:       "VERIFICATION_LIMIT_EXCEEDED",
+		Message:     "verification limit exceeded",
+		Description: `The referrer graph of the subject exceeds the configured maxNestedDepth or maxReferrersPerSubject of the executor and verification was truncated. Please review the referrers attached to the subject or adjust the executor configuration.`,
+	})
+
+	// ErrorCodeReferrerCycleDetected is returned when a referrer points back to
+	// an artifact that is already being verified in the same request.
+	ErrorCodeReferrerCycleDetected = Register("errcode", ErrorDescriptor{
+		Value:       "REFERRER_CYCLE_DETECTED",
+		Message:     "referrer cycle detected",
+		Description: `A referrer refers back to an ancestor artifact in the referrer graph and nested verification was stopped. Please validate the referrers attached to the subject.`,
+	})
+
 	// ErrorCodeBadRequest is returned if the request is not valid.
 	ErrorCodeBadRequest = Register("errcode", ErrorDescriptor{
 		Value:       "BAD_REQUEST",
@@ -126,6 +142,14 @@ var (
 		Description: "The requested resource is not found. Please verify the resource exists.",
 	})
 
+	// ErrorCodeTooManyRequests is returned when the request exceeds a limit of
+	// concurrent or retained requests.
+	ErrorCodeTooManyRequests = Register("errcode", ErrorDescriptor{
+		Value:       "TOO_MANY_REQUESTS",
+		Message:     "too many requests",
+		Description: "The request exceeds a limit of the server. Please retry the request later.",
+	})
+
 	// ErrorCodeForbidden is returned when the requested operation is forbidden.
 	ErrorCodeForbidden = Register("errcode", ErrorDescriptor{
 		Value:       "OPERATION_FORBIDDEN",
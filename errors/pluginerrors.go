@@ -138,6 +138,30 @@ var (
 		Description: "Failed to download plugin. Please verify the provided plugin configuration is correct and check the error details for further investigation. Refer to https://ratify.dev/docs/reference/dynamic-plugins for more information.",
 	})
 
+	// ErrorCodePluginTimeout is returned when the executor kills a plugin
+	// that exceeds the timeout of its execution policy.
+	ErrorCodePluginTimeout = Register("errcode", ErrorDescriptor{
+		Value:       "PLUGIN_TIMEOUT",
+		Message:     "plugin timeout",
+		Description: "The plugin did not complete within the timeout of its execution policy and was killed. Please check the plugin is responsive or increase the timeout in the executionPolicy of the plugin config.",
+	})
+
+	// ErrorCodePluginOutputLimitExceeded is returned when the executor kills
+	// a plugin that writes more output than allowed by its execution policy.
+	ErrorCodePluginOutputLimitExceeded = Register("errcode", ErrorDescriptor{
+		Value:       "PLUGIN_OUTPUT_LIMIT_EXCEEDED",
+		Message:     "plugin output limit exceeded",
+		Description: "The plugin wrote more to stdout or stderr than allowed by its execution policy and was killed. Please check the plugin output or increase maxOutputBytes in the executionPolicy of the plugin config.",
+	})
+
+	// ErrorCodePluginResourceLimitExceeded is returned when a plugin is
+	// stopped by a resource limit of its execution policy.
+	ErrorCodePluginResourceLimitExceeded = Register("errcode", ErrorDescriptor{
+		Value:       "PLUGIN_RESOURCE_LIMIT_EXCEEDED",
+		Message:     "plugin resource limit exceeded",
+		Description: "The plugin exceeded a resource limit of its execution policy, such as CPU time, memory or open files. Please check the error details and the resource limits in the executionPolicy of the plugin config.",
+	})
+
 	// ErrorCodeCertInvalid is returned when provided certificates are invalid.
 	ErrorCodeCertInvalid = Register("errcode", ErrorDescriptor{
 		Value:       "CERT_INVALID",
@@ -143,3 +143,91 @@ var PluginOrchestrator_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "orchestrator.proto",
 }
+
+// VerificationServiceClient is the client API for VerificationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VerificationServiceClient interface {
+	// Verify the subject and return the verification results of its referrers.
+	VerifySubject(ctx context.Context, in *VerifySubjectRequest, opts ...grpc.CallOption) (*VerifySubjectResponse, error)
+}
+
+type verificationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVerificationServiceClient(cc grpc.ClientConnInterface) VerificationServiceClient {
+	return &verificationServiceClient{cc}
+}
+
+func (c *verificationServiceClient) VerifySubject(ctx context.Context, in *VerifySubjectRequest, opts ...grpc.CallOption) (*VerifySubjectResponse, error) {
+	out := new(VerifySubjectResponse)
+	err := c.cc.Invoke(ctx, "/orchestrator.VerificationService/VerifySubject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerificationServiceServer is the server API for VerificationService service.
+// All implementations must embed UnimplementedVerificationServiceServer
+// for forward compatibility
+type VerificationServiceServer interface {
+	// Verify the subject and return the verification results of its referrers.
+	VerifySubject(context.Context, *VerifySubjectRequest) (*VerifySubjectResponse, error)
+	mustEmbedUnimplementedVerificationServiceServer()
+}
+
+// UnimplementedVerificationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedVerificationServiceServer struct {
+}
+
+func (UnimplementedVerificationServiceServer) VerifySubject(context.Context, *VerifySubjectRequest) (*VerifySubjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifySubject not implemented")
+}
+func (UnimplementedVerificationServiceServer) mustEmbedUnimplementedVerificationServiceServer() {}
+
+// UnsafeVerificationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VerificationServiceServer will
+// result in compilation errors.
+type UnsafeVerificationServiceServer interface {
+	mustEmbedUnimplementedVerificationServiceServer()
+}
+
+func RegisterVerificationServiceServer(s grpc.ServiceRegistrar, srv VerificationServiceServer) {
+	s.RegisterService(&VerificationService_ServiceDesc, srv)
+}
+
+func _VerificationService_VerifySubject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifySubjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerificationServiceServer).VerifySubject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orchestrator.VerificationService/VerifySubject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerificationServiceServer).VerifySubject(ctx, req.(*VerifySubjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VerificationService_ServiceDesc is the grpc.ServiceDesc for VerificationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VerificationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orchestrator.VerificationService",
+	HandlerType: (*VerificationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "VerifySubject",
+			Handler:    _VerificationService_VerifySubject_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orchestrator.proto",
+}
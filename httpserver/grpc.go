@@ -0,0 +1,292 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/experimental/proto/v1/common"
+	"github.com/ratify-project/ratify/experimental/proto/v1/orchestrator"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	pkgUtils "github.com/ratify-project/ratify/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCNamespaceMetadataKey is the metadata key of the namespace whose
+// verifiers, stores and policy verify the subject of a gRPC request.
+const GRPCNamespaceMetadataKey = "ratify-namespace"
+
+// verificationService implements the VerificationService of the experimental
+// orchestrator protos on top of the verify handler of the server, so that
+// results are shared with the cache of the Gatekeeper provider.
+type verificationService struct {
+	orchestrator.UnimplementedVerificationServiceServer
+	server *Server
+}
+
+// grpcReportNode is the union of the verifier reports of a verify result in
+// either format, verifier.VerifierResult with nested results or
+// types.NestedVerifierReport with nested reports. Cached results are decoded
+// as JSON objects, so reports are normalized through JSON.
+type grpcReportNode struct {
+	IsSuccess       *bool            `json:"isSuccess"`
+	Subject         string           `json:"subject"`
+	ReferenceDigest string           `json:"referenceDigest"`
+	ArtifactType    string           `json:"artifactType"`
+	Name            string           `json:"name"`
+	VerifierName    string           `json:"verifierName"`
+	Message         string           `json:"message"`
+	ErrorReason     string           `json:"errorReason"`
+	Remediation     string           `json:"remediation"`
+	Extensions      interface{}      `json:"extensions"`
+	VerifierReports []grpcReportNode `json:"verifierReports"`
+	NestedResults   []grpcReportNode `json:"nestedResults"`
+	NestedReports   []grpcReportNode `json:"nestedReports"`
+}
+
+// serveGRPC starts serving the VerificationService on the gRPC address of the
+// server, with TLS if tlsConfig is set.
+func (server *Server) serveGRPC(tlsConfig *tls.Config) (*grpc.Server, error) {
+	lsnr, err := net.Listen("tcp", server.GRPCAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
+	orchestrator.RegisterVerificationServiceServer(grpcServer, &verificationService{server: server})
+
+	logrus.Infof("starting gRPC server at %s", server.GRPCAddress)
+	go func() {
+		if err := grpcServer.Serve(lsnr); err != nil {
+			logrus.Errorf("gRPC server stopped with error: %v", err)
+		}
+	}()
+	return grpcServer, nil
+}
+
+// grpcTLSConfig returns the TLS config of the gRPC server. Certificates are
+// reloaded by the watcher, and HTTP/2 is negotiated as required by gRPC.
+func grpcTLSConfig(tlsCertWatcher *TLSCertWatcher) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			config, err := tlsCertWatcher.GetConfigForClient(hello)
+			if err != nil {
+				return nil, err
+			}
+			config.NextProtos = []string{"h2"}
+			return config, nil
+		},
+	}
+}
+
+// VerifySubject verifies the subject with the verifiers, stores and policy of
+// the namespace in the request metadata, or of the cluster if not set.
+func (s *verificationService) VerifySubject(ctx context.Context, request *orchestrator.VerifySubjectRequest) (*orchestrator.VerifySubjectResponse, error) {
+	startTime := time.Now()
+	header := http.Header{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	ctx = logger.InitContext(ctx, &http.Request{Header: header})
+	if namespaces := md.Get(GRPCNamespaceMetadataKey); len(namespaces) > 0 {
+		ctx = ctxUtils.SetContextWithNamespace(ctx, namespaces[0])
+	}
+
+	if request.GetSubjectReference() == "" {
+		return nil, status.Error(codes.InvalidArgument, "subjectReference is required")
+	}
+	if len(request.GetStoreConfigurations()) > 0 || len(request.GetVerifierConfigurations()) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "store and verifier configurations are not supported, subjects are verified with the configured stores and verifiers")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.server.GetExecutor(ctx).GetVerifyRequestTimeout())
+	defer cancel()
+	response, err := s.server.verifySubjectWithDeadline(ctx, subjectVerification{subject: request.GetSubjectReference()})
+	elapsedTime := time.Since(startTime).Milliseconds()
+	logger.GetLogger(ctx, s.server.LogOption).Debugf("gRPC verification: execution time for request: %dms", elapsedTime)
+	metrics.ReportVerificationRequest(ctx, elapsedTime)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	results, err := toReferrerVerificationReports(request.GetSubjectReference(), response.VerifierReports)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &orchestrator.VerifySubjectResponse{
+		SubjectReference: request.GetSubjectReference(),
+		Results:          results,
+		IsSuccess:        response.IsSuccess,
+	}, nil
+}
+
+// grpcError converts an error of the verify handler into a gRPC status error.
+func grpcError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	var ratifyErr re.Error
+	if !errors.As(err, &ratifyErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	switch ratifyErr.ErrorCode() {
+	case re.ErrorCodeBadRequest, re.ErrorCodeReferenceInvalid:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case re.ErrorCodeNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case re.ErrorCodeTooManyRequests:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case re.ErrorCodeConfigInvalid:
+		// the namespace of the request has no store, verifier or policy
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toReferrerVerificationReports maps the verifier reports of a verify result
+// into reports per referrer of the subject. Verifier results of the same
+// referrer are grouped into a single report.
+func toReferrerVerificationReports(subject string, verifierReports []interface{}) ([]*orchestrator.VerifySubjectResponse_ReferrerVerificationReport, error) {
+	bytes, err := json.Marshal(verifierReports)
+	if err != nil {
+		return nil, re.ErrorCodeDataEncodingFailure.WithError(err).WithDetail("unable to encode verifier reports")
+	}
+	var nodes []grpcReportNode
+	if err := json.Unmarshal(bytes, &nodes); err != nil {
+		return nil, re.ErrorCodeDataDecodingFailure.WithError(err).WithDetail("unable to decode verifier reports")
+	}
+	return toReferrerReports(subject, nodes), nil
+}
+
+// toReferrerReports maps sibling report nodes of a subject into referrer
+// reports.
+func toReferrerReports(subject string, nodes []grpcReportNode) []*orchestrator.VerifySubjectResponse_ReferrerVerificationReport {
+	var reports []*orchestrator.VerifySubjectResponse_ReferrerVerificationReport
+	byReferrer := map[string]*orchestrator.VerifySubjectResponse_ReferrerVerificationReport{}
+	for _, node := range nodes {
+		if node.Subject == "" {
+			node.Subject = subject
+		}
+		key := node.ReferenceDigest + "/" + node.ArtifactType
+		report, ok := byReferrer[key]
+		if !ok {
+			report = &orchestrator.VerifySubjectResponse_ReferrerVerificationReport{
+				Referrer: toReferrer(node),
+			}
+			byReferrer[key] = report
+			reports = append(reports, report)
+		}
+
+		if node.IsSuccess != nil {
+			// a verifier result of the referrer
+			report.Reports = append(report.Reports, toVerificationReport(node))
+		} else {
+			// a nested verifier report of the referrer with its verifier results
+			for _, result := range node.VerifierReports {
+				report.Reports = append(report.Reports, toVerificationReport(result))
+			}
+		}
+		referrerSubject := referrerReference(node)
+		report.Children = append(report.Children, toReferrerReports(referrerSubject, node.NestedResults)...)
+		report.Children = append(report.Children, toReferrerReports(referrerSubject, node.NestedReports)...)
+	}
+	return reports
+}
+
+// toReferrer returns the referrer verified by the report node.
+func toReferrer(node grpcReportNode) *common.Referrer {
+	attributes := map[string]string{"subject": node.Subject}
+	if node.ReferenceDigest != "" {
+		attributes["digest"] = node.ReferenceDigest
+	}
+	return &common.Referrer{
+		ArtifactType: node.ArtifactType,
+		Descriptor_: &common.Descriptor{
+			RawPath:    referrerReference(node),
+			Attributes: []*common.Descriptor_Attributes{{Values: attributes}},
+		},
+	}
+}
+
+// referrerReference returns the reference of the referrer in the repository
+// of its subject.
+func referrerReference(node grpcReportNode) string {
+	if node.ReferenceDigest == "" {
+		return ""
+	}
+	repository := node.Subject
+	if subjectReference, err := pkgUtils.ParseSubjectReference(node.Subject); err == nil {
+		repository = subjectReference.Path
+	}
+	return fmt.Sprintf("%s@%s", repository, node.ReferenceDigest)
+}
+
+// toVerificationReport maps a verifier result into a verification report.
+// The message, error reason and remediation are reported as reasons and the
+// extensions as data.
+func toVerificationReport(node grpcReportNode) *orchestrator.VerifySubjectResponse_VerificationReport {
+	verifierName := node.VerifierName
+	if verifierName == "" {
+		verifierName = node.Name
+	}
+	report := &orchestrator.VerifySubjectResponse_VerificationReport{
+		VerifierName: verifierName,
+		Valid:        node.IsSuccess != nil && *node.IsSuccess,
+	}
+	for _, reason := range []string{node.Message, node.ErrorReason, node.Remediation} {
+		if reason != "" {
+			report.Reasons = append(report.Reasons, reason)
+		}
+	}
+	if extensions, ok := node.Extensions.(map[string]interface{}); ok && len(extensions) > 0 {
+		values := map[string]string{}
+		for key, value := range extensions {
+			if s, ok := value.(string); ok {
+				values[key] = s
+				continue
+			}
+			if bytes, err := json.Marshal(value); err == nil {
+				values[key] = string(bytes)
+			}
+		}
+		report.Data = append(report.Data, &orchestrator.VerifySubjectResponse_VerificationReport_ExtensionData{Values: values})
+	}
+	return report
+}
@@ -0,0 +1,257 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/experimental/proto/v1/orchestrator"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// newGRPCTestClient serves the verification service of the server over an
+// in-memory connection and returns a client of it.
+func newGRPCTestClient(t *testing.T, server *Server) orchestrator.VerificationServiceClient {
+	t.Helper()
+	lsnr := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	orchestrator.RegisterVerificationServiceServer(grpcServer, &verificationService{server: server})
+	go func() {
+		_ = grpcServer.Serve(lsnr)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lsnr.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return orchestrator.NewVerificationServiceClient(conn)
+}
+
+func TestVerificationService_VerifySubject(t *testing.T) {
+	storeConfig, _ := structpb.NewStruct(map[string]interface{}{"name": "oras"})
+	testCases := []struct {
+		name        string
+		executor    *core.Executor
+		request     *orchestrator.VerifySubjectRequest
+		wantCode    codes.Code
+		wantSuccess bool
+	}{
+		{
+			name:        "verification success",
+			executor:    newVerificationsTestExecutor(true),
+			request:     &orchestrator.VerifySubjectRequest{SubjectReference: "localhost:5000/net-monitor:v1"},
+			wantCode:    codes.OK,
+			wantSuccess: true,
+		},
+		{
+			name:     "verification failure",
+			executor: newVerificationsTestExecutor(false),
+			request:  &orchestrator.VerifySubjectRequest{SubjectReference: "localhost:5000/net-monitor:v1"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "missing subject",
+			executor: newVerificationsTestExecutor(true),
+			request:  &orchestrator.VerifySubjectRequest{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid subject",
+			executor: newVerificationsTestExecutor(true),
+			request:  &orchestrator.VerifySubjectRequest{SubjectReference: "invalid subject"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "store configurations",
+			executor: newVerificationsTestExecutor(true),
+			request: &orchestrator.VerifySubjectRequest{
+				SubjectReference:    "localhost:5000/net-monitor:v1",
+				StoreConfigurations: []*structpb.Struct{storeConfig},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "no verifiers",
+			executor: &core.Executor{},
+			request:  &orchestrator.VerifySubjectRequest{SubjectReference: "localhost:5000/net-monitor:v1"},
+			wantCode: codes.Unavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newGRPCTestClient(t, newVerificationsTestServer(t, tc.executor))
+			response, err := client.VerifySubject(context.Background(), tc.request)
+			if status.Code(err) != tc.wantCode {
+				t.Fatalf("VerifySubject() returned code %v, want %v: %v", status.Code(err), tc.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			if response.GetIsSuccess() != tc.wantSuccess {
+				t.Fatalf("expected isSuccess %t, got %t", tc.wantSuccess, response.GetIsSuccess())
+			}
+			if response.GetSubjectReference() != tc.request.GetSubjectReference() {
+				t.Fatalf("expected subject %s, got %s", tc.request.GetSubjectReference(), response.GetSubjectReference())
+			}
+			if len(response.GetResults()) != 1 || len(response.GetResults()[0].GetReports()) != 1 {
+				t.Fatalf("expected 1 referrer report with 1 verifier report, got %v", response.GetResults())
+			}
+			if valid := response.GetResults()[0].GetReports()[0].GetValid(); valid != tc.wantSuccess {
+				t.Fatalf("expected verifier report valid %t, got %t", tc.wantSuccess, valid)
+			}
+		})
+	}
+}
+
+func TestVerificationService_Namespace(t *testing.T) {
+	ex := newVerificationsTestExecutor(true)
+	var namespace string
+	server, err := NewServer(context.Background(), ":0", func(ctx context.Context) *core.Executor {
+		namespace = ctxUtils.GetNamespace(ctx)
+		return ex
+	}, "", "", 0, false, "", 0)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error: %v", err)
+	}
+	client := newGRPCTestClient(t, server)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GRPCNamespaceMetadataKey, "team-a")
+	if _, err := client.VerifySubject(ctx, &orchestrator.VerifySubjectRequest{SubjectReference: "localhost:5000/net-monitor:v1"}); err != nil {
+		t.Fatalf("VerifySubject() unexpected error: %v", err)
+	}
+	if namespace != "team-a" {
+		t.Fatalf("expected subject to be verified in namespace team-a, got %q", namespace)
+	}
+}
+
+func TestToReferrerVerificationReports(t *testing.T) {
+	subject := "localhost:5000/net-monitor:v1"
+	repository := "localhost:5000/net-monitor"
+	signatureDigest := digest.FromString("signature").String()
+	sbomDigest := digest.FromString("sbom").String()
+	testCases := []struct {
+		name    string
+		reports []interface{}
+	}{
+		{
+			name: "verifier results",
+			reports: []interface{}{
+				verifier.VerifierResult{
+					Subject:         subject,
+					IsSuccess:       true,
+					VerifierName:    "notation",
+					ReferenceDigest: signatureDigest,
+					ArtifactType:    "application/vnd.cncf.notary.signature",
+					Extensions:      map[string]interface{}{"issuer": "ratify", "certificates": []string{"leaf"}},
+					NestedResults: []verifier.VerifierResult{{
+						Subject:         repository + "@" + signatureDigest,
+						IsSuccess:       false,
+						Name:            "sbom",
+						ReferenceDigest: sbomDigest,
+						ArtifactType:    "application/spdx+json",
+						Message:         "sbom is not valid",
+						ErrorReason:     "missing license",
+					}},
+				},
+				verifier.VerifierResult{
+					Subject:         subject,
+					IsSuccess:       true,
+					VerifierName:    "notation-2",
+					ReferenceDigest: signatureDigest,
+					ArtifactType:    "application/vnd.cncf.notary.signature",
+					Extensions:      map[string]interface{}{"issuer": "ratify", "certificates": []string{"leaf"}},
+				},
+			},
+		},
+		{
+			name: "nested verifier reports",
+			reports: []interface{}{
+				types.NestedVerifierReport{
+					Subject:         subject,
+					ReferenceDigest: signatureDigest,
+					ArtifactType:    "application/vnd.cncf.notary.signature",
+					VerifierReports: []vt.VerifierResult{
+						{IsSuccess: true, VerifierName: "notation", Extensions: map[string]interface{}{"issuer": "ratify", "certificates": []string{"leaf"}}},
+						{IsSuccess: true, VerifierName: "notation-2"},
+					},
+					NestedReports: []types.NestedVerifierReport{{
+						Subject:         repository + "@" + signatureDigest,
+						ReferenceDigest: sbomDigest,
+						ArtifactType:    "application/spdx+json",
+						VerifierReports: []vt.VerifierResult{{IsSuccess: false, Name: "sbom", Message: "sbom is not valid", ErrorReason: "missing license"}},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := toReferrerVerificationReports(subject, tc.reports)
+			if err != nil {
+				t.Fatalf("toReferrerVerificationReports() unexpected error: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("expected 1 referrer report, got %d", len(results))
+			}
+			signature := results[0]
+			if signature.GetReferrer().GetArtifactType() != "application/vnd.cncf.notary.signature" ||
+				signature.GetReferrer().GetDescriptor_().GetRawPath() != repository+"@"+signatureDigest {
+				t.Fatalf("unexpected referrer %v", signature.GetReferrer())
+			}
+			if len(signature.GetReports()) != 2 || !signature.GetReports()[0].GetValid() || signature.GetReports()[0].GetVerifierName() != "notation" {
+				t.Fatalf("unexpected verifier reports %v", signature.GetReports())
+			}
+			data := signature.GetReports()[0].GetData()
+			if len(data) != 1 || data[0].GetValues()["issuer"] != "ratify" || data[0].GetValues()["certificates"] != `["leaf"]` {
+				t.Fatalf("unexpected extension data %v", data)
+			}
+
+			if len(signature.GetChildren()) != 1 {
+				t.Fatalf("expected 1 nested referrer report, got %d", len(signature.GetChildren()))
+			}
+			sbom := signature.GetChildren()[0]
+			if sbom.GetReferrer().GetDescriptor_().GetRawPath() != repository+"@"+sbomDigest {
+				t.Fatalf("unexpected nested referrer %v", sbom.GetReferrer())
+			}
+			if len(sbom.GetReports()) != 1 || sbom.GetReports()[0].GetValid() || sbom.GetReports()[0].GetVerifierName() != "sbom" ||
+				len(sbom.GetReports()[0].GetReasons()) != 2 {
+				t.Fatalf("unexpected nested verifier reports %v", sbom.GetReports())
+			}
+		})
+	}
+}
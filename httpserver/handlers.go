@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -29,8 +30,10 @@ import (
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/cache"
 	"github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	pkgUtils "github.com/ratify-project/ratify/pkg/utils"
 	"github.com/ratify-project/ratify/utils"
@@ -42,11 +45,17 @@ const apiVersion = "externaldata.gatekeeper.sh/v1alpha1"
 const verifyComponents string = "verify"
 const mutateComponents string = "mutate"
 
+// traceQueryParameter enables the verification trace of the verify request
+// when set to true.
+const traceQueryParameter = "trace"
+
 // verify validates provided images against the configured policy.
 // The image key could be either a standalone image(repo:tag) or an image within a specific namespace([namespace]repo:tag).
 // e.g.
 // 1. docker.io/library/nginx:latest an image without a namespace would be evaluated by cluster-wide policy.
 // 2. [ratify]docker.io/library/nginx:latest an image with a namespace would be evaluated by namespaced policy.
+// If the trace query parameter is true, the verification response of each
+// image includes the trace of the decisions made by the executor.
 func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	startTime := time.Now()
 	sanitizedMethod := utils.SanitizeString(r.Method)
@@ -64,6 +73,10 @@ func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http
 	if err = json.Unmarshal(body, &providerRequest); err != nil {
 		return fmt.Errorf("unable to unmarshal request body: %w", err)
 	}
+	traceEnabled, err := parseTraceParameter(r)
+	if err != nil {
+		return err
+	}
 
 	results := make([]externaldata.Item, 0)
 	wg := sync.WaitGroup{}
@@ -88,66 +101,18 @@ func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http
 				returnItem.Error = err.Error()
 				return
 			}
-			subjectReference, err := pkgUtils.ParseSubjectReference(requestKey.Subject)
-			if err != nil {
-				returnItem.Error = err.Error()
-				return
-			}
 			ctx = ctxUtils.SetContextWithNamespace(ctx, requestKey.Namespace)
 
-			if err := server.validateComponents(ctx, verifyComponents); err != nil {
-				logger.GetLogger(ctx, server.LogOption).Error(err)
+			verificationResponse, err := server.verifySubjectWithDeadline(ctx, subjectVerification{
+				subject:      requestKey.Subject,
+				includeTrace: traceEnabled,
+			})
+			if err != nil {
 				returnItem.Error = err.Error()
 				return
 			}
-
-			if subjectReference.Digest.String() == "" {
-				logger.GetLogger(ctx, server.LogOption).Warn("Digest should be used instead of tagged reference. The resolved digest may not point to the same signed artifact, since tags are mutable.")
-			}
-			resolvedSubjectReference := subjectReference.Original
-			unlock := server.keyMutex.Lock(resolvedSubjectReference)
-			defer unlock()
-
-			logger.GetLogger(ctx, server.LogOption).Infof("verifying subject %v", resolvedSubjectReference)
-			var result types.VerifyResult
-			found := false
-			cacheHit := false
-			var cacheResponse string
-			cacheProvider := cache.GetCacheProvider()
-			if cacheProvider != nil {
-				cacheResponse, found = cacheProvider.Get(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference))
-			}
-			if found && cacheResponse != "" {
-				if err := json.Unmarshal([]byte(cacheResponse), &result); err != nil {
-					err = errors.ErrorCodeDataDecodingFailure.WithError(err).WithDetail(fmt.Sprintf("unable to unmarshal cache entry for subject %v", resolvedSubjectReference))
-					logger.GetLogger(ctx, server.LogOption).Warn(err)
-				} else {
-					cacheHit = true
-					logger.GetLogger(ctx, server.LogOption).Debugf("cache hit for subject %v", resolvedSubjectReference)
-				}
-			}
-			if !cacheHit {
-				verifyParameters := executor.VerifyParameters{
-					Subject: resolvedSubjectReference,
-				}
-				if result, err = server.GetExecutor(ctx).VerifySubject(ctx, verifyParameters); err != nil {
-					returnItem.Error = errors.ErrorCodeExecutorFailure.WithError(err).WithComponentType(errors.Executor).Error()
-					return
-				}
-
-				if cacheProvider != nil {
-					logger.GetLogger(ctx, server.LogOption).Debugf("cache miss for subject %v", resolvedSubjectReference)
-					if !cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference), result, server.CacheTTL) {
-						logger.GetLogger(ctx, server.LogOption).Warnf("unable to insert cache entry for subject %v", resolvedSubjectReference)
-					}
-				}
-			}
-			verificationResponse := fromVerifyResult(ctx, result, server.GetExecutor(ctx).PolicyEnforcer.GetPolicyType(ctx))
 			returnItem.Value = verificationResponse
-			if res, err := json.MarshalIndent(verificationResponse, "", "  "); err == nil {
-				logger.GetLogger(ctx, server.LogOption).Infof("verification response for subject %s: \n%s", resolvedSubjectReference, string(res))
-			}
-			logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for image %s: %dms", resolvedSubjectReference, time.Since(routineStartTime).Milliseconds())
+			logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for image %s: %dms", requestKey.Subject, time.Since(routineStartTime).Milliseconds())
 		}(utils.SanitizeString(key), ctx)
 	}
 	wg.Wait()
@@ -157,6 +122,195 @@ func (server *Server) verify(ctx context.Context, w http.ResponseWriter, r *http
 	return sendResponse(&results, "", w, http.StatusOK, false)
 }
 
+// subjectVerification describes the verification of a single subject.
+type subjectVerification struct {
+	subject       string
+	artifactTypes []string
+	// policyEnforcer overrides the policy of the executor if set.
+	policyEnforcer policyprovider.PolicyProvider
+	includeTrace   bool
+	// cacheReadOnly does not write the result to the cache of the admission
+	// requests. It is set for requests of the verification API, which choose
+	// the namespace to verify the subject in.
+	cacheReadOnly bool
+	// prewarmTTL caches the result as pre-warmed entry for the TTL, capped
+	// at CacheTTL, without reading the cache if set.
+	prewarmTTL time.Duration
+}
+
+// cacheable returns true if the result of the verification is read from the
+// cache.
+func (request subjectVerification) cacheable() bool {
+	return request.policyEnforcer == nil && len(request.artifactTypes) == 0
+}
+
+// readsCache returns true if the result is looked up in the cache. Traced
+// requests bypass the cache so that the trace records the decisions of the
+// executor, and pre-warming refreshes the entry.
+func (request subjectVerification) readsCache() bool {
+	return request.cacheable() && request.prewarmTTL <= 0 && !request.includeTrace
+}
+
+// verifySubjectWithDeadline verifies the subject until the deadline of the
+// context. If the result is cacheable, a verification exceeding the deadline
+// keeps running in the background until BackgroundVerifyTimeout so that the
+// next request of the subject, such as a retry of Gatekeeper, hits the cache.
+func (server *Server) verifySubjectWithDeadline(ctx context.Context, request subjectVerification) (VerificationResponse, error) {
+	if server.BackgroundVerifyTimeout <= 0 || !request.cacheable() || request.cacheReadOnly || cache.GetCacheProvider() == nil {
+		return server.verifySubject(ctx, request)
+	}
+
+	type verificationResult struct {
+		response VerificationResponse
+		err      error
+	}
+	done := make(chan verificationResult, 1)
+	backgroundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), server.BackgroundVerifyTimeout)
+	go func() {
+		defer cancel()
+		response, err := server.verifySubject(backgroundCtx, request)
+		done <- verificationResult{response: response, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.response, result.err
+	case <-ctx.Done():
+		logger.GetLogger(ctx, server.LogOption).Infof("verification of subject %s exceeded the request deadline and continues in the background", request.subject)
+		return VerificationResponse{}, errors.ErrorCodeExecutorFailure.WithError(ctx.Err()).WithDetail(fmt.Sprintf("verification of subject %s continues in the background", request.subject))
+	}
+}
+
+// verifySubject verifies the subject with the executor of the namespace of
+// the context. Verifications of the same subject are serialized and their
+// results are cached unless the request overrides the policy or filters the
+// artifact types, since cache entries are keyed by subject only, or the
+// request is read-only. Traced requests do not read the cache.
+func (server *Server) verifySubject(ctx context.Context, request subjectVerification) (VerificationResponse, error) {
+	subjectReference, err := pkgUtils.ParseSubjectReference(request.subject)
+	if err != nil {
+		return VerificationResponse{}, err
+	}
+
+	if err := server.validateComponents(ctx, verifyComponents); err != nil {
+		logger.GetLogger(ctx, server.LogOption).Error(err)
+		return VerificationResponse{}, err
+	}
+
+	if subjectReference.Digest.String() == "" {
+		logger.GetLogger(ctx, server.LogOption).Warn("Digest should be used instead of tagged reference. The resolved digest may not point to the same signed artifact, since tags are mutable.")
+	}
+	resolvedSubjectReference := subjectReference.Original
+	// cache entries are keyed by the namespace of the context
+	unlock := server.keyMutex.Lock(ctxUtils.CreateCacheKey(ctx, resolvedSubjectReference))
+	defer unlock()
+
+	logger.GetLogger(ctx, server.LogOption).Infof("verifying subject %v", resolvedSubjectReference)
+	var verificationTrace *trace.Trace
+	if request.includeTrace {
+		verificationTrace = trace.New(resolvedSubjectReference, logger.GetTraceID(ctx))
+		ctx = trace.NewContext(ctx, verificationTrace)
+	}
+
+	ex := server.GetExecutor(ctx)
+	if request.policyEnforcer != nil {
+		overridden := *ex
+		overridden.PolicyEnforcer = request.policyEnforcer
+		ex = &overridden
+	}
+
+	var result types.VerifyResult
+	found := false
+	cacheHit := false
+	var cacheResponse string
+	var cacheProvider cache.CacheProvider
+	if request.cacheable() {
+		cacheProvider = cache.GetCacheProvider()
+	}
+	if cacheProvider != nil && request.readsCache() {
+		cacheResponse, found = cacheProvider.Get(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference))
+	}
+	if found && cacheResponse != "" {
+		if err := json.Unmarshal([]byte(cacheResponse), &result); err != nil {
+			err = errors.ErrorCodeDataDecodingFailure.WithError(err).WithDetail(fmt.Sprintf("unable to unmarshal cache entry for subject %v", resolvedSubjectReference))
+			logger.GetLogger(ctx, server.LogOption).Warn(err)
+		} else {
+			cacheHit = true
+			logger.GetLogger(ctx, server.LogOption).Debugf("cache hit for subject %v", resolvedSubjectReference)
+			trace.Record(ctx, trace.Event{Type: trace.EventCacheHit, Subject: resolvedSubjectReference, Message: "verify handler cache"})
+		}
+	}
+	if cacheProvider != nil && request.readsCache() {
+		prewarmed := false
+		if cacheHit {
+			_, prewarmed = cacheProvider.Get(ctx, fmt.Sprintf(cache.CacheKeyPrewarm, resolvedSubjectReference))
+		}
+		metrics.ReportVerifyCacheCount(ctx, cacheHit, prewarmed)
+	}
+	if !cacheHit {
+		if cacheProvider != nil && request.readsCache() {
+			trace.Record(ctx, trace.Event{Type: trace.EventCacheMiss, Subject: resolvedSubjectReference, Message: "verify handler cache"})
+		}
+		verifyParameters := executor.VerifyParameters{
+			Subject:        resolvedSubjectReference,
+			ReferenceTypes: request.artifactTypes,
+		}
+		if result, err = ex.VerifySubject(ctx, verifyParameters); err != nil {
+			return VerificationResponse{}, errors.ErrorCodeExecutorFailure.WithError(err).WithComponentType(errors.Executor)
+		}
+
+		if cacheProvider != nil && request.prewarmTTL > 0 {
+			if err := server.cachePrewarmedResult(ctx, cacheProvider, resolvedSubjectReference, result, request.prewarmTTL); err != nil {
+				return VerificationResponse{}, err
+			}
+		} else if cacheProvider != nil && !request.cacheReadOnly {
+			logger.GetLogger(ctx, server.LogOption).Debugf("cache miss for subject %v", resolvedSubjectReference)
+			if !cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, resolvedSubjectReference), result, server.CacheTTL) {
+				logger.GetLogger(ctx, server.LogOption).Warnf("unable to insert cache entry for subject %v", resolvedSubjectReference)
+			}
+		}
+	}
+	verificationResponse := fromVerifyResult(ctx, result, ex.PolicyEnforcer.GetPolicyType(ctx))
+	if res, err := json.MarshalIndent(verificationResponse, "", "  "); err == nil {
+		logger.GetLogger(ctx, server.LogOption).Infof("verification response for subject %s: \n%s", resolvedSubjectReference, string(res))
+	}
+	if verificationTrace != nil {
+		verificationTrace.Finish()
+		verificationResponse.Trace = verificationTrace
+	}
+	return verificationResponse, nil
+}
+
+// PrewarmSubject verifies the subject with the executor of the namespace of the
+// context ahead of its admission and caches the result for the TTL, capped at
+// CacheTTL. The cache is not read so that the entry is refreshed before it
+// expires.
+func (server *Server) PrewarmSubject(ctx context.Context, subject string, ttl time.Duration) error {
+	if cache.GetCacheProvider() == nil {
+		return errors.ErrorCodeCacheNotSet.WithDetail("cache pre-warming requires the cache to be enabled")
+	}
+	if ttl <= 0 {
+		ttl = server.CacheTTL
+	}
+	_, err := server.verifySubject(ctx, subjectVerification{subject: subject, prewarmTTL: ttl})
+	return err
+}
+
+// cachePrewarmedResult caches the result of a pre-warmed subject along with the
+// marker reporting cache hits of pre-warmed entries.
+func (server *Server) cachePrewarmedResult(ctx context.Context, cacheProvider cache.CacheProvider, subject string, result types.VerifyResult, ttl time.Duration) error {
+	if server.CacheTTL > 0 && ttl > server.CacheTTL {
+		ttl = server.CacheTTL
+	}
+	if !cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyVerifyHandler, subject), result, ttl) {
+		return errors.ErrorCodeCacheNotSet.WithDetail(fmt.Sprintf("unable to insert cache entry for subject %s", subject))
+	}
+	if !cacheProvider.SetWithTTL(ctx, fmt.Sprintf(cache.CacheKeyPrewarm, subject), true, ttl) {
+		return errors.ErrorCodeCacheNotSet.WithDetail(fmt.Sprintf("unable to insert pre-warm marker for subject %s", subject))
+	}
+	return nil
+}
+
 func (server *Server) mutate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	startTime := time.Now()
 	sanitizedMethod := utils.SanitizeString(r.Method)
@@ -266,6 +420,20 @@ func (server *Server) validateComponents(ctx context.Context, handlerComponents
 	return nil
 }
 
+// parseTraceParameter returns true if the request enables the verification
+// trace.
+func parseTraceParameter(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get(traceQueryParameter)
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.ErrorCodeBadRequest.WithError(err).WithDetail(fmt.Sprintf("invalid %s query parameter %s", traceQueryParameter, utils.SanitizeString(value)))
+	}
+	return enabled, nil
+}
+
 func sendResponse(results *[]externaldata.Item, systemErr string, w http.ResponseWriter, respCode int, isMutation bool) error {
 	response := externaldata.ProviderResponse{
 		APIVersion: apiVersion,
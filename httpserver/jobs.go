@@ -0,0 +1,465 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	re "github.com/ratify-project/ratify/errors"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/internal/logger"
+	pkgUtils "github.com/ratify-project/ratify/pkg/utils"
+	"github.com/ratify-project/ratify/utils"
+)
+
+const (
+	jobsPath  = "jobs"
+	jobIDVar  = "id"
+	statusVar = "status"
+
+	// defaultBackgroundVerifyTimeout is the maximum duration of a verification
+	// running in the background, either as a job or after the request timed out.
+	defaultBackgroundVerifyTimeout = 5 * time.Minute
+	defaultMaxConcurrentJobs       = 4
+	defaultMaxJobs                 = 1000
+	defaultJobRetention            = time.Hour
+
+	webhookTimeout         = 10 * time.Second
+	webhookMaxAttempts     = 3
+	webhookRetryInterval   = time.Second
+	webhookSignatureHeader = "X-Ratify-Signature"
+	webhookSignaturePrefix = "sha256="
+)
+
+// JobStatus is the status of a verification job.
+type JobStatus string
+
+const (
+	// JobStatusPending is the status of a job waiting for a worker.
+	JobStatusPending JobStatus = "pending"
+	// JobStatusRunning is the status of a job verifying its subject.
+	JobStatusRunning JobStatus = "running"
+	// JobStatusSucceeded is the status of a job that verified its subject,
+	// whether the subject passed the verification or not.
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusFailed is the status of a job that failed to verify its subject.
+	JobStatusFailed JobStatus = "failed"
+)
+
+// jobManager runs verification jobs in the background and retains them in
+// memory until they expire.
+type jobManager struct {
+	mu        sync.Mutex
+	jobs      map[string]*VerificationJob
+	workers   chan struct{}
+	maxJobs   int
+	retention time.Duration
+	// client posts to webhooks resolving to public addresses only,
+	// allowListedClient posts to the webhooks of the allow-list.
+	client            *http.Client
+	allowListedClient *http.Client
+}
+
+func newJobManager(maxConcurrentJobs, maxJobs int, retention time.Duration) *jobManager {
+	return &jobManager{
+		jobs:              map[string]*VerificationJob{},
+		workers:           make(chan struct{}, maxConcurrentJobs),
+		maxJobs:           maxJobs,
+		retention:         retention,
+		client:            newWebhookClient(true),
+		allowListedClient: newWebhookClient(false),
+	}
+}
+
+// newWebhookClient returns the client posting to webhooks. Redirects are not
+// followed. If restricted, connections to addresses that are not public are
+// rejected after DNS resolution and proxies are not used, so that webhooks
+// cannot reach the services of the cluster or the cloud metadata endpoints.
+func newWebhookClient(restricted bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if restricted {
+		dialer.Control = publicAddressControl
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddressControl rejects connections to the resolved address unless it
+// is public.
+func publicAddressControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public and not in the webhook allow-list", host)
+	}
+	return nil
+}
+
+// isPublicIP returns false for loopback, link-local, private, multicast and
+// unspecified addresses.
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsPrivate() && !ip.IsUnspecified()
+}
+
+// add creates a pending job for the request. Completed jobs past the
+// retention are removed first, the request fails if the manager still retains
+// maxJobs jobs.
+func (m *jobManager) add(request VerificationJobRequest) (VerificationJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range m.jobs {
+		if job.CompletedAt != nil && now.Sub(*job.CompletedAt) > m.retention {
+			delete(m.jobs, id)
+		}
+	}
+	if len(m.jobs) >= m.maxJobs {
+		return VerificationJob{}, re.ErrorCodeTooManyRequests.WithDetail(fmt.Sprintf("the server retains the maximum of %d verification jobs", m.maxJobs))
+	}
+
+	job := &VerificationJob{
+		ID:        uuid.New().String(),
+		Status:    JobStatusPending,
+		Subject:   request.Subject,
+		Namespace: request.Namespace,
+		CreatedAt: now,
+	}
+	if request.Webhook != nil {
+		job.Webhook = &WebhookStatus{URL: request.Webhook.URL}
+	}
+	m.jobs[job.ID] = job
+	return *job, nil
+}
+
+// get returns a copy of the job with the ID.
+func (m *jobManager) get(id string) (VerificationJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return VerificationJob{}, false
+	}
+	return job.snapshot(), true
+}
+
+// list returns copies of the jobs with the status, or all jobs if the status is
+// empty, in the order of creation.
+func (m *jobManager) list(status JobStatus) []VerificationJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]VerificationJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if status == "" || job.Status == status {
+			jobs = append(jobs, job.snapshot())
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// update applies the change to the job with the ID, unless it expired, and
+// returns a copy of it.
+func (m *jobManager) update(id string, change func(job *VerificationJob)) VerificationJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return VerificationJob{}
+	}
+	change(job)
+	return job.snapshot()
+}
+
+// snapshot returns a copy of the job that is not modified by later updates.
+func (job *VerificationJob) snapshot() VerificationJob {
+	copied := *job
+	if job.Webhook != nil {
+		webhook := *job.Webhook
+		copied.Webhook = &webhook
+	}
+	return copied
+}
+
+// runJob verifies the subject of the job once a worker is available, then
+// notifies the webhook of the job if any. The context is detached from the
+// request that submitted the job.
+func (server *Server) runJob(ctx context.Context, id string, verification subjectVerification, webhook *Webhook, webhookAllowListed bool) {
+	server.jobs.workers <- struct{}{}
+	defer func() { <-server.jobs.workers }()
+
+	server.jobs.update(id, func(job *VerificationJob) {
+		startedAt := time.Now()
+		job.Status = JobStatusRunning
+		job.StartedAt = &startedAt
+	})
+
+	timeout := server.BackgroundVerifyTimeout
+	if timeout <= 0 {
+		timeout = defaultBackgroundVerifyTimeout
+	}
+	verifyCtx, cancel := context.WithTimeout(ctx, timeout)
+	response, err := server.verifySubject(verifyCtx, verification)
+	cancel()
+
+	job := server.jobs.update(id, func(job *VerificationJob) {
+		completedAt := time.Now()
+		job.CompletedAt = &completedAt
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.Error = &Error{Code: apiErrorCode(err), Message: err.Error()}
+			return
+		}
+		job.Status = JobStatusSucceeded
+		job.Result = &response
+	})
+	if err != nil {
+		logger.GetLogger(ctx, server.LogOption).Warnf("verification job %s of subject %s failed: %v", id, verification.subject, err)
+	}
+
+	if webhook != nil {
+		attempts, err := server.jobs.notify(ctx, webhook, job, webhookAllowListed)
+		server.jobs.update(id, func(job *VerificationJob) {
+			job.Webhook.Attempts = attempts
+			job.Webhook.Delivered = err == nil
+			if err != nil {
+				job.Webhook.Error = err.Error()
+			}
+		})
+		if err != nil {
+			logger.GetLogger(ctx, server.LogOption).Warnf("failed to notify webhook %s of verification job %s: %v", utils.SanitizeString(webhook.URL), id, err)
+		}
+	}
+}
+
+// notify posts the completed job to the webhook and retries on failures. It
+// returns the number of attempts.
+func (m *jobManager) notify(ctx context.Context, webhook *Webhook, job VerificationJob, allowListed bool) (int, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		if lastErr = m.post(ctx, webhook, body, allowListed); lastErr == nil {
+			return attempt, nil
+		}
+		if attempt < webhookMaxAttempts {
+			select {
+			case <-ctx.Done():
+				return attempt, ctx.Err()
+			case <-time.After(webhookRetryInterval * time.Duration(1<<(attempt-1))):
+			}
+		}
+	}
+	return webhookMaxAttempts, lastErr
+}
+
+func (m *jobManager) post(ctx context.Context, webhook *Webhook, body []byte, allowListed bool) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		request.Header.Set(webhookSignatureHeader, webhookSignature(webhook.Secret, body))
+	}
+
+	client := m.client
+	if allowListed {
+		client = m.allowListedClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// webhookSignature returns the HMAC-SHA256 signature of the body, which
+// receivers compare with the X-Ratify-Signature header to authenticate the
+// notification.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// createJob submits a VerificationJobRequest and returns the pending job
+// without waiting for the verification.
+func (server *Server) createJob(ctx context.Context, r *http.Request) (interface{}, int, error) {
+	if server.jobs == nil {
+		return nil, 0, re.ErrorCodeConfigInvalid.WithDetail("verification jobs are not enabled")
+	}
+
+	var request VerificationJobRequest
+	if err := decodeAPIRequest(r, &request); err != nil {
+		return nil, 0, err
+	}
+	if err := validateVerificationRequest(request.VerificationRequest); err != nil {
+		return nil, 0, err
+	}
+	webhookAllowListed, err := server.validateWebhook(request.Webhook)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := pkgUtils.ParseSubjectReference(request.Subject); err != nil {
+		return nil, 0, err
+	}
+
+	verification := subjectVerification{
+		subject:       request.Subject,
+		artifactTypes: request.ArtifactTypes,
+		includeTrace:  request.IncludeTrace,
+	}
+	if request.Policy != nil {
+		var err error
+		if verification.policyEnforcer, err = createPolicyOverride(request.Policy); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	job, err := server.jobs.add(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	logger.GetLogger(ctx, server.LogOption).Infof("submitted verification job %s of subject %s", job.ID, utils.SanitizeString(request.Subject))
+
+	jobCtx := ctxUtils.SetContextWithNamespace(context.WithoutCancel(ctx), request.Namespace)
+	go server.runJob(jobCtx, job.ID, verification, request.Webhook, webhookAllowListed)
+	return job, http.StatusAccepted, nil
+}
+
+// getJob returns the verification job with the ID of the request path.
+func (server *Server) getJob(_ context.Context, r *http.Request) (interface{}, int, error) {
+	id := mux.Vars(r)[jobIDVar]
+	if server.jobs != nil {
+		if job, ok := server.jobs.get(id); ok {
+			return job, http.StatusOK, nil
+		}
+	}
+	return nil, 0, re.ErrorCodeNotFound.WithDetail(fmt.Sprintf("verification job %s is not found", utils.SanitizeString(id)))
+}
+
+// listJobs returns the retained verification jobs, filtered by the status
+// query parameter if set.
+func (server *Server) listJobs(_ context.Context, r *http.Request) (interface{}, int, error) {
+	status := JobStatus(r.URL.Query().Get(statusVar))
+	switch status {
+	case "", JobStatusPending, JobStatusRunning, JobStatusSucceeded, JobStatusFailed:
+	default:
+		return nil, 0, re.ErrorCodeBadRequest.WithDetail(fmt.Sprintf("invalid job status %s", utils.SanitizeString(string(status))))
+	}
+
+	jobs := []VerificationJob{}
+	if server.jobs != nil {
+		jobs = server.jobs.list(status)
+	}
+	return VerificationJobList{Jobs: jobs}, http.StatusOK, nil
+}
+
+// validateWebhook validates the webhook URL against the WebhookAllowList of
+// the server and returns whether the webhook is in the allow-list. Webhooks
+// that are not in the allow-list are rejected if the allow-list is set, or
+// if their host is an address that is not public.
+func (server *Server) validateWebhook(webhook *Webhook) (bool, error) {
+	if webhook == nil {
+		return false, nil
+	}
+	webhookURL, err := url.Parse(webhook.URL)
+	if err != nil {
+		return false, re.ErrorCodeBadRequest.WithError(err).WithDetail("invalid webhook url")
+	}
+	if (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		return false, re.ErrorCodeBadRequest.WithDetail("webhook url must be an absolute http or https url")
+	}
+	if webhookAllowListed(server.WebhookAllowList, webhookURL) {
+		return true, nil
+	}
+	if len(server.WebhookAllowList) > 0 {
+		return false, re.ErrorCodeBadRequest.WithDetail("webhook url is not in the webhook allow-list of the server")
+	}
+	host := webhookURL.Hostname()
+	if ip := net.ParseIP(host); strings.EqualFold(host, "localhost") || (ip != nil && !isPublicIP(ip)) {
+		return false, re.ErrorCodeBadRequest.WithDetail("webhook url must have a public address unless it is in the webhook allow-list of the server")
+	}
+	return false, nil
+}
+
+// webhookAllowListed returns true if an entry of the allow-list is the host of
+// the URL, with or without its port, or a prefix of the URL ending at a path
+// boundary if the entry has a scheme.
+func webhookAllowListed(allowList []string, webhookURL *url.URL) bool {
+	rawURL := webhookURL.String()
+	for _, entry := range allowList {
+		if strings.Contains(entry, "://") {
+			if rest, ok := strings.CutPrefix(rawURL, entry); ok && (rest == "" || strings.HasSuffix(entry, "/") || strings.ContainsAny(rest[:1], "/?#")) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(entry, webhookURL.Host) || strings.EqualFold(entry, webhookURL.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiErrorCode returns the code of an error of the verification API.
+func apiErrorCode(err error) string {
+	var ratifyErr re.Error
+	if errors.As(err, &ratifyErr) {
+		return ratifyErr.ErrorCode().Descriptor().Value
+	}
+	return re.ErrorCodeUnknown.Descriptor().Value
+}
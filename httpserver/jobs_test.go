@@ -0,0 +1,370 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/executor/core"
+	config "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+)
+
+func submitTestJob(t *testing.T, server *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodPost, "/ratify/v1/jobs", strings.NewReader(body))
+	responseRecorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(responseRecorder, request)
+	return responseRecorder
+}
+
+func getTestJob(t *testing.T, server *Server, id string) (VerificationJob, int) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, "/ratify/v1/jobs/"+id, nil)
+	responseRecorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(responseRecorder, request)
+
+	var job VerificationJob
+	if responseRecorder.Code == http.StatusOK {
+		if err := json.NewDecoder(responseRecorder.Body).Decode(&job); err != nil {
+			t.Fatalf("failed to decode job: %v", err)
+		}
+	}
+	return job, responseRecorder.Code
+}
+
+func TestCreateJob_Webhook(t *testing.T) {
+	const secret = "test-secret"
+	notifications := make(chan VerificationJob, 1)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read notification: %v", err)
+		}
+		if signature := r.Header.Get(webhookSignatureHeader); signature != webhookSignature(secret, body) {
+			t.Errorf("notification has signature %s, want %s", signature, webhookSignature(secret, body))
+		}
+		var job VerificationJob
+		if err := json.Unmarshal(body, &job); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		notifications <- job
+	}))
+	defer webhookServer.Close()
+
+	server := newVerificationsTestServer(t, newVerificationsTestExecutor(true))
+	// the test server listens on a loopback address
+	server.WebhookAllowList = []string{webhookServer.URL}
+	responseRecorder := submitTestJob(t, server, fmt.Sprintf(`{"subject": "localhost:5000/net-monitor:v1", "webhook": {"url": %q, "secret": %q}}`, webhookServer.URL, secret))
+	if responseRecorder.Code != http.StatusAccepted {
+		t.Fatalf("Want status '%d', got '%d': %s", http.StatusAccepted, responseRecorder.Code, responseRecorder.Body.String())
+	}
+	if strings.Contains(responseRecorder.Body.String(), secret) {
+		t.Fatalf("job exposes the webhook secret: %s", responseRecorder.Body.String())
+	}
+	var submitted VerificationJob
+	if err := json.NewDecoder(responseRecorder.Body).Decode(&submitted); err != nil {
+		t.Fatalf("failed to decode job: %v", err)
+	}
+	if submitted.ID == "" || submitted.Status != JobStatusPending {
+		t.Fatalf("unexpected submitted job %+v", submitted)
+	}
+
+	select {
+	case notified := <-notifications:
+		if notified.ID != submitted.ID || notified.Status != JobStatusSucceeded || notified.Result == nil || !notified.Result.IsSuccess {
+			t.Fatalf("unexpected notified job %+v", notified)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook was not notified of the completed job")
+	}
+
+	// the delivery status is updated after the webhook responded
+	var job VerificationJob
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var code int
+		if job, code = getTestJob(t, server, submitted.ID); code != http.StatusOK {
+			t.Fatalf("Want status '%d', got '%d'", http.StatusOK, code)
+		}
+		if job.Webhook != nil && job.Webhook.Delivered {
+			break
+		}
+	}
+	if job.Status != JobStatusSucceeded || job.CompletedAt == nil || job.Webhook == nil || !job.Webhook.Delivered || job.Webhook.Attempts != 1 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/ratify/v1/jobs?status=succeeded", nil)
+	listRecorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(listRecorder, request)
+	var list VerificationJobList
+	if err := json.NewDecoder(listRecorder.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode job list: %v", err)
+	}
+	if len(list.Jobs) != 1 || list.Jobs[0].ID != submitted.ID {
+		t.Fatalf("unexpected job list %+v", list)
+	}
+}
+
+func TestCreateJob_Failure(t *testing.T) {
+	server := newVerificationsTestServer(t, &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{},
+		ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{}},
+	})
+	responseRecorder := submitTestJob(t, server, `{"subject": "localhost:5000/net-monitor:v1"}`)
+	if responseRecorder.Code != http.StatusAccepted {
+		t.Fatalf("Want status '%d', got '%d': %s", http.StatusAccepted, responseRecorder.Code, responseRecorder.Body.String())
+	}
+	var submitted VerificationJob
+	if err := json.NewDecoder(responseRecorder.Body).Decode(&submitted); err != nil {
+		t.Fatalf("failed to decode job: %v", err)
+	}
+
+	var job VerificationJob
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if job, _ = getTestJob(t, server, submitted.ID); job.CompletedAt != nil {
+			break
+		}
+	}
+	if job.Status != JobStatusFailed || job.Error == nil || job.Error.Code != re.ErrorCodeConfigInvalid.Descriptor().Value || job.Result != nil {
+		t.Fatalf("unexpected job %+v", job)
+	}
+}
+
+func TestJobsAPI_InvalidRequests(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "missing subject",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"namespace": "default"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "webhook with unsupported scheme",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"subject": "localhost:5000/net-monitor:v1", "webhook": {"url": "ftp://example.com/callback"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "webhook with loopback address",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"subject": "localhost:5000/net-monitor:v1", "webhook": {"url": "http://127.0.0.1:8080/callback"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "webhook with link-local address",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"subject": "localhost:5000/net-monitor:v1", "webhook": {"url": "http://169.254.169.254/latest/meta-data"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "relative webhook url",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"subject": "localhost:5000/net-monitor:v1", "webhook": {"url": "/callback"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid subject",
+			method:   http.MethodPost,
+			path:     "/ratify/v1/jobs",
+			body:     `{"subject": "localhost:5000/Net-Monitor:v1"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown job",
+			method:   http.MethodGet,
+			path:     "/ratify/v1/jobs/unknown",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "invalid status",
+			method:   http.MethodGet,
+			path:     "/ratify/v1/jobs?status=unknown",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	server := newVerificationsTestServer(t, newVerificationsTestExecutor(true))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			responseRecorder := httptest.NewRecorder()
+			server.Router.ServeHTTP(responseRecorder, request)
+			if responseRecorder.Code != tc.wantCode {
+				t.Fatalf("Want status '%d', got '%d': %s", tc.wantCode, responseRecorder.Code, responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestJobManager_MaxJobs(t *testing.T) {
+	manager := newJobManager(1, 1, time.Hour)
+	request := VerificationJobRequest{VerificationRequest: VerificationRequest{Subject: "localhost:5000/net-monitor:v1"}}
+	job, err := manager.add(request)
+	if err != nil {
+		t.Fatalf("failed to add job: %v", err)
+	}
+
+	var ratifyErr re.Error
+	if _, err := manager.add(request); !errors.As(err, &ratifyErr) || ratifyErr.ErrorCode() != re.ErrorCodeTooManyRequests {
+		t.Fatalf("expected error %v, got %v", re.ErrorCodeTooManyRequests, err)
+	}
+
+	// completed jobs past the retention are removed to add new jobs
+	manager.retention = 0
+	manager.update(job.ID, func(job *VerificationJob) {
+		completedAt := time.Now().Add(-time.Second)
+		job.CompletedAt = &completedAt
+		job.Status = JobStatusSucceeded
+	})
+	if _, err := manager.add(request); err != nil {
+		t.Fatalf("failed to add job after expiry: %v", err)
+	}
+	if _, ok := manager.get(job.ID); ok {
+		t.Fatalf("expired job %s is still retained", job.ID)
+	}
+}
+
+func TestJobManager_WebhookRetry(t *testing.T) {
+	attempts := 0
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhookServer.Close()
+
+	manager := newJobManager(1, 1, time.Hour)
+	delivered, err := manager.notify(context.Background(), &Webhook{URL: webhookServer.URL}, VerificationJob{ID: "test"}, true)
+	if err != nil {
+		t.Fatalf("failed to notify webhook: %v", err)
+	}
+	if delivered != 2 || attempts != 2 {
+		t.Fatalf("webhook delivered after %d attempts, want 2", delivered)
+	}
+}
+
+func TestJobManager_WebhookRestrictions(t *testing.T) {
+	redirected := false
+	targetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		redirected = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer targetServer.Close()
+	webhookServer := httptest.NewServer(http.RedirectHandler(targetServer.URL, http.StatusTemporaryRedirect))
+	defer webhookServer.Close()
+
+	manager := newJobManager(1, 1, time.Hour)
+	webhook := &Webhook{URL: webhookServer.URL}
+	// the test server resolves to a loopback address
+	if err := manager.post(context.Background(), webhook, nil, false); err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Fatalf("expected the loopback address to be rejected, got %v", err)
+	}
+	if err := manager.post(context.Background(), webhook, nil, true); err == nil {
+		t.Fatal("expected the redirect response to fail the notification")
+	}
+	if redirected {
+		t.Fatal("webhook redirect was followed")
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	testCases := []struct {
+		name            string
+		allowList       []string
+		url             string
+		wantAllowListed bool
+		wantErr         bool
+	}{
+		{
+			name: "public host",
+			url:  "https://hooks.example.com/ratify",
+		},
+		{
+			name:    "localhost",
+			url:     "http://localhost:8080/ratify",
+			wantErr: true,
+		},
+		{
+			name:    "private address",
+			url:     "http://10.0.0.1/ratify",
+			wantErr: true,
+		},
+		{
+			name:            "allow-listed host",
+			allowList:       []string{"ratify-webhook.default.svc"},
+			url:             "http://ratify-webhook.default.svc:8080/ratify",
+			wantAllowListed: true,
+		},
+		{
+			name:            "allow-listed private address",
+			allowList:       []string{"http://10.0.0.1/ratify"},
+			url:             "http://10.0.0.1/ratify/jobs",
+			wantAllowListed: true,
+		},
+		{
+			name:      "host not in allow-list",
+			allowList: []string{"hooks.example.com"},
+			url:       "https://other.example.com/ratify",
+			wantErr:   true,
+		},
+		{
+			name:      "prefix not at path boundary",
+			allowList: []string{"https://hooks.example.com"},
+			url:       "https://hooks.example.com.attacker.io/ratify",
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &Server{WebhookAllowList: tc.allowList}
+			allowListed, err := server.validateWebhook(&Webhook{URL: tc.url})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validateWebhook() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if allowListed != tc.wantAllowListed {
+				t.Fatalf("validateWebhook() allow-listed = %v, want %v", allowListed, tc.wantAllowListed)
+			}
+		})
+	}
+}
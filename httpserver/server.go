@@ -63,8 +63,20 @@ type Server struct {
 	MetricsPort       int
 	CacheTTL          time.Duration
 	LogOption         logger.Option
+	// BackgroundVerifyTimeout is the maximum duration of a verification
+	// running in the background after its request timed out or as a job.
+	// Verifications stop with their request if it is not positive.
+	BackgroundVerifyTimeout time.Duration
+	// GRPCAddress is the address of the gRPC verification service. The
+	// service is not started if it is empty.
+	GRPCAddress string
+	// WebhookAllowList lists the hosts, or URL prefixes with scheme, that
+	// verification jobs may notify. If it is empty, webhooks may be any URL
+	// resolving to a public address.
+	WebhookAllowList []string
 
 	keyMutex keyMutex
+	jobs     *jobManager
 }
 
 // keyMutex is a thread-safe map of mutexes, indexed by key.
@@ -108,6 +120,9 @@ func NewServer(context context.Context,
 		CacheTTL:          cacheTTL,
 		keyMutex:          keyMutex{},
 		LogOption:         logger.Option{ComponentType: logger.Server},
+
+		BackgroundVerifyTimeout: defaultBackgroundVerifyTimeout,
+		jobs:                    newJobManager(defaultMaxConcurrentJobs, defaultMaxJobs, defaultJobRetention),
 	}
 
 	return server, server.registerHandlers()
@@ -163,8 +178,22 @@ func (server *Server) Run(certRotatorReady chan struct{}) error {
 			MinVersion:         tls.VersionTLS13,
 		}
 
+		if server.GRPCAddress != "" {
+			grpcServer, err := server.serveGRPC(grpcTLSConfig(tlsCertWatcher))
+			if err != nil {
+				return err
+			}
+			defer grpcServer.GracefulStop()
+		}
 		return startServerWithGracefulShutdown(true, svr, lsnr, certFile, keyFile)
 	}
+	if server.GRPCAddress != "" {
+		grpcServer, err := server.serveGRPC(nil)
+		if err != nil {
+			return err
+		}
+		defer grpcServer.GracefulStop()
+	}
 	return startServerWithGracefulShutdown(false, svr, lsnr, "", "")
 }
 
@@ -188,6 +217,26 @@ func (server *Server) registerHandlers() error {
 	}
 	server.register(http.MethodPost, mutatePath, processTimeout(server.mutate, server.GetExecutor(server.Context).GetMutationRequestTimeout(), true))
 
+	verificationsURL, err := url.JoinPath(APIRootURL, verificationsPath)
+	if err != nil {
+		return err
+	}
+	server.register(http.MethodPost, verificationsURL, processAPIRequest(server.createVerification, server.GetExecutor(server.Context).GetAPIRequestTimeout()))
+
+	openAPIURL, err := url.JoinPath(APIRootURL, openAPIPath)
+	if err != nil {
+		return err
+	}
+	server.register(http.MethodGet, openAPIURL, server.getOpenAPISpec)
+
+	jobsURL, err := url.JoinPath(APIRootURL, jobsPath)
+	if err != nil {
+		return err
+	}
+	server.register(http.MethodPost, jobsURL, processAPIRequest(server.createJob, server.GetExecutor(server.Context).GetAPIRequestTimeout()))
+	server.register(http.MethodGet, jobsURL, processAPIRequest(server.listJobs, server.GetExecutor(server.Context).GetAPIRequestTimeout()))
+	server.register(http.MethodGet, jobsURL+"/{"+jobIDVar+"}", processAPIRequest(server.getJob, server.GetExecutor(server.Context).GetAPIRequestTimeout()))
+
 	return nil
 }
 
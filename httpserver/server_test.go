@@ -24,13 +24,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
 
 	ratifyerrors "github.com/ratify-project/ratify/errors"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/pkg/cache"
+	"github.com/ratify-project/ratify/pkg/controllers/prewarm"
 	exconfig "github.com/ratify-project/ratify/pkg/executor/config"
 	"github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	config "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
 	"github.com/sirupsen/logrus"
@@ -237,6 +242,163 @@ func TestServer_MultipleSubjects_Success(t *testing.T) {
 	})
 }
 
+// TestServer_Verify_Trace tests the verification trace is returned only if
+// requested with the trace query parameter
+func TestServer_Verify_Trace(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantTrace bool
+	}{
+		{name: "trace enabled", query: "?trace=true", wantCode: http.StatusOK, wantTrace: true},
+		{name: "trace disabled", query: "?trace=false", wantCode: http.StatusOK},
+		{name: "no trace parameter", wantCode: http.StatusOK},
+		{name: "invalid trace parameter", query: "?trace=verbose", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			if err := json.NewEncoder(body).Encode(externaldata.NewProviderRequest([]string{testImageNameTagged})); err != nil {
+				t.Fatalf("failed to encode request body: %v", err)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify"+tc.query, bytes.NewReader(body.Bytes()))
+			responseRecorder := httptest.NewRecorder()
+
+			configPolicy := config.PolicyEnforcer{
+				ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+					testArtifactType: types.AnyVerifySuccess,
+				}}
+			store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+				{
+					ArtifactType: testArtifactType,
+				}},
+				ResolveMap: map[string]digest.Digest{
+					"v1": digest.FromString("test"),
+				},
+			}
+			ver := &core.TestVerifier{
+				CanVerifyFunc: func(at string) bool {
+					return at == testArtifactType
+				},
+				VerifyResult: func(_ string) bool {
+					return true
+				},
+			}
+			ex := &core.Executor{
+				PolicyEnforcer: configPolicy,
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				Verifiers:      []verifier.ReferenceVerifier{ver},
+			}
+			server := &Server{
+				GetExecutor: func(context.Context) *core.Executor { return ex },
+				Context:     request.Context(),
+				keyMutex:    keyMutex{},
+			}
+			handler := contextHandler{
+				context: server.Context,
+				handler: processTimeout(server.verify, ex.GetVerifyRequestTimeout(), false),
+			}
+
+			handler.ServeHTTP(responseRecorder, request)
+			if responseRecorder.Code != tc.wantCode {
+				t.Fatalf("Want status '%d', got '%d'", tc.wantCode, responseRecorder.Code)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var respBody struct {
+				Response struct {
+					Items []struct {
+						Value struct {
+							IsSuccess bool `json:"isSuccess"`
+							Trace     *struct {
+								Subject string `json:"subject"`
+								Events  []struct {
+									Type string `json:"type"`
+								} `json:"events"`
+							} `json:"trace"`
+						} `json:"value"`
+					} `json:"items"`
+				} `json:"response"`
+			}
+			if err := json.NewDecoder(responseRecorder.Result().Body).Decode(&respBody); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			if len(respBody.Response.Items) != 1 || !respBody.Response.Items[0].Value.IsSuccess {
+				t.Fatalf("unexpected response items %+v", respBody.Response.Items)
+			}
+			verificationTrace := respBody.Response.Items[0].Value.Trace
+			if !tc.wantTrace {
+				if verificationTrace != nil {
+					t.Fatalf("response has an unexpected trace")
+				}
+				return
+			}
+			if verificationTrace == nil || verificationTrace.Subject != testImageNameTagged || len(verificationTrace.Events) == 0 {
+				t.Fatalf("response has no trace of subject %s: %+v", testImageNameTagged, verificationTrace)
+			}
+		})
+	}
+}
+
+func TestServer_Verify_TraceWarmCache(t *testing.T) {
+	if _, err := cache.NewCacheProvider(context.Background(), prewarmTestCacheType, "", 0); err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = cache.NewCacheProvider(context.Background(), noCacheType, "", 0)
+	})
+	testPrewarmCache.mu.Lock()
+	testPrewarmCache.entries = map[string]string{}
+	testPrewarmCache.mu.Unlock()
+
+	verifications := 0
+	var mu sync.Mutex
+	ex := newVerificationsTestExecutor(true)
+	ex.Verifiers = []verifier.ReferenceVerifier{&core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			verifications++
+			return true
+		},
+	}}
+	server := newVerificationsTestServer(t, ex)
+	server.CacheTTL = 10 * time.Second
+
+	if _, err := server.verifySubject(context.Background(), subjectVerification{subject: testImageNameTagged}); err != nil {
+		t.Fatalf("verifySubject() unexpected error: %v", err)
+	}
+	response, err := server.verifySubject(context.Background(), subjectVerification{subject: testImageNameTagged, includeTrace: true})
+	if err != nil {
+		t.Fatalf("verifySubject() unexpected error: %v", err)
+	}
+	if verifications != 2 {
+		t.Fatalf("expected the traced request to bypass the warm cache, got %d verifications", verifications)
+	}
+	if response.Trace == nil {
+		t.Fatalf("response has no trace")
+	}
+	eventTypes := map[trace.EventType]bool{}
+	for _, event := range response.Trace.Events {
+		eventTypes[event.Type] = true
+	}
+	for _, eventType := range []trace.EventType{trace.EventVerifierResult, trace.EventPolicyEvaluated} {
+		if !eventTypes[eventType] {
+			t.Fatalf("trace has no %s event: %+v", eventType, response.Trace.Events)
+		}
+	}
+	if eventTypes[trace.EventCacheHit] {
+		t.Fatalf("trace of a request bypassing the cache has a cache hit")
+	}
+}
+
 func TestServer_Mutation_Success(t *testing.T) {
 	timeoutDuration := 6
 	testDigest := digest.FromString("test")
@@ -716,3 +878,182 @@ func TestServer_serverGracefulShutdown(t *testing.T) {
 	// wait some time to see shutdown logs
 	time.Sleep(5 * time.Second)
 }
+
+const (
+	prewarmTestCacheType = "httpserver-prewarm-test"
+	noCacheType          = "httpserver-no-cache"
+)
+
+// prewarmTestCache is an in-memory cache provider recording the TTL of its
+// entries, which are keyed by the namespace of the context.
+type prewarmTestCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+	ttls    map[string]time.Duration
+}
+
+type prewarmTestCacheFactory struct {
+	cache *prewarmTestCache
+}
+
+func (f prewarmTestCacheFactory) Create(_ context.Context, _ string, _ int) (cache.CacheProvider, error) {
+	if f.cache == nil {
+		return nil, nil
+	}
+	return f.cache, nil
+}
+
+func (c *prewarmTestCache) Get(ctx context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.entries[ctxUtils.CreateCacheKey(ctx, key)]
+	return value, ok
+}
+
+func (c *prewarmTestCache) Set(ctx context.Context, key string, value interface{}) bool {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+func (c *prewarmTestCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) bool {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ctxUtils.CreateCacheKey(ctx, key)] = string(bytes)
+	c.ttls[ctxUtils.CreateCacheKey(ctx, key)] = ttl
+	return true
+}
+
+func (c *prewarmTestCache) Delete(ctx context.Context, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, ctxUtils.CreateCacheKey(ctx, key))
+	return true
+}
+
+var testPrewarmCache = &prewarmTestCache{entries: map[string]string{}, ttls: map[string]time.Duration{}}
+
+func init() {
+	cache.Register(prewarmTestCacheType, prewarmTestCacheFactory{cache: testPrewarmCache})
+	cache.Register(noCacheType, prewarmTestCacheFactory{})
+}
+
+func TestServer_PrewarmSubject(t *testing.T) {
+	if err := (&Server{}).PrewarmSubject(context.Background(), testImageNameTagged, time.Minute); err == nil {
+		t.Fatalf("expected error without cache")
+	}
+
+	if _, err := cache.NewCacheProvider(context.Background(), prewarmTestCacheType, "", 0); err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() {
+		// the cache provider is global, other tests run without cache
+		_, _ = cache.NewCacheProvider(context.Background(), noCacheType, "", 0)
+	})
+
+	verifications := 0
+	var mu sync.Mutex
+	ex := newVerificationsTestExecutor(true)
+	ex.Verifiers = []verifier.ReferenceVerifier{&core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			verifications++
+			return true
+		},
+	}}
+	server := newVerificationsTestServer(t, ex)
+	server.CacheTTL = 10 * time.Second
+
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), "default")
+	if err := server.PrewarmSubject(ctx, testImageNameTagged, 10*time.Minute); err != nil {
+		t.Fatalf("PrewarmSubject() unexpected error: %v", err)
+	}
+	entryKey := "default:" + fmt.Sprintf(cache.CacheKeyVerifyHandler, testImageNameTagged)
+	if _, ok := testPrewarmCache.entries[entryKey]; !ok {
+		t.Fatalf("pre-warmed entry %s not found", entryKey)
+	}
+	if ttl := testPrewarmCache.ttls[entryKey]; ttl != server.CacheTTL {
+		t.Fatalf("pre-warmed entry has ttl %v, want the cache ttl %v", ttl, server.CacheTTL)
+	}
+	if _, ok := testPrewarmCache.entries["default:"+fmt.Sprintf(cache.CacheKeyPrewarm, testImageNameTagged)]; !ok {
+		t.Fatalf("pre-warm marker of subject %s not found", testImageNameTagged)
+	}
+
+	// admission requests of the namespace hit the pre-warmed entry
+	if _, err := server.verifySubject(ctx, subjectVerification{subject: testImageNameTagged}); err != nil {
+		t.Fatalf("verifySubject() unexpected error: %v", err)
+	}
+	if verifications != 1 {
+		t.Fatalf("expected the admission request to hit the cache, got %d verifications", verifications)
+	}
+
+	// entries are not shared across namespaces
+	otherCtx := ctxUtils.SetContextWithNamespace(context.Background(), "other")
+	if _, err := server.verifySubject(otherCtx, subjectVerification{subject: testImageNameTagged}); err != nil {
+		t.Fatalf("verifySubject() unexpected error: %v", err)
+	}
+	if verifications != 2 {
+		t.Fatalf("expected the admission request of another namespace to miss the cache, got %d verifications", verifications)
+	}
+}
+
+func TestServer_PrewarmSubject_DefaultKeys(t *testing.T) {
+	if _, err := cache.NewCacheProvider(context.Background(), prewarmTestCacheType, "", 0); err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = cache.NewCacheProvider(context.Background(), noCacheType, "", 0)
+	})
+	testPrewarmCache.mu.Lock()
+	testPrewarmCache.entries = map[string]string{}
+	testPrewarmCache.mu.Unlock()
+
+	verifications := 0
+	var mu sync.Mutex
+	ex := newVerificationsTestExecutor(true)
+	ex.Verifiers = []verifier.ReferenceVerifier{&core.TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == testArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			verifications++
+			return true
+		},
+	}}
+	server := newVerificationsTestServer(t, ex)
+	server.CacheTTL = 10 * time.Second
+
+	// the pre-warmer verifies the image of a workload in the default namespace
+	prewarmer := prewarm.NewPrewarmer(prewarm.Options{})
+	prewarmer.SetVerifier(server)
+	if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImageNameTagged}); err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+
+	// the default constraint template sends the bare image of the pod
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(externaldata.NewProviderRequest([]string{testImageNameTagged})); err != nil {
+		t.Fatalf("failed to encode request body: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v1/verify", bytes.NewReader(body.Bytes()))
+	responseRecorder := httptest.NewRecorder()
+	handler := contextHandler{
+		context: server.Context,
+		handler: processTimeout(server.verify, ex.GetVerifyRequestTimeout(), false),
+	}
+	handler.ServeHTTP(responseRecorder, request)
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Want status '%d', got '%d'", http.StatusOK, responseRecorder.Code)
+	}
+	if verifications != 1 {
+		t.Fatalf("expected the admission request to hit the pre-warmed entry, got %d verifications", verifications)
+	}
+}
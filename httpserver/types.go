@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 )
@@ -39,6 +40,78 @@ type VerificationResponse struct {
 	TraceID         string        `json:"traceID,omitempty"`
 	Timestamp       string        `json:"timestamp,omitempty"`
 	VerifierReports []interface{} `json:"verifierReports,omitempty"`
+	// Trace is the timeline of the verification, only set if requested.
+	Trace *trace.Trace `json:"trace,omitempty"`
+}
+
+// VerificationRequest is a request of the verification API to verify a
+// subject.
+type VerificationRequest struct {
+	// Subject is the reference of the subject, preferably by digest.
+	Subject string `json:"subject"`
+	// Namespace selects the namespaced resources used to verify the subject.
+	// The cluster-wide resources are used if empty.
+	Namespace string `json:"namespace,omitempty"`
+	// ArtifactTypes filters the referrers to verify. All referrers are
+	// verified if empty.
+	ArtifactTypes []string `json:"artifactTypes,omitempty"`
+	// Policy evaluates the verifier reports instead of the configured policy.
+	Policy *PolicyOverride `json:"policy,omitempty"`
+	// IncludeTrace returns the trace of the verification in the response.
+	IncludeTrace bool `json:"includeTrace,omitempty"`
+}
+
+// PolicyOverride describes a policy provider in the same way as the spec of a
+// Policy resource.
+type PolicyOverride struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// VerificationJobRequest is a request of the verification API to verify a
+// subject in the background.
+type VerificationJobRequest struct {
+	VerificationRequest
+	// Webhook is notified when the job completes.
+	Webhook *Webhook `json:"webhook,omitempty"`
+}
+
+// Webhook is the endpoint receiving the completed job as a POST request.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs the body of the notification with HMAC-SHA256 in the
+	// X-Ratify-Signature header if set.
+	Secret string `json:"secret,omitempty"`
+}
+
+// VerificationJob is a verification running in the background.
+type VerificationJob struct {
+	ID          string     `json:"id"`
+	Status      JobStatus  `json:"status"`
+	Subject     string     `json:"subject"`
+	Namespace   string     `json:"namespace,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Result is the response of the verification once the job succeeded.
+	Result *VerificationResponse `json:"result,omitempty"`
+	// Error is the reason of the failure once the job failed.
+	Error   *Error         `json:"error,omitempty"`
+	Webhook *WebhookStatus `json:"webhook,omitempty"`
+}
+
+// WebhookStatus is the delivery status of the webhook of a job, which never
+// includes its secret.
+type WebhookStatus struct {
+	URL       string `json:"url"`
+	Delivered bool   `json:"delivered"`
+	Attempts  int    `json:"attempts,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// VerificationJobList is the list of retained verification jobs.
+type VerificationJobList struct {
+	Jobs []VerificationJob `json:"jobs"`
 }
 
 func fromVerifyResult(ctx context.Context, res types.VerifyResult, policyType string) VerificationResponse {
@@ -0,0 +1,226 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/policyprovider"
+	pc "github.com/ratify-project/ratify/pkg/policyprovider/config"
+	pf "github.com/ratify-project/ratify/pkg/policyprovider/factory"
+	"github.com/ratify-project/ratify/utils"
+)
+
+const (
+	// APIRootURL is the root of the versioned verification API, which does not
+	// depend on the Gatekeeper external data protocol.
+	APIRootURL = "/ratify/v1"
+
+	verificationsPath = "verifications"
+	openAPIPath       = "openapi.yaml"
+
+	// maxVerificationRequestSize is the maximum size of a verification request
+	// body in bytes.
+	maxVerificationRequestSize = 1 << 20
+)
+
+// openAPISpec is the OpenAPI specification of the verification API.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// APIHandler handles a request of the verification API and returns the
+// response body with its status code.
+type APIHandler func(ctx context.Context, r *http.Request) (interface{}, int, error)
+
+// createVerification verifies the subject of the VerificationRequest and
+// returns the VerificationResponse. The response status is 200 whether the
+// subject passes the verification or not, errors are returned with a 4xx or
+// 5xx status.
+func (server *Server) createVerification(ctx context.Context, r *http.Request) (interface{}, int, error) {
+	startTime := time.Now()
+	logger.GetLogger(ctx, server.LogOption).Debugf("start request %s %s", utils.SanitizeString(r.Method), utils.SanitizeURL(*r.URL))
+
+	request, err := parseVerificationRequest(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx = ctxUtils.SetContextWithNamespace(ctx, request.Namespace)
+
+	verification := subjectVerification{
+		subject:       request.Subject,
+		artifactTypes: request.ArtifactTypes,
+		includeTrace:  request.IncludeTrace,
+		cacheReadOnly: true,
+	}
+	if request.Policy != nil {
+		if verification.policyEnforcer, err = createPolicyOverride(request.Policy); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	response, err := server.verifySubjectWithDeadline(ctx, verification)
+	elapsedTime := time.Since(startTime).Milliseconds()
+	logger.GetLogger(ctx, server.LogOption).Debugf("verification: execution time for request: %dms", elapsedTime)
+	metrics.ReportVerificationRequest(ctx, elapsedTime)
+	if err != nil {
+		return nil, 0, err
+	}
+	return response, http.StatusOK, nil
+}
+
+// getOpenAPISpec returns the OpenAPI specification of the verification API.
+func (server *Server) getOpenAPISpec(_ context.Context, w http.ResponseWriter, _ *http.Request) error {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(openAPISpec)
+	return err
+}
+
+func parseVerificationRequest(r *http.Request) (VerificationRequest, error) {
+	var request VerificationRequest
+	if err := decodeAPIRequest(r, &request); err != nil {
+		return VerificationRequest{}, err
+	}
+	if err := validateVerificationRequest(request); err != nil {
+		return VerificationRequest{}, err
+	}
+	return request, nil
+}
+
+// decodeAPIRequest unmarshals the JSON body of a request of the verification
+// API.
+func decodeAPIRequest(r *http.Request, request interface{}) error {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxVerificationRequestSize+1))
+	if err != nil {
+		return re.ErrorCodeBadRequest.WithError(err).WithDetail("unable to read request body")
+	}
+	if len(body) > maxVerificationRequestSize {
+		return re.ErrorCodeBadRequest.WithDetail(fmt.Sprintf("request body exceeds %d bytes", maxVerificationRequestSize))
+	}
+	if err := json.Unmarshal(body, request); err != nil {
+		return re.ErrorCodeBadRequest.WithError(err).WithDetail("unable to unmarshal request body")
+	}
+	return nil
+}
+
+func validateVerificationRequest(request VerificationRequest) error {
+	if request.Subject == "" {
+		return re.ErrorCodeBadRequest.WithDetail("subject is required")
+	}
+	if request.Policy != nil && request.Policy.Type == "" {
+		return re.ErrorCodeBadRequest.WithDetail("policy type is required")
+	}
+	return nil
+}
+
+// createPolicyOverride creates the policy provider evaluating the results of
+// the request instead of the configured policy.
+func createPolicyOverride(policy *PolicyOverride) (policyprovider.PolicyProvider, error) {
+	pluginConfig := pc.PolicyPluginConfig{}
+	for key, value := range policy.Parameters {
+		pluginConfig[key] = value
+	}
+	pluginConfig["name"] = policy.Type
+
+	policyEnforcer, err := pf.CreatePolicyProviderFromConfig(pc.PoliciesConfig{PolicyPlugin: pluginConfig})
+	if err != nil {
+		return nil, re.ErrorCodeBadRequest.WithError(err).WithDetail(fmt.Sprintf("invalid policy override of type %s", policy.Type))
+	}
+	return policyEnforcer, nil
+}
+
+// apiStatusCode returns the HTTP status code of an error of the verification
+// API.
+func apiStatusCode(err error) int {
+	var ratifyErr re.Error
+	if !errors.As(err, &ratifyErr) {
+		return http.StatusInternalServerError
+	}
+	switch ratifyErr.ErrorCode() {
+	case re.ErrorCodeBadRequest, re.ErrorCodeReferenceInvalid:
+		return http.StatusBadRequest
+	case re.ErrorCodeNotFound:
+		return http.StatusNotFound
+	case re.ErrorCodeTooManyRequests:
+		return http.StatusTooManyRequests
+	case re.ErrorCodeConfigInvalid:
+		// the namespace of the request has no store, verifier or policy
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// sendAPIResponse writes the JSON response of the verification API.
+func sendAPIResponse(w http.ResponseWriter, statusCode int, body interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(body)
+}
+
+// sendAPIError writes the error as the JSON response of the verification API.
+func sendAPIError(w http.ResponseWriter, statusCode int, err error) error {
+	return sendAPIResponse(w, statusCode, Error{Code: apiErrorCode(err), Message: err.Error()})
+}
+
+// processAPIRequest runs the handler of the verification API with a timeout
+// and writes its response. The handler does not write to the response so
+// that a handler still running after the timeout cannot race with the
+// timeout response.
+func processAPIRequest(h APIHandler, duration time.Duration) ContextHandler {
+	return func(_ context.Context, w http.ResponseWriter, r *http.Request) error {
+		ctx, cancel := context.WithTimeout(r.Context(), duration)
+		defer cancel()
+
+		ctx = logger.InitContext(ctx, r)
+		r = r.WithContext(ctx)
+
+		type apiResult struct {
+			body       interface{}
+			statusCode int
+			err        error
+		}
+		processDone := make(chan apiResult, 1)
+		go func() {
+			body, statusCode, err := h(ctx, r)
+			processDone <- apiResult{body: body, statusCode: statusCode, err: err}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return sendAPIError(w, http.StatusGatewayTimeout, re.ErrorCodeExecutorFailure.WithDetail(fmt.Sprintf("operation timed out after duration %v", duration)))
+		case result := <-processDone:
+			if result.err != nil {
+				logger.GetLogger(ctx, logger.Option{ComponentType: logger.Server}).Errorf("request %s %s failed with error %v", utils.SanitizeString(r.Method), utils.SanitizeURL(*r.URL), result.err)
+				return sendAPIError(w, apiStatusCode(result.err), result.err)
+			}
+			return sendAPIResponse(w, result.statusCode, result.body)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	config "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	"github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+func newVerificationsTestServer(t *testing.T, ex *core.Executor) *Server {
+	t.Helper()
+	server, err := NewServer(context.Background(), ":0", func(context.Context) *core.Executor { return ex }, "", "", 0, false, "", 0)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error: %v", err)
+	}
+	return server
+}
+
+func newVerificationsTestExecutor(verifySuccess bool) *core.Executor {
+	return &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]types.ArtifactTypeVerifyPolicy{
+				testArtifactType: types.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{
+			References: []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType}},
+			ResolveMap: map[string]digest.Digest{"v1": digest.FromString("test")},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{&core.TestVerifier{
+			CanVerifyFunc: func(at string) bool {
+				return at == testArtifactType
+			},
+			VerifyResult: func(_ string) bool {
+				return verifySuccess
+			},
+		}},
+	}
+}
+
+func TestCreateVerification(t *testing.T) {
+	testCases := []struct {
+		name          string
+		executor      *core.Executor
+		body          string
+		wantCode      int
+		wantErrorCode string
+		wantSuccess   bool
+		wantTrace     bool
+	}{
+		{
+			name:        "verification success",
+			executor:    newVerificationsTestExecutor(true),
+			body:        `{"subject": "localhost:5000/net-monitor:v1"}`,
+			wantCode:    http.StatusOK,
+			wantSuccess: true,
+		},
+		{
+			name:     "verification failure",
+			executor: newVerificationsTestExecutor(false),
+			body:     `{"subject": "localhost:5000/net-monitor:v1", "artifactTypes": ["test-type1"]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "verification with trace",
+			executor:    newVerificationsTestExecutor(true),
+			body:        `{"subject": "localhost:5000/net-monitor:v1", "includeTrace": true}`,
+			wantCode:    http.StatusOK,
+			wantSuccess: true,
+			wantTrace:   true,
+		},
+		{
+			name:        "verification with policy override",
+			executor:    newVerificationsTestExecutor(true),
+			body:        `{"subject": "localhost:5000/net-monitor:v1", "policy": {"type": "configpolicy", "parameters": {"artifactVerificationPolicies": {"test-type1": "any"}}}}`,
+			wantCode:    http.StatusOK,
+			wantSuccess: true,
+		},
+		{
+			name:          "unknown policy override",
+			executor:      newVerificationsTestExecutor(true),
+			body:          `{"subject": "localhost:5000/net-monitor:v1", "policy": {"type": "unknown"}}`,
+			wantCode:      http.StatusBadRequest,
+			wantErrorCode: "BAD_REQUEST",
+		},
+		{
+			name:          "missing subject",
+			executor:      newVerificationsTestExecutor(true),
+			body:          `{"namespace": "default"}`,
+			wantCode:      http.StatusBadRequest,
+			wantErrorCode: "BAD_REQUEST",
+		},
+		{
+			name:          "invalid body",
+			executor:      newVerificationsTestExecutor(true),
+			body:          `[]`,
+			wantCode:      http.StatusBadRequest,
+			wantErrorCode: "BAD_REQUEST",
+		},
+		{
+			name:          "invalid subject",
+			executor:      newVerificationsTestExecutor(true),
+			body:          `{"subject": "localhost:5000/Net-Monitor:v1"}`,
+			wantCode:      http.StatusBadRequest,
+			wantErrorCode: "REFERENCE_INVALID",
+		},
+		{
+			name: "no verifier configured",
+			executor: &core.Executor{
+				PolicyEnforcer: config.PolicyEnforcer{},
+				ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{}},
+			},
+			body:          `{"subject": "localhost:5000/net-monitor:v1"}`,
+			wantCode:      http.StatusServiceUnavailable,
+			wantErrorCode: "CONFIG_INVALID",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newVerificationsTestServer(t, tc.executor)
+			request := httptest.NewRequest(http.MethodPost, "/ratify/v1/verifications", strings.NewReader(tc.body))
+			responseRecorder := httptest.NewRecorder()
+			server.Router.ServeHTTP(responseRecorder, request)
+
+			if responseRecorder.Code != tc.wantCode {
+				t.Fatalf("Want status '%d', got '%d': %s", tc.wantCode, responseRecorder.Code, responseRecorder.Body.String())
+			}
+			if tc.wantErrorCode != "" {
+				var apiErr Error
+				if err := json.NewDecoder(responseRecorder.Body).Decode(&apiErr); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if apiErr.Code != tc.wantErrorCode || apiErr.Message == "" {
+					t.Fatalf("unexpected error response %+v, want code %s", apiErr, tc.wantErrorCode)
+				}
+				return
+			}
+
+			var response struct {
+				Version         string            `json:"version"`
+				IsSuccess       bool              `json:"isSuccess"`
+				VerifierReports []json.RawMessage `json:"verifierReports"`
+				Trace           *json.RawMessage  `json:"trace"`
+			}
+			if err := json.NewDecoder(responseRecorder.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode verification response: %v", err)
+			}
+			if response.IsSuccess != tc.wantSuccess || response.Version == "" || len(response.VerifierReports) == 0 {
+				t.Fatalf("unexpected verification response %+v", response)
+			}
+			if (response.Trace != nil) != tc.wantTrace {
+				t.Fatalf("verification response has trace = %t, want %t", response.Trace != nil, tc.wantTrace)
+			}
+		})
+	}
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	server := newVerificationsTestServer(t, newVerificationsTestExecutor(true))
+	request := httptest.NewRequest(http.MethodGet, "/ratify/v1/openapi.yaml", nil)
+	responseRecorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(responseRecorder, request)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Fatalf("Want status '%d', got '%d'", http.StatusOK, responseRecorder.Code)
+	}
+	if !strings.Contains(responseRecorder.Body.String(), "/verifications:") {
+		t.Fatalf("response is not the OpenAPI specification of the verification API")
+	}
+}
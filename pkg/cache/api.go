@@ -25,6 +25,10 @@ const (
 	CacheKeyListReferrers     string = "cache_ratify_list_referrers_%s"
 	CacheKeyVerifyHandler     string = "cache_ratify_verify_handler_%s"
 	CacheKeyOrasAuth          string = "cache_ratify_oras_auth_%s"
+	CacheKeyCRL               string = "cache_ratify_crl_%s"
+	// CacheKeyPrewarm marks a verify handler cache entry populated ahead of
+	// admission by the cache pre-warmer.
+	CacheKeyPrewarm string = "cache_ratify_prewarm_%s"
 
 	DefaultCacheType string = "ristretto"
 	// DefaultCacheTTL is the default time-to-live for the cache entry.
@@ -0,0 +1,168 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package artifactverifier verifies the signature of plugin artifacts downloaded
+// by the dynamic plugins feature with the built-in notation or cosign verifier.
+// It is registered with the plugin package from a separate package as the
+// built-in verifiers and referrer stores depend on the plugin package.
+package artifactverifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/internal/constants"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/common/plugin"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	rc "github.com/ratify-project/ratify/pkg/referrerstore/config"
+	sf "github.com/ratify-project/ratify/pkg/referrerstore/factory"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras" // register oras referrer store
+	st "github.com/ratify-project/ratify/pkg/referrerstore/types"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	vc "github.com/ratify-project/ratify/pkg/verifier/config"
+	vf "github.com/ratify-project/ratify/pkg/verifier/factory"
+	"github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	notationVerifierType = "notation"
+	cosignVerifierType   = "cosign"
+	orasStoreName        = "oras"
+
+	notationSignatureArtifactType = "application/vnd.cncf.notary.signature"
+	cosignSignatureArtifactType   = "application/vnd.dev.cosign.artifact.sig.v1+json"
+)
+
+func init() {
+	plugin.RegisterArtifactVerifier(VerifyArtifact)
+}
+
+// VerifyArtifact verifies that the plugin artifact has at least one signature
+// accepted by the verifier configured in the plugin source.
+func VerifyArtifact(ctx context.Context, source plugin.PluginSource, artifactDesc oci.Descriptor) error {
+	referenceVerifier, verifierType, err := createVerifier(source.Verification.Verifier)
+	if err != nil {
+		return err
+	}
+
+	store, err := newStore(source, verifierType)
+	if err != nil {
+		return fmt.Errorf("failed to create the referrer store for the plugin artifact: %w", err)
+	}
+
+	artifactReference, err := utils.ParseSubjectReference(source.Artifact)
+	if err != nil {
+		return err
+	}
+	subjectReference, err := utils.ParseSubjectReference(fmt.Sprintf("%s@%s", artifactReference.Path, artifactDesc.Digest))
+	if err != nil {
+		return err
+	}
+	return verifySignatures(ctx, referenceVerifier, store, subjectReference, &ocispecs.SubjectDescriptor{Descriptor: artifactDesc})
+}
+
+// newStore creates the ORAS store listing the signatures of the plugin
+// artifact. It is a variable for mocking purposes.
+var newStore = func(source plugin.PluginSource, verifierType string) (referrerstore.ReferrerStore, error) {
+	return sf.CreateStoreFromConfig(rc.StorePluginConfig{
+		st.Name:         orasStoreName,
+		"cosignEnabled": verifierType == cosignVerifierType,
+		"authProvider":  source.AuthProvider,
+	}, st.SpecVersion, []string{""})
+}
+
+// newVerifier creates the built-in verifier from the completed verifier
+// config. It is a variable for mocking purposes.
+var newVerifier = func(config vc.VerifierConfig) (verifier.ReferenceVerifier, error) {
+	return vf.CreateVerifierFromConfig(config, types.SpecVersion, []string{""}, constants.EmptyNamespace)
+}
+
+// createVerifier creates the built-in verifier from the verification config of
+// the plugin source.
+func createVerifier(verifierConfig map[string]interface{}) (verifier.ReferenceVerifier, string, error) {
+	config := vc.VerifierConfig{}
+	for key, value := range verifierConfig {
+		config[key] = value
+	}
+	if _, ok := config[types.Source]; ok {
+		return nil, "", fmt.Errorf("the verifier of a plugin artifact must be a built-in verifier and cannot specify %s", types.Source)
+	}
+
+	verifierType, _ := config[types.Type].(string)
+	if verifierType == "" {
+		verifierType, _ = config[types.Name].(string)
+	}
+	var defaultArtifactType string
+	switch verifierType {
+	case notationVerifierType:
+		defaultArtifactType = notationSignatureArtifactType
+	case cosignVerifierType:
+		defaultArtifactType = cosignSignatureArtifactType
+	default:
+		return nil, "", fmt.Errorf("unsupported verifier type %q for plugin artifacts, only %s and %s are supported", verifierType, notationVerifierType, cosignVerifierType)
+	}
+	if _, ok := config[types.Name]; !ok {
+		config[types.Name] = verifierType
+	}
+	if _, ok := config[types.ArtifactTypes]; !ok {
+		config[types.ArtifactTypes] = defaultArtifactType
+	}
+
+	referenceVerifier, err := newVerifier(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create the %s verifier for the plugin artifact: %w", verifierType, err)
+	}
+	return referenceVerifier, verifierType, nil
+}
+
+// verifySignatures returns nil as soon as one signature of the subject passes
+// verification.
+func verifySignatures(ctx context.Context, referenceVerifier verifier.ReferenceVerifier, store referrerstore.ReferrerStore, subjectReference common.Reference, subjectDesc *ocispecs.SubjectDescriptor) error {
+	var failures []string
+	var continuationToken string
+	for {
+		referrersResult, err := store.ListReferrers(ctx, subjectReference, nil, continuationToken, subjectDesc)
+		if err != nil {
+			return fmt.Errorf("failed to list the signatures of the plugin artifact: %w", err)
+		}
+		for _, referrer := range referrersResult.Referrers {
+			if !referenceVerifier.CanVerify(ctx, referrer) {
+				continue
+			}
+			result, err := referenceVerifier.Verify(ctx, subjectReference, referrer, store)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", referrer.Digest, err))
+				continue
+			}
+			if result.IsSuccess {
+				return nil
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", referrer.Digest, result.Message))
+		}
+		continuationToken = referrersResult.NextToken
+		if continuationToken == "" {
+			break
+		}
+	}
+	if len(failures) == 0 {
+		return fmt.Errorf("no signature found for %s", subjectReference.String())
+	}
+	return fmt.Errorf("no valid signature found for %s: %s", subjectReference.String(), strings.Join(failures, "; "))
+}
@@ -0,0 +1,246 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactverifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/common/plugin"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	vc "github.com/ratify-project/ratify/pkg/verifier/config"
+	"github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	testArtifact       = "wabbitnetworks.azurecr.io/test/sample-plugin:v1"
+	testArtifactDigest = "sha256:2e4d3e8e7dd2aa4bb1d7e8d0b8d3f1c1cbc8c9ac5d2ab4a4b1d0e6f2cbe3a1c7"
+	testSignature      = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	otherSignature     = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+type mockVerifier struct {
+	// results by signature digest, signatures without result fail with an error
+	results  map[digest.Digest]bool
+	verified []digest.Digest
+}
+
+func (v *mockVerifier) Name() string {
+	return notationVerifierType
+}
+
+func (v *mockVerifier) Type() string {
+	return notationVerifierType
+}
+
+func (v *mockVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == notationSignatureArtifactType
+}
+
+func (v *mockVerifier) Verify(_ context.Context, _ common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, _ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	v.verified = append(v.verified, referenceDescriptor.Digest)
+	isSuccess, ok := v.results[referenceDescriptor.Digest]
+	if !ok {
+		return verifier.VerifierResult{}, errors.New("signature is invalid")
+	}
+	return verifier.VerifierResult{IsSuccess: isSuccess, Message: "signature verification failed"}, nil
+}
+
+func (v *mockVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+// pagedStore returns each referrer on its own page
+type pagedStore struct {
+	mocks.TestStore
+}
+
+func (s *pagedStore) ListReferrers(_ context.Context, _ common.Reference, _ []string, nextToken string, _ *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	index := 0
+	if nextToken != "" {
+		index = int(nextToken[0] - '0')
+	}
+	if index >= len(s.References) {
+		return referrerstore.ListReferrersResult{}, nil
+	}
+	result := referrerstore.ListReferrersResult{Referrers: s.References[index : index+1]}
+	if index+1 < len(s.References) {
+		result.NextToken = string(rune('0' + index + 1))
+	}
+	return result, nil
+}
+
+func signature(artifactType string, signatureDigest string) ocispecs.ReferenceDescriptor {
+	return ocispecs.ReferenceDescriptor{
+		ArtifactType: artifactType,
+		Descriptor:   oci.Descriptor{Digest: digest.Digest(signatureDigest)},
+	}
+}
+
+func mockFactories(t *testing.T, referenceVerifier verifier.ReferenceVerifier, store referrerstore.ReferrerStore, storeErr error) *vc.VerifierConfig {
+	var created vc.VerifierConfig
+	originalStore, originalVerifier := newStore, newVerifier
+	t.Cleanup(func() {
+		newStore, newVerifier = originalStore, originalVerifier
+	})
+	newStore = func(_ plugin.PluginSource, _ string) (referrerstore.ReferrerStore, error) {
+		return store, storeErr
+	}
+	newVerifier = func(config vc.VerifierConfig) (verifier.ReferenceVerifier, error) {
+		created = config
+		return referenceVerifier, nil
+	}
+	return &created
+}
+
+func TestVerifyArtifact(t *testing.T) {
+	testCases := []struct {
+		name       string
+		signatures []ocispecs.ReferenceDescriptor
+		results    map[digest.Digest]bool
+		storeErr   error
+		expectErr  string
+	}{
+		{
+			name:       "signature verified",
+			signatures: []ocispecs.ReferenceDescriptor{signature(notationSignatureArtifactType, testSignature)},
+			results:    map[digest.Digest]bool{testSignature: true},
+		},
+		{
+			name: "one of the signatures verified",
+			signatures: []ocispecs.ReferenceDescriptor{
+				signature(notationSignatureArtifactType, otherSignature),
+				signature(notationSignatureArtifactType, testSignature),
+			},
+			results: map[digest.Digest]bool{testSignature: true},
+		},
+		{
+			name:       "signature verification failed",
+			signatures: []ocispecs.ReferenceDescriptor{signature(notationSignatureArtifactType, testSignature)},
+			results:    map[digest.Digest]bool{testSignature: false},
+			expectErr:  "signature verification failed",
+		},
+		{
+			name:       "invalid signature",
+			signatures: []ocispecs.ReferenceDescriptor{signature(notationSignatureArtifactType, testSignature)},
+			expectErr:  "signature is invalid",
+		},
+		{
+			name:       "no signature",
+			signatures: []ocispecs.ReferenceDescriptor{signature(cosignSignatureArtifactType, testSignature)},
+			results:    map[digest.Digest]bool{testSignature: true},
+			expectErr:  "no signature found",
+		},
+		{
+			name:      "store creation failed",
+			storeErr:  errors.New("invalid auth provider"),
+			expectErr: "failed to create the referrer store",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			referenceVerifier := &mockVerifier{results: tc.results}
+			store := &pagedStore{TestStore: mocks.TestStore{References: tc.signatures}}
+			mockFactories(t, referenceVerifier, store, tc.storeErr)
+
+			source := plugin.PluginSource{
+				Artifact:     testArtifact,
+				Verification: &plugin.PluginVerification{Verifier: map[string]interface{}{"name": notationVerifierType}},
+			}
+			err := VerifyArtifact(context.Background(), source, oci.Descriptor{Digest: digest.Digest(testArtifactDigest)})
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateVerifier(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		verifierConfig        map[string]interface{}
+		expectedType          string
+		expectedArtifactTypes string
+		expectErr             bool
+	}{
+		{
+			name:                  "notation by name",
+			verifierConfig:        map[string]interface{}{"name": "notation"},
+			expectedType:          notationVerifierType,
+			expectedArtifactTypes: notationSignatureArtifactType,
+		},
+		{
+			name:                  "cosign by type",
+			verifierConfig:        map[string]interface{}{"name": "plugin-signature", "type": "cosign"},
+			expectedType:          cosignVerifierType,
+			expectedArtifactTypes: cosignSignatureArtifactType,
+		},
+		{
+			name:                  "artifact types kept",
+			verifierConfig:        map[string]interface{}{"name": "notation", "artifactTypes": "application/example"},
+			expectedType:          notationVerifierType,
+			expectedArtifactTypes: "application/example",
+		},
+		{
+			name:           "unsupported verifier",
+			verifierConfig: map[string]interface{}{"name": "sbom"},
+			expectErr:      true,
+		},
+		{
+			name:           "plugin verifier",
+			verifierConfig: map[string]interface{}{"name": "notation", "source": map[string]interface{}{"artifact": testArtifact}},
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			created := mockFactories(t, &mockVerifier{}, nil, nil)
+
+			_, verifierType, err := createVerifier(tc.verifierConfig)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if verifierType != tc.expectedType {
+				t.Fatalf("expected verifier type %s, got %s", tc.expectedType, verifierType)
+			}
+			if (*created)[types.ArtifactTypes] != tc.expectedArtifactTypes {
+				t.Fatalf("expected artifact types %s, got %v", tc.expectedArtifactTypes, (*created)[types.ArtifactTypes])
+			}
+			if (*created)[types.Name] == nil {
+				t.Fatalf("expected verifier name to be set")
+			}
+		})
+	}
+}
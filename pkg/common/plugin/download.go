@@ -22,6 +22,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
@@ -30,6 +31,7 @@ import (
 	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
@@ -37,6 +39,19 @@ import (
 type PluginSource struct { //nolint:revive // ignore linter to have unique type name
 	Artifact     string                          `json:"artifact"`
 	AuthProvider authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
+	Verification *PluginVerification             `json:"verification,omitempty"`
+}
+
+// PluginVerification describes how the plugin artifact is verified before the
+// plugin is installed.
+type PluginVerification struct {
+	// Digest pins the digest of the plugin artifact manifest.
+	Digest string `json:"digest,omitempty"`
+	// Skip explicitly opts out of the verification of the plugin artifact.
+	Skip bool `json:"skip,omitempty"`
+	// Verifier is the configuration of a built-in notation or cosign verifier
+	// used to verify the signature of the plugin artifact.
+	Verifier map[string]interface{} `json:"verifier,omitempty"`
 }
 
 func ParsePluginSource(source interface{}) (PluginSource, error) {
@@ -59,6 +74,11 @@ func ParsePluginSource(source interface{}) (PluginSource, error) {
 func DownloadPlugin(source PluginSource, targetPath string) error {
 	ctx := context.TODO()
 
+	// reject the plugin source before any network access if it cannot be verified
+	if err := validatePluginVerification(source); err != nil {
+		return err
+	}
+
 	// initialize a repository
 	repository, err := remote.NewRepository(source.Artifact)
 	if err != nil {
@@ -93,6 +113,20 @@ func DownloadPlugin(source PluginSource, targetPath string) error {
 		},
 	}
 
+	return installPlugin(ctx, repository, source, targetPath)
+}
+
+// pluginRepository is the repository the plugin artifact is downloaded from.
+type pluginRepository interface {
+	content.Resolver
+	content.Fetcher
+}
+
+// installPlugin resolves and verifies the plugin artifact and writes its first
+// blob to the target path. The manifest and the blob are checked against the
+// digests of their descriptors so that the installed plugin is the content
+// that was verified.
+func installPlugin(ctx context.Context, repository pluginRepository, source PluginSource, targetPath string) error {
 	// read the reference manifest
 	referenceManifestDescriptor, err := repository.Resolve(ctx, source.Artifact)
 	if err != nil {
@@ -100,12 +134,11 @@ func DownloadPlugin(source PluginSource, targetPath string) error {
 	}
 	logrus.Debugf("Resolved plugin manifest: %v", referenceManifestDescriptor)
 
-	manifestReader, err := repository.Fetch(ctx, referenceManifestDescriptor)
-	if err != nil {
+	if err := verifyPluginArtifact(ctx, source, referenceManifestDescriptor); err != nil {
 		return err
 	}
 
-	manifestBytes, err := io.ReadAll(manifestReader)
+	manifestBytes, err := content.FetchAll(ctx, repository, referenceManifestDescriptor)
 	if err != nil {
 		return err
 	}
@@ -130,31 +163,41 @@ func DownloadPlugin(source PluginSource, targetPath string) error {
 		return fmt.Errorf("no blobs found in the manifest")
 	}
 
-	// download the first blob to the target path
-	logrus.Debugf("Downloading blob %s", referenceManifest.Blobs[0].Digest.String())
-	_, blobReader, err := repository.Blobs().FetchReference(ctx, referenceManifest.Blobs[0].Digest.String())
+	// download the first blob to a temporary file next to the target path and
+	// move it into place once its digest is verified
+	blobDesc := referenceManifest.Blobs[0]
+	logrus.Debugf("Downloading blob %s", blobDesc.Digest.String())
+	blobReader, err := repository.Fetch(ctx, blobDesc)
 	if err != nil {
 		return err
 	}
+	defer blobReader.Close()
 
-	logrus.Debugf("writing plugin bytes to %s", targetPath)
-	pluginFile, err := os.Create(targetPath)
+	pluginFile, err := os.CreateTemp(filepath.Dir(targetPath), filepath.Base(targetPath)+".download-*")
 	if err != nil {
 		return err
 	}
-
-	defer pluginFile.Close()
-	_, err = io.Copy(pluginFile, blobReader)
-	if err != nil {
+	tempPath := pluginFile.Name()
+	defer os.Remove(tempPath)
+
+	logrus.Debugf("writing plugin bytes to %s", tempPath)
+	verifyReader := content.NewVerifyReader(blobReader, blobDesc)
+	if _, err := io.Copy(pluginFile, verifyReader); err != nil {
+		pluginFile.Close()
+		return fmt.Errorf("failed to download blob %s of plugin artifact %s: %w", blobDesc.Digest, source.Artifact, err)
+	}
+	if err := verifyReader.Verify(); err != nil {
+		pluginFile.Close()
+		return fmt.Errorf("failed to verify blob %s of plugin artifact %s: %w", blobDesc.Digest, source.Artifact, err)
+	}
+	if err := pluginFile.Close(); err != nil {
 		return err
 	}
 
 	// mark the plugin as executable
 	logrus.Debugf("marking %s as executable", targetPath)
-	err = os.Chmod(targetPath, 0700)
-	if err != nil {
+	if err := os.Chmod(tempPath, 0700); err != nil {
 		return err
 	}
-
-	return nil
+	return os.Rename(tempPath, targetPath)
 }
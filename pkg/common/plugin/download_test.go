@@ -16,12 +16,143 @@ limitations under the License.
 package plugin
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// testPluginRepository serves the plugin artifact from memory. The served
+// content may differ from the descriptors to simulate a malicious registry.
+type testPluginRepository struct {
+	manifestDesc oci.Descriptor
+	content      map[digest.Digest][]byte
+}
+
+func (r *testPluginRepository) Resolve(_ context.Context, _ string) (oci.Descriptor, error) {
+	return r.manifestDesc, nil
+}
+
+func (r *testPluginRepository) Fetch(_ context.Context, desc oci.Descriptor) (io.ReadCloser, error) {
+	blob, ok := r.content[desc.Digest]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(blob)), nil
+}
+
+// newTestPluginRepository returns a repository serving a plugin artifact with
+// the given plugin binary. The served manifest and binary are replaced with the
+// tampered content if set.
+func newTestPluginRepository(t *testing.T, plugin []byte, tamperedManifest []byte, tamperedPlugin []byte) *testPluginRepository {
+	pluginDesc := oci.Descriptor{
+		MediaType: "application/octet-stream",
+		Digest:    digest.FromBytes(plugin),
+		Size:      int64(len(plugin)),
+	}
+	manifestBytes, err := json.Marshal(oci.Manifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Config:    oci.DescriptorEmptyJSON,
+		Layers:    []oci.Descriptor{pluginDesc},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	manifestDesc := oci.Descriptor{
+		MediaType: oci.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+	if tamperedManifest != nil {
+		manifestBytes = tamperedManifest
+	}
+	if tamperedPlugin != nil {
+		plugin = tamperedPlugin
+	}
+	return &testPluginRepository{
+		manifestDesc: manifestDesc,
+		content: map[digest.Digest][]byte{
+			manifestDesc.Digest: manifestBytes,
+			pluginDesc.Digest:   plugin,
+		},
+	}
+}
+
+func TestInstallPlugin(t *testing.T) {
+	plugin := []byte("#!/bin/sh\necho plugin\n")
+	testCases := []struct {
+		name       string
+		repository *testPluginRepository
+		expectErr  bool
+	}{
+		{
+			name:       "verified plugin installed",
+			repository: newTestPluginRepository(t, plugin, nil, nil),
+		},
+		{
+			name:       "tampered manifest rejected",
+			repository: newTestPluginRepository(t, plugin, []byte(`{"schemaVersion":2,"layers":[]}`), nil),
+			expectErr:  true,
+		},
+		{
+			name:       "tampered plugin rejected",
+			repository: newTestPluginRepository(t, plugin, nil, []byte("#!/bin/sh\necho evil\n")),
+			expectErr:  true,
+		},
+		{
+			name:       "truncated plugin rejected",
+			repository: newTestPluginRepository(t, plugin, nil, plugin[:4]),
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targetPath := filepath.Join(t.TempDir(), "plugin")
+			source := PluginSource{
+				Artifact:     testArtifact,
+				Verification: &PluginVerification{Digest: tc.repository.manifestDesc.Digest.String()},
+			}
+			err := installPlugin(context.Background(), tc.repository, source, targetPath)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+			installed, readErr := os.ReadFile(targetPath)
+			if tc.expectErr {
+				if !os.IsNotExist(readErr) {
+					t.Fatalf("expected no plugin to be installed, got: %v", readErr)
+				}
+				entries, _ := os.ReadDir(filepath.Dir(targetPath))
+				if len(entries) != 0 {
+					t.Fatalf("expected the downloaded content to be removed, got %d files", len(entries))
+				}
+				return
+			}
+			if !bytes.Equal(installed, plugin) {
+				t.Fatalf("unexpected plugin content: %s", installed)
+			}
+		})
+	}
+}
+
+func TestDownloadPlugin_NoVerification(t *testing.T) {
+	// the source is rejected before the unreachable registry is contacted
+	source := PluginSource{Artifact: "localhost:0/test/sample-plugin:v1"}
+	err := DownloadPlugin(source, filepath.Join(t.TempDir(), "plugin"))
+	if err == nil || err.Error() != validatePluginVerification(source).Error() {
+		t.Fatalf("expected the missing verification to be reported, got: %v", err)
+	}
+}
+
 func TestParsePluginSource_HandlesJSON(t *testing.T) {
 	js := `{
 	"name": "dynamic",
@@ -61,6 +192,10 @@ func TestParsePluginSource_HandlesCRD(t *testing.T) {
 		ArtifactTypes: "sbom/example",
 		Source: &v1beta1.PluginSource{
 			Artifact: "wabbitnetworks.azurecr.io/test/sample-plugin:v1",
+			Verification: &v1beta1.PluginVerification{
+				Digest:   "sha256:2e4d3e8e7dd2aa4bb1d7e8d0b8d3f1c1cbc8c9ac5d2ab4a4b1d0e6f2cbe3a1c7",
+				Verifier: runtime.RawExtension{Raw: []byte(`{"name":"notation"}`)},
+			},
 		},
 	}
 
@@ -72,4 +207,12 @@ func TestParsePluginSource_HandlesCRD(t *testing.T) {
 	if source.Artifact != "wabbitnetworks.azurecr.io/test/sample-plugin:v1" {
 		t.Fatalf("unexpected artifact: %s", source.Artifact)
 	}
+
+	if source.Verification == nil || source.Verification.Digest != verifierConfig.Source.Verification.Digest {
+		t.Fatalf("unexpected verification: %+v", source.Verification)
+	}
+
+	if source.Verification.Verifier["name"] != "notation" {
+		t.Fatalf("unexpected verifier: %v", source.Verification.Verifier)
+	}
 }
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -51,14 +50,22 @@ type Executor interface {
 // DefaultExecutor finds the plugin executable and invokes it as a os command
 type DefaultExecutor struct {
 	Stderr io.Writer
+	// Policy restricts the execution of the plugin if set
+	Policy *ExecutionPolicy
 }
 
 // return the command output and the error
 func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	c := exec.CommandContext(ctx, pluginPath, cmdArgs...)
-	c.Env = environ
+	execCtx, cancel := e.Policy.context(ctx)
+	defer cancel()
+	stdout := e.Policy.newOutputBuffer(cancel)
+	stderr := e.Policy.newOutputBuffer(cancel)
+	c, err := e.Policy.Command(execCtx, pluginPath, cmdArgs, environ)
+	if err != nil {
+		return nil, err
+	}
+	// stop waiting for the output of processes started by a killed plugin
+	c.WaitDelay = waitDuration
 	c.Stdin = bytes.NewBuffer(stdinData)
 	c.Stdout = stdout
 	c.Stderr = stderr
@@ -97,10 +104,14 @@ func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string,
 		}
 
 		// For all other errors return failed.
-		return nil, e.pluginErr(err, stdout.Bytes(), stderr.Bytes())
+		pluginErr := e.pluginErr(err, stdout.Bytes(), stderr.Bytes())
+		if limitErr := e.Policy.limitErr(ctx, execCtx, pluginPath, c.ProcessState, stdout, stderr, pluginErr); limitErr != nil {
+			return nil, limitErr
+		}
+		return nil, pluginErr
 	}
 
-	pluginOutputJSON, pluginOutputMsgs := parsePluginOutput(stdout, stderr)
+	pluginOutputJSON, pluginOutputMsgs := parsePluginOutput(&stdout.buf, &stderr.buf)
 
 	// Disregards plugin source stream and logs the plugin messages to stderr
 	for _, msg := range pluginOutputMsgs {
@@ -0,0 +1,252 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ExecutionPolicyKey is the key of the execution policy in the config of
+	// a verifier or store plugin.
+	ExecutionPolicyKey = "executionPolicy"
+
+	// ratifyEnvPrefix is the prefix of the environment variables of the
+	// plugin protocol, which are always passed to the plugin.
+	ratifyEnvPrefix = "RATIFY_"
+)
+
+// errOutputLimitExceeded is returned to the plugin once it exceeds the output
+// limit, which stops copying its output.
+var errOutputLimitExceeded = errors.New("plugin output limit exceeded")
+
+// ExecutionPolicy restricts the execution of a plugin. Resource limits,
+// no-new-privileges and seccomp are only supported on Linux and ignored on
+// other platforms.
+type ExecutionPolicy struct {
+	// AllowedEnv lists the environment variables passed to the plugin. A
+	// trailing * matches all variables with the prefix. RATIFY_* variables
+	// are always passed. All variables are passed if not set.
+	AllowedEnv []string `json:"allowedEnv,omitempty"`
+	// MaxOutputBytes is the maximum size of each of stdout and stderr.
+	MaxOutputBytes int64 `json:"maxOutputBytes,omitempty"`
+	// Timeout is the maximum duration of an execution, e.g. "30s",
+	// independent of the deadline of the request.
+	Timeout string `json:"timeout,omitempty"`
+	// MaxMemoryBytes is the maximum size of the address space of the plugin.
+	MaxMemoryBytes uint64 `json:"maxMemoryBytes,omitempty"`
+	// MaxCPUSeconds is the maximum CPU time of the plugin.
+	MaxCPUSeconds uint64 `json:"maxCPUSeconds,omitempty"`
+	// MaxOpenFiles is the maximum number of files opened by the plugin.
+	MaxOpenFiles uint64 `json:"maxOpenFiles,omitempty"`
+	// NoNewPrivileges prevents the plugin from gaining privileges, e.g.
+	// through setuid binaries.
+	NoNewPrivileges bool `json:"noNewPrivileges,omitempty"`
+	// Seccomp denies system calls which plugins have no use for, such as
+	// mounting, tracing or loading kernel modules. It implies
+	// NoNewPrivileges.
+	Seccomp bool `json:"seccomp,omitempty"`
+
+	timeout time.Duration
+}
+
+// ParseExecutionPolicy returns the execution policy in the config of a
+// plugin, or nil if the config has no execution policy.
+func ParseExecutionPolicy(config map[string]interface{}) (*ExecutionPolicy, error) {
+	raw, ok := config[ExecutionPolicyKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	policyBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("failed to marshal %s", ExecutionPolicyKey)).WithError(err)
+	}
+	policy := &ExecutionPolicy{}
+	decoder := json.NewDecoder(bytes.NewReader(policyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("failed to parse %s", ExecutionPolicyKey)).WithError(err)
+	}
+
+	if policy.MaxOutputBytes < 0 {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("maxOutputBytes of %s must not be negative, got %d", ExecutionPolicyKey, policy.MaxOutputBytes))
+	}
+	if policy.Timeout != "" {
+		policy.timeout, err = time.ParseDuration(policy.Timeout)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("failed to parse timeout %s of %s", policy.Timeout, ExecutionPolicyKey)).WithError(err)
+		}
+		if policy.timeout <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("timeout of %s must be positive, got %s", ExecutionPolicyKey, policy.Timeout))
+		}
+	}
+	if policy.sandboxed() && !sandboxSupported {
+		logrus.Warnf("resource limits, noNewPrivileges and seccomp of %s are not supported on this platform and are ignored", ExecutionPolicyKey)
+	}
+	return policy, nil
+}
+
+// GetTimeout returns the timeout of an execution, or 0 if there is none.
+func (p *ExecutionPolicy) GetTimeout() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.timeout
+}
+
+// ServerPolicy returns the policy applied to a plugin served over gRPC.
+// The CPU time and output of a served plugin accumulate over all its
+// verifications, so these limits are not applied to it.
+func (p *ExecutionPolicy) ServerPolicy() *ExecutionPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := *p
+	policy.MaxCPUSeconds = 0
+	policy.MaxOutputBytes = 0
+	return &policy
+}
+
+// Command returns the command executing the plugin under the policy.
+func (p *ExecutionPolicy) Command(ctx context.Context, pluginPath string, cmdArgs []string, environ []string) (*exec.Cmd, error) {
+	environ = p.filterEnviron(environ)
+	if !p.sandboxed() || !sandboxSupported {
+		c := exec.CommandContext(ctx, pluginPath, cmdArgs...)
+		c.Env = environ
+		return c, nil
+	}
+	return sandboxCommand(ctx, sandboxConfig{Path: pluginPath, Policy: *p}, cmdArgs, environ)
+}
+
+// filterEnviron returns the environment variables allowed by the policy.
+func (p *ExecutionPolicy) filterEnviron(environ []string) []string {
+	if p == nil || p.AllowedEnv == nil {
+		return environ
+	}
+	filtered := []string{}
+	for _, env := range environ {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, ratifyEnvPrefix) || p.allowsEnv(name) {
+			filtered = append(filtered, env)
+		}
+	}
+	return filtered
+}
+
+func (p *ExecutionPolicy) allowsEnv(name string) bool {
+	for _, allowed := range p.AllowedEnv {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); (ok && strings.HasPrefix(name, prefix)) || allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sandboxed returns true if the plugin is executed in a sandbox applying
+// resource limits, no-new-privileges or seccomp.
+func (p *ExecutionPolicy) sandboxed() bool {
+	return p != nil && (p.MaxMemoryBytes > 0 || p.MaxCPUSeconds > 0 || p.MaxOpenFiles > 0 || p.NoNewPrivileges || p.Seccomp)
+}
+
+// context returns the context of an execution, which is canceled once the
+// timeout of the policy expires.
+func (p *ExecutionPolicy) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := p.GetTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// newOutputBuffer returns a buffer for stdout or stderr of the plugin, which
+// calls kill once the output limit of the policy is exceeded.
+func (p *ExecutionPolicy) newOutputBuffer(kill func()) *limitedBuffer {
+	b := &limitedBuffer{kill: kill}
+	if p != nil {
+		b.limit = p.MaxOutputBytes
+	}
+	return b
+}
+
+// limitErr returns the error of a plugin stopped by a limit of the policy, or
+// nil if the plugin failed for another reason. ctx is the context of the
+// request and execCtx the context of the execution.
+func (p *ExecutionPolicy) limitErr(ctx, execCtx context.Context, pluginPath string, state *os.ProcessState, stdout, stderr *limitedBuffer, pluginErr error) error {
+	if p == nil || ctx.Err() != nil {
+		return nil
+	}
+	if stdout.exceeded || stderr.exceeded {
+		return re.ErrorCodePluginOutputLimitExceeded.WithDetail(fmt.Sprintf("plugin %s wrote more than %d bytes of output", pluginPath, p.MaxOutputBytes)).WithError(pluginErr)
+	}
+	if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+		return re.ErrorCodePluginTimeout.WithDetail(fmt.Sprintf("plugin %s did not complete within %s", pluginPath, p.timeout)).WithError(pluginErr)
+	}
+	if !p.sandboxed() || !sandboxSupported {
+		return nil
+	}
+
+	var limit string
+	output := strings.ToLower(stderr.String())
+	switch {
+	case p.MaxCPUSeconds > 0 && cpuLimitSignaled(state, p.MaxCPUSeconds):
+		limit = fmt.Sprintf("CPU time limit of %d seconds", p.MaxCPUSeconds)
+	case p.MaxMemoryBytes > 0 && (strings.Contains(output, "out of memory") || strings.Contains(output, "cannot allocate memory")):
+		limit = fmt.Sprintf("memory limit of %d bytes", p.MaxMemoryBytes)
+	case p.MaxOpenFiles > 0 && strings.Contains(output, "too many open files"):
+		limit = fmt.Sprintf("open files limit of %d", p.MaxOpenFiles)
+	default:
+		return nil
+	}
+	return re.ErrorCodePluginResourceLimitExceeded.WithDetail(fmt.Sprintf("plugin %s exceeded the %s", pluginPath, limit)).WithError(pluginErr)
+}
+
+// limitedBuffer is a buffer which stops accepting writes and kills the
+// plugin once its limit is exceeded. It has no limit if limit is 0.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	kill     func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && int64(b.buf.Len()+len(p)) > b.limit {
+		if !b.exceeded {
+			b.exceeded = true
+			b.kill()
+		}
+		return 0, errOutputLimitExceeded
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
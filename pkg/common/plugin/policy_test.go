@@ -0,0 +1,214 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+)
+
+func TestMain(m *testing.M) {
+	RunSandbox()
+	os.Exit(m.Run())
+}
+
+func TestParseExecutionPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      map[string]interface{}
+		wantTimeout time.Duration
+		wantNil     bool
+		wantErr     bool
+	}{
+		{
+			name:    "no policy",
+			config:  map[string]interface{}{"name": "test"},
+			wantNil: true,
+		},
+		{
+			name: "valid policy",
+			config: map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{
+				"allowedEnv":     []interface{}{"HOME", "AZURE_*"},
+				"maxOutputBytes": 1024,
+				"timeout":        "30s",
+				"maxOpenFiles":   64,
+				"seccomp":        true,
+			}},
+			wantTimeout: 30 * time.Second,
+		},
+		{
+			name:    "invalid timeout",
+			config:  map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{"timeout": "soon"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			config:  map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{"timeout": "-1s"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative output limit",
+			config:  map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{"maxOutputBytes": -1}},
+			wantErr: true,
+		},
+		{
+			name:    "negative resource limit",
+			config:  map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{"maxMemoryBytes": -1}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			config:  map[string]interface{}{ExecutionPolicyKey: map[string]interface{}{"maxThreads": 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := ParseExecutionPolicy(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseExecutionPolicy() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, re.ErrorCodeConfigInvalid.WithDetail("")) {
+					t.Fatalf("expected config invalid error, got %v", err)
+				}
+				return
+			}
+			if (policy == nil) != tc.wantNil {
+				t.Fatalf("expected nil policy %v, got %+v", tc.wantNil, policy)
+			}
+			if policy.GetTimeout() != tc.wantTimeout {
+				t.Fatalf("expected timeout %v, got %v", tc.wantTimeout, policy.GetTimeout())
+			}
+		})
+	}
+}
+
+func TestExecutionPolicy_FilterEnviron(t *testing.T) {
+	environ := []string{"HOME=/root", "AZURE_CLIENT_ID=id", "AWS_SECRET_ACCESS_KEY=secret", "RATIFY_VERIFIER_COMMAND=VERIFY"}
+	testCases := []struct {
+		name   string
+		policy *ExecutionPolicy
+		want   []string
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+			want:   environ,
+		},
+		{
+			name:   "no allow-list",
+			policy: &ExecutionPolicy{},
+			want:   environ,
+		},
+		{
+			name:   "empty allow-list",
+			policy: &ExecutionPolicy{AllowedEnv: []string{}},
+			want:   []string{"RATIFY_VERIFIER_COMMAND=VERIFY"},
+		},
+		{
+			name:   "names and prefixes",
+			policy: &ExecutionPolicy{AllowedEnv: []string{"HOME", "AZURE_*"}},
+			want:   []string{"HOME=/root", "AZURE_CLIENT_ID=id", "RATIFY_VERIFIER_COMMAND=VERIFY"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.policy.filterEnviron(environ); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected environ %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestExecutionPolicy_ServerPolicy(t *testing.T) {
+	policy := &ExecutionPolicy{MaxOutputBytes: 1024, MaxCPUSeconds: 10, MaxOpenFiles: 64}
+	serverPolicy := policy.ServerPolicy()
+	if serverPolicy.MaxOutputBytes != 0 || serverPolicy.MaxCPUSeconds != 0 || serverPolicy.MaxOpenFiles != 64 {
+		t.Fatalf("unexpected server policy %+v", serverPolicy)
+	}
+	if policy.MaxCPUSeconds != 10 {
+		t.Fatalf("expected policy to be unchanged, got %+v", policy)
+	}
+}
+
+func TestExecutePlugin_Limits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+	testCases := []struct {
+		name    string
+		policy  *ExecutionPolicy
+		script  string
+		wantErr error
+	}{
+		{
+			name:    "output limit",
+			policy:  &ExecutionPolicy{MaxOutputBytes: 1024},
+			script:  "exec yes",
+			wantErr: re.ErrorCodePluginOutputLimitExceeded.WithDetail(""),
+		},
+		{
+			name:    "timeout",
+			policy:  &ExecutionPolicy{timeout: 100 * time.Millisecond},
+			script:  "exec sleep 10",
+			wantErr: re.ErrorCodePluginTimeout.WithDetail(""),
+		},
+		{
+			name:   "within limits",
+			policy: &ExecutionPolicy{MaxOutputBytes: 1024, timeout: 10 * time.Second},
+			script: `echo '{"result":"ok"}'`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &DefaultExecutor{Policy: tc.policy}
+			output, err := e.ExecutePlugin(context.Background(), "/bin/sh", []string{"-c", tc.script}, nil, os.Environ())
+			if tc.wantErr == nil {
+				if err != nil || string(output) != `{"result":"ok"}` {
+					t.Fatalf("unexpected output %s, error %v", output, err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecutePlugin_RequestDeadline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	e := &DefaultExecutor{Policy: &ExecutionPolicy{timeout: time.Minute}}
+	_, err := e.ExecutePlugin(ctx, "/bin/sh", []string{"-c", "exec sleep 10"}, nil, os.Environ())
+	if err == nil || errors.Is(err, re.ErrorCodePluginTimeout.WithDetail("")) {
+		t.Fatalf("expected plugin failure for the request deadline, got %v", err)
+	}
+}
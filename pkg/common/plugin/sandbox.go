@@ -0,0 +1,83 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	re "github.com/ratify-project/ratify/errors"
+)
+
+const (
+	// sandboxEnvKey is set to the sandbox config when the binary is executed
+	// as the sandbox of a plugin.
+	sandboxEnvKey = "RATIFY_PLUGIN_SANDBOX"
+
+	// sandboxFailureExitCode is the exit code of the sandbox if it fails to
+	// apply the policy before executing the plugin.
+	sandboxFailureExitCode = 126
+)
+
+// sandboxInitialized is set once RunSandbox is called by the main function,
+// which is required to execute plugins in a sandbox.
+var sandboxInitialized bool
+
+// sandboxConfig is passed to the sandbox to execute the plugin at Path under
+// Policy.
+type sandboxConfig struct {
+	Path   string          `json:"path"`
+	Policy ExecutionPolicy `json:"policy"`
+}
+
+// RunSandbox must be called at the start of the main function of binaries
+// executing plugins with resource limits, no-new-privileges or seccomp.
+// These plugins are executed through the binary itself, which applies the
+// execution policy to its process and then replaces it with the plugin.
+// RunSandbox does not return if the process is the sandbox of a plugin.
+func RunSandbox() {
+	config, ok := os.LookupEnv(sandboxEnvKey)
+	if !ok {
+		sandboxInitialized = true
+		return
+	}
+	if err := execSandboxed(config); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to execute plugin in sandbox: %v\n", err)
+		os.Exit(sandboxFailureExitCode)
+	}
+}
+
+// sandboxCommand returns the command executing the binary as the sandbox of
+// the plugin.
+func sandboxCommand(ctx context.Context, config sandboxConfig, cmdArgs []string, environ []string) (*exec.Cmd, error) {
+	if !sandboxInitialized {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail(fmt.Sprintf("plugin %s requires a sandbox for its %s, which is not initialized by this binary", config.Path, ExecutionPolicyKey))
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail(fmt.Sprintf("failed to find executable to sandbox plugin %s", config.Path)).WithError(err)
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail(fmt.Sprintf("failed to marshal sandbox config of plugin %s", config.Path)).WithError(err)
+	}
+	c := exec.CommandContext(ctx, self, cmdArgs...)
+	c.Env = append(environ, fmt.Sprintf("%s=%s", sandboxEnvKey, configBytes))
+	return c, nil
+}
@@ -0,0 +1,102 @@
+// Copyright The Ratify Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const sandboxSupported = true
+
+// execSandboxed applies the policy of the sandbox config to the process and
+// replaces it with the plugin.
+func execSandboxed(configJSON string) error {
+	var config sandboxConfig
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return fmt.Errorf("failed to parse sandbox config: %w", err)
+	}
+	if err := os.Unsetenv(sandboxEnvKey); err != nil {
+		return err
+	}
+
+	// no-new-privileges and seccomp apply to the calling thread, which must
+	// be the thread executing the plugin
+	runtime.LockOSThread()
+
+	policy := config.Policy
+	limits := []struct {
+		name     string
+		resource int
+		limit    unix.Rlimit
+	}{
+		{"maxMemoryBytes", unix.RLIMIT_AS, unix.Rlimit{Cur: policy.MaxMemoryBytes, Max: policy.MaxMemoryBytes}},
+		// the plugin receives SIGXCPU at the soft limit and SIGKILL a second
+		// later at the hard limit
+		{"maxCPUSeconds", unix.RLIMIT_CPU, unix.Rlimit{Cur: policy.MaxCPUSeconds, Max: policy.MaxCPUSeconds + 1}},
+		{"maxOpenFiles", unix.RLIMIT_NOFILE, unix.Rlimit{Cur: policy.MaxOpenFiles, Max: policy.MaxOpenFiles}},
+	}
+	for _, l := range limits {
+		if l.limit.Cur == 0 {
+			continue
+		}
+		if err := unix.Setrlimit(l.resource, &l.limit); err != nil {
+			return fmt.Errorf("failed to set %s: %w", l.name, err)
+		}
+	}
+
+	if policy.NoNewPrivileges || policy.Seccomp {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("failed to set no-new-privileges: %w", err)
+		}
+	}
+	if policy.Seccomp {
+		if err := installSeccompFilter(); err != nil {
+			return err
+		}
+	}
+
+	return syscall.Exec(config.Path, append([]string{config.Path}, os.Args[1:]...), os.Environ())
+}
+
+// cpuLimitSignaled returns true if the process was killed by the CPU time
+// limit of maxCPUSeconds, either by SIGXCPU at the soft limit or by SIGKILL
+// at the hard limit. SIGKILL is only attributed to the limit if the process
+// used up its CPU time, as it is also sent on OOM or by other processes.
+func cpuLimitSignaled(state *os.ProcessState, maxCPUSeconds uint64) bool {
+	if state == nil {
+		return false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return false
+	}
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return true
+	case syscall.SIGKILL:
+		return state.UserTime()+state.SystemTime() >= time.Duration(maxCPUSeconds)*time.Second
+	default:
+		return false
+	}
+}
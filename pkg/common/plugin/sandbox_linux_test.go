@@ -0,0 +1,64 @@
+// Copyright The Ratify Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+)
+
+func TestExecutePlugin_Sandbox(t *testing.T) {
+	e := &DefaultExecutor{Policy: &ExecutionPolicy{
+		AllowedEnv:   []string{"PATH"},
+		MaxOpenFiles: 32,
+		Seccomp:      true,
+	}}
+	script := `printf '{"openFiles":"%s","status":"%s","sandbox":"%s"}\n' "$(ulimit -n)" "$(grep -E '^(NoNewPrivs|Seccomp):' /proc/self/status | tr -s '\t\n' '  ')" "$` + sandboxEnvKey + `"`
+	output, err := e.ExecutePlugin(context.Background(), "/bin/sh", []string{"-c", script}, nil, os.Environ())
+	if err != nil {
+		t.Fatalf("ExecutePlugin() unexpected error: %v", err)
+	}
+	var result map[string]string
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse plugin output %s: %v", output, err)
+	}
+	if result["openFiles"] != "32" || result["status"] != "NoNewPrivs: 1 Seccomp: 2 " || result["sandbox"] != "" {
+		t.Fatalf("unexpected sandbox of plugin %v", result)
+	}
+}
+
+func TestExecutePlugin_CPULimit(t *testing.T) {
+	e := &DefaultExecutor{Policy: &ExecutionPolicy{MaxCPUSeconds: 1, timeout: time.Minute}}
+	_, err := e.ExecutePlugin(context.Background(), "/bin/sh", []string{"-c", "while :; do :; done"}, nil, os.Environ())
+	if !errors.Is(err, re.ErrorCodePluginResourceLimitExceeded.WithDetail("")) {
+		t.Fatalf("expected resource limit error, got %v", err)
+	}
+}
+
+func TestExecutePlugin_KilledWithinCPULimit(t *testing.T) {
+	e := &DefaultExecutor{Policy: &ExecutionPolicy{MaxCPUSeconds: 10, timeout: time.Minute}}
+	_, err := e.ExecutePlugin(context.Background(), "/bin/sh", []string{"-c", "kill -9 $$"}, nil, os.Environ())
+	if err == nil || errors.Is(err, re.ErrorCodePluginResourceLimitExceeded.WithDetail("")) {
+		t.Fatalf("expected plugin error other than the resource limit error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// ArtifactVerifier verifies the signature of a plugin artifact identified by
+// the resolved manifest descriptor.
+type ArtifactVerifier func(ctx context.Context, source PluginSource, artifactDesc oci.Descriptor) error
+
+var (
+	artifactVerifier     ArtifactVerifier
+	artifactVerifierLock sync.RWMutex
+)
+
+// RegisterArtifactVerifier registers the verifier used to verify the signature
+// of plugin artifacts. The signature verification lives outside of this
+// package as it depends on the built-in verifiers and referrer stores.
+func RegisterArtifactVerifier(verifier ArtifactVerifier) {
+	artifactVerifierLock.Lock()
+	defer artifactVerifierLock.Unlock()
+	artifactVerifier = verifier
+}
+
+// validatePluginVerification checks the verification configured in the plugin
+// source before the plugin artifact is resolved.
+func validatePluginVerification(source PluginSource) error {
+	// unverified plugins are installed only if the operator opts out explicitly
+	if source.Verification == nil {
+		return fmt.Errorf("no verification is configured for plugin artifact %s. Configure a digest or a verifier, or set skip to install the plugin without verification", source.Artifact)
+	}
+	verification := source.Verification
+	if verification.Skip {
+		if verification.Digest != "" || len(verification.Verifier) != 0 {
+			return fmt.Errorf("verification of plugin artifact %s cannot be skipped when a digest or a verifier is configured", source.Artifact)
+		}
+		return nil
+	}
+	if verification.Digest == "" && len(verification.Verifier) == 0 {
+		return fmt.Errorf("verification of plugin artifact %s requires a digest or a verifier", source.Artifact)
+	}
+	if verification.Digest != "" {
+		if _, err := digest.Parse(verification.Digest); err != nil {
+			return fmt.Errorf("invalid digest %s pinned for plugin artifact %s: %w", verification.Digest, source.Artifact, err)
+		}
+	}
+	return nil
+}
+
+// verifyPluginArtifact checks the resolved plugin artifact against the
+// verification configured in the plugin source.
+func verifyPluginArtifact(ctx context.Context, source PluginSource, artifactDesc oci.Descriptor) error {
+	if err := validatePluginVerification(source); err != nil {
+		return err
+	}
+	verification := source.Verification
+	if verification.Skip {
+		logrus.Infof("verification of plugin artifact %s is skipped, the plugin is installed without integrity check", source.Artifact)
+		return nil
+	}
+
+	if verification.Digest != "" {
+		if expected := digest.Digest(verification.Digest); artifactDesc.Digest != expected {
+			return fmt.Errorf("digest %s of plugin artifact %s does not match the pinned digest %s", artifactDesc.Digest, source.Artifact, expected)
+		}
+	}
+
+	if len(verification.Verifier) == 0 {
+		return nil
+	}
+	artifactVerifierLock.RLock()
+	verifier := artifactVerifier
+	artifactVerifierLock.RUnlock()
+	if verifier == nil {
+		return fmt.Errorf("signature verification is configured for plugin artifact %s but no artifact verifier is registered", source.Artifact)
+	}
+	if err := verifier(ctx, source, artifactDesc); err != nil {
+		return fmt.Errorf("failed to verify the signature of plugin artifact %s: %w", source.Artifact, err)
+	}
+	return nil
+}
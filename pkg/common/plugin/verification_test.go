@@ -0,0 +1,119 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	testArtifact       = "wabbitnetworks.azurecr.io/test/sample-plugin:v1"
+	testArtifactDigest = "sha256:2e4d3e8e7dd2aa4bb1d7e8d0b8d3f1c1cbc8c9ac5d2ab4a4b1d0e6f2cbe3a1c7"
+	otherDigest        = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+)
+
+func TestVerifyPluginArtifact(t *testing.T) {
+	artifactDesc := oci.Descriptor{Digest: digest.Digest(testArtifactDigest)}
+	testCases := []struct {
+		name         string
+		verification *PluginVerification
+		verifier     ArtifactVerifier
+		expectErr    bool
+	}{
+		{
+			name:      "no verification configured",
+			expectErr: true,
+		},
+		{
+			name:         "verification skipped",
+			verification: &PluginVerification{Skip: true},
+		},
+		{
+			name:         "verification skipped with digest",
+			verification: &PluginVerification{Skip: true, Digest: testArtifactDigest},
+			expectErr:    true,
+		},
+		{
+			name:         "empty verification",
+			verification: &PluginVerification{},
+			expectErr:    true,
+		},
+		{
+			name:         "matching digest",
+			verification: &PluginVerification{Digest: testArtifactDigest},
+		},
+		{
+			name:         "mismatching digest",
+			verification: &PluginVerification{Digest: otherDigest},
+			expectErr:    true,
+		},
+		{
+			name:         "invalid digest",
+			verification: &PluginVerification{Digest: "invalid"},
+			expectErr:    true,
+		},
+		{
+			name:         "verifier not registered",
+			verification: &PluginVerification{Verifier: map[string]interface{}{"name": "notation"}},
+			expectErr:    true,
+		},
+		{
+			name:         "signature verified",
+			verification: &PluginVerification{Verifier: map[string]interface{}{"name": "notation"}},
+			verifier: func(_ context.Context, _ PluginSource, _ oci.Descriptor) error {
+				return nil
+			},
+		},
+		{
+			name:         "signature verification failed",
+			verification: &PluginVerification{Verifier: map[string]interface{}{"name": "notation"}},
+			verifier: func(_ context.Context, _ PluginSource, _ oci.Descriptor) error {
+				return errors.New("no valid signature")
+			},
+			expectErr: true,
+		},
+		{
+			name: "digest mismatch skips signature verification",
+			verification: &PluginVerification{
+				Digest:   otherDigest,
+				Verifier: map[string]interface{}{"name": "notation"},
+			},
+			verifier: func(_ context.Context, _ PluginSource, _ oci.Descriptor) error {
+				t.Fatalf("signature verification should not be invoked")
+				return nil
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			RegisterArtifactVerifier(tc.verifier)
+			defer RegisterArtifactVerifier(nil)
+
+			source := PluginSource{Artifact: testArtifact, Verification: tc.verification}
+			err := verifyPluginArtifact(context.Background(), source, artifactDesc)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -34,3 +34,19 @@ func OciManifestToReferenceManifest(ociManifest oci.Manifest) ocispecs.Reference
 		Annotations:  ociManifest.Annotations,
 	}
 }
+
+// OciIndexToReferenceManifest converts an image index to a reference manifest.
+// The mediaType property is optional in OCI image indexes, so the media type of
+// the descriptor is used if the payload does not carry one.
+func OciIndexToReferenceManifest(mediaType string, ociIndex oci.Index) ocispecs.ReferenceManifest {
+	if ociIndex.MediaType != "" {
+		mediaType = ociIndex.MediaType
+	}
+	return ocispecs.ReferenceManifest{
+		MediaType:    mediaType,
+		ArtifactType: ociIndex.ArtifactType,
+		Manifests:    ociIndex.Manifests,
+		Subject:      ociIndex.Subject,
+		Annotations:  ociIndex.Annotations,
+	}
+}
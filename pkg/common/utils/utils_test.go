@@ -109,3 +109,50 @@ func TestOciManifestToReferenceManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestOciIndexToReferenceManifest(t *testing.T) {
+	manifests := []oci.Descriptor{
+		{
+			MediaType: oci.MediaTypeImageManifest,
+			Digest:    "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			Platform:  &oci.Platform{OS: "linux", Architecture: "amd64"},
+		},
+	}
+	tests := []struct {
+		name      string
+		mediaType string
+		index     oci.Index
+		want      ocispecs.ReferenceManifest
+	}{
+		{
+			name:      "oci index",
+			mediaType: oci.MediaTypeImageIndex,
+			index: oci.Index{
+				MediaType: oci.MediaTypeImageIndex,
+				Manifests: manifests,
+			},
+			want: ocispecs.ReferenceManifest{
+				MediaType: oci.MediaTypeImageIndex,
+				Manifests: manifests,
+			},
+		},
+		{
+			name:      "docker manifest list without media type",
+			mediaType: ocispecs.MediaTypeDockerManifestList,
+			index: oci.Index{
+				Manifests: manifests,
+			},
+			want: ocispecs.ReferenceManifest{
+				MediaType: ocispecs.MediaTypeDockerManifestList,
+				Manifests: manifests,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OciIndexToReferenceManifest(tt.mediaType, tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OciIndexToReferenceManifest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
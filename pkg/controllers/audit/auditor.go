@@ -0,0 +1,436 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
+	"github.com/ratify-project/ratify/config"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/constants"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultInterval is the default interval between audits of the running
+	// images.
+	DefaultInterval = time.Hour
+	// DefaultQPS is the default number of verifications per second of the
+	// auditor.
+	DefaultQPS = 2
+	// DefaultBurst is the default burst of verifications of the auditor.
+	DefaultBurst = 5
+
+	// EventReasonVerificationFailed is the reason of the events emitted when a
+	// running image fails verification.
+	EventReasonVerificationFailed = "VerificationFailed"
+	// EventReasonVerificationSucceeded is the reason of the events emitted
+	// when a running image passes verification again.
+	EventReasonVerificationSucceeded = "VerificationSucceeded"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "ratify"
+	recorderName   = "ratify-auditor"
+	// maxFailures caps the failures recorded in a report to keep the object
+	// size bounded.
+	maxFailures = 20
+	// maxNameLength caps the length of the repository part of report names.
+	maxNameLength = 40
+)
+
+// Options configures the auditor.
+type Options struct {
+	// Interval is the interval between audits of the running images.
+	Interval time.Duration
+	// Namespaces restricts the audit to pods of the namespaces. Pods of all
+	// namespaces are audited if empty.
+	Namespaces []string
+	// QPS and Burst limit the rate of verifications of the auditor.
+	QPS   float64
+	Burst int
+}
+
+// Auditor periodically verifies the images of running pods with the current
+// executor and policy of their namespace, and records the results in a
+// VerificationReport per namespace and image digest. Changes of the result
+// are reported as Kubernetes events and metrics.
+type Auditor struct {
+	client      client.Client
+	reader      client.Reader
+	recorder    record.EventRecorder
+	getExecutor config.GetExecutor
+	options     Options
+	limiter     *rate.Limiter
+}
+
+// runningImage is an image by digest run by pods of a namespace.
+type runningImage struct {
+	namespace string
+	subject   string
+	// images are the references of the image in the pod specs.
+	images map[string]struct{}
+}
+
+// NewAuditor creates an Auditor reading pods and reports with reader and
+// writing reports with c.
+func NewAuditor(c client.Client, reader client.Reader, recorder record.EventRecorder, getExecutor config.GetExecutor, options Options) *Auditor {
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.QPS <= 0 {
+		options.QPS = DefaultQPS
+	}
+	if options.Burst <= 0 {
+		options.Burst = DefaultBurst
+	}
+	return &Auditor{
+		client:      c,
+		reader:      reader,
+		recorder:    recorder,
+		getExecutor: getExecutor,
+		options:     options,
+		limiter:     rate.NewLimiter(rate.Limit(options.QPS), options.Burst),
+	}
+}
+
+// SetupWithManager adds an Auditor to the Manager. Pods are listed from the
+// API server rather than the cache of the Manager to avoid watching all pods
+// of the cluster.
+func SetupWithManager(mgr ctrl.Manager, getExecutor config.GetExecutor, options Options) error {
+	return mgr.Add(NewAuditor(mgr.GetClient(), mgr.GetAPIReader(), mgr.GetEventRecorderFor(recorderName), getExecutor, options))
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that only
+// the leader audits the running images. The manager enables leader election
+// when the audit is enabled, the controllers loading the configuration keep
+// running on every replica.
+func (a *Auditor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable. It audits the running images right away
+// and then at every interval until the context is done.
+func (a *Auditor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(a.options.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Audit(ctx); err != nil {
+			logrus.WithContext(ctx).Errorf("failed to audit running images: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=verificationreports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=verificationreports/status,verbs=get;update;patch
+
+// Audit verifies the images of the running pods, updates their reports and
+// deletes the reports of images that are no longer running.
+func (a *Auditor) Audit(ctx context.Context) error {
+	images, err := a.runningImages(ctx)
+	if err != nil {
+		return err
+	}
+
+	active := map[types.NamespacedName]struct{}{}
+	for _, image := range images {
+		if err := a.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := a.auditImage(ctx, image); err != nil {
+			logrus.WithContext(ctx).Errorf("failed to audit image %s in namespace %s: %v", image.subject, image.namespace, err)
+		}
+		active[types.NamespacedName{Namespace: image.namespace, Name: reportName(image.subject)}] = struct{}{}
+	}
+	return a.deleteStaleReports(ctx, active)
+}
+
+// runningImages returns the images by digest of the running pods sorted by
+// namespace and subject. The digest is taken from the container statuses, or
+// from the pod spec if the image is referenced by digest.
+func (a *Auditor) runningImages(ctx context.Context) ([]*runningImage, error) {
+	namespaces := a.options.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	found := map[string]*runningImage{}
+	for _, namespace := range namespaces {
+		var pods corev1.PodList
+		if err := a.reader.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+				continue
+			}
+			for image, subject := range podImages(pod) {
+				key := pod.Namespace + "/" + subject
+				if _, ok := found[key]; !ok {
+					found[key] = &runningImage{namespace: pod.Namespace, subject: subject, images: map[string]struct{}{}}
+				}
+				found[key].images[image] = struct{}{}
+			}
+		}
+	}
+
+	images := make([]*runningImage, 0, len(found))
+	for _, image := range found {
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].namespace != images[j].namespace {
+			return images[i].namespace < images[j].namespace
+		}
+		return images[i].subject < images[j].subject
+	})
+	return images, nil
+}
+
+// podImages maps the images in the spec of the pod to their references by
+// digest. Images which digest is unknown are skipped.
+func podImages(pod *corev1.Pod) map[string]string {
+	specImages := map[string]string{}
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			specImages[container.Name] = container.Image
+		}
+	}
+
+	images := map[string]string{}
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			image := specImages[status.Name]
+			if image == "" {
+				image = status.Image
+			}
+			if subject := imageDigestReference(status.ImageID); subject != "" {
+				images[image] = subject
+			}
+		}
+	}
+	for _, image := range specImages {
+		if _, ok := images[image]; ok {
+			continue
+		}
+		if subject := imageDigestReference(image); subject != "" {
+			images[image] = subject
+		}
+	}
+	return images
+}
+
+// imageDigestReference returns the reference by digest of an image ID or
+// image, or an empty string if it is not referenced by digest.
+func imageDigestReference(image string) string {
+	image = strings.TrimPrefix(image, "docker-pullable://")
+	if !strings.Contains(image, "@") {
+		return ""
+	}
+	subjectReference, err := utils.ParseSubjectReference(image)
+	if err != nil || subjectReference.Digest == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s@%s", subjectReference.Path, subjectReference.Digest)
+}
+
+// auditImage verifies the image and updates its report. Events and metrics
+// are emitted based on the result of the previous verification.
+func (a *Auditor) auditImage(ctx context.Context, image *runningImage) error {
+	ctx = ctxUtils.SetContextWithNamespace(ctx, image.namespace)
+	status := a.verify(ctx, image.subject)
+
+	report, err := a.getOrCreateReport(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	verified := report.Status.LastVerifiedTime != nil
+	drift := verified && report.Status.IsSuccess != status.IsSuccess
+	now := metav1.Now()
+	status.LastVerifiedTime = &now
+	status.LastTransitionTime = report.Status.LastTransitionTime
+	if !verified || drift {
+		status.LastTransitionTime = &now
+	}
+	report.Status = status
+	if err := a.client.Status().Update(ctx, report); err != nil {
+		return err
+	}
+
+	metrics.ReportAuditVerification(ctx, status.IsSuccess, drift)
+	switch {
+	case !status.IsSuccess && (!verified || drift):
+		a.recorder.Eventf(report, corev1.EventTypeWarning, EventReasonVerificationFailed, "image %s failed verification: %s", image.subject, status.BriefError)
+	case status.IsSuccess && drift:
+		a.recorder.Eventf(report, corev1.EventTypeNormal, EventReasonVerificationSucceeded, "image %s passed verification", image.subject)
+	}
+	return nil
+}
+
+// verify verifies the subject with the executor of the namespace of the
+// context and returns the resulting report status.
+func (a *Auditor) verify(ctx context.Context, subject string) configv1beta1.VerificationReportStatus {
+	result, err := a.getExecutor(ctx).VerifySubject(ctx, executor.VerifyParameters{Subject: subject})
+	if err != nil {
+		var ratifyErr re.Error
+		if !errors.As(err, &ratifyErr) {
+			ratifyErr = re.ErrorCodeExecutorFailure.WithError(err)
+		}
+		return configv1beta1.VerificationReportStatus{
+			Error:      ratifyErr.Error(),
+			BriefError: ratifyErr.GetConciseError(constants.MaxBriefErrLength),
+		}
+	}
+
+	status := configv1beta1.VerificationReportStatus{
+		IsSuccess: result.IsSuccess,
+		Failures:  verificationFailures(result.VerifierReports),
+	}
+	if !result.IsSuccess {
+		status.Error = failureMessage(status.Failures)
+		status.BriefError = status.Error
+		if len(status.BriefError) > constants.MaxBriefErrLength {
+			status.BriefError = fmt.Sprintf("%s...", status.BriefError[:constants.MaxBriefErrLength-3])
+		}
+	}
+	return status
+}
+
+// getOrCreateReport returns the report of the image, creating it if it does
+// not exist. The images of an existing report are updated if they changed.
+func (a *Auditor) getOrCreateReport(ctx context.Context, image *runningImage) (*configv1beta1.VerificationReport, error) {
+	specImages := make([]string, 0, len(image.images))
+	for specImage := range image.images {
+		specImages = append(specImages, specImage)
+	}
+	sort.Strings(specImages)
+
+	report := &configv1beta1.VerificationReport{}
+	key := types.NamespacedName{Namespace: image.namespace, Name: reportName(image.subject)}
+	err := a.client.Get(ctx, key, report)
+	switch {
+	case apierrors.IsNotFound(err):
+		report = &configv1beta1.VerificationReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{managedByLabel: managedByValue},
+			},
+			Spec: configv1beta1.VerificationReportSpec{Subject: image.subject, Images: specImages},
+		}
+		if err := a.client.Create(ctx, report); err != nil {
+			return nil, err
+		}
+		return report, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if strings.Join(report.Spec.Images, ",") != strings.Join(specImages, ",") {
+		report.Spec.Images = specImages
+		if err := a.client.Update(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// deleteStaleReports deletes the reports managed by the auditor in the
+// audited namespaces which image is no longer running.
+func (a *Auditor) deleteStaleReports(ctx context.Context, active map[types.NamespacedName]struct{}) error {
+	namespaces := a.options.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, namespace := range namespaces {
+		var reports configv1beta1.VerificationReportList
+		if err := a.reader.List(ctx, &reports, client.InNamespace(namespace), client.MatchingLabels{managedByLabel: managedByValue}); err != nil {
+			return err
+		}
+		for i := range reports.Items {
+			report := &reports.Items[i]
+			if _, ok := active[types.NamespacedName{Namespace: report.Namespace, Name: report.Name}]; ok {
+				continue
+			}
+			if err := a.client.Delete(ctx, report); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			logrus.WithContext(ctx).Debugf("deleted report of image %s no longer running in namespace %s", report.Spec.Subject, report.Namespace)
+		}
+	}
+	return nil
+}
+
+// reportName returns the name of the report of a subject, made of the last
+// segment of its repository and a hash of the subject.
+func reportName(subject string) string {
+	repository := subject
+	if i := strings.LastIndex(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	if i := strings.LastIndex(repository, "/"); i >= 0 {
+		repository = repository[i+1:]
+	}
+
+	var name strings.Builder
+	for _, c := range strings.ToLower(repository) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			name.WriteRune(c)
+		} else {
+			name.WriteRune('-')
+		}
+	}
+	prefix := strings.Trim(name.String(), "-")
+	if len(prefix) > maxNameLength {
+		prefix = strings.TrimRight(prefix[:maxNameLength], "-")
+	}
+
+	hash := sha256.Sum256([]byte(subject))
+	suffix := hex.EncodeToString(hash[:])[:16]
+	if prefix == "" {
+		return suffix
+	}
+	return prefix + "-" + suffix
+}
@@ -0,0 +1,216 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
+	"github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	config "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testNamespace    = "default"
+	testArtifactType = "test-type"
+	testImage        = "localhost:5000/net-monitor:v1"
+)
+
+var testSubject = "localhost:5000/net-monitor@" + digest.FromString("net-monitor").String()
+
+func testPod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: testImage}}},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:    "app",
+				Image:   testImage,
+				ImageID: "docker-pullable://" + testSubject,
+			}},
+		},
+	}
+}
+
+// newTestAuditor returns an Auditor and a pointer to the result of the
+// verifier.
+func newTestAuditor(t *testing.T, objects ...client.Object) (*Auditor, client.Client, *record.FakeRecorder, *bool) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	if err := configv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&configv1beta1.VerificationReport{}).
+		Build()
+
+	verifyResult := false
+	ex := &core.Executor{
+		PolicyEnforcer: config.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]pt.ArtifactTypeVerifyPolicy{
+				testArtifactType: pt.AnyVerifySuccess,
+			}},
+		ReferrerStores: []referrerstore.ReferrerStore{&mocks.TestStore{
+			References: []ocispecs.ReferenceDescriptor{{ArtifactType: testArtifactType}},
+			ResolveMap: map[string]digest.Digest{"": digest.FromString("net-monitor")},
+		}},
+		Verifiers: []verifier.ReferenceVerifier{&core.TestVerifier{
+			CanVerifyFunc: func(at string) bool {
+				return at == testArtifactType
+			},
+			VerifyResult: func(_ string) bool {
+				return verifyResult
+			},
+		}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	auditor := NewAuditor(c, c, recorder, func(context.Context) *core.Executor { return ex }, Options{QPS: 100})
+	return auditor, c, recorder, &verifyResult
+}
+
+func expectEvent(t *testing.T, recorder *record.FakeRecorder, prefix string) {
+	t.Helper()
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, prefix) {
+			t.Fatalf("expected event %q, got %q", prefix, event)
+		}
+	default:
+		t.Fatalf("expected event %q, got none", prefix)
+	}
+}
+
+func TestAudit_RecordsReportsAndDrift(t *testing.T) {
+	staleReport := &configv1beta1.VerificationReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stale",
+			Namespace: testNamespace,
+			Labels:    map[string]string{managedByLabel: managedByValue},
+		},
+		Spec: configv1beta1.VerificationReportSpec{Subject: "localhost:5000/stale@" + digest.FromString("stale").String()},
+	}
+	unmanagedReport := &configv1beta1.VerificationReport{
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: testNamespace},
+	}
+	auditor, c, recorder, verifyResult := newTestAuditor(t,
+		testPod("running", corev1.PodRunning),
+		testPod("pending", corev1.PodPending),
+		staleReport,
+		unmanagedReport,
+	)
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: testNamespace, Name: reportName(testSubject)}
+
+	if err := auditor.Audit(ctx); err != nil {
+		t.Fatalf("Audit() unexpected error: %v", err)
+	}
+	report := &configv1beta1.VerificationReport{}
+	if err := c.Get(ctx, key, report); err != nil {
+		t.Fatalf("failed to get report: %v", err)
+	}
+	if report.Spec.Subject != testSubject || !reflect.DeepEqual(report.Spec.Images, []string{testImage}) {
+		t.Fatalf("unexpected report spec %+v", report.Spec)
+	}
+	if report.Status.IsSuccess || len(report.Status.Failures) != 1 || report.Status.BriefError == "" {
+		t.Fatalf("expected failing report status, got %+v", report.Status)
+	}
+	if report.Status.LastVerifiedTime == nil || report.Status.LastTransitionTime == nil {
+		t.Fatalf("expected verification and transition times to be set")
+	}
+	expectEvent(t, recorder, "Warning "+EventReasonVerificationFailed)
+
+	if err := c.Get(ctx, client.ObjectKeyFromObject(staleReport), &configv1beta1.VerificationReport{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected stale report to be deleted, got error %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(unmanagedReport), &configv1beta1.VerificationReport{}); err != nil {
+		t.Fatalf("expected unmanaged report to be kept, got error %v", err)
+	}
+
+	// unchanged results do not emit events
+	if err := auditor.Audit(ctx); err != nil {
+		t.Fatalf("Audit() unexpected error: %v", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no event for unchanged result, got %q", <-recorder.Events)
+	}
+
+	*verifyResult = true
+	if err := auditor.Audit(ctx); err != nil {
+		t.Fatalf("Audit() unexpected error: %v", err)
+	}
+	if err := c.Get(ctx, key, report); err != nil {
+		t.Fatalf("failed to get report: %v", err)
+	}
+	if !report.Status.IsSuccess || len(report.Status.Failures) != 0 || report.Status.Error != "" {
+		t.Fatalf("expected successful report status, got %+v", report.Status)
+	}
+	expectEvent(t, recorder, "Normal "+EventReasonVerificationSucceeded)
+}
+
+func TestPodImages(t *testing.T) {
+	pod := testPod("pod", corev1.PodRunning)
+	byDigest := "localhost:5000/sidecar@" + digest.FromString("sidecar").String()
+	pod.Spec.Containers = append(pod.Spec.Containers,
+		corev1.Container{Name: "sidecar", Image: byDigest},
+		corev1.Container{Name: "unknown", Image: "localhost:5000/unknown:v1"},
+	)
+	want := map[string]string{
+		testImage: testSubject,
+		byDigest:  byDigest,
+	}
+	if images := podImages(pod); !reflect.DeepEqual(images, want) {
+		t.Fatalf("podImages() = %v, want %v", images, want)
+	}
+}
+
+func TestReportName(t *testing.T) {
+	name := reportName(testSubject)
+	if !strings.HasPrefix(name, "net-monitor-") || name != reportName(testSubject) {
+		t.Fatalf("unexpected report name %s", name)
+	}
+	other := reportName("localhost:5000/other/net-monitor@" + digest.FromString("net-monitor").String())
+	if other == name {
+		t.Fatalf("expected distinct report names of distinct subjects")
+	}
+	long := reportName("localhost:5000/" + strings.Repeat("Repo_", 20) + "@" + digest.FromString("long").String())
+	if len(long) > maxNameLength+17 || strings.ContainsAny(long, "_R") {
+		t.Fatalf("report name %s is not a valid name", long)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
+)
+
+// reportNode is the union of the verifier reports of the executor in either
+// format, verifier.VerifierResult with nested results or
+// types.NestedVerifierReport with nested reports.
+type reportNode struct {
+	IsSuccess       *bool        `json:"isSuccess"`
+	Subject         string       `json:"subject"`
+	ReferenceDigest string       `json:"referenceDigest"`
+	ArtifactType    string       `json:"artifactType"`
+	Name            string       `json:"name"`
+	VerifierName    string       `json:"verifierName"`
+	Message         string       `json:"message"`
+	ErrorReason     string       `json:"errorReason"`
+	VerifierReports []reportNode `json:"verifierReports"`
+	NestedResults   []reportNode `json:"nestedResults"`
+	NestedReports   []reportNode `json:"nestedReports"`
+}
+
+// verificationFailures returns the failing verifier reports of a verify
+// result, including the reports of nested artifacts.
+func verificationFailures(verifierReports []interface{}) []configv1beta1.VerificationFailure {
+	bytes, err := json.Marshal(verifierReports)
+	if err != nil {
+		return nil
+	}
+	var nodes []reportNode
+	if err := json.Unmarshal(bytes, &nodes); err != nil {
+		return nil
+	}
+
+	var failures []configv1beta1.VerificationFailure
+	for _, node := range nodes {
+		failures = appendFailures(failures, node, reportNode{})
+	}
+	return failures
+}
+
+// appendFailures appends the failures of the node and its children. Fields
+// not set on a node are inherited from its parent.
+func appendFailures(failures []configv1beta1.VerificationFailure, node, parent reportNode) []configv1beta1.VerificationFailure {
+	if node.Subject == "" {
+		node.Subject = parent.Subject
+	}
+	if node.ReferenceDigest == "" {
+		node.ReferenceDigest = parent.ReferenceDigest
+	}
+	if node.ArtifactType == "" {
+		node.ArtifactType = parent.ArtifactType
+	}
+
+	if node.IsSuccess != nil && !*node.IsSuccess && len(failures) < maxFailures {
+		verifier := node.VerifierName
+		if verifier == "" {
+			verifier = node.Name
+		}
+		failures = append(failures, configv1beta1.VerificationFailure{
+			Verifier:        verifier,
+			Subject:         node.Subject,
+			ReferenceDigest: node.ReferenceDigest,
+			ArtifactType:    node.ArtifactType,
+			Message:         node.Message,
+			ErrorReason:     node.ErrorReason,
+		})
+	}
+	for _, children := range [][]reportNode{node.VerifierReports, node.NestedResults, node.NestedReports} {
+		for _, child := range children {
+			failures = appendFailures(failures, child, node)
+		}
+	}
+	return failures
+}
+
+// failureMessage summarizes the failures of an image that failed the policy.
+func failureMessage(failures []configv1beta1.VerificationFailure) string {
+	if len(failures) == 0 {
+		return "image failed the policy"
+	}
+	messages := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		message := failure.Message
+		if failure.ErrorReason != "" {
+			message = fmt.Sprintf("%s: %s", message, failure.ErrorReason)
+		}
+		messages = append(messages, fmt.Sprintf("verifier %s failed: %s", failure.Verifier, message))
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,109 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+func TestVerificationFailures(t *testing.T) {
+	testCases := []struct {
+		name    string
+		reports []interface{}
+		want    []configv1beta1.VerificationFailure
+	}{
+		{
+			name: "nested verifier results",
+			reports: []interface{}{
+				verifier.VerifierResult{
+					Subject:         "localhost:5000/net-monitor@sha256:1",
+					IsSuccess:       true,
+					VerifierName:    "notation",
+					ReferenceDigest: "sha256:2",
+					ArtifactType:    "application/vnd.cncf.notary.signature",
+					NestedResults: []verifier.VerifierResult{{
+						Subject:         "localhost:5000/net-monitor@sha256:2",
+						IsSuccess:       false,
+						Name:            "cosign",
+						ReferenceDigest: "sha256:3",
+						Message:         "signature is not valid",
+						ErrorReason:     "no matching signatures",
+					}},
+				},
+			},
+			want: []configv1beta1.VerificationFailure{{
+				Verifier:        "cosign",
+				Subject:         "localhost:5000/net-monitor@sha256:2",
+				ReferenceDigest: "sha256:3",
+				ArtifactType:    "application/vnd.cncf.notary.signature",
+				Message:         "signature is not valid",
+				ErrorReason:     "no matching signatures",
+			}},
+		},
+		{
+			name: "nested verifier reports",
+			reports: []interface{}{
+				types.NestedVerifierReport{
+					Subject:         "localhost:5000/net-monitor@sha256:1",
+					ReferenceDigest: "sha256:2",
+					ArtifactType:    "application/spdx+json",
+					VerifierReports: []vt.VerifierResult{
+						{IsSuccess: true, VerifierName: "sbom"},
+						{IsSuccess: false, VerifierName: "vulnerability", Message: "critical vulnerabilities found"},
+					},
+				},
+			},
+			want: []configv1beta1.VerificationFailure{{
+				Verifier:        "vulnerability",
+				Subject:         "localhost:5000/net-monitor@sha256:1",
+				ReferenceDigest: "sha256:2",
+				ArtifactType:    "application/spdx+json",
+				Message:         "critical vulnerabilities found",
+			}},
+		},
+		{
+			name:    "no reports",
+			reports: []interface{}{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if failures := verificationFailures(tc.reports); !reflect.DeepEqual(failures, tc.want) {
+				t.Fatalf("verificationFailures() = %+v, want %+v", failures, tc.want)
+			}
+		})
+	}
+}
+
+func TestFailureMessage(t *testing.T) {
+	if message := failureMessage(nil); message != "image failed the policy" {
+		t.Fatalf("unexpected message %q", message)
+	}
+	message := failureMessage([]configv1beta1.VerificationFailure{
+		{Verifier: "notation", Message: "signature is not valid", ErrorReason: "expired"},
+		{Verifier: "cosign", Message: "no signatures"},
+	})
+	if want := "verifier notation failed: signature is not valid: expired; verifier cosign failed: no signatures"; message != want {
+		t.Fatalf("failureMessage() = %q, want %q", message, want)
+	}
+}
@@ -546,3 +546,33 @@ func TestWriteKMProviderStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyManagementProviderReconciler_MapObjectToProviders(t *testing.T) {
+	scheme, _ := test.CreateScheme()
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&configv1beta1.KeyManagementProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-kmp"},
+			Spec: configv1beta1.KeyManagementProviderSpec{
+				Type:       "kubernetesSecret",
+				Parameters: runtime.RawExtension{Raw: []byte(`{"namespace": "gatekeeper-system", "keys": [{"name": "cosign-keys"}]}`)},
+			},
+		},
+		&configv1beta1.KeyManagementProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "inline-kmp"},
+			Spec: configv1beta1.KeyManagementProviderSpec{
+				Type:       "inline",
+				Parameters: runtime.RawExtension{Raw: []byte(`{"namespace": "gatekeeper-system", "keys": [{"name": "cosign-keys"}]}`)},
+			},
+		},
+	).Build()
+	r := &KeyManagementProviderReconciler{Client: client, Scheme: scheme}
+
+	secret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "cosign-keys", Namespace: "gatekeeper-system"}}
+	requests := r.mapObjectToProviders("Secret")(context.Background(), secret)
+	if len(requests) != 1 || requests[0].Name != "secret-kmp" || requests[0].Namespace != "" {
+		t.Fatalf("expected a request for secret-kmp, got %v", requests)
+	}
+	if requests := r.mapObjectToProviders("ConfigMap")(context.Background(), secret); len(requests) != 0 {
+		t.Fatalf("expected no requests, got %v", requests)
+	}
+}
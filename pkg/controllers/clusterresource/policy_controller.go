@@ -85,6 +85,7 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.Policy{}).
+		WithOptions(controllers.ReplicaOptions()).
 		Complete(r)
 }
 
@@ -81,6 +81,7 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 func (r *StoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.Store{}).
+		WithOptions(controllers.ReplicaOptions()).
 		Complete(r)
 }
 
@@ -99,6 +99,7 @@ func verifierAddOrReplace(spec configv1beta1.VerifierSpec, objectName string) er
 func (r *VerifierReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.Verifier{}).
+		WithOptions(controllers.ReplicaOptions()).
 		Complete(r)
 }
 
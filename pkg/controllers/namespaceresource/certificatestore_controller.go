@@ -115,6 +115,7 @@ func (r *CertificateStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// see more discussions at https://github.com/kubernetes-sigs/kubebuilder/issues/618
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.CertificateStore{}).WithEventFilter(pred).
+		WithOptions(controllers.ReplicaOptions()).
 		Complete(r)
 }
 
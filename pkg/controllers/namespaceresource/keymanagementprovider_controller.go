@@ -22,18 +22,28 @@ import (
 
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/constants"
+	"github.com/ratify-project/ratify/pkg/controllers"
 	cutils "github.com/ratify-project/ratify/pkg/controllers/utils"
 	kmp "github.com/ratify-project/ratify/pkg/keymanagementprovider"
-	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/azurekeyvault" // register azure key vault key management provider
-	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/inline"        // register inline key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/awskms"         // register aws kms key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/azurekeyvault"  // register azure key vault key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/gcpkms"         // register gcp kms key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/hashicorpvault" // register hashicorp vault key management provider
+	_ "github.com/ratify-project/ratify/pkg/keymanagementprovider/inline"         // register inline key management provider
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/kubernetessecret"
 	"github.com/ratify-project/ratify/pkg/keymanagementprovider/refresh"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
 )
@@ -42,6 +52,9 @@ import (
 type KeyManagementProviderReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// KubernetesSecretEnabled watches Secrets and ConfigMaps to refresh the
+	// kubernetesSecret key management providers referencing them
+	KubernetesSecretEnabled bool
 }
 
 func (r *KeyManagementProviderReconciler) ReconcileWithType(ctx context.Context, req ctrl.Request, refresherType string) (ctrl.Result, error) {
@@ -79,7 +92,7 @@ func (r *KeyManagementProviderReconciler) ReconcileWithType(ctx context.Context,
 		logger.Warn("Certificate Store already exists. Key management provider and certificate store should not be configured together. Please migrate to key management provider and delete certificate store.")
 	}
 
-	provider, err := cutils.SpecToKeyManagementProvider(keyManagementProvider.Spec.Parameters.Raw, keyManagementProvider.Spec.Type)
+	provider, err := cutils.SpecToKeyManagementProvider(keyManagementProvider.Spec.Parameters.Raw, keyManagementProvider.Spec.Type, keyManagementProvider.Namespace)
 	if err != nil {
 		kmpErr := re.ErrorCodeKeyManagementProviderFailure.WithError(err).WithDetail("Failed to create key management provider from CR")
 
@@ -133,6 +146,7 @@ func (r *KeyManagementProviderReconciler) ReconcileWithType(ctx context.Context,
 // +kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=namespacedkeymanagementproviders,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=namespacedkeymanagementproviders/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=namespacedkeymanagementproviders/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch
 func (r *KeyManagementProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	return r.ReconcileWithType(ctx, req, refresh.KubeRefresherType)
 }
@@ -144,9 +158,34 @@ func (r *KeyManagementProviderReconciler) SetupWithManager(mgr ctrl.Manager) err
 	// status updates will trigger a reconcile event
 	// if there are no changes to spec of CRD, this event should be filtered out by using the predicate
 	// see more discussions at https://github.com/kubernetes-sigs/kubebuilder/issues/618
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&configv1beta1.NamespacedKeyManagementProvider{}).WithEventFilter(pred).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&configv1beta1.NamespacedKeyManagementProvider{}, builder.WithPredicates(pred)).
+		WithOptions(controllers.ReplicaOptions())
+	// Secrets and ConfigMaps referenced by kubernetesSecret key management providers
+	// trigger a refresh of the providers once they change
+	if r.KubernetesSecretEnabled {
+		b = b.Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapObjectToProviders(kubernetessecret.SecretKind)), builder.OnlyMetadata).
+			Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.mapObjectToProviders(kubernetessecret.ConfigMapKind)), builder.OnlyMetadata)
+	}
+	return b.Complete(r)
+}
+
+// mapObjectToProviders returns the requests of the kubernetesSecret key management providers referencing the object of the given kind
+func (r *KeyManagementProviderReconciler) mapObjectToProviders(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var providers configv1beta1.NamespacedKeyManagementProviderList
+		if err := r.List(ctx, &providers); err != nil {
+			logrus.WithContext(ctx).Errorf("unable to list key management providers referencing %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, provider := range providers.Items {
+			if provider.Spec.Type == kubernetessecret.ProviderName && kubernetessecret.References(provider.Spec.Parameters.Raw, provider.Namespace, kind, obj) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: provider.Namespace, Name: provider.Name}})
+			}
+		}
+		return requests
+	}
 }
 
 // writeKMProviderStatusNamespaced updates the status of the key management provider resource
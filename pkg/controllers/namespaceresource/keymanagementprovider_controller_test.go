@@ -547,3 +547,27 @@ func TestWriteKMProviderStatusNamespaced(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyManagementProviderReconciler_MapObjectToProviders(t *testing.T) {
+	scheme, _ := test.CreateScheme()
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&configv1beta1.NamespacedKeyManagementProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmap-kmp", Namespace: "default"},
+			Spec: configv1beta1.NamespacedKeyManagementProviderSpec{
+				Type:       "kubernetesSecret",
+				Parameters: runtime.RawExtension{Raw: []byte(`{"namespace": "default", "certificates": [{"kind": "ConfigMap", "labelSelector": "ratify.dev/trust=notation"}]}`)},
+			},
+		},
+	).Build()
+	r := &KeyManagementProviderReconciler{Client: client, Scheme: scheme}
+
+	configMap := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default", Labels: map[string]string{"ratify.dev/trust": "notation"}}}
+	requests := r.mapObjectToProviders("ConfigMap")(context.Background(), configMap)
+	if len(requests) != 1 || requests[0].Name != "configmap-kmp" || requests[0].Namespace != "default" {
+		t.Fatalf("expected a request for default/configmap-kmp, got %v", requests)
+	}
+	configMap.Labels = nil
+	if requests := r.mapObjectToProviders("ConfigMap")(context.Background(), configMap); len(requests) != 0 {
+		t.Fatalf("expected no requests, got %v", requests)
+	}
+}
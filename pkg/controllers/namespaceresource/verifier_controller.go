@@ -98,6 +98,7 @@ func verifierAddOrReplace(spec configv1beta1.NamespacedVerifierSpec, objectName
 func (r *VerifierReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.NamespacedVerifier{}).
+		WithOptions(controllers.ReplicaOptions()).
 		Complete(r)
 }
 
@@ -0,0 +1,27 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import "sigs.k8s.io/controller-runtime/pkg/controller"
+
+// ReplicaOptions returns the options of the controllers loading the resources
+// verifications run with. Every replica verifies with its own in-memory copy of
+// the resources, so the controllers run on every replica regardless of leader
+// election.
+func ReplicaOptions() controller.Options {
+	needLeaderElection := false
+	return controller.Options{NeedLeaderElection: &needLeaderElection}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/cache"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultCacheTTL is the default time-to-live of pre-warmed cache entries,
+	// which is the default time-to-live of the cache entries of the verify
+	// handler.
+	DefaultCacheTTL = cache.DefaultCacheTTL
+	// DefaultQPS is the default number of verifications per second of the
+	// pre-warmer.
+	DefaultQPS = 2
+	// DefaultBurst is the default burst of verifications of the pre-warmer.
+	DefaultBurst = 5
+	// DefaultWindow is the default duration after a change of the pod template
+	// of a workload during which its images are kept pre-warmed.
+	DefaultWindow = 5 * time.Minute
+)
+
+var logOpt = logger.Option{
+	ComponentType: logger.Cache,
+}
+
+// Options configures the cache pre-warmer.
+type Options struct {
+	// Repositories restricts pre-warming to images of the registries or
+	// repositories, such as "myregistry.azurecr.io" or
+	// "myregistry.azurecr.io/net-monitor". Images of all repositories are
+	// pre-warmed if empty.
+	Repositories []string
+	// CacheTTL is the time-to-live of the pre-warmed cache entries, capped at
+	// the cache TTL of the verify handler. Images of workloads being rolled out
+	// are verified again after half of it so that their entries do not expire.
+	CacheTTL time.Duration
+	// Window is the duration after a change of the pod template of a workload
+	// during which its images are kept pre-warmed for the pods of the rollout.
+	Window time.Duration
+	// QPS and Burst limit the rate of verifications of the pre-warmer across
+	// all workloads.
+	QPS   float64
+	Burst int
+	// SharedCache is set if the replicas share the cache, such as the dapr
+	// cache, so that only the leader pre-warms it.
+	SharedCache bool
+	// NamespacedKeys is set if the admission requests prefix the images with
+	// the namespace of the pod, as the multi-tenancy constraint template does.
+	// Images are then verified and cached in the namespace of the workload,
+	// otherwise cluster-wide as the images of the default constraint template.
+	NamespacedKeys bool
+}
+
+// SubjectVerifier verifies subjects and caches their results for the
+// admission requests, which is implemented by the verification server.
+type SubjectVerifier interface {
+	// PrewarmSubject verifies the subject with the executor of the namespace
+	// of the context, cluster-wide if not set, and caches the result for the
+	// TTL.
+	PrewarmSubject(ctx context.Context, subject string, ttl time.Duration) error
+}
+
+// Prewarmer verifies the images of workloads ahead of their admission with
+// the verification server, so that admission requests hit the cache.
+type Prewarmer struct {
+	options Options
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	verifier SubjectVerifier
+	// verified is the time each image was last verified in a namespace.
+	verified map[string]time.Time
+}
+
+// NewPrewarmer creates a Prewarmer verifying images once the verifier is set.
+func NewPrewarmer(options Options) *Prewarmer {
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = DefaultCacheTTL
+	}
+	if options.QPS <= 0 {
+		options.QPS = DefaultQPS
+	}
+	if options.Burst <= 0 {
+		options.Burst = DefaultBurst
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultWindow
+	}
+	return &Prewarmer{
+		options:  options,
+		limiter:  rate.NewLimiter(rate.Limit(options.QPS), options.Burst),
+		verified: map[string]time.Time{},
+	}
+}
+
+// SetVerifier sets the verification server verifying the images, which starts
+// after the controllers.
+func (p *Prewarmer) SetVerifier(verifier SubjectVerifier) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.verifier = verifier
+}
+
+// RefreshInterval is the interval to verify the images of a workload being
+// rolled out again before their cache entries expire.
+func (p *Prewarmer) RefreshInterval() time.Duration {
+	return p.options.CacheTTL / 2
+}
+
+// Window is the duration after a change of the pod template of a workload
+// during which its images are kept pre-warmed.
+func (p *Prewarmer) Window() time.Duration {
+	return p.options.Window
+}
+
+// Prewarm verifies the images of a workload and caches the results under the
+// keys the admission requests look up, which are namespaced to the workload
+// if NamespacedKeys is set and cluster-wide otherwise. Images verified within the refresh interval or out
+// of the configured repositories are skipped. Verification failures of an
+// image are logged and do not stop pre-warming the other images. Prewarm does
+// not wait for the rate limiter: once the limit is reached, it returns the
+// delay after which the remaining images can be verified.
+func (p *Prewarmer) Prewarm(ctx context.Context, namespace string, images []string) (time.Duration, error) {
+	p.mu.Lock()
+	verifier := p.verifier
+	p.mu.Unlock()
+	if verifier == nil {
+		return 0, re.ErrorCodeExecutorFailure.WithDetail("cache pre-warming waits for the verification server to start")
+	}
+	// admission requests of the default constraint template send bare images,
+	// which are verified and cached cluster-wide
+	if p.options.NamespacedKeys {
+		ctx = ctxUtils.SetContextWithNamespace(ctx, namespace)
+	} else {
+		namespace = ""
+	}
+
+	for _, image := range images {
+		subjectReference, err := utils.ParseSubjectReference(image)
+		if err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("skipping pre-warming of invalid image reference %s: %v", image, err)
+			continue
+		}
+		subject := subjectReference.Original
+		if !p.inRepositories(subjectReference.Path) || !p.due(namespace, subject) {
+			continue
+		}
+
+		reservation := p.limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			p.forget(namespace, subject)
+			return delay, nil
+		}
+		if err := verifier.PrewarmSubject(ctx, subject, p.options.CacheTTL); err != nil {
+			p.forget(namespace, subject)
+			metrics.ReportCachePrewarm(ctx, false)
+			logger.GetLogger(ctx, logOpt).Warnf("failed to pre-warm cache for subject %s: %v", subject, err)
+			continue
+		}
+		metrics.ReportCachePrewarm(ctx, true)
+		logger.GetLogger(ctx, logOpt).Debugf("pre-warmed cache for subject %s", subject)
+	}
+	return 0, nil
+}
+
+// inRepositories returns true if the repository of an image is one of or
+// belongs to a registry of the configured repositories.
+func (p *Prewarmer) inRepositories(repository string) bool {
+	if len(p.options.Repositories) == 0 {
+		return true
+	}
+	for _, prefix := range p.options.Repositories {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if repository == prefix || strings.HasPrefix(repository, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// due returns true and marks the subject as verified if it was not verified
+// in the namespace, empty for cluster-wide entries, within the refresh
+// interval.
+func (p *Prewarmer) due(namespace, subject string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for key, verifiedAt := range p.verified {
+		if now.Sub(verifiedAt) >= p.RefreshInterval() {
+			delete(p.verified, key)
+		}
+	}
+	key := namespace + "/" + subject
+	if _, ok := p.verified[key]; ok {
+		return false
+	}
+	p.verified[key] = now
+	return true
+}
+
+// forget clears the verification time of the subject so that it is verified
+// again by the next reconciliation.
+func (p *Prewarmer) forget(namespace, subject string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.verified, namespace+"/"+subject)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ctxUtils "github.com/ratify-project/ratify/internal/context"
+)
+
+const (
+	testImage   = "localhost:5000/net-monitor:v1"
+	testSubject = "localhost:5000/net-monitor:v1"
+)
+
+// testVerifier records the subjects pre-warmed by namespace.
+type testVerifier struct {
+	mu       sync.Mutex
+	verified []string
+	ttl      time.Duration
+	err      error
+}
+
+func (v *testVerifier) PrewarmSubject(ctx context.Context, subject string, ttl time.Duration) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verified = append(v.verified, ctxUtils.GetNamespace(ctx)+"/"+subject)
+	v.ttl = ttl
+	return v.err
+}
+
+func (v *testVerifier) count() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.verified)
+}
+
+// newTestPrewarmer returns a Prewarmer verifying images with a testVerifier.
+func newTestPrewarmer(options Options) (*Prewarmer, *testVerifier) {
+	verifier := &testVerifier{}
+	prewarmer := NewPrewarmer(options)
+	prewarmer.SetVerifier(verifier)
+	return prewarmer, verifier
+}
+
+func TestPrewarm_VerifiesWithServer(t *testing.T) {
+	prewarmer, verifier := newTestPrewarmer(Options{CacheTTL: time.Minute, NamespacedKeys: true})
+
+	if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage, testImage}); err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+	if verifier.count() != 1 || verifier.verified[0] != "default/"+testSubject {
+		t.Fatalf("expected subject to be verified once in the namespace, got %v", verifier.verified)
+	}
+	if verifier.ttl != time.Minute {
+		t.Fatalf("expected cache TTL %v, got %v", time.Minute, verifier.ttl)
+	}
+
+	// images verified within the refresh interval are skipped
+	if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage}); err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+	if verifier.count() != 1 {
+		t.Fatalf("expected image to be verified once within the refresh interval, got %d verifications", verifier.count())
+	}
+
+	// images are pre-warmed in each namespace
+	if _, err := prewarmer.Prewarm(context.Background(), "other", []string{testImage}); err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+	if verifier.count() != 2 || verifier.verified[1] != "other/"+testSubject {
+		t.Fatalf("expected subject to be verified in the other namespace, got %v", verifier.verified)
+	}
+}
+
+func TestPrewarm_ClusterWideKeys(t *testing.T) {
+	prewarmer, verifier := newTestPrewarmer(Options{})
+
+	for _, namespace := range []string{"default", "other"} {
+		if _, err := prewarmer.Prewarm(context.Background(), namespace, []string{testImage}); err != nil {
+			t.Fatalf("Prewarm() unexpected error: %v", err)
+		}
+	}
+	// images are pre-warmed once for the bare keys of the default constraint template
+	if verifier.count() != 1 || verifier.verified[0] != "/"+testSubject {
+		t.Fatalf("expected subject to be verified once cluster-wide, got %v", verifier.verified)
+	}
+}
+
+func TestPrewarm_VerificationFailure(t *testing.T) {
+	prewarmer, verifier := newTestPrewarmer(Options{})
+	verifier.err = errors.New("unable to insert cache entry")
+
+	for i := 0; i < 2; i++ {
+		if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage}); err != nil {
+			t.Fatalf("Prewarm() unexpected error: %v", err)
+		}
+	}
+	// failed images are verified again by the next reconciliation
+	if verifier.count() != 2 {
+		t.Fatalf("expected failed image to be verified again, got %d verifications", verifier.count())
+	}
+}
+
+func TestPrewarm_RateLimited(t *testing.T) {
+	prewarmer, verifier := newTestPrewarmer(Options{QPS: 1, Burst: 1})
+	other := "localhost:5000/net-monitor:v2"
+
+	delay, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage, other})
+	if err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+	if delay <= 0 || delay > time.Second {
+		t.Fatalf("expected delay of at most a second once rate limited, got %v", delay)
+	}
+	if verifier.count() != 1 {
+		t.Fatalf("expected 1 verification before the rate limit, got %d", verifier.count())
+	}
+	// images skipped by the rate limit are verified by the next reconciliation
+	if !prewarmer.due("", other) {
+		t.Fatalf("expected rate limited image to be due")
+	}
+}
+
+func TestPrewarm_VerifierNotSet(t *testing.T) {
+	prewarmer := NewPrewarmer(Options{})
+	if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage}); err == nil {
+		t.Fatalf("expected error before the verification server is set")
+	}
+}
+func TestPrewarm_Repositories(t *testing.T) {
+	prewarmer, verifier := newTestPrewarmer(Options{Repositories: []string{"myregistry.azurecr.io/", "localhost:5000/app"}})
+
+	if _, err := prewarmer.Prewarm(context.Background(), "default", []string{testImage, "invalid image"}); err != nil {
+		t.Fatalf("Prewarm() unexpected error: %v", err)
+	}
+	if verifier.count() != 0 {
+		t.Fatalf("expected images out of the repositories to be skipped, got %d verifications", verifier.count())
+	}
+
+	testCases := []struct {
+		repository string
+		want       bool
+	}{
+		{repository: "myregistry.azurecr.io/net-monitor", want: true},
+		{repository: "localhost:5000/app", want: true},
+		{repository: "localhost:5000/app/nested", want: true},
+		{repository: "localhost:5000/application", want: false},
+		{repository: "docker.io/library/nginx", want: false},
+	}
+	for _, tc := range testCases {
+		if got := prewarmer.inRepositories(tc.repository); got != tc.want {
+			t.Errorf("inRepositories(%s) = %t, want %t", tc.repository, got, tc.want)
+		}
+	}
+}
+
+func TestNewPrewarmer_Defaults(t *testing.T) {
+	prewarmer, _ := newTestPrewarmer(Options{})
+	if prewarmer.options.CacheTTL != DefaultCacheTTL || prewarmer.options.QPS != DefaultQPS || prewarmer.options.Burst != DefaultBurst || prewarmer.Window() != DefaultWindow {
+		t.Fatalf("unexpected default options %+v", prewarmer.options)
+	}
+	if prewarmer.RefreshInterval() != DefaultCacheTTL/2 {
+		t.Fatalf("expected refresh interval %v, got %v", DefaultCacheTTL/2, prewarmer.RefreshInterval())
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// WorkloadReconciler pre-warms the verification cache with the images of a
+// kind of workload.
+type WorkloadReconciler struct {
+	client.Client
+	Prewarmer *Prewarmer
+	// NewObject returns an empty object of the kind of workload, one of
+	// Deployment, StatefulSet, DaemonSet or Job.
+	NewObject func() client.Object
+
+	mu sync.Mutex
+	// changes is the last observed change of the pod template of each
+	// workload.
+	changes map[types.NamespacedName]templateChange
+}
+
+// templateChange is a generation of a workload and the time it was observed.
+// The time is zero for workloads already rolled out when first observed.
+type templateChange struct {
+	generation int64
+	observedAt time.Time
+}
+
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+
+// Reconcile verifies the images of the workload within the pre-warming window
+// after a change of its pod template, and requeues it to verify them again
+// before their cache entries expire until the window ends. Workloads already
+// rolled out when first observed, deleted workloads and completed jobs are not
+// pre-warmed. Reconcile does not block on the rate limit of the pre-warmer but
+// requeues the workload once it allows more verifications.
+func (r *WorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	workloadLogger := logrus.WithContext(ctx)
+
+	workload := r.NewObject()
+	if err := r.Get(ctx, req.NamespacedName, workload); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			workloadLogger.Error(err, "unable to fetch workload")
+		} else {
+			r.forgetChange(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !workload.GetDeletionTimestamp().IsZero() {
+		r.forgetChange(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	podSpec, active := workloadPodSpec(workload)
+	if podSpec == nil || !active {
+		return ctrl.Result{}, nil
+	}
+	remaining := r.Prewarmer.Window() - time.Since(r.observeChange(req.NamespacedName, workload))
+	if remaining <= 0 {
+		return ctrl.Result{}, nil
+	}
+
+	workloadLogger.Debugf("pre-warming cache with images of workload %v", req.NamespacedName)
+	delay, err := r.Prewarmer.Prewarm(ctx, req.Namespace, podSpecImages(podSpec))
+	if err != nil {
+		workloadLogger.Errorf("failed to pre-warm cache with images of workload %v: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+	if delay > 0 {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	if refresh := r.Prewarmer.RefreshInterval(); refresh < remaining {
+		return ctrl.Result{RequeueAfter: refresh}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// observeChange records the generation of the workload and returns the time
+// its pod template was observed to change. A workload first observed is
+// considered changed only while its rollout is in progress.
+func (r *WorkloadReconciler) observeChange(key types.NamespacedName, workload client.Object) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.changes == nil {
+		r.changes = map[types.NamespacedName]templateChange{}
+	}
+
+	change, ok := r.changes[key]
+	switch {
+	case ok && change.generation == workload.GetGeneration():
+		return change.observedAt
+	case ok || rolloutInProgress(workload):
+		change = templateChange{generation: workload.GetGeneration(), observedAt: time.Now()}
+	default:
+		change = templateChange{generation: workload.GetGeneration()}
+	}
+	r.changes[key] = change
+	return change.observedAt
+}
+
+// forgetChange clears the recorded change of a deleted workload.
+func (r *WorkloadReconciler) forgetChange(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.changes, key)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WorkloadReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// each replica admits pods with its own in-memory cache, so the controller
+	// pre-warms it on every replica regardless of leader election. A cache
+	// shared by the replicas is pre-warmed by the leader only.
+	needLeaderElection := r.Prewarmer.options.SharedCache
+	return ctrl.NewControllerManagedBy(mgr).
+		For(r.NewObject()).
+		WithOptions(controller.Options{NeedLeaderElection: &needLeaderElection}).
+		Complete(r)
+}
+
+// SetupWithManager sets up the pre-warming controllers of Deployments,
+// StatefulSets, DaemonSets and Jobs with the Manager.
+func SetupWithManager(mgr ctrl.Manager, prewarmer *Prewarmer) error {
+	for _, newObject := range []func() client.Object{
+		func() client.Object { return &appsv1.Deployment{} },
+		func() client.Object { return &appsv1.StatefulSet{} },
+		func() client.Object { return &appsv1.DaemonSet{} },
+		func() client.Object { return &batchv1.Job{} },
+	} {
+		if err := (&WorkloadReconciler{
+			Client:    mgr.GetClient(),
+			Prewarmer: prewarmer,
+			NewObject: newObject,
+		}).SetupWithManager(mgr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workloadPodSpec returns the pod template spec of the workload and whether
+// the workload may still create pods.
+func workloadPodSpec(workload client.Object) (*corev1.PodSpec, bool) {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template.Spec, true
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template.Spec, true
+	case *appsv1.DaemonSet:
+		return &w.Spec.Template.Spec, true
+	case *batchv1.Job:
+		return &w.Spec.Template.Spec, w.Status.CompletionTime == nil
+	default:
+		return nil, false
+	}
+}
+
+// rolloutInProgress returns true if the controller of the workload has not
+// yet observed its latest generation. Jobs create their pods until they
+// complete.
+func rolloutInProgress(workload client.Object) bool {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return w.Status.ObservedGeneration < w.Generation
+	case *appsv1.StatefulSet:
+		return w.Status.ObservedGeneration < w.Generation
+	case *appsv1.DaemonSet:
+		return w.Status.ObservedGeneration < w.Generation
+	case *batchv1.Job:
+		return w.Status.CompletionTime == nil
+	default:
+		return false
+	}
+}
+
+// podSpecImages returns the distinct images of the init and app containers of
+// the pod spec.
+func podSpecImages(podSpec *corev1.PodSpec) []string {
+	seen := map[string]struct{}{}
+	var images []string
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, container := range containers {
+			if _, ok := seen[container.Image]; ok || container.Image == "" {
+				continue
+			}
+			seen[container.Image] = struct{}{}
+			images = append(images, container.Image)
+		}
+	}
+	return images
+}
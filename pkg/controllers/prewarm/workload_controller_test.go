@@ -0,0 +1,212 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "default"
+
+func testPodSpec(images ...string) corev1.PodTemplateSpec {
+	var containers []corev1.Container
+	for _, image := range images {
+		containers = append(containers, corev1.Container{Name: "app", Image: image})
+	}
+	return corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init", Image: images[0]}},
+		Containers:     containers,
+	}}
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	return scheme
+}
+
+func TestWorkloadReconciler_Reconcile(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: testNamespace, Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Template: testPodSpec(testImage)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+	rolledOut := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rolled-out", Namespace: testNamespace, Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Template: testPodSpec("localhost:5000/rolled-out:v1")},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+	rateLimited := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rate-limited", Namespace: testNamespace, Generation: 1},
+		Spec:       appsv1.DaemonSetSpec{Template: testPodSpec("localhost:5000/app:v1", "localhost:5000/sidecar:v1")},
+	}
+	completedAt := metav1.Now()
+	completedJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: testNamespace},
+		Spec:       batchv1.JobSpec{Template: testPodSpec("localhost:5000/completed:v1")},
+		Status:     batchv1.JobStatus{CompletionTime: &completedAt},
+	}
+
+	testCases := []struct {
+		name              string
+		options           Options
+		newObject         func() client.Object
+		workload          string
+		wantRequeue       bool
+		wantRateLimited   bool
+		wantVerifications int
+	}{
+		{
+			name:              "deployment being rolled out",
+			newObject:         func() client.Object { return &appsv1.Deployment{} },
+			workload:          deployment.Name,
+			wantRequeue:       true,
+			wantVerifications: 1,
+		},
+		{
+			name:      "deployment rolled out",
+			newObject: func() client.Object { return &appsv1.Deployment{} },
+			workload:  rolledOut.Name,
+		},
+		{
+			name:              "rate limited",
+			options:           Options{QPS: 1, Burst: 1},
+			newObject:         func() client.Object { return &appsv1.DaemonSet{} },
+			workload:          rateLimited.Name,
+			wantRateLimited:   true,
+			wantVerifications: 1,
+		},
+		{
+			name:      "completed job",
+			newObject: func() client.Object { return &batchv1.Job{} },
+			workload:  completedJob.Name,
+		},
+		{
+			name:      "deleted workload",
+			newObject: func() client.Object { return &appsv1.StatefulSet{} },
+			workload:  "deleted",
+		},
+	}
+
+	scheme := testScheme(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prewarmer, verifier := newTestPrewarmer(tc.options)
+			r := &WorkloadReconciler{
+				Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment, rolledOut, rateLimited, completedJob).Build(),
+				Prewarmer: prewarmer,
+				NewObject: tc.newObject,
+			}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: tc.workload}})
+			if err != nil {
+				t.Fatalf("Reconcile() unexpected error: %v", err)
+			}
+			if (result.RequeueAfter == prewarmer.RefreshInterval()) != tc.wantRequeue {
+				t.Fatalf("Reconcile() requeued after %v, want requeue %t", result.RequeueAfter, tc.wantRequeue)
+			}
+			if rateLimited := result.RequeueAfter > 0 && result.RequeueAfter <= time.Second; rateLimited != tc.wantRateLimited {
+				t.Fatalf("Reconcile() requeued after %v, want rate limited %t", result.RequeueAfter, tc.wantRateLimited)
+			}
+			if verifier.count() != tc.wantVerifications {
+				t.Fatalf("expected %d verifications, got %d", tc.wantVerifications, verifier.count())
+			}
+		})
+	}
+}
+
+func TestWorkloadReconciler_TemplateChange(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: testNamespace, Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Template: testPodSpec(testImage)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+	key := types.NamespacedName{Namespace: testNamespace, Name: deployment.Name}
+	prewarmer, verifier := newTestPrewarmer(Options{})
+	r := &WorkloadReconciler{
+		Client:    fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(deployment).Build(),
+		Prewarmer: prewarmer,
+		NewObject: func() client.Object { return &appsv1.Deployment{} },
+	}
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile() unexpected error: %v", err)
+		}
+		return result
+	}
+
+	// a workload rolled out before it is first observed is not pre-warmed
+	if result := reconcile(); result.RequeueAfter != 0 || verifier.count() != 0 {
+		t.Fatalf("expected rolled out workload not to be pre-warmed, got requeue after %v and %d verifications", result.RequeueAfter, verifier.count())
+	}
+
+	// a change of the pod template is pre-warmed within the window
+	deployment.Spec.Template = testPodSpec("localhost:5000/net-monitor:v2")
+	deployment.Generation = 2
+	if err := r.Update(context.Background(), deployment); err != nil {
+		t.Fatalf("failed to update deployment: %v", err)
+	}
+	if result := reconcile(); result.RequeueAfter != prewarmer.RefreshInterval() || verifier.count() != 1 {
+		t.Fatalf("expected changed workload to be pre-warmed and requeued, got requeue after %v and %d verifications", result.RequeueAfter, verifier.count())
+	}
+
+	// the workload is not pre-warmed nor requeued once the window ends
+	r.changes[key] = templateChange{generation: r.changes[key].generation, observedAt: time.Now().Add(-prewarmer.Window())}
+	if result := reconcile(); result.RequeueAfter != 0 || verifier.count() != 1 {
+		t.Fatalf("expected workload not to be pre-warmed after the window, got requeue after %v and %d verifications", result.RequeueAfter, verifier.count())
+	}
+}
+
+func TestPodSpecImages(t *testing.T) {
+	podSpec := testPodSpec("localhost:5000/app:v1", "localhost:5000/sidecar:v1", "localhost:5000/app:v1")
+	want := []string{"localhost:5000/app:v1", "localhost:5000/sidecar:v1"}
+	if images := podSpecImages(&podSpec.Spec); !reflect.DeepEqual(images, want) {
+		t.Fatalf("podSpecImages() = %v, want %v", images, want)
+	}
+}
+
+func TestWorkloadPodSpec(t *testing.T) {
+	for _, workload := range []client.Object{
+		&appsv1.Deployment{},
+		&appsv1.StatefulSet{},
+		&appsv1.DaemonSet{},
+		&batchv1.Job{},
+	} {
+		if podSpec, active := workloadPodSpec(workload); podSpec == nil || !active {
+			t.Fatalf("workloadPodSpec(%T) = %v, %t, want pod spec of active workload", workload, podSpec, active)
+		}
+	}
+	if podSpec, _ := workloadPodSpec(&corev1.Pod{}); podSpec != nil {
+		t.Fatalf("workloadPodSpec() returned pod spec of unsupported workload")
+	}
+}
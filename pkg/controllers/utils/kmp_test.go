@@ -48,7 +48,7 @@ func TestSpecToKeyManagementProviderProvider(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := SpecToKeyManagementProvider(tc.raw, tc.kmpType)
+			_, err := SpecToKeyManagementProvider(tc.raw, tc.kmpType, "")
 			if tc.expectErr != (err != nil) {
 				t.Fatalf("Expected error to be %t, got %t", tc.expectErr, err != nil)
 			}
@@ -61,6 +61,7 @@ func TestRawToKeyManagementProviderConfig(t *testing.T) {
 	testCases := []struct {
 		name         string
 		raw          []byte
+		namespace    string
 		expectErr    bool
 		expectConfig config.KeyManagementProviderConfig
 	}{
@@ -84,11 +85,29 @@ func TestRawToKeyManagementProviderConfig(t *testing.T) {
 				"type": "inline",
 			},
 		},
+		{
+			name:      "namespaced Raw",
+			raw:       []byte("{\"type\": \"inline\", \"resourceNamespace\": \"other\"}"),
+			namespace: "default",
+			expectErr: false,
+			expectConfig: config.KeyManagementProviderConfig{
+				"type":              "inline",
+				"resourceNamespace": "default",
+			},
+		},
+		{
+			name:      "cluster-wide Raw setting the resource namespace",
+			raw:       []byte("{\"type\": \"inline\", \"resourceNamespace\": \"other\"}"),
+			expectErr: false,
+			expectConfig: config.KeyManagementProviderConfig{
+				"type": "inline",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			config, err := rawToKeyManagementProviderConfig(tc.raw, "inline")
+			config, err := rawToKeyManagementProviderConfig(tc.raw, "inline", tc.namespace)
 
 			if tc.expectErr != (err != nil) {
 				t.Fatalf("Expected error to be %t, got %t", tc.expectErr, err != nil)
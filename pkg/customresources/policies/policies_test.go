@@ -23,6 +23,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 )
 
 type mockPolicy struct{}
@@ -47,6 +48,10 @@ func (p mockPolicy) GetPolicyType(_ context.Context) string {
 	return ""
 }
 
+func (p mockPolicy) GetImageIndexConfig(_ context.Context) pt.ImageIndexConfig {
+	return pt.ImageIndexConfig{}
+}
+
 const (
 	namespace1 = constants.EmptyNamespace
 	namespace2 = "namespace2"
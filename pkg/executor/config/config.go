@@ -15,11 +15,30 @@ limitations under the License.
 
 package config
 
+import "fmt"
+
 // ExecutorConfig represents the configuration for the executor
 type ExecutorConfig struct {
 	// Gatekeeper default verification webhook timeout is 3 seconds. 100ms network buffer added
 	VerificationRequestTimeout *int `json:"verificationRequestTimeout"`
 	// Gatekeeper default mutation webhook timeout is 1 seconds. 50ms network buffer added
 	MutationRequestTimeout *int `json:"mutationRequestTimeout"`
+	// APIRequestTimeout is the timeout of the requests of the verification
+	// API in milliseconds. Default to 30 seconds if not set.
+	APIRequestTimeout *int `json:"apiRequestTimeout,omitempty"`
+	// MaxNestedDepth is the maximum depth of nested verification below the
+	// subject. Default to 10 if not set, must be positive if set.
+	MaxNestedDepth *int `json:"maxNestedDepth,omitempty"`
+	// MaxReferrersPerSubject is the maximum number of referrers verified for a
+	// single subject per referrer store. No limit is applied if not set or 0.
+	MaxReferrersPerSubject *int `json:"maxReferrersPerSubject,omitempty"`
 	// TODO Add cache config
 }
+
+// Validate returns an error if the limits of the executor config are invalid
+func (c ExecutorConfig) Validate() error {
+	if c.MaxNestedDepth != nil && *c.MaxNestedDepth <= 0 {
+		return fmt.Errorf("maxNestedDepth must be a positive number, got %d", *c.MaxNestedDepth)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/common"
 	e "github.com/ratify-project/ratify/pkg/executor"
 	"github.com/ratify-project/ratify/pkg/executor/config"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/metrics"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
@@ -42,6 +43,7 @@ import (
 const (
 	defaultVerifyRequestTimeoutMilliseconds = 2900
 	defaultMutateRequestTimeoutMilliseconds = 950
+	defaultAPIRequestTimeoutMilliseconds    = 30000
 )
 
 var logOpt = logger.Option{
@@ -68,6 +70,12 @@ func (executor Executor) VerifySubject(ctx context.Context, verifyParameters e.V
 		// get the result for the error based on the policy.
 		// Do we need to consider no referrers as success or failure?
 		result = executor.PolicyEnforcer.ErrorToVerifyResult(ctx, verifyParameters.Subject, err)
+		recordTrace(ctx, trace.Event{
+			Type:    trace.EventVerificationError,
+			Subject: verifyParameters.Subject,
+			Message: err.Error(),
+			Details: map[string]interface{}{"isSuccess": result.IsSuccess},
+		})
 	}
 	if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.ConfigPolicy {
 		return result, nil
@@ -91,6 +99,15 @@ func (executor Executor) verifySubjectInternal(ctx context.Context, verifyParame
 	// NOTE: if Passthrough Mode is enabled, executor will just return the
 	// VerifierReports without evaluating the policy.
 	overallVerifySuccess := executor.PolicyEnforcer.OverallVerifyResult(ctx, verifierReports)
+	recordTrace(ctx, trace.Event{
+		Type:    trace.EventPolicyEvaluated,
+		Subject: verifyParameters.Subject,
+		Details: map[string]interface{}{
+			"policyType":      executor.PolicyEnforcer.GetPolicyType(ctx),
+			"verifierReports": verifierReports,
+			"isSuccess":       overallVerifySuccess,
+		},
+	})
 	return types.VerifyResult{IsSuccess: overallVerifySuccess, VerifierReports: verifierReports}, nil
 }
 
@@ -108,28 +125,69 @@ func (executor Executor) verifySubjectInternalWithoutDecision(ctx context.Contex
 	}
 
 	logger.GetLogger(ctx, logOpt).Infof("Resolve of the image completed successfully the digest is %s", desc.Digest)
+	recordTrace(ctx, trace.Event{
+		Type:    trace.EventSubjectResolved,
+		Subject: verifyParameters.Subject,
+		Details: map[string]interface{}{
+			"tag":       subjectReference.Tag,
+			"digest":    desc.Digest.String(),
+			"mediaType": desc.MediaType,
+		},
+	})
 
 	subjectReference.Digest = desc.Digest
+	ctx = withVisitedSubject(ctx, desc.Digest)
 
 	verifierReports := make([]interface{}, 0)
 	eg, errCtx := errgroup.WithContext(ctx)
 	var mu sync.Mutex
+	maxReferrers := executor.getMaxReferrersPerSubject()
 
 	for _, referrerStore := range executor.ReferrerStores {
 		referrerStore := referrerStore
 		eg.Go(func() error {
 			var continuationToken string
+			verifiedReferrers := 0
+			page := 0
 			innerGroup, innerErrCtx := errgroup.WithContext(errCtx)
+		listReferrers:
 			for {
 				referrersResult, err := referrerStore.ListReferrers(errCtx, subjectReference, verifyParameters.ReferenceTypes, continuationToken, desc)
 				if err != nil {
 					return errors.ErrorCodeListReferrersFailure.NewError(errors.ReferrerStore, referrerStore.Name(), errors.EmptyLink, err, nil, errors.HideStackTrace)
 				}
 				continuationToken = referrersResult.NextToken
+				page++
+				recordTrace(ctx, trace.Event{
+					Type:    trace.EventReferrersListed,
+					Subject: subjectReference.String(),
+					Store:   referrerStore.Name(),
+					Details: map[string]interface{}{
+						"page":      page,
+						"referrers": referrerDigests(referrersResult.Referrers),
+						"hasMore":   continuationToken != "",
+					},
+				})
 				for _, reference := range referrersResult.Referrers {
 					if !executor.PolicyEnforcer.VerifyNeeded(innerErrCtx, subjectReference, reference) {
+						recordReferrerTrace(ctx, trace.EventReferrerSkipped, subjectReference, reference, "verification is not needed by the policy")
 						continue
 					}
+					if maxReferrers > 0 && verifiedReferrers >= maxReferrers {
+						logger.GetLogger(ctx, logOpt).Warnf("subject %s has more than %d referrers in store %s, remaining referrers are not verified", subjectReference.String(), maxReferrers, referrerStore.Name())
+						recordTrace(ctx, trace.Event{
+							Type:    trace.EventReferrersLimitReached,
+							Subject: subjectReference.String(),
+							Store:   referrerStore.Name(),
+							Message: fmt.Sprintf("remaining referrers are not verified after maxReferrersPerSubject %d", maxReferrers),
+						})
+						limitReport := executor.newReferrersLimitReport(ctx, subjectReference.String(), maxReferrers)
+						mu.Lock()
+						verifierReports = append(verifierReports, limitReport)
+						mu.Unlock()
+						break listReferrers
+					}
+					verifiedReferrers++
 					reference := reference
 					innerGroup.Go(func() error {
 						if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy {
@@ -158,6 +216,19 @@ func (executor Executor) verifySubjectInternalWithoutDecision(ctx context.Contex
 		})
 	}
 
+	if ocispecs.IsImageIndex(desc.MediaType) && executor.PolicyEnforcer.GetImageIndexConfig(ctx).VerifyManifests {
+		eg.Go(func() error {
+			childReports, err := executor.verifyImageIndexManifests(errCtx, subjectReference, desc, verifyParameters)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			verifierReports = append(verifierReports, childReports...)
+			return nil
+		})
+	}
+
 	if err = eg.Wait(); err != nil {
 		return nil, err
 	}
@@ -173,16 +244,27 @@ func (executor Executor) verifyReferenceForJSONPolicy(ctx context.Context, subje
 
 	for _, verifier := range executor.Verifiers {
 		if verifier.CanVerify(ctx, referenceDesc) {
+			recordVerifierTrace(ctx, trace.EventVerifierMatched, subjectRef, referenceDesc, verifier.Name(), nil)
 			verifierStartTime := time.Now()
 			verifyResult, err := verifier.Verify(ctx, subjectRef, referenceDesc, referrerStore)
 			if err != nil {
 				verifierErr := errors.ErrorCodeVerifyReferenceFailure.WithError(err)
 				verifyResult = vr.NewVerifierResult("", verifier.Name(), verifier.Type(), "", false, &verifierErr, nil)
 			}
+			recordVerifierTrace(ctx, trace.EventVerifierResult, subjectRef, referenceDesc, verifier.Name(), map[string]interface{}{
+				"isSuccess": verifyResult.IsSuccess,
+				"message":   verifyResult.Message,
+			})
 
 			if len(verifier.GetNestedReferences()) > 0 {
 				executor.addNestedVerifierResult(ctx, referenceDesc, subjectRef, &verifyResult)
 			}
+			for _, nestedSubject := range getNestedSubjects(verifyResult.Extensions) {
+				if !executor.addNestedVerifierResult(ctx, nestedSubject, subjectRef, &verifyResult) {
+					verifyResult.IsSuccess = false
+					verifyResult.Message = nestedSubjectFailureMessage
+				}
+			}
 
 			verifyResult.Subject = subjectRef.String()
 			verifyResult.ReferenceDigest = referenceDesc.Digest.String()
@@ -193,6 +275,9 @@ func (executor Executor) verifyReferenceForJSONPolicy(ctx context.Context, subje
 			break
 		}
 	}
+	if len(verifyResults) == 0 {
+		recordReferrerTrace(ctx, trace.EventNoVerifierMatched, subjectRef, referenceDesc, "")
+	}
 
 	return types.VerifyResult{IsSuccess: isSuccess, VerifierReports: verifyResults}
 }
@@ -214,10 +299,13 @@ func (executor Executor) verifyReferenceForRegoPolicy(ctx context.Context, subje
 		return executor.addNestedReports(errCtx, referenceDesc, subjectRef, &nestedReport)
 	})
 
+	matched := false
 	for _, verifier := range executor.Verifiers {
 		if !verifier.CanVerify(ctx, referenceDesc) {
 			continue
 		}
+		matched = true
+		recordVerifierTrace(ctx, trace.EventVerifierMatched, subjectRef, referenceDesc, verifier.Name(), nil)
 		verifier := verifier
 		eg.Go(func() error {
 			var verifierReport vt.VerifierResult
@@ -229,6 +317,10 @@ func (executor Executor) verifyReferenceForRegoPolicy(ctx context.Context, subje
 			} else {
 				verifierReport = vt.NewVerifierResult(verifierResult)
 			}
+			recordVerifierTrace(errCtx, trace.EventVerifierResult, subjectRef, referenceDesc, verifier.Name(), map[string]interface{}{
+				"isSuccess": verifierReport.IsSuccess,
+				"message":   verifierReport.Message,
+			})
 
 			mu.Lock()
 			nestedReport.VerifierReports = append(nestedReport.VerifierReports, verifierReport)
@@ -238,35 +330,90 @@ func (executor Executor) verifyReferenceForRegoPolicy(ctx context.Context, subje
 			return nil
 		})
 	}
+	if !matched {
+		recordReferrerTrace(ctx, trace.EventNoVerifierMatched, subjectRef, referenceDesc, "")
+	}
 
 	if err := eg.Wait(); err != nil {
 		return types.NestedVerifierReport{}, err
 	}
+
+	// verify the referrers the verifiers relied on as nested subjects. A
+	// report relying on a nested subject without a successfully verified
+	// signature, such as an unsigned VEX document, fails regardless of whether
+	// the policy inspects the nested reports.
+	for i, verifierReport := range nestedReport.VerifierReports {
+		for _, nestedSubject := range getNestedSubjects(verifierReport.Extensions) {
+			subjectReport := types.NestedVerifierReport{
+				Subject:         subjectRef.String(),
+				ArtifactType:    nestedSubject.ArtifactType,
+				ReferenceDigest: nestedSubject.Digest.String(),
+				VerifierReports: make([]vt.VerifierResult, 0),
+				NestedReports:   make([]types.NestedVerifierReport, 0),
+			}
+			if err := executor.addNestedReports(ctx, nestedSubject, subjectRef, &subjectReport); err != nil {
+				return types.NestedVerifierReport{}, err
+			}
+			nestedReport.NestedReports = append(nestedReport.NestedReports, subjectReport)
+			if !hasVerifiedSignature(subjectReport.NestedReports) {
+				nestedReport.VerifierReports[i].IsSuccess = false
+				nestedReport.VerifierReports[i].Message = nestedSubjectFailureMessage
+			}
+		}
+	}
 	return nestedReport, nil
 }
 
+// newReferrersLimitReport creates the report recorded when the referrers of a
+// subject exceed maxReferrersPerSubject.
+func (executor Executor) newReferrersLimitReport(ctx context.Context, subject string, limit int) interface{} {
+	limitErr := newReferrersLimitError(subject, limit)
+	if executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy {
+		return types.NestedVerifierReport{
+			Subject:         subject,
+			VerifierReports: []vt.VerifierResult{newLimitReport(limitErr)},
+			NestedReports:   make([]types.NestedVerifierReport, 0),
+		}
+	}
+	return newLimitVerifierResult(subject, limitErr)
+}
+
 // addNestedVerifierResult adds the nested verifier result to the parent verify
-// result used for Json-based policy enforcer.
-func (executor Executor) addNestedVerifierResult(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor, subjectRef common.Reference, verifyResult *vr.VerifierResult) {
+// result used for Json-based policy enforcer. It returns true if the nested
+// verification succeeded with at least one successfully verified signature.
+func (executor Executor) addNestedVerifierResult(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor, subjectRef common.Reference, verifyResult *vr.VerifierResult) bool {
 	verifyParameters := e.VerifyParameters{
 		Subject:        fmt.Sprintf("%s@%s", subjectRef.Path, referenceDesc.Digest),
 		ReferenceTypes: []string{"*"},
 	}
 
-	nestedVerifyResult, err := executor.VerifySubject(ctx, verifyParameters)
+	if limitErr, ok := executor.checkNestedVerification(ctx, referenceDesc); !ok {
+		logger.GetLogger(ctx, logOpt).Warnf("skipping nested verification of %s: %v", verifyParameters.Subject, limitErr)
+		recordReferrerTrace(ctx, trace.EventNestedVerificationSkipped, subjectRef, referenceDesc, limitErr.Error())
+		verifyResult.NestedResults = append(verifyResult.NestedResults, newLimitVerifierResult(verifyParameters.Subject, limitErr))
+		verifyResult.IsSuccess = false
+		verifyResult.Message = "nested verification failed"
+		return false
+	}
+
+	recordReferrerTrace(ctx, trace.EventNestedVerification, subjectRef, referenceDesc, "")
+	nestedVerifyResult, err := executor.VerifySubject(withNestedSubject(ctx), verifyParameters)
 	if err != nil {
 		nestedVerifyResult = executor.PolicyEnforcer.ErrorToVerifyResult(ctx, verifyParameters.Subject, err)
 	}
 
+	verified := false
 	for _, report := range nestedVerifyResult.VerifierReports {
 		if result, ok := report.(vr.VerifierResult); ok {
 			verifyResult.NestedResults = append(verifyResult.NestedResults, result)
+			verified = verified || (result.IsSuccess && isSignatureArtifactType(result.ArtifactType))
 			if !nestedVerifyResult.IsSuccess {
 				verifyResult.IsSuccess = false
 				verifyResult.Message = "nested verification failed"
 			}
 		}
 	}
+	return verified && nestedVerifyResult.IsSuccess
 }
 
 // addNestedReports adds the nested verifier reports to the parent report used
@@ -277,8 +424,20 @@ func (executor Executor) addNestedReports(ctx context.Context, referenceDes ocis
 		ReferenceTypes: []string{"*"},
 	}
 
+	if limitErr, ok := executor.checkNestedVerification(ctx, referenceDes); !ok {
+		logger.GetLogger(ctx, logOpt).Warnf("skipping nested verification of %s: %v", verifyParameters.Subject, limitErr)
+		recordReferrerTrace(ctx, trace.EventNestedVerificationSkipped, subjectRef, referenceDes, limitErr.Error())
+		verifierReport.NestedReports = []types.NestedVerifierReport{{
+			Subject:         verifyParameters.Subject,
+			VerifierReports: []vt.VerifierResult{newLimitReport(limitErr)},
+			NestedReports:   make([]types.NestedVerifierReport, 0),
+		}}
+		return nil
+	}
+
 	// get nested reports.
-	reports, err := executor.verifySubjectInternal(ctx, verifyParameters)
+	recordReferrerTrace(ctx, trace.EventNestedVerification, subjectRef, referenceDes, "")
+	reports, err := executor.verifySubjectInternal(withNestedSubject(ctx), verifyParameters)
 	if err != nil {
 		return fmt.Errorf("failed to verify nested subject, param: %+v, err: %w", verifyParameters, err)
 	}
@@ -311,3 +470,13 @@ func (executor Executor) GetMutationRequestTimeout() time.Duration {
 	}
 	return time.Duration(timeoutMilliSeconds) * time.Millisecond
 }
+
+// GetAPIRequestTimeout returns the timeout of the requests of the verification
+// API, which does not depend on the Gatekeeper webhook timeouts.
+func (executor Executor) GetAPIRequestTimeout() time.Duration {
+	timeoutMilliSeconds := defaultAPIRequestTimeoutMilliseconds
+	if executor.Config != nil && executor.Config.APIRequestTimeout != nil {
+		timeoutMilliSeconds = *executor.Config.APIRequestTimeout
+	}
+	return time.Duration(timeoutMilliSeconds) * time.Millisecond
+}
@@ -52,6 +52,7 @@ const (
 type mockPolicyProvider struct {
 	result     bool
 	policyType string
+	imageIndex pt.ImageIndexConfig
 }
 
 func (p *mockPolicyProvider) VerifyNeeded(_ context.Context, _ common.Reference, _ ocispecs.ReferenceDescriptor) bool {
@@ -77,6 +78,10 @@ func (p *mockPolicyProvider) GetPolicyType(_ context.Context) string {
 	return pt.RegoPolicy
 }
 
+func (p *mockPolicyProvider) GetImageIndexConfig(_ context.Context) pt.ImageIndexConfig {
+	return p.imageIndex
+}
+
 type mockStore struct {
 	referrers map[string][]ocispecs.ReferenceDescriptor
 }
@@ -709,6 +714,36 @@ func TestGetMutationRequestTimeout_ExpectedResults(t *testing.T) {
 	}
 }
 
+// TestGetAPIRequestTimeout_ExpectedResults tests the verification API request timeout returned
+func TestGetAPIRequestTimeout_ExpectedResults(t *testing.T) {
+	timeout := 10000
+	testcases := []struct {
+		ex              Executor
+		expectedTimeout int
+	}{
+		{
+			ex:              Executor{Config: nil},
+			expectedTimeout: 30000,
+		},
+		{
+			ex:              Executor{Config: &exConfig.ExecutorConfig{VerificationRequestTimeout: &timeout}},
+			expectedTimeout: 30000,
+		},
+		{
+			ex:              Executor{Config: &exConfig.ExecutorConfig{APIRequestTimeout: &timeout}},
+			expectedTimeout: 10000,
+		},
+	}
+
+	for _, testcase := range testcases {
+		expected := time.Millisecond * time.Duration(testcase.expectedTimeout)
+		actual := testcase.ex.GetAPIRequestTimeout()
+		if actual != expected {
+			t.Fatalf("API request timeout returned expected %dms but got %dms", expected.Milliseconds(), actual.Milliseconds())
+		}
+	}
+}
+
 func TestVerifySubject(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/verifiercache"
 )
@@ -36,8 +37,10 @@ func (executor ExecutorWithCache) VerifySubject(ctx context.Context, verifyParam
 	cachedResult, ok := executor.verifierCache.GetVerifyResult(ctx, verifyParameters.Subject)
 
 	if ok {
+		trace.Record(ctx, trace.Event{Type: trace.EventCacheHit, Subject: verifyParameters.Subject, Message: "verifier cache"})
 		return cachedResult, nil
 	}
+	trace.Record(ctx, trace.Event{Type: trace.EventCacheMiss, Subject: verifyParameters.Subject, Message: "verifier cache"})
 
 	result, err := executor.base.VerifySubject(ctx, verifyParameters)
 
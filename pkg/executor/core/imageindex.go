@@ -0,0 +1,201 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	su "github.com/ratify-project/ratify/pkg/referrerstore/utils"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// dockerReferenceTypeAnnotation is set by BuildKit on the attestation
+	// manifests it adds to an image index.
+	dockerReferenceTypeAnnotation = "vnd.docker.reference.type"
+	dockerAttestationManifest     = "attestation-manifest"
+)
+
+// verifyImageIndexManifests verifies the referrers of the child manifests of
+// an image index. Each child manifest results in one report whose nested
+// reports are the reports of its referrers, so that the policy can decide
+// whether the index or every child manifest must be verified. The report is
+// identified by the media type of the child manifest as its artifact type.
+func (executor Executor) verifyImageIndexManifests(ctx context.Context, subjectReference common.Reference, indexDesc *ocispecs.SubjectDescriptor, verifyParameters e.VerifyParameters) ([]interface{}, error) {
+	indexConfig := executor.PolicyEnforcer.GetImageIndexConfig(ctx)
+	manifests, err := su.GetImageIndexManifests(ctx, &executor.ReferrerStores, subjectReference, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	platforms := append([]string{}, indexConfig.Platforms...)
+	if indexConfig.MatchNodePlatform {
+		platforms = append(platforms, nodePlatform())
+	}
+
+	reports := make([]interface{}, 0, len(manifests))
+	var mu sync.Mutex
+	eg, errCtx := errgroup.WithContext(ctx)
+	for _, manifest := range manifests {
+		if !shouldVerifyChildManifest(manifest, platforms) {
+			continue
+		}
+		manifest := manifest
+		eg.Go(func() error {
+			report, err := executor.verifyChildManifest(errCtx, subjectReference, manifest, verifyParameters)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, report...)
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// verifyChildManifest verifies the referrers of a single child manifest.
+func (executor Executor) verifyChildManifest(ctx context.Context, subjectReference common.Reference, manifest oci.Descriptor, verifyParameters e.VerifyParameters) ([]interface{}, error) {
+	childParameters := e.VerifyParameters{
+		Subject:        fmt.Sprintf("%s@%s", subjectReference.Path, manifest.Digest),
+		ReferenceTypes: verifyParameters.ReferenceTypes,
+	}
+	isRegoPolicy := executor.PolicyEnforcer.GetPolicyType(ctx) == pt.RegoPolicy
+
+	manifestDesc := ocispecs.ReferenceDescriptor{Descriptor: manifest, ArtifactType: manifest.MediaType}
+	if limitErr, ok := executor.checkNestedVerification(ctx, manifestDesc); !ok {
+		logger.GetLogger(ctx, logOpt).Warnf("skipping verification of child manifest %s: %v", childParameters.Subject, limitErr)
+		recordReferrerTrace(ctx, trace.EventNestedVerificationSkipped, subjectReference, manifestDesc, limitErr.Error())
+		if isRegoPolicy {
+			return []interface{}{newChildManifestReport(subjectReference, manifest, []vt.VerifierResult{newLimitReport(limitErr)}, nil)}, nil
+		}
+		return []interface{}{executor.newChildManifestResult(ctx, subjectReference, manifest, []interface{}{newLimitVerifierResult(childParameters.Subject, limitErr)})}, nil
+	}
+
+	recordReferrerTrace(ctx, trace.EventNestedVerification, subjectReference, manifestDesc, "child manifest of the image index")
+	childReports, err := executor.verifySubjectInternalWithoutDecision(withNestedSubject(ctx), childParameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify child manifest, param: %+v, err: %w", childParameters, err)
+	}
+	if !isRegoPolicy {
+		return []interface{}{executor.newChildManifestResult(ctx, subjectReference, manifest, childReports)}, nil
+	}
+
+	nestedReports := make([]types.NestedVerifierReport, 0, len(childReports))
+	for _, report := range childReports {
+		nestedReport, err := types.NewNestedVerifierReport(report)
+		if err != nil {
+			return nil, errors.ErrorCodeExecutorFailure.WithError(err)
+		}
+		nestedReports = append(nestedReports, nestedReport)
+	}
+	return []interface{}{newChildManifestReport(subjectReference, manifest, make([]vt.VerifierResult, 0), nestedReports)}, nil
+}
+
+// newChildManifestReport creates the report of a child manifest used for the
+// Rego-based policy enforcer. The report is identified by the media type of the
+// child manifest as its artifact type.
+func newChildManifestReport(subjectReference common.Reference, manifest oci.Descriptor, verifierReports []vt.VerifierResult, nestedReports []types.NestedVerifierReport) types.NestedVerifierReport {
+	if nestedReports == nil {
+		nestedReports = make([]types.NestedVerifierReport, 0)
+	}
+	return types.NestedVerifierReport{
+		Subject:         subjectReference.String(),
+		ReferenceDigest: manifest.Digest.String(),
+		ArtifactType:    manifest.MediaType,
+		VerifierReports: verifierReports,
+		NestedReports:   nestedReports,
+	}
+}
+
+// newChildManifestResult creates the result of a child manifest used for the
+// Json-based policy enforcer. The results of the referrers of the child
+// manifest are nested and evaluated with the policy on their own, so that the
+// referrers of a child manifest do not satisfy the policy of the image index.
+func (executor Executor) newChildManifestResult(ctx context.Context, subjectReference common.Reference, manifest oci.Descriptor, childReports []interface{}) vr.VerifierResult {
+	nestedResults := make([]vr.VerifierResult, 0, len(childReports))
+	for _, report := range childReports {
+		if result, ok := report.(vr.VerifierResult); ok {
+			nestedResults = append(nestedResults, result)
+		}
+	}
+	isSuccess := executor.PolicyEnforcer.OverallVerifyResult(ctx, childReports)
+	message := fmt.Sprintf("child manifest %s passed verification", manifest.Digest)
+	if !isSuccess {
+		message = fmt.Sprintf("child manifest %s failed verification", manifest.Digest)
+	}
+	result := vr.NewVerifierResult(subjectReference.String(), executorVerifierName, executorVerifierType, message, isSuccess, nil, nil)
+	result.ReferenceDigest = manifest.Digest.String()
+	result.ArtifactType = manifest.MediaType
+	result.NestedResults = nestedResults
+	return result
+}
+
+// shouldVerifyChildManifest returns true if the child manifest matches any of
+// the platforms. Attestation manifests added by BuildKit are always skipped.
+func shouldVerifyChildManifest(manifest oci.Descriptor, platforms []string) bool {
+	if manifest.Annotations[dockerReferenceTypeAnnotation] == dockerAttestationManifest {
+		return false
+	}
+	if len(platforms) == 0 {
+		return true
+	}
+	if manifest.Platform == nil {
+		return false
+	}
+	for _, platform := range platforms {
+		if matchPlatform(*manifest.Platform, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPlatform returns true if the platform matches the given platform string
+// in the form of os/arch[/variant]. The variant is only compared if provided.
+func matchPlatform(platform oci.Platform, expected string) bool {
+	parts := strings.Split(expected, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	if platform.OS != parts[0] || platform.Architecture != parts[1] {
+		return false
+	}
+	return len(parts) == 2 || platform.Variant == parts[2]
+}
+
+// nodePlatform returns the platform Ratify is running on.
+func nodePlatform() string {
+	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const (
+	amd64ManifestDigest       = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	arm64ManifestDigest       = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+	attestationManifestDigest = "sha256:4444444444444444444444444444444444444444444444444444444444444444"
+)
+
+// mockIndexStore resolves the subject to an image index.
+type mockIndexStore struct {
+	mockStore
+	manifests []oci.Descriptor
+}
+
+func (s *mockIndexStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	desc, err := s.mockStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Digest == subjectDigest {
+		desc.MediaType = oci.MediaTypeImageIndex
+	}
+	return desc, nil
+}
+
+func (s *mockIndexStore) GetReferenceManifest(_ context.Context, _ common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	if referenceDesc.Digest == subjectDigest {
+		return ocispecs.ReferenceManifest{MediaType: oci.MediaTypeImageIndex, Manifests: s.manifests}, nil
+	}
+	return ocispecs.ReferenceManifest{}, nil
+}
+
+func TestVerifySubject_ImageIndexManifests(t *testing.T) {
+	store := &mockIndexStore{
+		mockStore: mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+			amd64ManifestDigest: {newReferrer(signatureDigest)},
+			arm64ManifestDigest: {newReferrer(nestedSignatureDigest)},
+		}},
+		manifests: []oci.Descriptor{
+			{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(amd64ManifestDigest),
+				Platform:  &oci.Platform{OS: "linux", Architecture: "amd64"},
+			},
+			{
+				MediaType: oci.MediaTypeImageManifest,
+				Digest:    digest.Digest(arm64ManifestDigest),
+				Platform:  &oci.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+			{
+				MediaType:   oci.MediaTypeImageManifest,
+				Digest:      digest.Digest(attestationManifestDigest),
+				Platform:    &oci.Platform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{dockerReferenceTypeAnnotation: dockerAttestationManifest},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		imageIndex      pt.ImageIndexConfig
+		expectedDigests []string
+	}{
+		{
+			name:       "index only",
+			imageIndex: pt.ImageIndexConfig{},
+		},
+		{
+			name:            "all child manifests",
+			imageIndex:      pt.ImageIndexConfig{VerifyManifests: true},
+			expectedDigests: []string{amd64ManifestDigest, arm64ManifestDigest},
+		},
+		{
+			name:            "filtered by platform",
+			imageIndex:      pt.ImageIndexConfig{VerifyManifests: true, Platforms: []string{"linux/arm64/v8"}},
+			expectedDigests: []string{arm64ManifestDigest},
+		},
+		{
+			name:       "no matching platform",
+			imageIndex: pt.ImageIndexConfig{VerifyManifests: true, Platforms: []string{"windows/amd64"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := &Executor{
+				ReferrerStores: []referrerstore.ReferrerStore{store},
+				PolicyEnforcer: &mockPolicyProvider{result: true, policyType: pt.RegoPolicy, imageIndex: tc.imageIndex},
+				Verifiers: []verifier.ReferenceVerifier{
+					&mockVerifier{canVerify: true, verifierResult: verifier.VerifierResult{IsSuccess: true}},
+				},
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.VerifierReports) != len(tc.expectedDigests) {
+				t.Fatalf("expected %d reports but got %d", len(tc.expectedDigests), len(result.VerifierReports))
+			}
+			digests := map[string]bool{}
+			for _, report := range result.VerifierReports {
+				nestedReport := report.(types.NestedVerifierReport)
+				if nestedReport.ArtifactType != oci.MediaTypeImageManifest || len(nestedReport.NestedReports) != 1 {
+					t.Fatalf("unexpected child manifest report: %+v", nestedReport)
+				}
+				digests[nestedReport.ReferenceDigest] = true
+			}
+			for _, d := range tc.expectedDigests {
+				if !digests[d] {
+					t.Fatalf("expected report for child manifest %s", d)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPlatform(t *testing.T) {
+	platform := oci.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	testCases := []struct {
+		expected string
+		match    bool
+	}{
+		{expected: "linux/arm", match: true},
+		{expected: "linux/arm/v7", match: true},
+		{expected: "linux/arm/v6", match: false},
+		{expected: "linux/amd64", match: false},
+		{expected: "linux", match: false},
+	}
+	for _, tc := range testCases {
+		if got := matchPlatform(platform, tc.expected); got != tc.match {
+			t.Errorf("matchPlatform(%s) = %v, want %v", tc.expected, got, tc.match)
+		}
+	}
+}
+
+func TestVerifySubject_ImageIndexManifestsConfigPolicy(t *testing.T) {
+	store := &mockIndexStore{
+		mockStore: mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+			amd64ManifestDigest: {newReferrer(signatureDigest)},
+		}},
+		manifests: []oci.Descriptor{
+			{MediaType: oci.MediaTypeImageManifest, Digest: digest.Digest(amd64ManifestDigest)},
+		},
+	}
+	ex := &Executor{
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		PolicyEnforcer: &mockPolicyProvider{result: true, imageIndex: pt.ImageIndexConfig{VerifyManifests: true}},
+		Verifiers: []verifier.ReferenceVerifier{
+			&mockVerifier{canVerify: true, verifierResult: verifier.VerifierResult{IsSuccess: true}},
+		},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.VerifierReports) != 1 {
+		t.Fatalf("expected 1 report but got %d", len(result.VerifierReports))
+	}
+	// the signature of the child manifest is nested in the report of the child
+	// manifest instead of being evaluated as a referrer of the image index
+	report := result.VerifierReports[0].(verifier.VerifierResult)
+	if report.ArtifactType != oci.MediaTypeImageManifest || report.ReferenceDigest != amd64ManifestDigest || !report.IsSuccess {
+		t.Fatalf("unexpected child manifest report: %+v", report)
+	}
+	if len(report.NestedResults) != 1 || report.NestedResults[0].ReferenceDigest != signatureDigest {
+		t.Fatalf("expected the signature of the child manifest to be nested, got: %+v", report.NestedResults)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	vr "github.com/ratify-project/ratify/pkg/verifier"
+	vt "github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	defaultMaxNestedDepth = 10
+
+	// executorVerifierName and executorVerifierType identify the reports
+	// generated by the executor itself instead of a verifier.
+	executorVerifierName = "executor"
+	executorVerifierType = "executor"
+
+	// TruncatedExtensionKey is set in the extensions of an executor report
+	// when the referrer graph was not fully verified.
+	TruncatedExtensionKey = "truncated"
+	// ErrorCodeExtensionKey is the error code of an executor report.
+	ErrorCodeExtensionKey = "errorCode"
+
+	// nestedSubjectFailureMessage is the message of a verifier result relying
+	// on a nested subject which failed verification.
+	nestedSubjectFailureMessage = "nested subject verification failed"
+
+	// notationSignatureArtifactType and cosignSignatureArtifactType are the
+	// artifact types of the signatures vouching for a nested subject.
+	notationSignatureArtifactType = "application/vnd.cncf.notary.signature"
+	cosignSignatureArtifactType   = "application/vnd.dev.cosign.artifact.sig.v1+json"
+)
+
+type nestedVerificationStateKey struct{}
+
+// nestedVerificationState tracks where a verification is located in the
+// referrer graph of the subject in the original request.
+type nestedVerificationState struct {
+	// depth is the number of nested subjects above the current subject.
+	depth int
+	// visited contains the digests of the current subject and its ancestors.
+	visited map[digest.Digest]struct{}
+}
+
+func getNestedVerificationState(ctx context.Context) nestedVerificationState {
+	if state, ok := ctx.Value(nestedVerificationStateKey{}).(nestedVerificationState); ok {
+		return state
+	}
+	return nestedVerificationState{}
+}
+
+// withVisitedSubject returns a context that records the subject digest as
+// visited. The visited set is copied so that sibling branches verified in
+// parallel do not observe each other.
+func withVisitedSubject(ctx context.Context, subjectDigest digest.Digest) context.Context {
+	state := getNestedVerificationState(ctx)
+	if _, ok := state.visited[subjectDigest]; ok {
+		return ctx
+	}
+	visited := make(map[digest.Digest]struct{}, len(state.visited)+1)
+	for d := range state.visited {
+		visited[d] = struct{}{}
+	}
+	visited[subjectDigest] = struct{}{}
+	return context.WithValue(ctx, nestedVerificationStateKey{}, nestedVerificationState{
+		depth:   state.depth,
+		visited: visited,
+	})
+}
+
+// withNestedSubject returns a context used to verify a referrer as a nested
+// subject.
+func withNestedSubject(ctx context.Context) context.Context {
+	state := getNestedVerificationState(ctx)
+	return context.WithValue(ctx, nestedVerificationStateKey{}, nestedVerificationState{
+		depth:   state.depth + 1,
+		visited: state.visited,
+	})
+}
+
+// checkNestedVerification returns an error if verifying the referrer as a
+// nested subject would revisit an ancestor or exceed the maximum depth.
+func (executor Executor) checkNestedVerification(ctx context.Context, referenceDesc ocispecs.ReferenceDescriptor) (errors.Error, bool) {
+	state := getNestedVerificationState(ctx)
+	if _, ok := state.visited[referenceDesc.Digest]; ok {
+		return errors.ErrorCodeReferrerCycleDetected.NewError(errors.Executor, "", errors.EmptyLink, nil, fmt.Sprintf("referrer %s refers back to an artifact already being verified", referenceDesc.Digest), errors.HideStackTrace), false
+	}
+	if maxDepth := executor.getMaxNestedDepth(); state.depth >= maxDepth {
+		return errors.ErrorCodeVerificationLimitExceeded.NewError(errors.Executor, "", errors.EmptyLink, nil, fmt.Sprintf("nested verification of referrer %s exceeds maxNestedDepth %d", referenceDesc.Digest, maxDepth), errors.HideStackTrace), false
+	}
+	return errors.Error{}, true
+}
+
+// getNestedSubjects returns the referrers listed in the NestedSubjectsExtension
+// of a verifier result. Plugin results carry decoded JSON, so the extensions
+// are round-tripped through JSON.
+func getNestedSubjects(extensions interface{}) []ocispecs.ReferenceDescriptor {
+	if extensions == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(extensions)
+	if err != nil {
+		return nil
+	}
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil
+	}
+	raw, ok := decoded[vr.NestedSubjectsExtension]
+	if !ok {
+		return nil
+	}
+	var nestedSubjects []ocispecs.ReferenceDescriptor
+	if err := json.Unmarshal(raw, &nestedSubjects); err != nil {
+		return nil
+	}
+	return nestedSubjects
+}
+
+// hasVerifiedSignature returns true if a signature of a nested subject, such
+// as a VEX document, was verified successfully. Reports of other referrers,
+// such as an SBOM attached to the nested subject, do not vouch for it.
+func hasVerifiedSignature(reports []types.NestedVerifierReport) bool {
+	for _, report := range reports {
+		if !isSignatureArtifactType(report.ArtifactType) {
+			continue
+		}
+		for _, verifierReport := range report.VerifierReports {
+			if verifierReport.IsSuccess {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSignatureArtifactType returns true if the artifact type is a signature
+// verified by the built-in notation or cosign verifiers.
+func isSignatureArtifactType(artifactType string) bool {
+	return artifactType == notationSignatureArtifactType || artifactType == cosignSignatureArtifactType
+}
+
+// newReferrersLimitError returns the error used when a subject has more
+// referrers than the configured limit.
+func newReferrersLimitError(subject string, limit int) errors.Error {
+	return errors.ErrorCodeVerificationLimitExceeded.NewError(errors.Executor, "", errors.EmptyLink, nil, fmt.Sprintf("subject %s has more than maxReferrersPerSubject %d referrers", subject, limit), errors.HideStackTrace)
+}
+
+// newLimitVerifierResult creates a failed report for the Json-based policy
+// enforcer describing why the executor truncated verification.
+func newLimitVerifierResult(subject string, limitErr errors.Error) vr.VerifierResult {
+	return vr.NewVerifierResult(subject, executorVerifierName, executorVerifierType, "", false, &limitErr, limitExtensions(limitErr))
+}
+
+// newLimitReport creates a failed report for the Rego-based policy enforcer
+// describing why the executor truncated verification.
+func newLimitReport(limitErr errors.Error) vt.VerifierResult {
+	report := vt.CreateVerifierResult(executorVerifierName, executorVerifierType, "", false, &limitErr)
+	report.Extensions = limitExtensions(limitErr)
+	return report
+}
+
+func limitExtensions(limitErr errors.Error) map[string]interface{} {
+	return map[string]interface{}{
+		TruncatedExtensionKey: true,
+		ErrorCodeExtensionKey: limitErr.ErrorCode().String(),
+	}
+}
+
+// getMaxNestedDepth returns the maximum depth of nested verification. Non
+// positive values are rejected when loading the config and fall back to the
+// default.
+func (executor Executor) getMaxNestedDepth() int {
+	if executor.Config != nil && executor.Config.MaxNestedDepth != nil && *executor.Config.MaxNestedDepth > 0 {
+		return *executor.Config.MaxNestedDepth
+	}
+	return defaultMaxNestedDepth
+}
+
+// getMaxReferrersPerSubject returns the maximum number of referrers verified
+// for a subject per store. 0 means no limit.
+func (executor Executor) getMaxReferrersPerSubject() int {
+	if executor.Config != nil && executor.Config.MaxReferrersPerSubject != nil && *executor.Config.MaxReferrersPerSubject > 0 {
+		return *executor.Config.MaxReferrersPerSubject
+	}
+	return 0
+}
@@ -0,0 +1,319 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/common"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	exConfig "github.com/ratify-project/ratify/pkg/executor/config"
+	"github.com/ratify-project/ratify/pkg/executor/types"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+const nestedSignatureDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func newReferrer(d string) ocispecs.ReferenceDescriptor {
+	return ocispecs.ReferenceDescriptor{
+		ArtifactType: artifactType,
+		Descriptor: oci.Descriptor{
+			Digest: digest.Digest(d),
+		},
+	}
+}
+
+func newSignatureReferrer(d string) ocispecs.ReferenceDescriptor {
+	referrer := newReferrer(d)
+	referrer.ArtifactType = notationSignatureArtifactType
+	return referrer
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// findLimitReport returns the first executor report found in the nested
+// report tree.
+func findLimitReport(reports []types.NestedVerifierReport) (string, bool) {
+	for _, report := range reports {
+		for _, verifierReport := range report.VerifierReports {
+			if verifierReport.VerifierName == executorVerifierName {
+				extensions := verifierReport.Extensions.(map[string]interface{})
+				return extensions[ErrorCodeExtensionKey].(string), true
+			}
+		}
+		if code, ok := findLimitReport(report.NestedReports); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+func TestVerifySubject_NestedLimits(t *testing.T) {
+	testCases := []struct {
+		name         string
+		referrers    map[string][]ocispecs.ReferenceDescriptor
+		config       *exConfig.ExecutorConfig
+		expectedCode string
+	}{
+		{
+			name: "referrer cycle",
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest:   {newReferrer(signatureDigest)},
+				signatureDigest: {newReferrer(subjectDigest)},
+			},
+			expectedCode: errors.ErrorCodeReferrerCycleDetected.String(),
+		},
+		{
+			name: "nested depth exceeded",
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest:   {newReferrer(signatureDigest)},
+				signatureDigest: {newReferrer(nestedSignatureDigest)},
+			},
+			config:       &exConfig.ExecutorConfig{MaxNestedDepth: intPtr(1)},
+			expectedCode: errors.ErrorCodeVerificationLimitExceeded.String(),
+		},
+		{
+			name: "referrers per subject exceeded",
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest: {newReferrer(signatureDigest), newReferrer(nestedSignatureDigest)},
+			},
+			config:       &exConfig.ExecutorConfig{MaxReferrersPerSubject: intPtr(1)},
+			expectedCode: errors.ErrorCodeVerificationLimitExceeded.String(),
+		},
+		{
+			name: "within limits",
+			referrers: map[string][]ocispecs.ReferenceDescriptor{
+				subjectDigest:   {newReferrer(signatureDigest)},
+				signatureDigest: {newReferrer(nestedSignatureDigest)},
+			},
+			config: &exConfig.ExecutorConfig{MaxNestedDepth: intPtr(2), MaxReferrersPerSubject: intPtr(1)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ex := &Executor{
+				ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: tc.referrers}},
+				PolicyEnforcer: &mockPolicyProvider{result: true, policyType: pt.RegoPolicy},
+				Verifiers: []verifier.ReferenceVerifier{
+					&mockVerifier{canVerify: true, verifierResult: verifier.VerifierResult{IsSuccess: true}},
+				},
+				Config: tc.config,
+			}
+
+			result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			reports := make([]types.NestedVerifierReport, 0, len(result.VerifierReports))
+			for _, report := range result.VerifierReports {
+				reports = append(reports, report.(types.NestedVerifierReport))
+			}
+			code, found := findLimitReport(reports)
+			if tc.expectedCode == "" {
+				if found {
+					t.Fatalf("expected no limit report but got %s", code)
+				}
+				return
+			}
+			if !found || code != tc.expectedCode {
+				t.Fatalf("expected limit report with code %s but got %s", tc.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestVerifySubject_NestedCycleForJSONPolicy(t *testing.T) {
+	ex := &Executor{
+		ReferrerStores: []referrerstore.ReferrerStore{&mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest:   {newReferrer(signatureDigest)},
+			signatureDigest: {newReferrer(subjectDigest)},
+		}}},
+		PolicyEnforcer: &mockPolicyProvider{result: true},
+		Verifiers: []verifier.ReferenceVerifier{
+			&TestVerifier{
+				CanVerifyFunc:    func(_ string) bool { return true },
+				VerifyResult:     func(_ string) bool { return true },
+				nestedReferences: []string{"*"},
+			},
+		},
+	}
+
+	result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.VerifierReports) != 1 {
+		t.Fatalf("expected 1 report but got %d", len(result.VerifierReports))
+	}
+	report := result.VerifierReports[0].(verifier.VerifierResult)
+	if len(report.NestedResults) != 1 || report.NestedResults[0].IsSuccess {
+		t.Fatalf("expected the nested report to fail due to the referrer cycle")
+	}
+	nested := report.NestedResults[0].NestedResults
+	if len(nested) != 1 || nested[0].VerifierName != executorVerifierName {
+		t.Fatalf("expected a nested executor report but got %+v", nested)
+	}
+}
+
+const nestedSubjectDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+
+// nestedSubjectVerifier lists the nested subject in the extensions of the
+// results of the subject referrers and verifies the signature of the nested
+// subject.
+type nestedSubjectVerifier struct {
+	signatureValid bool
+}
+
+func (v *nestedSubjectVerifier) Name() string {
+	return "verifier-nestedSubjectVerifier"
+}
+
+func (v *nestedSubjectVerifier) Type() string {
+	return "nestedSubjectVerifier"
+}
+
+func (v *nestedSubjectVerifier) CanVerify(_ context.Context, _ ocispecs.ReferenceDescriptor) bool {
+	return true
+}
+
+func (v *nestedSubjectVerifier) Verify(_ context.Context, _ common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, _ referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	if referenceDescriptor.Digest == nestedSignatureDigest {
+		return verifier.VerifierResult{IsSuccess: v.signatureValid}, nil
+	}
+	return verifier.VerifierResult{
+		IsSuccess: true,
+		Extensions: map[string]interface{}{
+			verifier.NestedSubjectsExtension: []ocispecs.ReferenceDescriptor{newReferrer(nestedSubjectDigest)},
+		},
+	}, nil
+}
+
+func (v *nestedSubjectVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+func TestVerifySubject_NestedSubjectsExtension(t *testing.T) {
+	unsignedStore := &mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest: {newReferrer(signatureDigest)},
+	}}
+	for _, policyType := range []string{pt.ConfigPolicy, pt.RegoPolicy} {
+		ex := &Executor{
+			ReferrerStores: []referrerstore.ReferrerStore{unsignedStore},
+			PolicyEnforcer: &mockPolicyProvider{result: true, policyType: policyType},
+			Verifiers:      []verifier.ReferenceVerifier{&nestedSubjectVerifier{signatureValid: true}},
+		}
+		result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// a nested subject without any verified referrer fails the report relying on it
+		var isSuccess bool
+		switch report := result.VerifierReports[0].(type) {
+		case verifier.VerifierResult:
+			isSuccess = report.IsSuccess
+		case types.NestedVerifierReport:
+			isSuccess = report.VerifierReports[0].IsSuccess
+		}
+		if isSuccess {
+			t.Fatalf("expected the report relying on an unsigned nested subject to fail with %s policy", policyType)
+		}
+	}
+
+	for _, signatureValid := range []bool{true, false} {
+		store := &mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+			subjectDigest:       {newReferrer(signatureDigest)},
+			nestedSubjectDigest: {newSignatureReferrer(nestedSignatureDigest)},
+		}}
+
+		ex := &Executor{
+			ReferrerStores: []referrerstore.ReferrerStore{store},
+			PolicyEnforcer: &mockPolicyProvider{result: true},
+			Verifiers:      []verifier.ReferenceVerifier{&nestedSubjectVerifier{signatureValid: signatureValid}},
+		}
+		result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		report := result.VerifierReports[0].(verifier.VerifierResult)
+		if len(report.NestedResults) != 1 || report.NestedResults[0].IsSuccess != signatureValid {
+			t.Fatalf("expected the nested subject result to be %t but got %+v", signatureValid, report.NestedResults)
+		}
+		// the mock policy accepts any nested result, the report fails without a valid signature
+		if report.IsSuccess != signatureValid {
+			t.Fatalf("expected the report relying on the nested subject to be %t but got %+v", signatureValid, report)
+		}
+
+		ex.PolicyEnforcer = &mockPolicyProvider{result: true, policyType: pt.RegoPolicy}
+		result, err = ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		nestedReports := result.VerifierReports[0].(types.NestedVerifierReport).NestedReports
+		if len(nestedReports) != 1 || nestedReports[0].ReferenceDigest != nestedSubjectDigest {
+			t.Fatalf("expected a nested report of the nested subject but got %+v", nestedReports)
+		}
+		signatureReports := nestedReports[0].NestedReports
+		if len(signatureReports) != 1 || signatureReports[0].VerifierReports[0].IsSuccess != signatureValid {
+			t.Fatalf("expected the nested subject signature report to be %t but got %+v", signatureValid, signatureReports)
+		}
+		if verifierReport := result.VerifierReports[0].(types.NestedVerifierReport).VerifierReports[0]; verifierReport.IsSuccess != signatureValid {
+			t.Fatalf("expected the report relying on the nested subject to be %t but got %+v", signatureValid, verifierReport)
+		}
+	}
+}
+
+func TestVerifySubject_NestedSubjectsExtension_NonSignatureReferrer(t *testing.T) {
+	// the only referrer of the nested subject passes verification but is not a
+	// signature, so the nested subject is not vouched for
+	store := &mockStore{referrers: map[string][]ocispecs.ReferenceDescriptor{
+		subjectDigest:       {newReferrer(signatureDigest)},
+		nestedSubjectDigest: {newReferrer(nestedSignatureDigest)},
+	}}
+	for _, policyType := range []string{pt.ConfigPolicy, pt.RegoPolicy} {
+		ex := &Executor{
+			ReferrerStores: []referrerstore.ReferrerStore{store},
+			PolicyEnforcer: &mockPolicyProvider{result: true, policyType: policyType},
+			Verifiers:      []verifier.ReferenceVerifier{&nestedSubjectVerifier{signatureValid: true}},
+		}
+		result, err := ex.VerifySubject(context.Background(), e.VerifyParameters{Subject: subject1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var isSuccess bool
+		switch report := result.VerifierReports[0].(type) {
+		case verifier.VerifierResult:
+			if len(report.NestedResults) != 1 || !report.NestedResults[0].IsSuccess {
+				t.Fatalf("expected the referrer of the nested subject to pass but got %+v", report.NestedResults)
+			}
+			isSuccess = report.IsSuccess
+		case types.NestedVerifierReport:
+			isSuccess = report.VerifierReports[0].IsSuccess
+		}
+		if isSuccess {
+			t.Fatalf("expected the report relying on a nested subject without verified signature to fail with %s policy", policyType)
+		}
+	}
+}
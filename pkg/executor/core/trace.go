@@ -0,0 +1,67 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+)
+
+// recordTrace records the event with the nesting depth of the context if the
+// verification is traced.
+func recordTrace(ctx context.Context, event trace.Event) {
+	if !trace.Enabled(ctx) {
+		return
+	}
+	event.Depth = getNestedVerificationState(ctx).depth
+	trace.Record(ctx, event)
+}
+
+// recordReferrerTrace records an event about a referrer of the subject.
+func recordReferrerTrace(ctx context.Context, eventType trace.EventType, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, message string) {
+	recordTrace(ctx, trace.Event{
+		Type:         eventType,
+		Subject:      subjectRef.String(),
+		Reference:    referenceDesc.Digest.String(),
+		ArtifactType: referenceDesc.ArtifactType,
+		Message:      message,
+	})
+}
+
+// recordVerifierTrace records an event about a verifier verifying a referrer
+// of the subject.
+func recordVerifierTrace(ctx context.Context, eventType trace.EventType, subjectRef common.Reference, referenceDesc ocispecs.ReferenceDescriptor, verifierName string, details map[string]interface{}) {
+	recordTrace(ctx, trace.Event{
+		Type:         eventType,
+		Subject:      subjectRef.String(),
+		Verifier:     verifierName,
+		Reference:    referenceDesc.Digest.String(),
+		ArtifactType: referenceDesc.ArtifactType,
+		Details:      details,
+	})
+}
+
+// referrerDigests returns the digests of the referrers recorded in a trace.
+func referrerDigests(referrers []ocispecs.ReferenceDescriptor) []string {
+	digests := make([]string, 0, len(referrers))
+	for _, referrer := range referrers {
+		digests = append(digests, referrer.Digest.String())
+	}
+	return digests
+}
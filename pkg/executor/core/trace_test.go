@@ -0,0 +1,101 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	e "github.com/ratify-project/ratify/pkg/executor"
+	"github.com/ratify-project/ratify/pkg/executor/trace"
+	policyConfig "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	policyTypes "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// TestVerifySubject_Trace tests that the executor records its decisions for
+// the subject and nested subjects when the verification is traced
+func TestVerifySubject_Trace(t *testing.T) {
+	configPolicy := policyConfig.PolicyEnforcer{
+		ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+			"default": "all",
+		}}
+
+	sbomVerifier := &TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == mocks.SbomArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			return true
+		},
+		nestedReferences: []string{"string-content-does-not-matter"},
+	}
+	signatureVerifier := &TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return at == mocks.SignatureArtifactType
+		},
+		VerifyResult: func(_ string) bool {
+			return true
+		},
+	}
+
+	ex := &Executor{
+		PolicyEnforcer: configPolicy,
+		ReferrerStores: []referrerstore.ReferrerStore{mocks.CreateNewTestStoreForNestedSbom()},
+		Verifiers:      []verifier.ReferenceVerifier{sbomVerifier, signatureVerifier},
+	}
+
+	verificationTrace := trace.New(mocks.TestSubjectWithDigest, "")
+	ctx := trace.NewContext(context.Background(), verificationTrace)
+	result, err := ex.VerifySubject(ctx, e.VerifyParameters{Subject: mocks.TestSubjectWithDigest})
+	if err != nil {
+		t.Fatalf("verification failed with err %v", err)
+	}
+	if !result.IsSuccess {
+		t.Fatal("verification expected to succeed")
+	}
+	verificationTrace.Finish()
+
+	events := map[trace.EventType]int{}
+	nestedEvents := 0
+	for _, event := range verificationTrace.Events {
+		events[event.Type]++
+		if event.Depth > 0 {
+			nestedEvents++
+		}
+	}
+	for _, eventType := range []trace.EventType{
+		trace.EventSubjectResolved,
+		trace.EventReferrersListed,
+		trace.EventVerifierMatched,
+		trace.EventVerifierResult,
+		trace.EventNestedVerification,
+		trace.EventPolicyEvaluated,
+	} {
+		if events[eventType] == 0 {
+			t.Fatalf("trace has no %s event: %+v", eventType, verificationTrace.Events)
+		}
+	}
+	// the subject and the nested sbom are both resolved and evaluated
+	if events[trace.EventSubjectResolved] != 2 || events[trace.EventPolicyEvaluated] != 2 {
+		t.Fatalf("trace has %d subjectResolved and %d policyEvaluated events, want 2", events[trace.EventSubjectResolved], events[trace.EventPolicyEvaluated])
+	}
+	if nestedEvents == 0 {
+		t.Fatalf("trace has no event of the nested verification")
+	}
+}
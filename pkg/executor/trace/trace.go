@@ -0,0 +1,145 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of decision recorded in a verification trace.
+type EventType string
+
+const (
+	// EventSubjectResolved records the resolution of the subject to a digest.
+	EventSubjectResolved EventType = "subjectResolved"
+	// EventReferrersListed records a page of referrers returned by a store.
+	EventReferrersListed EventType = "referrersListed"
+	// EventReferrerSkipped records a referrer the policy does not need verified.
+	EventReferrerSkipped EventType = "referrerSkipped"
+	// EventReferrersLimitReached records the referrers left unverified because
+	// of maxReferrersPerSubject.
+	EventReferrersLimitReached EventType = "referrersLimitReached"
+	// EventVerifierMatched records a verifier able to verify a referrer.
+	EventVerifierMatched EventType = "verifierMatched"
+	// EventNoVerifierMatched records a referrer no verifier is able to verify.
+	EventNoVerifierMatched EventType = "noVerifierMatched"
+	// EventVerifierResult records the result of a verifier.
+	EventVerifierResult EventType = "verifierResult"
+	// EventNestedVerification records the verification of a referrer as a
+	// nested subject.
+	EventNestedVerification EventType = "nestedVerification"
+	// EventNestedVerificationSkipped records a nested verification skipped
+	// because of a cycle or maxNestedDepth.
+	EventNestedVerificationSkipped EventType = "nestedVerificationSkipped"
+	// EventCacheHit records a verification result returned from a cache.
+	EventCacheHit EventType = "cacheHit"
+	// EventCacheMiss records a verification result not found in a cache.
+	EventCacheMiss EventType = "cacheMiss"
+	// EventPolicyEvaluated records the input and output of the policy.
+	EventPolicyEvaluated EventType = "policyEvaluated"
+	// EventVerificationError records an error converted to a result by the
+	// policy.
+	EventVerificationError EventType = "verificationError"
+)
+
+// Event is a single decision made while verifying a subject.
+type Event struct {
+	// Time is the time the event was recorded.
+	Time time.Time `json:"time"`
+	// ElapsedMs is the time since the start of the trace in milliseconds.
+	ElapsedMs int64     `json:"elapsedMs"`
+	Type      EventType `json:"type"`
+	// Subject is the subject being verified, which is a referrer of the
+	// original subject for nested verifications.
+	Subject string `json:"subject,omitempty"`
+	// Depth is the nesting depth of Subject, 0 for the original subject.
+	Depth        int    `json:"depth"`
+	Store        string `json:"store,omitempty"`
+	Verifier     string `json:"verifier,omitempty"`
+	Reference    string `json:"reference,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Message      string `json:"message,omitempty"`
+	// Details holds event specific data such as the policy input and output.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Trace is the timeline of decisions made while verifying a subject. It is
+// safe for concurrent use.
+type Trace struct {
+	Subject    string    `json:"subject"`
+	TraceID    string    `json:"traceID,omitempty"`
+	StartTime  time.Time `json:"startTime"`
+	DurationMs int64     `json:"durationMs"`
+	Events     []Event   `json:"events"`
+
+	mu sync.Mutex
+}
+
+type traceKey struct{}
+
+// New creates an empty trace of the subject.
+func New(subject string, traceID string) *Trace {
+	return &Trace{
+		Subject:   subject,
+		TraceID:   traceID,
+		StartTime: time.Now(),
+		Events:    make([]Event, 0),
+	}
+}
+
+// NewContext returns a context recording the verification events to the trace.
+func NewContext(ctx context.Context, trace *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// FromContext returns the trace of the context, or nil if tracing is disabled.
+func FromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// Enabled returns true if the context records a trace.
+func Enabled(ctx context.Context) bool {
+	return FromContext(ctx) != nil
+}
+
+// Record adds the event to the trace of the context. It is a no-op if
+// tracing is disabled.
+func Record(ctx context.Context, event Event) {
+	if trace := FromContext(ctx); trace != nil {
+		trace.Record(event)
+	}
+}
+
+// Record adds the event to the trace.
+func (t *Trace) Record(event Event) {
+	now := time.Now()
+	event.Time = now
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	event.ElapsedMs = now.Sub(t.StartTime).Milliseconds()
+	t.Events = append(t.Events, event)
+}
+
+// Finish records the duration of the trace. It must be called once the
+// verification completes and no more events are recorded.
+func (t *Trace) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.DurationMs = time.Since(t.StartTime).Milliseconds()
+}
@@ -0,0 +1,72 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestRecord_Disabled(t *testing.T) {
+	ctx := context.Background()
+	if Enabled(ctx) {
+		t.Fatalf("Enabled() = true for a context without trace")
+	}
+	// recording without a trace is a no-op
+	Record(ctx, Event{Type: EventCacheMiss})
+}
+
+func TestRecord_Concurrent(t *testing.T) {
+	trace := New("localhost:5000/net-monitor:v1", "trace-id")
+	ctx := NewContext(context.Background(), trace)
+	if !Enabled(ctx) || FromContext(ctx) != trace {
+		t.Fatalf("FromContext() did not return the trace of the context")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Record(ctx, Event{Type: EventVerifierResult, Verifier: "notation"})
+		}()
+	}
+	wg.Wait()
+	trace.Finish()
+
+	if len(trace.Events) != 10 {
+		t.Fatalf("trace has %d events, want 10", len(trace.Events))
+	}
+	for _, event := range trace.Events {
+		if event.Time.IsZero() || event.Time.Before(trace.StartTime) {
+			t.Fatalf("event time %v is not set after the start of the trace", event.Time)
+		}
+	}
+
+	traceBytes, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("failed to marshal trace: %v", err)
+	}
+	var decoded Trace
+	if err := json.Unmarshal(traceBytes, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal trace: %v", err)
+	}
+	if decoded.Subject != trace.Subject || decoded.TraceID != "trace-id" || len(decoded.Events) != 10 {
+		t.Fatalf("unexpected decoded trace %s", traceBytes)
+	}
+}
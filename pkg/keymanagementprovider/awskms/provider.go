@@ -0,0 +1,250 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
+)
+
+const (
+	ProviderName string = "awskms"
+	// URIPrefix is the prefix of cosign key references to AWS KMS keys
+	URIPrefix = "awskms://"
+
+	awsSessionName = "ratifyKMSKeyManagementProvider"
+
+	// key of the key status property
+	keysStatus = "Keys"
+	// name of the key for the status property
+	statusName = "Name"
+	// ARN of the key for the status property
+	statusKeyID = "KeyID"
+	// key spec of the key for the status property
+	statusKeySpec = "KeySpec"
+	// error of a key which failed to load for the status property
+	statusError = "Error"
+	// last refreshed time for the status property
+	statusLastRefreshed = "LastRefreshed"
+)
+
+var logOpt = logger.Option{
+	ComponentType: logger.KeyManagementProvider,
+}
+
+// KeyValue references an asymmetric AWS KMS key
+type KeyValue struct {
+	// Name is the key ID, key ARN, alias name or alias ARN of the key,
+	// optionally as cosign reference, e.g. awskms:///alias/cosign
+	Name string `json:"name"`
+}
+
+//nolint:revive
+type AWSKMSKeyManagementProviderConfig struct {
+	Type string `json:"type"`
+	// Region of the keys. Defaults to the AWS_REGION environment variable.
+	Region string     `json:"region,omitempty"`
+	Keys   []KeyValue `json:"keys"`
+}
+
+// kmsAPI is the subset of the AWS KMS client used by the provider
+type kmsAPI interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+}
+
+type awsKMSKMProvider struct {
+	keys   []KeyValue
+	client kmsAPI
+}
+
+type awsKMSKMProviderFactory struct{}
+
+// newKMSClient creates the KMS client authenticating with IRSA or any other
+// credentials of the default credential chain. It is a variable for mocking purposes.
+var newKMSClient = func(ctx context.Context, region string) (kmsAPI, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithWebIdentityRoleCredentialOptions(func(options *stscreds.WebIdentityRoleOptions) {
+			options.RoleSessionName = awsSessionName
+		}),
+	}
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return kms.NewFromConfig(cfg), nil
+}
+
+// init calls to register the provider
+func init() {
+	factory.Register(ProviderName, &awsKMSKMProviderFactory{})
+}
+
+// Create creates a new instance of the provider after marshalling and validating the configuration
+func (f *awsKMSKMProviderFactory) Create(_ string, keyManagementProviderConfig config.KeyManagementProviderConfig, _ string) (keymanagementprovider.KeyManagementProvider, error) {
+	conf := AWSKMSKeyManagementProviderConfig{}
+
+	keyManagementProviderConfigBytes, err := json.Marshal(keyManagementProviderConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithError(err).WithComponentType(re.KeyManagementProvider)
+	}
+
+	if err := json.Unmarshal(keyManagementProviderConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, "", re.EmptyLink, err, "failed to parse AWS KMS key management provider configuration", re.HideStackTrace)
+	}
+
+	if len(conf.Keys) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "no AWS KMS keys configured", re.HideStackTrace)
+	}
+	// all keys must have a name
+	for i := range conf.Keys {
+		if keyID(conf.Keys[i].Name) == "" {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("name is not set for the %d th key", i+1), re.HideStackTrace)
+		}
+	}
+
+	region := strings.TrimSpace(conf.Region)
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	client, err := newKMSClient(context.Background(), region)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.NewError(re.KeyManagementProvider, ProviderName, re.AWSIRSALink, err, "failed to create AWS KMS client", re.HideStackTrace)
+	}
+
+	return &awsKMSKMProvider{
+		keys:   conf.Keys,
+		client: client,
+	}, nil
+}
+
+// GetCertificates returns no certificates as AWS KMS only holds keys
+func (s *awsKMSKMProvider) GetCertificates(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	return map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}, nil, nil
+}
+
+// GetKeys returns the public keys by configured name. AWS KMS does not
+// rotate asymmetric keys, so each key has a single version and the key ARN
+// is reported in the status. Keys which fail to load are reported with their
+// error in the status so that the other keys are kept, an error is only
+// returned if no key could be loaded.
+func (s *awsKMSKMProvider) GetKeys(ctx context.Context) (map[keymanagementprovider.KMPMapKey]crypto.PublicKey, keymanagementprovider.KeyManagementProviderStatus, error) {
+	keysMap := map[keymanagementprovider.KMPMapKey]crypto.PublicKey{}
+	keyProperties := []map[string]string{}
+	var failures []error
+	for _, key := range s.keys {
+		logger.GetLogger(ctx, logOpt).Debugf("fetching public key from AWS KMS, key %s", key.Name)
+
+		publicKey, output, err := s.getPublicKey(ctx, key)
+		if err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to load key %s: %v", key.Name, err)
+			failures = append(failures, err)
+			keyProperties = append(keyProperties, getFailedStatusProperty(key.Name, err, time.Now().Format(time.RFC3339)))
+			continue
+		}
+
+		keysMap[keymanagementprovider.KMPMapKey{Name: key.Name}] = publicKey
+		keyProperties = append(keyProperties, getStatusProperty(key.Name, aws.ToString(output.KeyId), string(output.KeySpec), time.Now().Format(time.RFC3339)))
+	}
+	if len(keysMap) == 0 {
+		return nil, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.AWSKMSLink, errors.Join(failures...), "failed to load any of the AWS KMS keys", re.HideStackTrace)
+	}
+
+	return keysMap, getStatusMap(keyProperties, keysStatus), nil
+}
+
+// getPublicKey returns the public key of a sign and verify key
+func (s *awsKMSKMProvider) getPublicKey(ctx context.Context, key KeyValue) (crypto.PublicKey, *kms.GetPublicKeyOutput, error) {
+	output, err := s.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID(key.Name))})
+	if err != nil {
+		return nil, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.AWSKMSLink, err, fmt.Sprintf("failed to get public key %s", key.Name), re.HideStackTrace)
+	}
+	if output.KeyUsage != types.KeyUsageTypeSignVerify {
+		return nil, nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.AWSKMSLink, nil, fmt.Sprintf("key %s has usage %s, only %s keys are supported", key.Name, output.KeyUsage, types.KeyUsageTypeSignVerify), re.HideStackTrace)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(output.PublicKey)
+	if err != nil {
+		return nil, nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("failed to parse public key %s", key.Name), re.HideStackTrace)
+	}
+	return publicKey, output, nil
+}
+
+// IsRefreshable returns true as keys may be replaced, e.g. by updating an alias
+func (s *awsKMSKMProvider) IsRefreshable() bool {
+	return true
+}
+
+// keyID returns the key ID of the key name which may be a cosign reference
+// in the format awskms://[ENDPOINT]/[ID/ALIAS/ARN]
+func keyID(name string) string {
+	name = strings.TrimSpace(name)
+	if !strings.HasPrefix(name, URIPrefix) {
+		return name
+	}
+	// the endpoint is ignored as the client resolves it from the region
+	ref := strings.TrimPrefix(name, URIPrefix)
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ""
+}
+
+// AWS KMS provider key status is a map from "Keys" key to an array of key management provider status
+func getStatusMap(statusMap []map[string]string, contentType string) keymanagementprovider.KeyManagementProviderStatus {
+	status := keymanagementprovider.KeyManagementProviderStatus{}
+	status[contentType] = statusMap
+	return status
+}
+
+// return a status object that consist of the key name, ARN, key spec and last refreshed time
+func getStatusProperty(name, arn, keySpec, lastRefreshed string) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusKeyID] = arn
+	properties[statusKeySpec] = keySpec
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
+
+// return a status object that consist of the name and error of a key which failed to load and the last refreshed time
+func getFailedStatusProperty(name string, err error, lastRefreshed string) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusError] = err.Error()
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
@@ -0,0 +1,233 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awskms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+)
+
+const testKeyARN = "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+type mockKMSClient struct {
+	publicKey []byte
+	keyUsage  types.KeyUsageType
+	err       error
+	// errors by key ID, returned in place of err
+	keyErrs map[string]error
+	keyIDs  []string
+}
+
+func (m *mockKMSClient) GetPublicKey(_ context.Context, params *kms.GetPublicKeyInput, _ ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	m.keyIDs = append(m.keyIDs, aws.ToString(params.KeyId))
+	if err, ok := m.keyErrs[aws.ToString(params.KeyId)]; ok {
+		return nil, err
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &kms.GetPublicKeyOutput{
+		KeyId:     aws.String(testKeyARN),
+		KeySpec:   types.KeySpecEccNistP256,
+		KeyUsage:  m.keyUsage,
+		PublicKey: m.publicKey,
+	}, nil
+}
+
+func generatePublicKey(t *testing.T) []byte {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return keyDER
+}
+
+// TestCreate tests the Create function
+func TestCreate(t *testing.T) {
+	factory := &awsKMSKMProviderFactory{}
+	newKMSClient = func(_ context.Context, region string) (kmsAPI, error) {
+		if region == "invalid" {
+			return nil, errors.New("failed to load config")
+		}
+		return &mockKMSClient{}, nil
+	}
+	testCases := []struct {
+		name      string
+		config    config.KeyManagementProviderConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			config: config.KeyManagementProviderConfig{
+				"type":   ProviderName,
+				"region": "us-west-2",
+				"keys":   []interface{}{map[string]interface{}{"name": "alias/cosign"}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "no keys",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+			},
+			expectErr: true,
+		},
+		{
+			name: "key name not set",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": "awskms://localhost"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "client creation failed",
+			config: config.KeyManagementProviderConfig{
+				"type":   ProviderName,
+				"region": "invalid",
+				"keys":   []interface{}{map[string]interface{}{"name": "alias/cosign"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := factory.Create("v1", tc.config, "")
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestGetKeys tests the GetKeys function
+func TestGetKeys(t *testing.T) {
+	client := &mockKMSClient{publicKey: generatePublicKey(t), keyUsage: types.KeyUsageTypeSignVerify}
+	provider := &awsKMSKMProvider{
+		keys:   []KeyValue{{Name: "awskms:///alias/cosign"}, {Name: testKeyARN}},
+		client: client,
+	}
+
+	keys, status, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(client.keyIDs) != 2 || client.keyIDs[0] != "alias/cosign" || client.keyIDs[1] != testKeyARN {
+		t.Fatalf("unexpected key IDs %v", client.keyIDs)
+	}
+	if len(keys) != 2 || keys[keymanagementprovider.KMPMapKey{Name: "awskms:///alias/cosign"}] == nil {
+		t.Fatalf("expected keys by configured name, got %v", keys)
+	}
+	properties := status[keysStatus].([]map[string]string)
+	if len(properties) != 2 || properties[0][statusKeyID] != testKeyARN || properties[0][statusKeySpec] != string(types.KeySpecEccNistP256) {
+		t.Fatalf("unexpected key status %v", properties)
+	}
+
+	certs, _, err := provider.GetCertificates(context.Background())
+	if err != nil || len(certs) != 0 {
+		t.Fatalf("expected no certificates, got %v with error %v", certs, err)
+	}
+}
+
+// TestGetKeys_Errors tests the errors of the GetKeys function
+func TestGetKeys_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		client *mockKMSClient
+	}{
+		{
+			name:   "get public key failed",
+			client: &mockKMSClient{err: errors.New("access denied")},
+		},
+		{
+			name:   "encryption key",
+			client: &mockKMSClient{publicKey: generatePublicKey(t), keyUsage: types.KeyUsageTypeEncryptDecrypt},
+		},
+		{
+			name:   "invalid public key",
+			client: &mockKMSClient{publicKey: []byte("invalid"), keyUsage: types.KeyUsageTypeSignVerify},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &awsKMSKMProvider{keys: []KeyValue{{Name: "alias/cosign"}}, client: tc.client}
+			if _, _, err := provider.GetKeys(context.Background()); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+// TestGetKeys_PartialFailure tests that GetKeys keeps the keys which loaded
+// and reports the failed keys in the status
+func TestGetKeys_PartialFailure(t *testing.T) {
+	client := &mockKMSClient{
+		publicKey: generatePublicKey(t),
+		keyUsage:  types.KeyUsageTypeSignVerify,
+		keyErrs:   map[string]error{"alias/deleted": errors.New("key not found")},
+	}
+	provider := &awsKMSKMProvider{
+		keys:   []KeyValue{{Name: "alias/deleted"}, {Name: "alias/cosign"}},
+		client: client,
+	}
+
+	keys, status, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(keys) != 1 || keys[keymanagementprovider.KMPMapKey{Name: "alias/cosign"}] == nil {
+		t.Fatalf("expected the loaded key only, got %v", keys)
+	}
+	properties := status[keysStatus].([]map[string]string)
+	if len(properties) != 2 || properties[0][statusName] != "alias/deleted" || !strings.Contains(properties[0][statusError], "key not found") || properties[1][statusError] != "" {
+		t.Fatalf("unexpected key status %v", properties)
+	}
+}
+
+// TestKeyID tests the keyID function
+func TestKeyID(t *testing.T) {
+	testCases := map[string]string{
+		"alias/cosign":                            "alias/cosign",
+		"awskms:///alias/cosign":                  "alias/cosign",
+		"awskms://localhost:4566/alias/cosign":    "alias/cosign",
+		"awskms:///" + testKeyARN:                 testKeyARN,
+		"awskms://kms.us-west-2.amazonaws.com":    "",
+		" 1234abcd-12ab-34cd-56ef-1234567890ab\n": "1234abcd-12ab-34cd-56ef-1234567890ab",
+	}
+	for name, expected := range testCases {
+		if actual := keyID(name); actual != expected {
+			t.Fatalf("expected key ID %q for %q, got %q", expected, name, actual)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/version"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	ProviderName string = "gcpkms"
+	// URIPrefix is the prefix of cosign key references to GCP KMS keys
+	URIPrefix = "gcpkms://"
+
+	defaultEndpoint = "https://cloudkms.googleapis.com"
+	cloudKMSScope   = "https://www.googleapis.com/auth/cloudkms"
+	enabledState    = "ENABLED"
+	signPurpose     = "ASYMMETRIC_SIGN"
+	requestTimeout  = 30 * time.Second
+
+	// key of the key status property
+	keysStatus = "Keys"
+	// name of the key for the status property
+	statusName = "Name"
+	// version of the key for the status property
+	statusVersion = "Version"
+	// algorithm of the key version for the status property
+	statusAlgorithm = "Algorithm"
+	// error of a key version which failed to load for the status property
+	statusError = "Error"
+	// last refreshed time for the status property
+	statusLastRefreshed = "LastRefreshed"
+)
+
+var (
+	logOpt = logger.Option{
+		ComponentType: logger.KeyManagementProvider,
+	}
+
+	// cryptoKeyRegex matches crypto key resource names with an optional version
+	cryptoKeyRegex = regexp.MustCompile(`^(projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+)(?:/cryptoKeyVersions/([1-9][0-9]*))?$`)
+)
+
+// KeyValue references an asymmetric signing key of GCP KMS
+type KeyValue struct {
+	// Name is the resource name of the crypto key, optionally as cosign
+	// reference, e.g. gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k.
+	// A crypto key version may be appended instead of setting Version.
+	Name string `json:"name"`
+	// Version of the crypto key. All enabled versions are used if not set.
+	Version string `json:"version,omitempty"`
+}
+
+//nolint:revive
+type GCPKMSKeyManagementProviderConfig struct {
+	Type string     `json:"type"`
+	Keys []KeyValue `json:"keys"`
+}
+
+// cryptoKey is a parsed key reference
+type cryptoKey struct {
+	// name as configured without the version, used as name of the key in the key map
+	name string
+	// resource name of the crypto key
+	resourceName string
+	version      string
+}
+
+type gcpKMSKMProvider struct {
+	keys       []cryptoKey
+	endpoint   string
+	httpClient *http.Client
+}
+
+type gcpKMSKMProviderFactory struct{}
+
+type cryptoKeyResponse struct {
+	Purpose string `json:"purpose"`
+}
+
+type cryptoKeyVersion struct {
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Algorithm string `json:"algorithm"`
+}
+
+type listCryptoKeyVersionsResponse struct {
+	CryptoKeyVersions []cryptoKeyVersion `json:"cryptoKeyVersions"`
+	NextPageToken     string             `json:"nextPageToken"`
+}
+
+type publicKeyResponse struct {
+	Pem       string `json:"pem"`
+	Algorithm string `json:"algorithm"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// newHTTPClient creates the client authenticating with Workload Identity or
+// any other application default credentials. It is a variable for mocking purposes.
+var newHTTPClient = func(ctx context.Context) (*http.Client, error) {
+	return google.DefaultClient(ctx, cloudKMSScope)
+}
+
+// endpoint of the Cloud KMS API, a variable for mocking purposes
+var endpoint = defaultEndpoint
+
+// init calls to register the provider
+func init() {
+	factory.Register(ProviderName, &gcpKMSKMProviderFactory{})
+}
+
+// Create creates a new instance of the provider after marshalling and validating the configuration
+func (f *gcpKMSKMProviderFactory) Create(_ string, keyManagementProviderConfig config.KeyManagementProviderConfig, _ string) (keymanagementprovider.KeyManagementProvider, error) {
+	conf := GCPKMSKeyManagementProviderConfig{}
+
+	keyManagementProviderConfigBytes, err := json.Marshal(keyManagementProviderConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithError(err).WithComponentType(re.KeyManagementProvider)
+	}
+
+	if err := json.Unmarshal(keyManagementProviderConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, "", re.EmptyLink, err, "failed to parse GCP KMS key management provider configuration", re.HideStackTrace)
+	}
+
+	if len(conf.Keys) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "no GCP KMS keys configured", re.HideStackTrace)
+	}
+	keys := make([]cryptoKey, 0, len(conf.Keys))
+	for _, key := range conf.Keys {
+		parsed, err := parseCryptoKey(key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, parsed)
+	}
+
+	httpClient, err := newHTTPClient(context.Background())
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPWorkloadIdentityLink, err, "failed to create GCP KMS client", re.HideStackTrace)
+	}
+	httpClient.Timeout = requestTimeout
+
+	return &gcpKMSKMProvider{
+		keys:       keys,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: httpClient,
+	}, nil
+}
+
+// GetCertificates returns no certificates as GCP KMS only holds keys
+func (s *gcpKMSKMProvider) GetCertificates(_ context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	return map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}, nil, nil
+}
+
+// GetKeys returns the public keys of the configured or all enabled versions
+// of the crypto keys by configured name and version. Keys and versions which
+// fail to load are reported with their error in the status so that the other
+// keys are kept, an error is only returned if no key could be loaded.
+func (s *gcpKMSKMProvider) GetKeys(ctx context.Context) (map[keymanagementprovider.KMPMapKey]crypto.PublicKey, keymanagementprovider.KeyManagementProviderStatus, error) {
+	keysMap := map[keymanagementprovider.KMPMapKey]crypto.PublicKey{}
+	keyProperties := []map[string]string{}
+	var failures []error
+	addFailure := func(key cryptoKey, keyVersion string, err error) {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to load key %s version %s: %v", key.name, keyVersion, err)
+		failures = append(failures, err)
+		keyProperties = append(keyProperties, getFailedStatusProperty(key.name, keyVersion, err, time.Now().Format(time.RFC3339)))
+	}
+	for _, key := range s.keys {
+		logger.GetLogger(ctx, logOpt).Debugf("fetching public keys from GCP KMS, key %s, version %s", key.resourceName, key.version)
+
+		versions, err := s.getVersions(ctx, key)
+		if err != nil {
+			addFailure(key, key.version, err)
+			continue
+		}
+
+		for _, keyVersion := range versions {
+			response := publicKeyResponse{}
+			if err := s.get(ctx, key.resourceName+"/cryptoKeyVersions/"+keyVersion+"/publicKey", nil, &response); err != nil {
+				addFailure(key, keyVersion, err)
+				continue
+			}
+			publicKey, err := keymanagementprovider.DecodeKey([]byte(response.Pem))
+			if err != nil {
+				addFailure(key, keyVersion, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("failed to parse public key of key %s keyVersion %s", key.name, keyVersion), re.HideStackTrace))
+				continue
+			}
+			keysMap[keymanagementprovider.KMPMapKey{Name: key.name, Version: keyVersion}] = publicKey
+			keyProperties = append(keyProperties, getStatusProperty(key.name, keyVersion, response.Algorithm, time.Now().Format(time.RFC3339)))
+		}
+	}
+	if len(keysMap) == 0 {
+		return nil, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, errors.Join(failures...), "failed to load any of the GCP KMS keys", re.HideStackTrace)
+	}
+
+	return keysMap, getStatusMap(keyProperties, keysStatus), nil
+}
+
+// getVersions returns the configured or the enabled versions of the crypto
+// key after checking that it is a signing key
+func (s *gcpKMSKMProvider) getVersions(ctx context.Context, key cryptoKey) ([]string, error) {
+	response := cryptoKeyResponse{}
+	if err := s.get(ctx, key.resourceName, nil, &response); err != nil {
+		return nil, err
+	}
+	if response.Purpose != signPurpose {
+		return nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, nil, fmt.Sprintf("key %s has purpose %s, only %s keys are supported", key.name, response.Purpose, signPurpose), re.HideStackTrace)
+	}
+
+	if key.version != "" {
+		return []string{key.version}, nil
+	}
+	versions, err := s.listEnabledVersions(ctx, key.resourceName)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, nil, fmt.Sprintf("key %s has no enabled versions", key.name), re.HideStackTrace)
+	}
+	return versions, nil
+}
+
+// IsRefreshable returns true as crypto keys may be rotated
+func (s *gcpKMSKMProvider) IsRefreshable() bool {
+	return true
+}
+
+// listEnabledVersions returns the enabled versions of the crypto key in descending order
+func (s *gcpKMSKMProvider) listEnabledVersions(ctx context.Context, resourceName string) ([]string, error) {
+	var versions []int
+	query := url.Values{}
+	query.Set("filter", "state="+enabledState)
+	for {
+		response := listCryptoKeyVersionsResponse{}
+		if err := s.get(ctx, resourceName+"/cryptoKeyVersions", query, &response); err != nil {
+			return nil, err
+		}
+		for _, keyVersion := range response.CryptoKeyVersions {
+			// the filter is applied by the API, the state is checked for robustness
+			if keyVersion.State != enabledState {
+				continue
+			}
+			v, err := strconv.Atoi(keyVersion.Name[strings.LastIndex(keyVersion.Name, "/")+1:])
+			if err != nil {
+				return nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("invalid crypto key version %s", keyVersion.Name), re.HideStackTrace)
+			}
+			versions = append(versions, v)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		query.Set("pageToken", response.NextPageToken)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	result := make([]string, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, strconv.Itoa(v))
+	}
+	return result, nil
+}
+
+// get sends a GET request for the resource to the Cloud KMS API and parses the response
+func (s *gcpKMSKMProvider) get(ctx context.Context, resource string, query url.Values, data interface{}) error {
+	requestURL := s.endpoint + "/v1/" + resource
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, err, fmt.Sprintf("failed to create request for %s", resource), re.HideStackTrace)
+	}
+	req.Header.Set("User-Agent", version.UserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, err, fmt.Sprintf("failed to send request for %s to GCP KMS", resource), re.HideStackTrace)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, err, fmt.Sprintf("failed to read response for %s from GCP KMS", resource), re.HideStackTrace)
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := errorResponse{}
+		_ = json.Unmarshal(body, &errResp)
+		return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, nil, fmt.Sprintf("failed to get %s from GCP KMS, status code %d: %s %s", resource, resp.StatusCode, errResp.Error.Status, errResp.Error.Message), re.HideStackTrace)
+	}
+	if err := json.Unmarshal(body, data); err != nil {
+		return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, err, fmt.Sprintf("failed to parse %s from GCP KMS", resource), re.HideStackTrace)
+	}
+	return nil
+}
+
+// parseCryptoKey parses the resource name and version of the key
+func parseCryptoKey(key KeyValue) (cryptoKey, error) {
+	name := strings.TrimSpace(key.Name)
+	matches := cryptoKeyRegex.FindStringSubmatch(strings.TrimPrefix(name, URIPrefix))
+	if matches == nil {
+		return cryptoKey{}, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.GCPKMSLink, nil, fmt.Sprintf("key %s is not a valid crypto key name, expected format is projects/<project>/locations/<location>/keyRings/<keyRing>/cryptoKeys/<key>", key.Name), re.HideStackTrace)
+	}
+	parsed := cryptoKey{name: name, resourceName: matches[1], version: key.Version}
+	if matches[2] != "" {
+		if key.Version != "" && key.Version != matches[2] {
+			return cryptoKey{}, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("version %s of key %s does not match the version of the name", key.Version, key.Name), re.HideStackTrace)
+		}
+		parsed.name = strings.TrimSuffix(name, "/cryptoKeyVersions/"+matches[2])
+		parsed.version = matches[2]
+	}
+	if parsed.version != "" {
+		if v, err := strconv.Atoi(parsed.version); err != nil || v <= 0 {
+			return cryptoKey{}, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("version %s of key %s is not a positive number", parsed.version, key.Name), re.HideStackTrace)
+		}
+	}
+	return parsed, nil
+}
+
+// GCP KMS provider key status is a map from "Keys" key to an array of key management provider status
+func getStatusMap(statusMap []map[string]string, contentType string) keymanagementprovider.KeyManagementProviderStatus {
+	status := keymanagementprovider.KeyManagementProviderStatus{}
+	status[contentType] = statusMap
+	return status
+}
+
+// return a status object that consist of the key name, version, algorithm and last refreshed time
+func getStatusProperty(name, version, algorithm, lastRefreshed string) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusVersion] = version
+	properties[statusAlgorithm] = algorithm
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
+
+// return a status object that consist of the key name, version and error of a key version which failed to load and the last refreshed time
+func getFailedStatusProperty(name, version string, err error, lastRefreshed string) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusVersion] = version
+	properties[statusError] = err.Error()
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
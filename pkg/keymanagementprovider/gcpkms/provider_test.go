@@ -0,0 +1,319 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcpkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+)
+
+const (
+	testKeyRing = "projects/ratify/locations/global/keyRings/cosign"
+	testKeyName = testKeyRing + "/cryptoKeys/signing"
+)
+
+// newFakeKMS starts a stand-in for the Cloud KMS API serving the versions 1
+// to 3 of the test key, of which version 1 is disabled. The decrypt key of the
+// key ring is an encryption key.
+func newFakeKMS(t *testing.T) {
+	publicKeys := map[string]string{}
+	for _, version := range []string{"1", "2", "3"} {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		keyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		if err != nil {
+			t.Fatalf("failed to marshal public key: %v", err)
+		}
+		publicKeys[version] = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		switch {
+		case path == testKeyRing+"/cryptoKeys/decrypt":
+			writeResponse(w, http.StatusOK, map[string]interface{}{"name": path, "purpose": "ASYMMETRIC_DECRYPT"})
+		case strings.HasPrefix(path, testKeyRing+"/cryptoKeys/") && !strings.Contains(strings.TrimPrefix(path, testKeyRing+"/cryptoKeys/"), "/"):
+			writeResponse(w, http.StatusOK, map[string]interface{}{"name": path, "purpose": "ASYMMETRIC_SIGN"})
+		case path == testKeyName+"/cryptoKeyVersions":
+			if r.URL.Query().Get("filter") != "state=ENABLED" {
+				writeResponse(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]interface{}{"status": "INVALID_ARGUMENT"}})
+				return
+			}
+			// versions are returned on two pages
+			if r.URL.Query().Get("pageToken") == "" {
+				writeResponse(w, http.StatusOK, map[string]interface{}{
+					"cryptoKeyVersions": []interface{}{map[string]interface{}{"name": testKeyName + "/cryptoKeyVersions/2", "state": "ENABLED"}},
+					"nextPageToken":     "next",
+				})
+				return
+			}
+			writeResponse(w, http.StatusOK, map[string]interface{}{
+				"cryptoKeyVersions": []interface{}{map[string]interface{}{"name": testKeyName + "/cryptoKeyVersions/3", "state": "ENABLED"}},
+			})
+		case strings.HasPrefix(path, testKeyName+"/cryptoKeyVersions/") && strings.HasSuffix(path, "/publicKey"):
+			version := strings.TrimSuffix(strings.TrimPrefix(path, testKeyName+"/cryptoKeyVersions/"), "/publicKey")
+			if version == "1" {
+				writeResponse(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]interface{}{"status": "FAILED_PRECONDITION", "message": "version is disabled"}})
+				return
+			}
+			publicKey, ok := publicKeys[version]
+			if !ok {
+				writeResponse(w, http.StatusNotFound, map[string]interface{}{"error": map[string]interface{}{"status": "NOT_FOUND"}})
+				return
+			}
+			writeResponse(w, http.StatusOK, map[string]interface{}{"pem": publicKey, "algorithm": "EC_SIGN_P256_SHA256"})
+		case strings.HasPrefix(path, testKeyRing+"/cryptoKeys/empty/"):
+			writeResponse(w, http.StatusOK, map[string]interface{}{})
+		case strings.HasPrefix(path, testKeyRing+"/cryptoKeys/invalid/"):
+			writeResponse(w, http.StatusOK, map[string]interface{}{"pem": "invalid"})
+		default:
+			writeResponse(w, http.StatusForbidden, map[string]interface{}{"error": map[string]interface{}{"status": "PERMISSION_DENIED"}})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	newHTTPClient = func(_ context.Context) (*http.Client, error) {
+		return server.Client(), nil
+	}
+	endpoint = server.URL
+	t.Cleanup(func() { endpoint = defaultEndpoint })
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// TestCreate tests the Create function
+func TestCreate(t *testing.T) {
+	factory := &gcpKMSKMProviderFactory{}
+	testCases := []struct {
+		name      string
+		config    config.KeyManagementProviderConfig
+		clientErr error
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": "gcpkms://" + testKeyName}, map[string]interface{}{"name": testKeyName, "version": "2"}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "no keys",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid key name",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": "projects/ratify/cryptoKeys/signing"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid version",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": testKeyName, "version": "latest"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "conflicting versions",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": testKeyName + "/cryptoKeyVersions/2", "version": "3"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "client creation failed",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": testKeyName}},
+			},
+			clientErr: errors.New("could not find default credentials"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newHTTPClient = func(_ context.Context) (*http.Client, error) {
+				return &http.Client{}, tc.clientErr
+			}
+			_, err := factory.Create("v1", tc.config, "")
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestGetKeys tests the GetKeys function
+func TestGetKeys(t *testing.T) {
+	newFakeKMS(t)
+	testCases := []struct {
+		name             string
+		key              map[string]interface{}
+		expectedName     string
+		expectedVersions []string
+		expectErr        bool
+	}{
+		{
+			name:             "all enabled versions",
+			key:              map[string]interface{}{"name": "gcpkms://" + testKeyName},
+			expectedName:     "gcpkms://" + testKeyName,
+			expectedVersions: []string{"3", "2"},
+		},
+		{
+			name:             "version",
+			key:              map[string]interface{}{"name": testKeyName, "version": "2"},
+			expectedName:     testKeyName,
+			expectedVersions: []string{"2"},
+		},
+		{
+			name:             "version of the name",
+			key:              map[string]interface{}{"name": testKeyName + "/cryptoKeyVersions/3"},
+			expectedName:     testKeyName,
+			expectedVersions: []string{"3"},
+		},
+		{
+			name:      "disabled version",
+			key:       map[string]interface{}{"name": testKeyName, "version": "1"},
+			expectErr: true,
+		},
+		{
+			name:      "permission denied",
+			key:       map[string]interface{}{"name": "projects/other/locations/global/keyRings/cosign/cryptoKeys/signing"},
+			expectErr: true,
+		},
+		{
+			name:      "no enabled versions",
+			key:       map[string]interface{}{"name": testKeyRing + "/cryptoKeys/empty"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid public key",
+			key:       map[string]interface{}{"name": testKeyRing + "/cryptoKeys/invalid", "version": "1"},
+			expectErr: true,
+		},
+		{
+			name:      "encryption key",
+			key:       map[string]interface{}{"name": testKeyRing + "/cryptoKeys/decrypt", "version": "1"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := (&gcpKMSKMProviderFactory{}).Create("v1", config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{tc.key},
+			}, "")
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+			keys, status, err := provider.GetKeys(context.Background())
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(keys) != len(tc.expectedVersions) {
+				t.Fatalf("expected versions %v, got %v", tc.expectedVersions, keys)
+			}
+			properties := status[keysStatus].([]map[string]string)
+			for i, version := range tc.expectedVersions {
+				if keys[keymanagementprovider.KMPMapKey{Name: tc.expectedName, Version: version}] == nil {
+					t.Fatalf("expected version %s of %s, got %v", version, tc.expectedName, keys)
+				}
+				if properties[i][statusVersion] != version || properties[i][statusAlgorithm] != "EC_SIGN_P256_SHA256" {
+					t.Fatalf("unexpected key status %v", properties)
+				}
+			}
+
+			certs, _, err := provider.GetCertificates(context.Background())
+			if err != nil || len(certs) != 0 {
+				t.Fatalf("expected no certificates, got %v with error %v", certs, err)
+			}
+		})
+	}
+}
+
+// TestGetKeys_PartialFailure tests that GetKeys keeps the keys which loaded
+// and reports the failed keys in the status
+func TestGetKeys_PartialFailure(t *testing.T) {
+	newFakeKMS(t)
+	provider, err := (&gcpKMSKMProviderFactory{}).Create("v1", config.KeyManagementProviderConfig{
+		"type": ProviderName,
+		"keys": []interface{}{
+			map[string]interface{}{"name": testKeyRing + "/cryptoKeys/decrypt"},
+			map[string]interface{}{"name": testKeyName, "version": "1"},
+			map[string]interface{}{"name": testKeyName, "version": "2"},
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	keys, status, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(keys) != 1 || keys[keymanagementprovider.KMPMapKey{Name: testKeyName, Version: "2"}] == nil {
+		t.Fatalf("expected the loaded version only, got %v", keys)
+	}
+	properties := status[keysStatus].([]map[string]string)
+	if len(properties) != 3 {
+		t.Fatalf("unexpected key status %v", properties)
+	}
+	if properties[0][statusName] != testKeyRing+"/cryptoKeys/decrypt" || !strings.Contains(properties[0][statusError], "ASYMMETRIC_DECRYPT") {
+		t.Fatalf("expected the encryption key to be reported, got %v", properties[0])
+	}
+	if properties[1][statusVersion] != "1" || !strings.Contains(properties[1][statusError], "version is disabled") {
+		t.Fatalf("expected the disabled version to be reported, got %v", properties[1])
+	}
+	if properties[2][statusVersion] != "2" || properties[2][statusError] != "" {
+		t.Fatalf("expected the loaded version to be reported, got %v", properties[2])
+	}
+}
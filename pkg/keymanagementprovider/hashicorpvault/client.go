@@ -0,0 +1,233 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/internal/version"
+)
+
+const (
+	// KubernetesAuthMethod logs in to Vault with a service account token of Ratify
+	KubernetesAuthMethod = "kubernetes"
+	// TokenAuthMethod uses the Vault token of the VAULT_TOKEN environment variable of Ratify
+	TokenAuthMethod = "token"
+
+	tokenEnvKey            = "VAULT_TOKEN"
+	defaultKubernetesMount = "kubernetes"
+	namespaceHeader        = "X-Vault-Namespace"
+	tokenHeader            = "X-Vault-Token"
+	requestTimeout         = 30 * time.Second
+	// the token is renewed before it expires to tolerate clock skew and slow requests
+	tokenExpiryDelta = 10 * time.Second
+)
+
+// serviceAccountTokenPath is the service account token projected by the chart
+// with the audience of Vault, so that the token cannot be used against the
+// Kubernetes API server if it is sent to another address. It is a variable
+// for mocking purposes.
+var serviceAccountTokenPath = "/var/run/secrets/ratify/vault/token" // #nosec G101
+
+// AuthConfig configures how Ratify authenticates to Vault
+type AuthConfig struct {
+	// Method is kubernetes or token. Defaults to kubernetes.
+	Method string `json:"method,omitempty"`
+	// Role is the Vault role bound to the service account of Ratify. Required for kubernetes auth.
+	Role string `json:"role,omitempty"`
+	// MountPath of the kubernetes auth method. Defaults to kubernetes.
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// vaultClient is a minimal client of the Vault HTTP API which logs in on
+// demand and caches the token until it expires
+type vaultClient struct {
+	address    string
+	namespace  string
+	auth       AuthConfig
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// vaultResponse is the envelope of Vault API responses
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Auth   *vaultAuth      `json:"auth"`
+	Errors []string        `json:"errors"`
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// newVaultClient creates a client for the Vault server at address. caCert
+// optionally holds the PEM encoded CA certificates of the server.
+func newVaultClient(address, namespace, caCert string, auth AuthConfig) (*vaultClient, error) {
+	if _, err := url.ParseRequestURI(address); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, err, fmt.Sprintf("address %s is not valid", address), re.HideStackTrace)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, nil, "caCert does not contain any PEM encoded certificates", re.HideStackTrace)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &vaultClient{
+		address:    strings.TrimSuffix(address, "/"),
+		namespace:  namespace,
+		auth:       auth,
+		httpClient: &http.Client{Transport: transport, Timeout: requestTimeout},
+	}, nil
+}
+
+// read returns the data of the Vault path. A permission denied response
+// discards the cached token and the request is retried once with a new token,
+// so that revoked tokens are replaced before they expire.
+func (c *vaultClient) read(ctx context.Context, path string, query url.Values, data interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.getToken(ctx)
+		if err != nil {
+			return err
+		}
+		statusCode, resp, err := c.do(ctx, http.MethodGet, path, query, token, nil)
+		if err != nil {
+			return err
+		}
+		if statusCode == http.StatusForbidden && attempt == 0 && c.auth.Method == KubernetesAuthMethod {
+			c.resetToken(token)
+			continue
+		}
+		if statusCode != http.StatusOK {
+			// the errors of the response are only logged as they are written
+			// by the server and would be copied to the status of the resource
+			logger.GetLogger(ctx, logOpt).Warnf("failed to read %s from vault, status code %d: %s", path, statusCode, strings.Join(resp.Errors, ", "))
+			return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, nil, fmt.Sprintf("failed to read %s from vault, status code %d", path, statusCode), re.HideStackTrace)
+		}
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			return re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, err, fmt.Sprintf("failed to parse %s from vault", path), re.HideStackTrace)
+		}
+		return nil
+	}
+}
+
+// getToken returns the cached token or logs in to Vault
+func (c *vaultClient) getToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.tokenExpiry.IsZero() || time.Now().Before(c.tokenExpiry)) {
+		return c.token, nil
+	}
+
+	if c.auth.Method == TokenAuthMethod {
+		token := os.Getenv(tokenEnvKey)
+		if token == "" {
+			return "", re.ErrorCodeAuthDenied.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, nil, fmt.Sprintf("%s is not set", tokenEnvKey), re.HideStackTrace)
+		}
+		return token, nil
+	}
+
+	// the token is read on each login as it is rotated by the kubelet
+	jwt, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return "", re.ErrorCodeAuthDenied.NewError(re.KeyManagementProvider, ProviderName, re.VaultKubernetesAuthLink, err, fmt.Sprintf("failed to read the service account token for vault from %s", serviceAccountTokenPath), re.HideStackTrace)
+	}
+	body, _ := json.Marshal(map[string]string{"role": c.auth.Role, "jwt": strings.TrimSpace(string(jwt))})
+	statusCode, resp, err := c.do(ctx, http.MethodPost, "auth/"+c.auth.MountPath+"/login", nil, "", body)
+	if err != nil {
+		return "", err
+	}
+	if statusCode != http.StatusOK || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to login to vault with role %s, status code %d: %s", c.auth.Role, statusCode, strings.Join(resp.Errors, ", "))
+		return "", re.ErrorCodeAuthDenied.NewError(re.KeyManagementProvider, ProviderName, re.VaultKubernetesAuthLink, nil, fmt.Sprintf("failed to login to vault with role %s, status code %d", c.auth.Role, statusCode), re.HideStackTrace)
+	}
+
+	c.token = resp.Auth.ClientToken
+	c.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration)*time.Second - tokenExpiryDelta)
+	}
+	return c.token, nil
+}
+
+// resetToken discards the cached token unless it was replaced already
+func (c *vaultClient) resetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends the request to the Vault API and returns the status code and the
+// parsed response
+func (c *vaultClient) do(ctx context.Context, method, path string, query url.Values, token string, body []byte) (int, *vaultResponse, error) {
+	requestURL := c.address + "/v1/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, err, fmt.Sprintf("failed to create request for %s", path), re.HideStackTrace)
+	}
+	req.Header.Set("User-Agent", version.UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set(tokenHeader, token)
+	}
+	if c.namespace != "" {
+		req.Header.Set(namespaceHeader, c.namespace)
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, err, fmt.Sprintf("failed to send request for %s to vault", path), re.HideStackTrace)
+	}
+	defer httpResp.Body.Close()
+
+	resp := &vaultResponse{}
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return 0, nil, re.ErrorCodeKeyManagementProviderFailure.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, err, fmt.Sprintf("failed to read response for %s from vault", path), re.HideStackTrace)
+	}
+	if len(respBody) > 0 {
+		// error responses of proxies may not be JSON, the status code is reported instead
+		_ = json.Unmarshal(respBody, resp)
+	}
+	return httpResp.StatusCode, resp, nil
+}
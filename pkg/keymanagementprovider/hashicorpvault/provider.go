@@ -0,0 +1,393 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/types"
+)
+
+const (
+	ProviderName string = "hashicorpvault"
+
+	defaultKVMount      = "secret"
+	defaultTransitMount = "transit"
+	defaultField        = "certificate"
+
+	// key of the certificate status property
+	certificatesStatus = "Certificates"
+	// key of the key status property
+	keysStatus = "Keys"
+	// name of the certificate/key for the status property
+	statusName = "Name"
+	// version of the certificate/key for the status property
+	statusVersion = "Version"
+	// whether the key version can be used for verification for the status property
+	statusEnabled = "Enabled"
+	// last refreshed time for the status property
+	statusLastRefreshed = "LastRefreshed"
+)
+
+var logOpt = logger.Option{
+	ComponentType: logger.KeyManagementProvider,
+}
+
+// Certificate references PEM encoded certificates stored in a KV v2 secret
+type Certificate struct {
+	// Mount of the KV v2 secrets engine. Defaults to secret.
+	Mount string `json:"mount,omitempty"`
+	// Path of the secret in the secrets engine
+	Path string `json:"path"`
+	// Field of the secret holding the certificates. Defaults to certificate.
+	Field string `json:"field,omitempty"`
+	// Version of the secret. Defaults to the latest version.
+	Version string `json:"version,omitempty"`
+}
+
+// Key references an asymmetric key of the Transit secrets engine
+type Key struct {
+	// Mount of the Transit secrets engine. Defaults to transit.
+	Mount string `json:"mount,omitempty"`
+	// Name of the key
+	Name string `json:"name"`
+	// Version of the key. All enabled versions are used if not set.
+	Version string `json:"version,omitempty"`
+	// VersionHistory limits the versions to the latest n versions if set.
+	// Disabled versions count towards the limit.
+	VersionHistory int `json:"versionHistory,omitempty"`
+}
+
+//nolint:revive
+type VaultKMProviderConfig struct {
+	Type string `json:"type"`
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200
+	Address string `json:"address"`
+	// Namespace of Vault Enterprise
+	Namespace string `json:"namespace,omitempty"`
+	// CACert holds the PEM encoded CA certificates of the Vault server
+	CACert       string        `json:"caCert,omitempty"`
+	Auth         AuthConfig    `json:"auth,omitempty"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+	Keys         []Key         `json:"keys,omitempty"`
+}
+
+type vaultKMProvider struct {
+	certificates []Certificate
+	keys         []Key
+	client       *vaultClient
+}
+
+type vaultKMProviderFactory struct{}
+
+// kvSecret is the data of a KV v2 secret
+type kvSecret struct {
+	Data     map[string]interface{} `json:"data"`
+	Metadata struct {
+		Version int `json:"version"`
+	} `json:"metadata"`
+}
+
+// transitKey is the data of a Transit key. The versions of asymmetric keys
+// hold their public keys.
+type transitKey struct {
+	Type                 string                     `json:"type"`
+	LatestVersion        int                        `json:"latest_version"`
+	MinDecryptionVersion int                        `json:"min_decryption_version"`
+	Keys                 map[string]json.RawMessage `json:"keys"`
+}
+
+type transitKeyVersion struct {
+	PublicKey string `json:"public_key"`
+}
+
+// init calls to register the provider
+func init() {
+	factory.Register(ProviderName, &vaultKMProviderFactory{})
+}
+
+// Create creates a new instance of the provider after marshalling and validating the configuration.
+// Namespaced providers are not supported as the provider authenticates to Vault with the
+// credentials of Ratify.
+func (f *vaultKMProviderFactory) Create(_ string, keyManagementProviderConfig config.KeyManagementProviderConfig, _ string) (keymanagementprovider.KeyManagementProvider, error) {
+	if namespace, _ := keyManagementProviderConfig[types.ResourceNamespace].(string); namespace != "" {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.VaultLink, nil, "hashicorpvault is not supported for namespaced key management providers as it authenticates with the credentials of Ratify, use a cluster-wide key management provider instead", re.HideStackTrace)
+	}
+	conf := VaultKMProviderConfig{}
+
+	keyManagementProviderConfigBytes, err := json.Marshal(keyManagementProviderConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithError(err).WithComponentType(re.KeyManagementProvider)
+	}
+
+	if err := json.Unmarshal(keyManagementProviderConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, "", re.EmptyLink, err, "failed to parse hashicorp vault key management provider configuration", re.HideStackTrace)
+	}
+
+	if err := validate(&conf); err != nil {
+		return nil, err
+	}
+
+	client, err := newVaultClient(strings.TrimSpace(conf.Address), conf.Namespace, conf.CACert, conf.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultKMProvider{
+		certificates: conf.Certificates,
+		keys:         conf.Keys,
+		client:       client,
+	}, nil
+}
+
+// GetCertificates returns the certificates of the KV secrets by path and
+// configured version
+func (s *vaultKMProvider) GetCertificates(ctx context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	certsMap := map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}
+	certsStatus := []map[string]string{}
+	for _, cert := range s.certificates {
+		logger.GetLogger(ctx, logOpt).Debugf("fetching secret from vault, path %s, version %s", cert.Path, cert.Version)
+
+		query := url.Values{}
+		if cert.Version != "" {
+			query.Set("version", cert.Version)
+		}
+		secret := kvSecret{}
+		if err := s.client.read(ctx, cert.Mount+"/data/"+cert.Path, query, &secret); err != nil {
+			return nil, nil, err
+		}
+
+		value, ok := secret.Data[cert.Field].(string)
+		if !ok {
+			return nil, nil, re.ErrorCodeCertInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("field %s of secret %s version %d is not a string", cert.Field, cert.Path, secret.Metadata.Version), re.HideStackTrace)
+		}
+		certs, err := keymanagementprovider.DecodeCertificates([]byte(value))
+		if err != nil {
+			return nil, nil, re.ErrorCodeCertInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("failed to decode certificates of secret %s version %d", cert.Path, secret.Metadata.Version), re.HideStackTrace)
+		}
+
+		certsMap[keymanagementprovider.KMPMapKey{Name: cert.Path, Version: cert.Version}] = certs
+		lastRefreshed := time.Now().Format(time.RFC3339)
+		for range certs {
+			certsStatus = append(certsStatus, getStatusProperty(cert.Path, strconv.Itoa(secret.Metadata.Version), lastRefreshed, true))
+		}
+	}
+
+	return certsMap, getStatusMap(certsStatus, certificatesStatus), nil
+}
+
+// GetKeys returns the public keys of the enabled versions of the Transit keys
+// by name and version. Versions below the minimum decryption version of a key
+// are reported as disabled and are not returned.
+func (s *vaultKMProvider) GetKeys(ctx context.Context) (map[keymanagementprovider.KMPMapKey]crypto.PublicKey, keymanagementprovider.KeyManagementProviderStatus, error) {
+	keysMap := map[keymanagementprovider.KMPMapKey]crypto.PublicKey{}
+	keyProperties := []map[string]string{}
+	for _, key := range s.keys {
+		logger.GetLogger(ctx, logOpt).Debugf("fetching key from vault, name %s, version %s", key.Name, key.Version)
+
+		transit := transitKey{}
+		if err := s.client.read(ctx, key.Mount+"/keys/"+key.Name, nil, &transit); err != nil {
+			return nil, nil, err
+		}
+
+		versions, err := selectVersions(key, transit)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lastRefreshed := time.Now().Format(time.RFC3339)
+		enabledCount := 0
+		for _, version := range versions {
+			versionString := strconv.Itoa(version)
+			enabled := version >= transit.MinDecryptionVersion
+			keyProperties = append(keyProperties, getStatusProperty(key.Name, versionString, lastRefreshed, enabled))
+			if !enabled {
+				logger.GetLogger(ctx, logOpt).Debugf("skipping disabled version %d of key %s", version, key.Name)
+				continue
+			}
+
+			publicKey, err := parsePublicKey(transit.Type, transit.Keys[versionString])
+			if err != nil {
+				return nil, nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("failed to parse public key of key %s version %d", key.Name, version), re.HideStackTrace)
+			}
+			keysMap[keymanagementprovider.KMPMapKey{Name: key.Name, Version: versionString}] = publicKey
+			enabledCount++
+		}
+		if enabledCount == 0 {
+			return nil, nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("key %s has no enabled versions, minimum decryption version is %d", key.Name, transit.MinDecryptionVersion), re.HideStackTrace)
+		}
+	}
+
+	return keysMap, getStatusMap(keyProperties, keysStatus), nil
+}
+
+// IsRefreshable returns true as keys may be rotated and secrets updated in Vault
+func (s *vaultKMProvider) IsRefreshable() bool {
+	return true
+}
+
+// selectVersions returns the versions of the key in descending order, limited
+// to the configured version or version history
+func selectVersions(key Key, transit transitKey) ([]int, error) {
+	if key.Version != "" {
+		if _, ok := transit.Keys[key.Version]; !ok {
+			return nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("version %s of key %s not found", key.Version, key.Name), re.HideStackTrace)
+		}
+		// the version is validated to be a number on creation
+		version, _ := strconv.Atoi(key.Version)
+		return []int{version}, nil
+	}
+
+	versions := make([]int, 0, len(transit.Keys))
+	for versionString := range transit.Keys {
+		version, err := strconv.Atoi(versionString)
+		if err != nil {
+			return nil, re.ErrorCodeKeyInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("invalid version %s of key %s", versionString, key.Name), re.HideStackTrace)
+		}
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if key.VersionHistory > 0 && len(versions) > key.VersionHistory {
+		versions = versions[:key.VersionHistory]
+	}
+	return versions, nil
+}
+
+// parsePublicKey parses the public key of a Transit key version. Ed25519 keys
+// are base64 encoded while all other asymmetric keys are PEM encoded.
+func parsePublicKey(keyType string, rawVersion json.RawMessage) (crypto.PublicKey, error) {
+	version := transitKeyVersion{}
+	// versions of symmetric keys are timestamps instead of objects
+	if err := json.Unmarshal(rawVersion, &version); err != nil || version.PublicKey == "" {
+		return nil, fmt.Errorf("key type %s is not an asymmetric key type", keyType)
+	}
+	if keyType == "ed25519" {
+		decoded, err := base64.StdEncoding.DecodeString(version.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key size %d", len(decoded))
+		}
+		return ed25519.PublicKey(decoded), nil
+	}
+	return keymanagementprovider.DecodeKey([]byte(version.PublicKey))
+}
+
+// validate checks the address and auth are set, all certificates/keys are
+// named, and sets the defaults
+func validate(conf *VaultKMProviderConfig) error {
+	if strings.TrimSpace(conf.Address) == "" {
+		return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "address is not set", re.HideStackTrace)
+	}
+	if len(conf.Certificates) == 0 && len(conf.Keys) == 0 {
+		return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, "no vault certificates or keys configured", re.HideStackTrace)
+	}
+
+	switch conf.Auth.Method {
+	case "", KubernetesAuthMethod:
+		conf.Auth.Method = KubernetesAuthMethod
+		if conf.Auth.Role == "" {
+			return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.VaultKubernetesAuthLink, nil, "role is not set for kubernetes auth", re.HideStackTrace)
+		}
+		if conf.Auth.MountPath == "" {
+			conf.Auth.MountPath = defaultKubernetesMount
+		}
+	case TokenAuthMethod:
+	default:
+		return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("auth method %s is not supported, supported methods are %s and %s", conf.Auth.Method, KubernetesAuthMethod, TokenAuthMethod), re.HideStackTrace)
+	}
+
+	// all certificates must have a path
+	for i := range conf.Certificates {
+		cert := &conf.Certificates[i]
+		if cert.Path == "" {
+			return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("path is not set for the %d th certificate", i+1), re.HideStackTrace)
+		}
+		if err := validateVersion(cert.Version, cert.Path); err != nil {
+			return err
+		}
+		if cert.Mount == "" {
+			cert.Mount = defaultKVMount
+		}
+		if cert.Field == "" {
+			cert.Field = defaultField
+		}
+	}
+
+	// all keys must have a name
+	for i := range conf.Keys {
+		key := &conf.Keys[i]
+		if key.Name == "" {
+			return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("name is not set for the %d th key", i+1), re.HideStackTrace)
+		}
+		if err := validateVersion(key.Version, key.Name); err != nil {
+			return err
+		}
+		if key.VersionHistory < 0 {
+			return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, nil, fmt.Sprintf("versionHistory of key %s must not be negative", key.Name), re.HideStackTrace)
+		}
+		if key.Mount == "" {
+			key.Mount = defaultTransitMount
+		}
+	}
+
+	return nil
+}
+
+// validateVersion checks the version of a certificate or key is a positive number if set
+func validateVersion(version, name string) error {
+	if version == "" {
+		return nil
+	}
+	if v, err := strconv.Atoi(version); err != nil || v <= 0 {
+		return re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("version %s of %s is not a positive number", version, name), re.HideStackTrace)
+	}
+	return nil
+}
+
+// vault provider certificate/key status is a map from "Certificates" key or "Keys" key to an array of key management provider status
+func getStatusMap(statusMap []map[string]string, contentType string) keymanagementprovider.KeyManagementProviderStatus {
+	status := keymanagementprovider.KeyManagementProviderStatus{}
+	status[contentType] = statusMap
+	return status
+}
+
+// return a status object that consist of the cert/key name, version, whether it is enabled and last refreshed time
+func getStatusProperty(name, version, lastRefreshed string, enabled bool) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusVersion] = version
+	properties[statusEnabled] = strconv.FormatBool(enabled)
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
@@ -0,0 +1,527 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/types"
+)
+
+const (
+	testRole  = "ratify"
+	testJWT   = "service-account-token"
+	testToken = "vault-token"
+)
+
+// fakeVault is a stand-in for the Vault HTTP API serving a KV v2 secret and
+// Transit keys
+type fakeVault struct {
+	t           *testing.T
+	certPEM     string
+	ecdsaKeys   map[string]string
+	ed25519Key  string
+	logins      atomic.Int32
+	revokeToken atomic.Bool
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	v := &fakeVault{t: t, certPEM: generateCertificate(t), ecdsaKeys: map[string]string{}}
+	for _, version := range []string{"1", "2", "3"} {
+		v.ecdsaKeys[version] = generateECDSAKey(t)
+	}
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	v.ed25519Key = base64.StdEncoding.EncodeToString(publicKey)
+	server := httptest.NewServer(http.HandlerFunc(v.serveHTTP))
+	t.Cleanup(server.Close)
+	return v, server
+}
+
+func (v *fakeVault) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		body := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["role"] != testRole || body["jwt"] != testJWT {
+			writeResponse(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or jwt"}})
+			return
+		}
+		v.logins.Add(1)
+		writeResponse(w, http.StatusOK, map[string]interface{}{"auth": map[string]interface{}{"client_token": testToken, "lease_duration": 3600}})
+		return
+	}
+	if r.Header.Get(tokenHeader) != testToken || v.revokeToken.CompareAndSwap(true, false) {
+		writeResponse(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/secret/data/ratify/notation":
+		version := 4
+		if r.URL.Query().Get("version") == "2" {
+			version = 2
+		}
+		writeResponse(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"data":     map[string]interface{}{"certificate": v.certPEM, "count": 1},
+			"metadata": map[string]interface{}{"version": version},
+		}})
+	case "/v1/transit/keys/cosign":
+		keys := map[string]interface{}{}
+		for version, key := range v.ecdsaKeys {
+			keys[version] = map[string]interface{}{"public_key": key, "name": "P-256"}
+		}
+		writeResponse(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"type": "ecdsa-p256", "latest_version": 3, "min_decryption_version": 2, "keys": keys,
+		}})
+	case "/v1/transit/keys/ed25519":
+		writeResponse(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"type": "ed25519", "latest_version": 1, "min_decryption_version": 1, "keys": map[string]interface{}{"1": map[string]interface{}{"public_key": v.ed25519Key}},
+		}})
+	case "/v1/transit/keys/aes":
+		writeResponse(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"type": "aes256-gcm96", "latest_version": 1, "min_decryption_version": 1, "keys": map[string]interface{}{"1": 1700000000},
+		}})
+	default:
+		writeResponse(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func generateCertificate(t *testing.T) string {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ratify.test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+}
+
+func generateECDSAKey(t *testing.T) string {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}))
+}
+
+// writeJWT writes the service account token used for kubernetes auth
+func writeJWT(t *testing.T) {
+	original := serviceAccountTokenPath
+	t.Cleanup(func() { serviceAccountTokenPath = original })
+	serviceAccountTokenPath = filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(serviceAccountTokenPath, []byte(testJWT+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+}
+
+// TestCreate tests the Create function
+func TestCreate(t *testing.T) {
+	factory := &vaultKMProviderFactory{}
+	testCases := []struct {
+		name      string
+		config    config.KeyManagementProviderConfig
+		namespace string
+		expectErr bool
+	}{
+		{
+			name: "valid kubernetes auth",
+			config: config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"address":      "https://vault.vault.svc:8200",
+				"auth":         map[string]interface{}{"role": testRole},
+				"certificates": []interface{}{map[string]interface{}{"path": "ratify/notation"}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "valid token auth",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"auth":    map[string]interface{}{"method": "token"},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign", "versionHistory": 2}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "namespaced provider",
+			config: config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"address":      "https://vault.vault.svc:8200",
+				"auth":         map[string]interface{}{"role": testRole},
+				"certificates": []interface{}{map[string]interface{}{"path": "ratify/notation"}},
+			},
+			namespace: "default",
+			expectErr: true,
+		},
+		{
+			name: "address not set",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"auth": map[string]interface{}{"role": testRole},
+				"keys": []interface{}{map[string]interface{}{"name": "cosign"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid address",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "vault",
+				"auth":    map[string]interface{}{"role": testRole},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no certificates or keys",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"auth":    map[string]interface{}{"role": testRole},
+			},
+			expectErr: true,
+		},
+		{
+			name: "role not set",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unsupported auth method",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"auth":    map[string]interface{}{"method": "approle"},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "certificate path not set",
+			config: config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"address":      "https://vault.vault.svc:8200",
+				"auth":         map[string]interface{}{"role": testRole},
+				"certificates": []interface{}{map[string]interface{}{"field": "ca"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid key version",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"auth":    map[string]interface{}{"role": testRole},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign", "version": "latest"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative version history",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"auth":    map[string]interface{}{"role": testRole},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign", "versionHistory": -1}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid ca cert",
+			config: config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": "https://vault.vault.svc:8200",
+				"caCert":  "invalid",
+				"auth":    map[string]interface{}{"role": testRole},
+				"keys":    []interface{}{map[string]interface{}{"name": "cosign"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.namespace != "" {
+				tc.config[types.ResourceNamespace] = tc.namespace
+			}
+			_, err := factory.Create("v1", tc.config, "")
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestGetCertificates tests the GetCertificates function
+func TestGetCertificates(t *testing.T) {
+	vault, server := newFakeVault(t)
+	writeJWT(t)
+	provider, err := (&vaultKMProviderFactory{}).Create("v1", config.KeyManagementProviderConfig{
+		"type":    ProviderName,
+		"address": server.URL,
+		"auth":    map[string]interface{}{"role": testRole},
+		"certificates": []interface{}{
+			map[string]interface{}{"path": "ratify/notation"},
+			map[string]interface{}{"path": "ratify/notation", "version": "2"},
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	certs, status, err := provider.GetCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(certs) != 2 || len(certs[keymanagementprovider.KMPMapKey{Name: "ratify/notation"}]) != 1 || len(certs[keymanagementprovider.KMPMapKey{Name: "ratify/notation", Version: "2"}]) != 1 {
+		t.Fatalf("expected certificates of the latest version and version 2, got %v", certs)
+	}
+	properties := status[certificatesStatus].([]map[string]string)
+	if len(properties) != 2 || properties[0][statusVersion] != "4" || properties[1][statusVersion] != "2" {
+		t.Fatalf("unexpected certificate status %v", properties)
+	}
+	if vault.logins.Load() != 1 {
+		t.Fatalf("expected the token to be cached, got %d logins", vault.logins.Load())
+	}
+
+	// a revoked token is replaced by logging in again
+	vault.revokeToken.Store(true)
+	if _, _, err := provider.GetCertificates(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if vault.logins.Load() != 2 {
+		t.Fatalf("expected to login again after the token was revoked, got %d logins", vault.logins.Load())
+	}
+}
+
+// TestGetCertificates_Errors tests the errors of the GetCertificates function
+func TestGetCertificates_Errors(t *testing.T) {
+	_, server := newFakeVault(t)
+	writeJWT(t)
+	t.Setenv(tokenEnvKey, "")
+	testCases := []struct {
+		name      string
+		auth      map[string]interface{}
+		cert      map[string]interface{}
+		tokenPath string
+	}{
+		{
+			name: "login failed",
+			auth: map[string]interface{}{"role": "other"},
+			cert: map[string]interface{}{"path": "ratify/notation"},
+		},
+		{
+			name:      "token file not found",
+			auth:      map[string]interface{}{"role": testRole},
+			cert:      map[string]interface{}{"path": "ratify/notation"},
+			tokenPath: filepath.Join(t.TempDir(), "missing"),
+		},
+		{
+			name: "vault token not set",
+			auth: map[string]interface{}{"method": "token"},
+			cert: map[string]interface{}{"path": "ratify/notation"},
+		},
+		{
+			name: "secret not found",
+			auth: map[string]interface{}{"role": testRole},
+			cert: map[string]interface{}{"path": "ratify/missing"},
+		},
+		{
+			name: "field not found",
+			auth: map[string]interface{}{"role": testRole},
+			cert: map[string]interface{}{"path": "ratify/notation", "field": "ca"},
+		},
+		{
+			name: "field is not a certificate",
+			auth: map[string]interface{}{"role": testRole},
+			cert: map[string]interface{}{"path": "ratify/notation", "field": "count"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.tokenPath != "" {
+				original := serviceAccountTokenPath
+				t.Cleanup(func() { serviceAccountTokenPath = original })
+				serviceAccountTokenPath = tc.tokenPath
+			}
+			provider, err := (&vaultKMProviderFactory{}).Create("v1", config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"address":      server.URL,
+				"auth":         tc.auth,
+				"certificates": []interface{}{tc.cert},
+			}, "")
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+			_, _, err = provider.GetCertificates(context.Background())
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			// the errors of vault responses are not reported in the status
+			if strings.Contains(err.Error(), "permission denied") || strings.Contains(err.Error(), "invalid role or jwt") {
+				t.Fatalf("expected the errors of vault not to be reported, got %v", err)
+			}
+		})
+	}
+}
+
+// TestGetKeys tests the GetKeys function
+func TestGetKeys(t *testing.T) {
+	_, server := newFakeVault(t)
+	t.Setenv(tokenEnvKey, testToken)
+	testCases := []struct {
+		name             string
+		key              map[string]interface{}
+		expectedVersions []string
+		expectedStatus   map[string]string
+		expectErr        bool
+	}{
+		{
+			name:             "all enabled versions",
+			key:              map[string]interface{}{"name": "cosign"},
+			expectedVersions: []string{"3", "2"},
+			expectedStatus:   map[string]string{"3": "true", "2": "true", "1": "false"},
+		},
+		{
+			name:             "version history",
+			key:              map[string]interface{}{"name": "cosign", "versionHistory": 1},
+			expectedVersions: []string{"3"},
+			expectedStatus:   map[string]string{"3": "true"},
+		},
+		{
+			name:             "version",
+			key:              map[string]interface{}{"name": "cosign", "version": "2"},
+			expectedVersions: []string{"2"},
+			expectedStatus:   map[string]string{"2": "true"},
+		},
+		{
+			name:             "ed25519",
+			key:              map[string]interface{}{"name": "ed25519"},
+			expectedVersions: []string{"1"},
+			expectedStatus:   map[string]string{"1": "true"},
+		},
+		{
+			name:      "disabled version",
+			key:       map[string]interface{}{"name": "cosign", "version": "1"},
+			expectErr: true,
+		},
+		{
+			name:      "version not found",
+			key:       map[string]interface{}{"name": "cosign", "version": "4"},
+			expectErr: true,
+		},
+		{
+			name:      "symmetric key",
+			key:       map[string]interface{}{"name": "aes"},
+			expectErr: true,
+		},
+		{
+			name:      "key not found",
+			key:       map[string]interface{}{"name": "missing"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := (&vaultKMProviderFactory{}).Create("v1", config.KeyManagementProviderConfig{
+				"type":    ProviderName,
+				"address": server.URL,
+				"auth":    map[string]interface{}{"method": "token"},
+				"keys":    []interface{}{tc.key},
+			}, "")
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+			keys, status, err := provider.GetKeys(context.Background())
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(keys) != len(tc.expectedVersions) {
+				t.Fatalf("expected versions %v, got %v", tc.expectedVersions, keys)
+			}
+			for _, version := range tc.expectedVersions {
+				if keys[keymanagementprovider.KMPMapKey{Name: tc.key["name"].(string), Version: version}] == nil {
+					t.Fatalf("expected version %s, got %v", version, keys)
+				}
+			}
+			properties := status[keysStatus].([]map[string]string)
+			enabled := map[string]string{}
+			for _, property := range properties {
+				enabled[property[statusVersion]] = property[statusEnabled]
+			}
+			if len(enabled) != len(tc.expectedStatus) {
+				t.Fatalf("expected status %v, got %v", tc.expectedStatus, properties)
+			}
+			for version, expected := range tc.expectedStatus {
+				if enabled[version] != expected {
+					t.Fatalf("expected status %v, got %v", tc.expectedStatus, properties)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetessecret
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/factory"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/types"
+	"github.com/ratify-project/ratify/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ProviderName is the type of the key management provider
+	ProviderName string = "kubernetesSecret"
+	// SecretKind is the kind of references to Secrets
+	SecretKind string = "Secret"
+	// ConfigMapKind is the kind of references to ConfigMaps
+	ConfigMapKind string = "ConfigMap"
+
+	// key of the certificate status property
+	certificatesStatus = "Certificates"
+	// key of the key status property
+	keysStatus = "Keys"
+	// name of the certificate/key for the status property
+	statusName = "Name"
+	// resource version of the Secret/ConfigMap for the status property
+	statusVersion = "Version"
+	// last refreshed time for the status property
+	statusLastRefreshed = "LastRefreshed"
+)
+
+var logOpt = logger.Option{
+	ComponentType: logger.KeyManagementProvider,
+}
+
+// ObjectReference references the data of Secrets or ConfigMaps holding PEM
+// encoded certificates or public keys.
+type ObjectReference struct {
+	// Kind is Secret or ConfigMap. Defaults to Secret.
+	Kind string `json:"kind,omitempty"`
+	// Name of the object. Exactly one of Name and LabelSelector must be set.
+	Name string `json:"name,omitempty"`
+	// LabelSelector selects the objects by label, e.g. "ratify.dev/trust=cosign".
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// Key of the data of the object. All keys holding a certificate or key
+	// are used if not set.
+	Key string `json:"key,omitempty"`
+}
+
+//nolint:revive
+type KubernetesSecretKMProviderConfig struct {
+	Type string `json:"type"`
+	// Namespace of the objects. Defaults to the namespace of Ratify, the
+	// objects of namespaced providers must be in their own namespace.
+	Namespace    string            `json:"namespace,omitempty"`
+	Certificates []ObjectReference `json:"certificates,omitempty"`
+	Keys         []ObjectReference `json:"keys,omitempty"`
+}
+
+type kubernetesSecretKMProvider struct {
+	namespace    string
+	certificates []ObjectReference
+	keys         []ObjectReference
+	clientSet    kubernetes.Interface
+}
+
+type kubernetesSecretKMProviderFactory struct{}
+
+// object is a Secret or ConfigMap
+type object struct {
+	name            string
+	resourceVersion string
+	data            map[string][]byte
+}
+
+// newClientSet creates the client of the cluster Ratify runs in
+var newClientSet = func() (kubernetes.Interface, error) {
+	clusterConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(clusterConfig)
+}
+
+// init calls to register the provider
+func init() {
+	factory.Register(ProviderName, &kubernetesSecretKMProviderFactory{})
+}
+
+// Create creates a new instance of the kubernetesSecret key management
+// provider. The referenced objects are fetched on each refresh.
+func (f *kubernetesSecretKMProviderFactory) Create(_ string, keyManagementProviderConfig config.KeyManagementProviderConfig, _ string) (keymanagementprovider.KeyManagementProvider, error) {
+	keyManagementProviderConfigBytes, err := json.Marshal(keyManagementProviderConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithError(err).WithComponentType(re.KeyManagementProvider)
+	}
+	namespace, _ := keyManagementProviderConfig[types.ResourceNamespace].(string)
+	conf, err := parseConfig(keyManagementProviderConfigBytes, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.Certificates) == 0 && len(conf.Keys) == 0 {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.KeyManagementProvider).WithDetail("no certificates or keys configured")
+	}
+
+	clientSet, err := newClientSet()
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, "failed to create kubernetes client set", re.HideStackTrace)
+	}
+
+	return &kubernetesSecretKMProvider{
+		namespace:    conf.Namespace,
+		certificates: conf.Certificates,
+		keys:         conf.Keys,
+		clientSet:    clientSet,
+	}, nil
+}
+
+// References returns true if the key management provider with the given
+// parameters references the Secret or ConfigMap, so that it is refreshed
+// once the object changes. namespace is the namespace of a namespaced key
+// management provider and empty otherwise.
+func References(parameters []byte, namespace string, kind string, obj metav1.Object) bool {
+	conf, err := parseConfig(parameters, namespace)
+	if err != nil || conf.Namespace != obj.GetNamespace() {
+		return false
+	}
+	for _, ref := range append(conf.Certificates, conf.Keys...) {
+		if ref.Kind != kind {
+			continue
+		}
+		if ref.Name != "" && ref.Name == obj.GetName() {
+			return true
+		}
+		if ref.LabelSelector != "" {
+			// the selector is validated by parseConfig
+			selector, _ := labels.Parse(ref.LabelSelector)
+			if selector.Matches(labels.Set(obj.GetLabels())) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetCertificates returns the certificates of the referenced objects by
+// "<object name>/<data key>"
+func (s *kubernetesSecretKMProvider) GetCertificates(ctx context.Context) (map[keymanagementprovider.KMPMapKey][]*x509.Certificate, keymanagementprovider.KeyManagementProviderStatus, error) {
+	certsMap := map[keymanagementprovider.KMPMapKey][]*x509.Certificate{}
+	certsStatus, err := s.decodeValues(ctx, s.certificates, func(name string, value []byte) error {
+		certs, err := keymanagementprovider.DecodeCertificates(value)
+		if err != nil {
+			return re.ErrorCodeCertInvalid.WithComponentType(re.KeyManagementProvider).WithDetail(fmt.Sprintf("failed to decode certificates of %s", name)).WithError(err)
+		}
+		certsMap[keymanagementprovider.KMPMapKey{Name: name}] = certs
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return certsMap, getStatusMap(certsStatus, certificatesStatus), nil
+}
+
+// GetKeys returns the public keys of the referenced objects by
+// "<object name>/<data key>"
+func (s *kubernetesSecretKMProvider) GetKeys(ctx context.Context) (map[keymanagementprovider.KMPMapKey]crypto.PublicKey, keymanagementprovider.KeyManagementProviderStatus, error) {
+	keysMap := map[keymanagementprovider.KMPMapKey]crypto.PublicKey{}
+	status, err := s.decodeValues(ctx, s.keys, func(name string, value []byte) error {
+		key, err := keymanagementprovider.DecodeKey(value)
+		if err != nil {
+			return re.ErrorCodeKeyInvalid.WithComponentType(re.KeyManagementProvider).WithDetail(fmt.Sprintf("failed to decode public key of %s", name)).WithError(err)
+		}
+		keysMap[keymanagementprovider.KMPMapKey{Name: name}] = key
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return keysMap, getStatusMap(status, keysStatus), nil
+}
+
+// IsRefreshable returns true as the referenced objects may change. Changes
+// are also picked up by the key management provider controllers watching
+// Secrets and ConfigMaps.
+func (s *kubernetesSecretKMProvider) IsRefreshable() bool {
+	return true
+}
+
+// decodeValues decodes the values of the data keys of the referenced
+// objects and returns their status. Values which fail to decode are skipped
+// if the reference has no key, so that e.g. the private key of a TLS Secret
+// is ignored.
+func (s *kubernetesSecretKMProvider) decodeValues(ctx context.Context, refs []ObjectReference, decode func(name string, value []byte) error) ([]map[string]string, error) {
+	status := []map[string]string{}
+	for _, ref := range refs {
+		objects, err := s.getObjects(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		decoded := 0
+		for _, obj := range objects {
+			keys := []string{ref.Key}
+			if ref.Key == "" {
+				keys = make([]string, 0, len(obj.data))
+				for key := range obj.data {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+			} else if _, ok := obj.data[ref.Key]; !ok {
+				return nil, re.ErrorCodeKeyManagementProviderFailure.WithDetail(fmt.Sprintf("key %s not found in %s %s/%s", ref.Key, ref.Kind, s.namespace, obj.name))
+			}
+
+			for _, key := range keys {
+				name := obj.name + "/" + key
+				if err := decode(name, obj.data[key]); err != nil {
+					if ref.Key != "" {
+						return nil, err
+					}
+					logger.GetLogger(ctx, logOpt).Debugf("skipping %s %s/%s: %v", ref.Kind, s.namespace, name, err)
+					continue
+				}
+				decoded++
+				status = append(status, getStatusProperty(name, obj.resourceVersion, time.Now().Format(time.RFC3339)))
+			}
+		}
+		if decoded == 0 {
+			return nil, re.ErrorCodeKeyManagementProviderFailure.WithDetail(fmt.Sprintf("no certificates or keys found in %s", describe(ref, s.namespace)))
+		}
+	}
+	return status, nil
+}
+
+// getObjects returns the objects referenced by name or label selector
+func (s *kubernetesSecretKMProvider) getObjects(ctx context.Context, ref ObjectReference) ([]object, error) {
+	var objects []object
+	var err error
+	switch {
+	case ref.Kind == SecretKind && ref.Name != "":
+		secret, getErr := s.clientSet.CoreV1().Secrets(s.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			objects = append(objects, object{name: secret.Name, resourceVersion: secret.ResourceVersion, data: secret.Data})
+		}
+	case ref.Kind == SecretKind:
+		secrets, listErr := s.clientSet.CoreV1().Secrets(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.LabelSelector})
+		if err = listErr; err == nil {
+			for _, secret := range secrets.Items {
+				objects = append(objects, object{name: secret.Name, resourceVersion: secret.ResourceVersion, data: secret.Data})
+			}
+		}
+	case ref.Name != "":
+		configMap, getErr := s.clientSet.CoreV1().ConfigMaps(s.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			objects = append(objects, object{name: configMap.Name, resourceVersion: configMap.ResourceVersion, data: configMapData(configMap.Data, configMap.BinaryData)})
+		}
+	default:
+		configMaps, listErr := s.clientSet.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.LabelSelector})
+		if err = listErr; err == nil {
+			for _, configMap := range configMaps.Items {
+				objects = append(objects, object{name: configMap.Name, resourceVersion: configMap.ResourceVersion, data: configMapData(configMap.Data, configMap.BinaryData)})
+			}
+		}
+	}
+	if err != nil {
+		return nil, re.ErrorCodeGetClusterResourceFailure.NewError(re.KeyManagementProvider, ProviderName, re.EmptyLink, err, fmt.Sprintf("failed to get %s", describe(ref, s.namespace)), re.HideStackTrace)
+	}
+	return objects, nil
+}
+
+// parseConfig parses the config of the provider and sets the defaults. The
+// objects of namespaced providers are restricted to their namespace so that
+// they cannot read the Secrets of other namespaces.
+func parseConfig(configBytes []byte, namespace string) (KubernetesSecretKMProviderConfig, error) {
+	conf := KubernetesSecretKMProviderConfig{}
+	if err := json.Unmarshal(configBytes, &conf); err != nil {
+		return conf, re.ErrorCodeConfigInvalid.NewError(re.KeyManagementProvider, "", re.EmptyLink, err, "failed to parse kubernetesSecret key management provider configuration", re.HideStackTrace)
+	}
+	switch {
+	case namespace == "" && conf.Namespace == "":
+		conf.Namespace = utils.GetNamespace()
+	case namespace != "" && conf.Namespace != "" && conf.Namespace != namespace:
+		return conf, re.ErrorCodeConfigInvalid.WithComponentType(re.KeyManagementProvider).WithDetail(fmt.Sprintf("namespace %s is not allowed, namespaced key management providers can only reference objects in their namespace %s", conf.Namespace, namespace))
+	case namespace != "":
+		conf.Namespace = namespace
+	}
+	for _, refs := range [][]ObjectReference{conf.Certificates, conf.Keys} {
+		for i := range refs {
+			ref := &refs[i]
+			if ref.Kind == "" {
+				ref.Kind = SecretKind
+			}
+			if ref.Kind != SecretKind && ref.Kind != ConfigMapKind {
+				return conf, re.ErrorCodeConfigInvalid.WithComponentType(re.KeyManagementProvider).WithDetail(fmt.Sprintf("kind %s is not supported, supported kinds are %s and %s", ref.Kind, SecretKind, ConfigMapKind))
+			}
+			if (ref.Name == "") == (ref.LabelSelector == "") {
+				return conf, re.ErrorCodeConfigInvalid.WithComponentType(re.KeyManagementProvider).WithDetail("exactly one of name and labelSelector must be set")
+			}
+			if ref.LabelSelector != "" {
+				if _, err := labels.Parse(ref.LabelSelector); err != nil {
+					return conf, re.ErrorCodeConfigInvalid.WithComponentType(re.KeyManagementProvider).WithDetail(fmt.Sprintf("labelSelector %s is not valid", ref.LabelSelector)).WithError(err)
+				}
+			}
+		}
+	}
+	return conf, nil
+}
+
+// configMapData merges the data and binary data of a ConfigMap
+func configMapData(data map[string]string, binaryData map[string][]byte) map[string][]byte {
+	merged := make(map[string][]byte, len(data)+len(binaryData))
+	for key, value := range data {
+		merged[key] = []byte(value)
+	}
+	for key, value := range binaryData {
+		merged[key] = value
+	}
+	return merged
+}
+
+// describe returns a description of the reference for errors
+func describe(ref ObjectReference, namespace string) string {
+	if ref.Name != "" {
+		return fmt.Sprintf("%s %s/%s", ref.Kind, namespace, ref.Name)
+	}
+	return fmt.Sprintf("%ss in namespace %s with labels %s", ref.Kind, namespace, ref.LabelSelector)
+}
+
+// the status of the provider is a map from "Certificates" key or "Keys" key to an array of certificate/key status
+func getStatusMap(statusMap []map[string]string, contentType string) keymanagementprovider.KeyManagementProviderStatus {
+	status := keymanagementprovider.KeyManagementProviderStatus{}
+	status[contentType] = statusMap
+	return status
+}
+
+// return a status object that consist of the cert/key name, resource version of its object and last refreshed time
+func getStatusProperty(name, version, lastRefreshed string) map[string]string {
+	properties := map[string]string{}
+	properties[statusName] = name
+	properties[statusVersion] = version
+	properties[statusLastRefreshed] = lastRefreshed
+	return properties
+}
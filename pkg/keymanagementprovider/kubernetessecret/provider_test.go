@@ -0,0 +1,330 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetessecret
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/config"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider/types"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "gatekeeper-system"
+
+// generateTestPEMs returns a PEM encoded self-signed certificate, its public
+// key and its private key
+func generateTestPEMs(t *testing.T) (certPEM, keyPEM, privateKeyPEM []byte) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ratify.test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	privateKeyDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateKeyDER})
+}
+
+// TestCreate tests the Create method
+func TestCreate(t *testing.T) {
+	newClientSet = func() (kubernetes.Interface, error) {
+		return fake.NewSimpleClientset(), nil
+	}
+	cases := []struct {
+		desc        string
+		config      config.KeyManagementProviderConfig
+		namespace   string
+		expectedErr bool
+	}{
+		{
+			desc:        "no certificates or keys",
+			config:      config.KeyManagementProviderConfig{"type": ProviderName},
+			expectedErr: true,
+		},
+		{
+			desc: "unsupported kind",
+			config: config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"certificates": []interface{}{map[string]interface{}{"kind": "Pod", "name": "certs"}},
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "name and label selector",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"name": "keys", "labelSelector": "app=ratify"}},
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "invalid label selector",
+			config: config.KeyManagementProviderConfig{
+				"type": ProviderName,
+				"keys": []interface{}{map[string]interface{}{"labelSelector": "app in ("}},
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "valid config",
+			config: config.KeyManagementProviderConfig{
+				"type":         ProviderName,
+				"certificates": []interface{}{map[string]interface{}{"name": "certs", "key": "ca.crt"}},
+				"keys":         []interface{}{map[string]interface{}{"kind": ConfigMapKind, "labelSelector": "ratify.dev/trust=cosign"}},
+			},
+			expectedErr: false,
+		},
+		{
+			desc: "namespaced provider in its namespace",
+			config: config.KeyManagementProviderConfig{
+				"type":      ProviderName,
+				"namespace": "default",
+				"keys":      []interface{}{map[string]interface{}{"name": "keys"}},
+			},
+			namespace:   "default",
+			expectedErr: false,
+		},
+		{
+			desc: "namespaced provider in another namespace",
+			config: config.KeyManagementProviderConfig{
+				"type":      ProviderName,
+				"namespace": testNamespace,
+				"keys":      []interface{}{map[string]interface{}{"name": "keys"}},
+			},
+			namespace:   "default",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			factory := &kubernetesSecretKMProviderFactory{}
+			if tc.namespace != "" {
+				tc.config[types.ResourceNamespace] = tc.namespace
+			}
+			provider, err := factory.Create("v1.0", tc.config, "")
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && tc.namespace != "" && provider.(*kubernetesSecretKMProvider).namespace != tc.namespace {
+				t.Fatalf("expected namespace %s, got %s", tc.namespace, provider.(*kubernetesSecretKMProvider).namespace)
+			}
+		})
+	}
+}
+
+// TestGetCertificatesAndKeys tests the GetCertificates and GetKeys methods
+func TestGetCertificatesAndKeys(t *testing.T) {
+	certPEM, keyPEM, privateKeyPEM := generateTestPEMs(t)
+	clientSet := fake.NewSimpleClientset(
+		&core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: testNamespace, ResourceVersion: "7"},
+			Data:       map[string][]byte{"tls.crt": certPEM, "tls.key": privateKeyPEM},
+		},
+		&core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cosign-a", Namespace: testNamespace, Labels: map[string]string{"ratify.dev/trust": "cosign"}},
+			Data:       map[string]string{"cosign.pub": string(keyPEM)},
+		},
+		&core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cosign-b", Namespace: testNamespace, Labels: map[string]string{"ratify.dev/trust": "cosign"}},
+			BinaryData: map[string][]byte{"cosign.pub": keyPEM},
+		},
+		&core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace},
+			Data:       map[string]string{"cosign.pub": "invalid"},
+		},
+	)
+	provider := &kubernetesSecretKMProvider{
+		namespace:    testNamespace,
+		certificates: []ObjectReference{{Kind: SecretKind, Name: "tls"}},
+		keys:         []ObjectReference{{Kind: ConfigMapKind, LabelSelector: "ratify.dev/trust=cosign", Key: "cosign.pub"}},
+		clientSet:    clientSet,
+	}
+
+	certs, certStatus, err := provider.GetCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("GetCertificates() unexpected error: %v", err)
+	}
+	if len(certs) != 1 || len(certs[keymanagementprovider.KMPMapKey{Name: "tls/tls.crt"}]) != 1 {
+		t.Fatalf("expected the certificate of tls/tls.crt, got %v", certs)
+	}
+	certProperties := certStatus[certificatesStatus].([]map[string]string)
+	if len(certProperties) != 1 || certProperties[0][statusName] != "tls/tls.crt" || certProperties[0][statusVersion] != "7" {
+		t.Fatalf("unexpected certificate status %v", certStatus)
+	}
+
+	keys, keyStatus, err := provider.GetKeys(context.Background())
+	if err != nil {
+		t.Fatalf("GetKeys() unexpected error: %v", err)
+	}
+	for _, name := range []string{"cosign-a/cosign.pub", "cosign-b/cosign.pub"} {
+		if _, ok := keys[keymanagementprovider.KMPMapKey{Name: name}]; !ok {
+			t.Fatalf("expected key %s, got %v", name, keys)
+		}
+	}
+	if len(keys) != 2 || len(keyStatus[keysStatus].([]map[string]string)) != 2 {
+		t.Fatalf("unexpected keys %v with status %v", keys, keyStatus)
+	}
+}
+
+// TestGetKeys_Errors tests the errors of the GetKeys method
+func TestGetKeys_Errors(t *testing.T) {
+	_, keyPEM, _ := generateTestPEMs(t)
+	clientSet := fake.NewSimpleClientset(
+		&core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: testNamespace},
+			Data:       map[string][]byte{"cosign.pub": keyPEM, "invalid.pub": []byte("invalid")},
+		},
+	)
+	cases := []struct {
+		desc string
+		ref  ObjectReference
+	}{
+		{
+			desc: "object not found",
+			ref:  ObjectReference{Kind: SecretKind, Name: "missing"},
+		},
+		{
+			desc: "key not found",
+			ref:  ObjectReference{Kind: SecretKind, Name: "keys", Key: "missing.pub"},
+		},
+		{
+			desc: "invalid key",
+			ref:  ObjectReference{Kind: SecretKind, Name: "keys", Key: "invalid.pub"},
+		},
+		{
+			desc: "no objects selected",
+			ref:  ObjectReference{Kind: SecretKind, LabelSelector: "app=missing"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			provider := &kubernetesSecretKMProvider{namespace: testNamespace, keys: []ObjectReference{tc.ref}, clientSet: clientSet}
+			if _, _, err := provider.GetKeys(context.Background()); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+
+	// invalid values are skipped if the reference has no key
+	provider := &kubernetesSecretKMProvider{namespace: testNamespace, keys: []ObjectReference{{Kind: SecretKind, Name: "keys"}}, clientSet: clientSet}
+	keys, _, err := provider.GetKeys(context.Background())
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected the valid key only, got %v with error %v", keys, err)
+	}
+}
+
+// TestReferences tests the References function
+func TestReferences(t *testing.T) {
+	parameters := []byte(`{
+		"namespace": "gatekeeper-system",
+		"certificates": [{"name": "certs"}],
+		"keys": [{"kind": "ConfigMap", "labelSelector": "ratify.dev/trust=cosign"}]
+	}`)
+	cases := []struct {
+		desc       string
+		parameters []byte
+		namespace  string
+		kind       string
+		obj        metav1.Object
+		expected   bool
+	}{
+		{
+			desc:     "secret by name",
+			kind:     SecretKind,
+			obj:      &metav1.ObjectMeta{Name: "certs", Namespace: testNamespace},
+			expected: true,
+		},
+		{
+			desc:     "configmap with the name of a secret",
+			kind:     ConfigMapKind,
+			obj:      &metav1.ObjectMeta{Name: "certs", Namespace: testNamespace},
+			expected: false,
+		},
+		{
+			desc:     "secret in another namespace",
+			kind:     SecretKind,
+			obj:      &metav1.ObjectMeta{Name: "certs", Namespace: "default"},
+			expected: false,
+		},
+		{
+			desc:     "configmap by label",
+			kind:     ConfigMapKind,
+			obj:      &metav1.ObjectMeta{Name: "keys", Namespace: testNamespace, Labels: map[string]string{"ratify.dev/trust": "cosign"}},
+			expected: true,
+		},
+		{
+			desc:     "configmap without label",
+			kind:     ConfigMapKind,
+			obj:      &metav1.ObjectMeta{Name: "keys", Namespace: testNamespace},
+			expected: false,
+		},
+		{
+			desc:       "secret in the namespace of a namespaced provider",
+			parameters: []byte(`{"certificates": [{"name": "certs"}]}`),
+			namespace:  "default",
+			kind:       SecretKind,
+			obj:        &metav1.ObjectMeta{Name: "certs", Namespace: "default"},
+			expected:   true,
+		},
+		{
+			desc:      "secret in another namespace than a namespaced provider",
+			namespace: "default",
+			kind:      SecretKind,
+			obj:       &metav1.ObjectMeta{Name: "certs", Namespace: testNamespace},
+			expected:  false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.parameters == nil {
+				tc.parameters = parameters
+			}
+			if actual := References(tc.parameters, tc.namespace, tc.kind, tc.obj); actual != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -20,4 +20,7 @@ const (
 	Version     string = "version"
 	Type        string = "type"
 	Source      string = "source"
+	// ResourceNamespace is set in the config of namespaced key management
+	// providers to the namespace of their resource
+	ResourceNamespace string = "resourceNamespace"
 )
@@ -33,6 +33,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/featureflag"
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/configpolicy" // register config policy provider
 	_ "github.com/ratify-project/ratify/pkg/policyprovider/regopolicy"   // register rego policy provider
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/ocilayout"     // register OCI layout referrer store
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"          // register ORAS referrer store
 	"github.com/ratify-project/ratify/pkg/utils"
 	_ "github.com/ratify-project/ratify/pkg/verifier/notation" // register notation verifier
@@ -50,8 +51,10 @@ import (
 	configv1beta1 "github.com/ratify-project/ratify/api/v1beta1"
 	ctxUtils "github.com/ratify-project/ratify/internal/context"
 	"github.com/ratify-project/ratify/pkg/controllers"
+	"github.com/ratify-project/ratify/pkg/controllers/audit"
 	"github.com/ratify-project/ratify/pkg/controllers/clusterresource"
 	"github.com/ratify-project/ratify/pkg/controllers/namespaceresource"
+	"github.com/ratify-project/ratify/pkg/controllers/prewarm"
 	ef "github.com/ratify-project/ratify/pkg/executor/core"
 	//+kubebuilder:scaffold:imports
 )
@@ -74,7 +77,7 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
-func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile string, cacheTTL time.Duration, metricsEnabled bool, metricsType string, metricsPort int, certRotatorReady chan struct{}) {
+func StartServer(httpServerAddress, grpcServerAddress, configFilePath, certDirectory, caCertFile string, webhookAllowList []string, cacheTTL time.Duration, metricsEnabled bool, metricsType string, metricsPort int, prewarmer *prewarm.Prewarmer, certRotatorReady chan struct{}) {
 	logrus.Info("initializing executor with config file at default config path")
 
 	cf, err := config.Load(configFilePath)
@@ -84,7 +87,28 @@ func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile st
 	}
 
 	// initialize server
-	server, err := httpserver.NewServer(context.Background(), httpServerAddress, func(ctx context.Context) *ef.Executor {
+	server, err := httpserver.NewServer(context.Background(), httpServerAddress, newExecutorGetter(cf), certDirectory, caCertFile, cacheTTL, metricsEnabled, metricsType, metricsPort)
+
+	if err != nil {
+		logrus.Errorf("initialize server failed with error %v, exiting..", err)
+		os.Exit(1)
+	}
+	server.GRPCAddress = grpcServerAddress
+	server.WebhookAllowList = webhookAllowList
+	if prewarmer != nil {
+		prewarmer.SetVerifier(server)
+	}
+	logrus.Infof("starting server at" + httpServerAddress)
+	if err := server.Run(certRotatorReady); err != nil {
+		logrus.Errorf("starting server failed with error %v, exiting..", err)
+		os.Exit(1)
+	}
+}
+
+// newExecutorGetter returns the executor with the latest verifiers, stores and
+// policy of the namespace of the context.
+func newExecutorGetter(cf config.Config) config.GetExecutor {
+	return func(ctx context.Context) *ef.Executor {
 		namespace := ctxUtils.GetNamespace(ctx)
 
 		activeVerifiers := controllers.NamespacedVerifiers.GetVerifiers(namespace)
@@ -99,20 +123,15 @@ func StartServer(httpServerAddress, configFilePath, certDirectory, caCertFile st
 			Config:         &cf.ExecutorConfig,
 		}
 		return &executor
-	}, certDirectory, caCertFile, cacheTTL, metricsEnabled, metricsType, metricsPort)
-
-	if err != nil {
-		logrus.Errorf("initialize server failed with error %v, exiting..", err)
-		os.Exit(1)
-	}
-	logrus.Infof("starting server at" + httpServerAddress)
-	if err := server.Run(certRotatorReady); err != nil {
-		logrus.Errorf("starting server failed with error %v, exiting..", err)
-		os.Exit(1)
 	}
 }
 
-func StartManager(certRotatorReady chan struct{}, probeAddr string) {
+// StartManager starts the controllers of the Ratify resources. The cache
+// pre-warming controllers are started as well if the prewarmer is set, which
+// verify images with the server started by StartServer. The auditor of running
+// images is started if auditOptions are set, which verifies images with the
+// executor configured by the config file.
+func StartManager(certRotatorReady chan struct{}, probeAddr, configFilePath string, prewarmer *prewarm.Prewarmer, auditOptions *audit.Options, kmpKubernetesSecretEnabled bool) {
 	var metricsAddr string
 	var enableLeaderElection bool
 
@@ -122,6 +141,13 @@ func StartManager(certRotatorReady chan struct{}, probeAddr string) {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.Parse()
 
+	// the auditor runs on the leader only so that replicas do not audit the
+	// same images and update the same VerificationReports.
+	if auditOptions != nil && !enableLeaderElection {
+		setupLog.Info("enabling leader election for the audit of running images")
+		enableLeaderElection = true
+	}
+
 	logrusSink := controllers.NewLogrusSink(logrus.StandardLogger())
 	ctrl.SetLogger(logr.New(logrusSink))
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -243,19 +269,38 @@ func StartManager(certRotatorReady chan struct{}, probeAddr string) {
 		os.Exit(1)
 	}
 	if err = (&clusterresource.KeyManagementProviderReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		KubernetesSecretEnabled: kmpKubernetesSecretEnabled,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster Key Management Provider")
 		os.Exit(1)
 	}
 	if err = (&namespaceresource.KeyManagementProviderReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		KubernetesSecretEnabled: kmpKubernetesSecretEnabled,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Namespaced Key Management Provider")
 		os.Exit(1)
 	}
+	if prewarmer != nil {
+		if err = prewarm.SetupWithManager(mgr, prewarmer); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Cache Pre-warming")
+			os.Exit(1)
+		}
+	}
+	if auditOptions != nil {
+		cf, err := config.Load(configFilePath)
+		if err != nil {
+			setupLog.Error(err, "unable to load config for verifying workload images")
+			os.Exit(1)
+		}
+		if err = audit.SetupWithManager(mgr, newExecutorGetter(cf), *auditOptions); err != nil {
+			setupLog.Error(err, "unable to set up auditor")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -34,6 +34,10 @@ var (
 	systemErrorCount     instrument.Int64Counter
 	registryRequestCount instrument.Int64Counter
 	cacheBlobCount       instrument.Int64Counter
+	referrersPathCount   instrument.Int64Counter
+	verifyCacheCount     instrument.Int64Counter
+	cachePrewarmCount    instrument.Int64Counter
+	auditCount           instrument.Int64Counter
 
 	// Azure Metrics
 	aadExchangeDuration    instrument.Int64Histogram
@@ -51,6 +55,10 @@ const (
 	metricNameSystemErrorCount     = "ratify_system_error_count"
 	metricNameRegistryRequestCount = "ratify_registry_request_count"
 	metricNameBlobCacheCount       = "ratify_blob_cache_count"
+	metricNameReferrersPathCount   = "ratify_referrers_path_count"
+	metricNameVerifyCacheCount     = "ratify_verify_cache_count"
+	metricNameCachePrewarmCount    = "ratify_cache_prewarm_count"
+	metricNameAuditCount           = "ratify_audit_verification_count"
 
 	// Azure Metrics
 	metricNameAADExchangeDuration    = "ratify_aad_exchange_duration"
@@ -179,6 +187,26 @@ func initStatsReporter() error {
 		logrus.Error(err)
 		return err
 	}
+	referrersPathCount, err = meter.Int64Counter(metricNameReferrersPathCount, instrument.WithDescription("referrers discovery count by path taken"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	verifyCacheCount, err = meter.Int64Counter(metricNameVerifyCacheCount, instrument.WithDescription("verify handler cache hit/miss count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	cachePrewarmCount, err = meter.Int64Counter(metricNameCachePrewarmCount, instrument.WithDescription("cache pre-warm verification count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	auditCount, err = meter.Int64Counter(metricNameAuditCount, instrument.WithDescription("audit verification count of running images"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
 	return nil
 }
 
@@ -303,3 +331,61 @@ func ReportBlobCacheCount(ctx context.Context, hit bool) {
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
 }
+
+// ReportReferrersPathCount reports the path taken to discover the referrers of a subject
+// Attributes:
+// registryHost: the host name of the registry
+// mode: the configured referrers mode of the registry
+// path: the path taken to discover the referrers (api or tagSchema)
+// workload_namespace: the namespace where workload is deployed
+func ReportReferrersPathCount(ctx context.Context, registryHost string, mode string, path string) {
+	if referrersPathCount != nil {
+		referrersPathCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(registryHost)},
+			attribute.KeyValue{Key: "mode", Value: attribute.StringValue(mode)},
+			attribute.KeyValue{Key: "path", Value: attribute.StringValue(path)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
+
+// ReportVerifyCacheCount reports a verify handler cache hit or miss. The ratio
+// of prewarmed hits to all lookups is the hit ratio of the cache pre-warmer.
+// Attributes:
+// hit: whether the verification result was found in the cache
+// prewarmed: whether the cached result was populated by the cache pre-warmer
+// workload_namespace: the namespace where workload is deployed
+func ReportVerifyCacheCount(ctx context.Context, hit bool, prewarmed bool) {
+	if verifyCacheCount != nil {
+		verifyCacheCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "hit", Value: attribute.BoolValue(hit)},
+			attribute.KeyValue{Key: "prewarmed", Value: attribute.BoolValue(prewarmed)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
+
+// ReportCachePrewarm reports a verification of the cache pre-warmer
+// Attributes:
+// success: whether the result was verified and cached
+// workload_namespace: the namespace where workload is deployed
+func ReportCachePrewarm(ctx context.Context, success bool) {
+	if cachePrewarmCount != nil {
+		cachePrewarmCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "success", Value: attribute.BoolValue(success)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
+
+// ReportAuditVerification reports a verification of a running image by the
+// auditor
+// Attributes:
+// success: whether the image passed the policy
+// drift: whether the result changed since the previous verification
+// workload_namespace: the namespace where workload is deployed
+func ReportAuditVerification(ctx context.Context, success bool, drift bool) {
+	if auditCount != nil {
+		auditCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "success", Value: attribute.BoolValue(success)},
+			attribute.KeyValue{Key: "drift", Value: attribute.BoolValue(drift)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
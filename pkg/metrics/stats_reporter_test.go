@@ -260,3 +260,98 @@ func TestReportBlobCacheCount(t *testing.T) {
 		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespac"])
 	}
 }
+
+func TestReportReferrersPathCount(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	referrersPathCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportReferrersPathCount(ctx, "test-registry", "auto", "tagSchema")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportReferrersPathCount() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if len(mockCounter.Attributes) != 4 {
+		t.Fatalf("ReportReferrersPathCount() len(mockCounter.Attributes) = %v, expected %v", len(mockCounter.Attributes), 4)
+	}
+	if mockCounter.Attributes["registry_host"] != "test-registry" {
+		t.Fatalf("expected registry_host attribute to be test-registry but got %s", mockCounter.Attributes["registry_host"])
+	}
+	if mockCounter.Attributes["mode"] != "auto" {
+		t.Fatalf("expected mode attribute to be auto but got %s", mockCounter.Attributes["mode"])
+	}
+	if mockCounter.Attributes["path"] != "tagSchema" {
+		t.Fatalf("expected path attribute to be tagSchema but got %s", mockCounter.Attributes["path"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
+
+func TestReportVerifyCacheCount(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	verifyCacheCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportVerifyCacheCount(ctx, true, true)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportVerifyCacheCount() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if len(mockCounter.Attributes) != 3 {
+		t.Fatalf("ReportVerifyCacheCount() len(mockCounter.Attributes) = %v, expected %v", len(mockCounter.Attributes), 3)
+	}
+	if mockCounter.Attributes["hit"] != "true" || mockCounter.Attributes["prewarmed"] != "true" {
+		t.Fatalf("expected hit and prewarmed attributes to be true but got %v", mockCounter.Attributes)
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
+
+func TestReportCachePrewarm(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	cachePrewarmCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportCachePrewarm(ctx, false)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportCachePrewarm() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["success"] != "false" {
+		t.Fatalf("expected success attribute to be false but got %s", mockCounter.Attributes["success"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
+
+func TestReportAuditVerification(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	auditCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportAuditVerification(ctx, false, true)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportAuditVerification() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["success"] != "false" {
+		t.Fatalf("expected success attribute to be false but got %s", mockCounter.Attributes["success"])
+	}
+	if mockCounter.Attributes["drift"] != "true" {
+		t.Fatalf("expected drift attribute to be true but got %s", mockCounter.Attributes["drift"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
@@ -19,7 +19,11 @@ import (
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-const MediaTypeArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
+const (
+	MediaTypeArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
+	// MediaTypeDockerManifestList is the media type of a Docker manifest list.
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
 
 // ReferenceDescriptor represents a descriptor for an artifact manifest
 type ReferenceDescriptor struct {
@@ -35,8 +39,16 @@ type ReferenceManifest struct {
 	Blobs        []oci.Descriptor  `json:"blobs"`
 	Subject      *oci.Descriptor   `json:"subject,omitempty"`
 	Annotations  map[string]string `json:"annotations,omitempty"`
+	// Manifests is only set if the manifest is an image index.
+	Manifests []oci.Descriptor `json:"manifests,omitempty"`
 }
 
 type SubjectDescriptor struct {
 	oci.Descriptor
 }
+
+// IsImageIndex returns true if the media type is an OCI image index or a Docker
+// manifest list.
+func IsImageIndex(mediaType string) bool {
+	return mediaType == oci.MediaTypeImageIndex || mediaType == MediaTypeDockerManifestList
+}
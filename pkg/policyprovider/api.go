@@ -21,6 +21,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 )
 
 // PolicyProvider is an interface with methods that represents policy decisions.
@@ -36,4 +37,16 @@ type PolicyProvider interface {
 	OverallVerifyResult(ctx context.Context, verifierReports []interface{}) bool
 	// GetPolicyType returns the type of the policy.
 	GetPolicyType(ctx context.Context) string
+	// GetImageIndexConfig returns how the executor verifies a subject resolved
+	// to an image index.
+	GetImageIndexConfig(ctx context.Context) pt.ImageIndexConfig
+}
+
+// PolicyExplainer is implemented by policy providers able to explain the final
+// outcome of verification.
+type PolicyExplainer interface {
+	// ExplainOverallVerifyResult determines the final outcome of verification
+	// like OverallVerifyResult and returns the trace of the policy evaluation
+	// filtered by the explain mode.
+	ExplainOverallVerifyResult(ctx context.Context, verifierReports []interface{}, explain string) (bool, string, error)
 }
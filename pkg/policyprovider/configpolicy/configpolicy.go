@@ -34,11 +34,13 @@ import (
 // PolicyEnforcer describes different polices that are enforced during verification
 type PolicyEnforcer struct {
 	ArtifactTypePolicies map[string]vt.ArtifactTypeVerifyPolicy
+	ImageIndex           vt.ImageIndexConfig
 }
 
 type configPolicyEnforcerConf struct {
 	Name                         string                                 `json:"name"`
 	ArtifactVerificationPolicies map[string]vt.ArtifactTypeVerifyPolicy `json:"artifactVerificationPolicies,omitempty"`
+	ImageIndex                   vt.ImageIndexConfig                    `json:"imageIndex,omitempty"`
 }
 
 const (
@@ -74,6 +76,7 @@ func (f *configPolicyFactory) Create(policyConfig config.PolicyPluginConfig) (po
 	if policyEnforcer.ArtifactTypePolicies[defaultPolicyName] == "" {
 		policyEnforcer.ArtifactTypePolicies[defaultPolicyName] = vt.AllVerifySuccess
 	}
+	policyEnforcer.ImageIndex = conf.ImageIndex
 	return &policyEnforcer, nil
 }
 
@@ -159,3 +162,9 @@ func (enforcer PolicyEnforcer) OverallVerifyResult(_ context.Context, verifierRe
 func (enforcer PolicyEnforcer) GetPolicyType(_ context.Context) string {
 	return vt.ConfigPolicy
 }
+
+// GetImageIndexConfig returns how the executor verifies a subject resolved to
+// an image index.
+func (enforcer PolicyEnforcer) GetImageIndexConfig(_ context.Context) vt.ImageIndexConfig {
+	return enforcer.ImageIndex
+}
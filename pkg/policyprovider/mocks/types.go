@@ -21,6 +21,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/executor/types"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
+	pt "github.com/ratify-project/ratify/pkg/policyprovider/types"
 	"github.com/ratify-project/ratify/pkg/verifier"
 )
 
@@ -52,3 +53,7 @@ func (p *TestPolicyProvider) OverallVerifyResult(_ context.Context, _ []interfac
 func (p *TestPolicyProvider) GetPolicyType(_ context.Context) string {
 	return ""
 }
+
+func (p *TestPolicyProvider) GetImageIndexConfig(_ context.Context) pt.ImageIndexConfig {
+	return pt.ImageIndexConfig{}
+}
@@ -25,3 +25,10 @@ type PolicyEngine interface {
 	// err indicates an error happened during the evaluation.
 	Evaluate(ctx context.Context, input map[string]interface{}) (result bool, err error)
 }
+
+// Explainer is implemented by policy engines able to explain their decision.
+type Explainer interface {
+	// Explain evaluates the policy with the given input like Evaluate and also
+	// returns the evaluation trace filtered by the explain mode.
+	Explain(ctx context.Context, input map[string]interface{}, explain string) (result bool, trace string, err error)
+}
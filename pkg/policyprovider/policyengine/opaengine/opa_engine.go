@@ -18,6 +18,7 @@ package opa
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/ratify-project/ratify/pkg/policyprovider/policyengine"
@@ -62,3 +63,13 @@ func (f *EngineFactory) Create(policy string, queryLanguage string) (policyengin
 func (oe *Engine) Evaluate(ctx context.Context, input map[string]interface{}) (bool, error) {
 	return oe.query.Evaluate(ctx, input)
 }
+
+// Explain evaluates the policy with the given input and returns the evaluation
+// trace filtered by the explain mode.
+func (oe *Engine) Explain(ctx context.Context, input map[string]interface{}, explain string) (bool, string, error) {
+	explainer, ok := oe.query.(policyquery.Explainer)
+	if !ok {
+		return false, "", fmt.Errorf("policy query does not support explanations")
+	}
+	return explainer.Explain(ctx, input, explain)
+}
@@ -96,3 +96,25 @@ func TestEvaluate(t *testing.T) {
 		t.Fatalf("expect result to be true")
 	}
 }
+
+func TestExplain(t *testing.T) {
+	engine := &Engine{
+		query: &mockQuery{},
+	}
+	if _, _, err := engine.Explain(context.Background(), nil, "notes"); err == nil {
+		t.Fatalf("expect error for query without explanations")
+	}
+
+	factory := &EngineFactory{}
+	regoEngine, err := factory.Create(policy1, query.RegoName)
+	if err != nil {
+		t.Fatalf("expect no err, but got err: %v", err)
+	}
+	result, _, err := regoEngine.(*Engine).Explain(context.Background(), map[string]interface{}{"method": "GET"}, "full")
+	if err != nil {
+		t.Fatalf("expect no err, but got err: %v", err)
+	}
+	if !result {
+		t.Fatalf("expect result to be true")
+	}
+}
@@ -25,3 +25,19 @@ type PolicyQuery interface {
 	// err indicates an error happened during the evaluation.
 	Evaluate(ctx context.Context, input map[string]interface{}) (bool, error)
 }
+
+const (
+	// ExplainNotes keeps the notes emitted by the policy in the trace.
+	ExplainNotes = "notes"
+	// ExplainFails keeps the failed expressions in the trace.
+	ExplainFails = "fails"
+	// ExplainFull keeps every evaluation step in the trace.
+	ExplainFull = "full"
+)
+
+// Explainer is implemented by policy queries able to explain their decision.
+type Explainer interface {
+	// Explain evaluates the policy with the given input like Evaluate and also
+	// returns the evaluation trace filtered by the explain mode.
+	Explain(ctx context.Context, input map[string]interface{}, explain string) (result bool, trace string, err error)
+}
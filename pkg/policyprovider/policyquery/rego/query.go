@@ -16,10 +16,13 @@ limitations under the License.
 package query
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/topdown/lineage"
 	"github.com/pkg/errors"
 	"github.com/ratify-project/ratify/pkg/policyprovider/policyquery"
 )
@@ -58,10 +61,44 @@ func (f *RegoFactory) Create(policy string) (policyquery.PolicyQuery, error) {
 // Evaluate evaluates the policy against the input.
 func (r *Rego) Evaluate(ctx context.Context, input map[string]interface{}) (bool, error) {
 	results, err := r.query.Eval(ctx, rego.EvalInput(input))
-
 	if err != nil {
 		return false, err
-	} else if len(results) == 0 || len(results[0].Expressions) == 0 {
+	}
+	return decision(results)
+}
+
+// Explain evaluates the policy against the input and returns the evaluation
+// trace filtered by the explain mode, in the format of `opa eval --explain`.
+func (r *Rego) Explain(ctx context.Context, input map[string]interface{}, explain string) (bool, string, error) {
+	tracer := topdown.NewBufferTracer()
+	// rule indexing skips the rules not matching the input, which leaves them
+	// out of the trace, so it is disabled to trace the failing expressions
+	results, err := r.query.Eval(ctx, rego.EvalInput(input), rego.EvalQueryTracer(tracer), rego.EvalRuleIndexing(false))
+	if err != nil {
+		return false, "", err
+	}
+
+	var events []*topdown.Event
+	switch explain {
+	case policyquery.ExplainNotes:
+		events = lineage.Notes(*tracer)
+	case policyquery.ExplainFails:
+		events = lineage.Fails(*tracer)
+	case policyquery.ExplainFull:
+		events = lineage.Full(*tracer)
+	default:
+		return false, "", fmt.Errorf("unsupported explain mode: %s", explain)
+	}
+	var trace bytes.Buffer
+	topdown.PrettyTraceWithLocation(&trace, events)
+
+	result, err := decision(results)
+	return result, trace.String(), err
+}
+
+// decision returns the boolean value of the policy query
+func decision(results rego.ResultSet) (bool, error) {
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
 		return false, errors.New("no results returned from query")
 	}
 
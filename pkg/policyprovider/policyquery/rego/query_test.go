@@ -17,7 +17,10 @@ package query
 
 import (
 	"context"
+	"strings"
 	"testing"
+
+	"github.com/ratify-project/ratify/pkg/policyprovider/policyquery"
 )
 
 const (
@@ -117,3 +120,47 @@ func TestEvaluate(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	factory := &RegoFactory{}
+	query, err := factory.Create(`
+package ratify.policy
+
+default valid := false
+
+valid {
+	trace("checking method")
+	input.method == "GET"
+}
+`)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	explainer, ok := query.(policyquery.Explainer)
+	if !ok {
+		t.Fatalf("rego query does not implement policyquery.Explainer")
+	}
+
+	result, trace, err := explainer.Explain(context.Background(), map[string]interface{}{"method": "GET"}, policyquery.ExplainNotes)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if !result {
+		t.Fatalf("result = %v, expectResult = true", result)
+	}
+	if !strings.Contains(trace, "checking method") {
+		t.Fatalf("trace does not contain the policy note: %s", trace)
+	}
+
+	result, trace, err = explainer.Explain(context.Background(), map[string]interface{}{"method": "POST"}, policyquery.ExplainFails)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if result || trace == "" {
+		t.Fatalf("result = %v, trace = %s, expect false result with failure trace", result, trace)
+	}
+
+	if _, _, err = explainer.Explain(context.Background(), nil, "debug"); err == nil {
+		t.Fatalf("expect error for unsupported explain mode")
+	}
+}
@@ -39,6 +39,7 @@ type policyEnforcer struct {
 	Policy             string
 	OpaEngine          policyengine.PolicyEngine
 	passthroughEnabled bool
+	imageIndex         policyTypes.ImageIndexConfig
 }
 
 type policyEnforcerConf struct {
@@ -46,6 +47,8 @@ type policyEnforcerConf struct {
 	Policy             string `json:"policy"`
 	PolicyPath         string `json:"policyPath"`
 	PassthroughEnabled bool   `json:"passthroughEnabled"`
+
+	ImageIndex policyTypes.ImageIndexConfig `json:"imageIndex,omitempty"`
 }
 
 // Factory is a factory for creating rego policy enforcers.
@@ -95,6 +98,7 @@ func (f *Factory) Create(policyConfig config.PolicyPluginConfig) (policyprovider
 		Policy:             conf.Policy,
 		OpaEngine:          engine,
 		passthroughEnabled: conf.PassthroughEnabled,
+		imageIndex:         conf.ImageIndex,
 	}
 
 	return policyEnforcer, nil
@@ -131,7 +135,29 @@ func (e *policyEnforcer) OverallVerifyResult(ctx context.Context, verifierReport
 	return result
 }
 
+// ExplainOverallVerifyResult determines the overall verification result like
+// OverallVerifyResult and returns the trace of the policy evaluation.
+func (e *policyEnforcer) ExplainOverallVerifyResult(ctx context.Context, verifierReports []interface{}, explain string) (bool, string, error) {
+	if e.passthroughEnabled {
+		return false, "passthrough is enabled, the policy is not evaluated\n", nil
+	}
+
+	explainer, ok := e.OpaEngine.(policyengine.Explainer)
+	if !ok {
+		return false, "", fmt.Errorf("policy engine does not support explanations")
+	}
+	nestedReports := map[string]interface{}{}
+	nestedReports["verifierReports"] = verifierReports
+	return explainer.Explain(ctx, nestedReports, explain)
+}
+
 // GetPolicyType returns the type of the policy.
 func (e *policyEnforcer) GetPolicyType(_ context.Context) string {
 	return policyTypes.RegoPolicy
 }
+
+// GetImageIndexConfig returns how the executor verifies a subject resolved to
+// an image index.
+func (e *policyEnforcer) GetImageIndexConfig(_ context.Context) policyTypes.ImageIndexConfig {
+	return e.imageIndex
+}
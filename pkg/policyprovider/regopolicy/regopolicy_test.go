@@ -187,6 +187,40 @@ func TestOverallVerifyResult(t *testing.T) {
 	}
 }
 
+func TestExplainOverallVerifyResult(t *testing.T) {
+	passthrough := &policyEnforcer{OpaEngine: policyEngine{}, passthroughEnabled: true}
+	result, trace, err := passthrough.ExplainOverallVerifyResult(context.Background(), nil, "notes")
+	if err != nil || result || trace == "" {
+		t.Fatalf("result = %v, trace = %s, err = %v, expect false result with passthrough trace", result, trace, err)
+	}
+
+	unsupported := &policyEnforcer{OpaEngine: policyEngine{}}
+	if _, _, err := unsupported.ExplainOverallVerifyResult(context.Background(), nil, "notes"); err == nil {
+		t.Fatalf("expect error for policy engine without explanations")
+	}
+
+	factory := &Factory{}
+	provider, err := factory.Create(config.PolicyPluginConfig{
+		"name": "regopolicy",
+		"policy": `
+package ratify.policy
+
+default valid := false
+
+valid {
+	count(input.verifierReports) > 0
+}
+`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy provider: %v", err)
+	}
+	result, _, err = provider.(*policyEnforcer).ExplainOverallVerifyResult(context.Background(), []interface{}{types.VerifyResult{IsSuccess: true}}, "full")
+	if err != nil || !result {
+		t.Fatalf("result = %v, err = %v, expect true result", result, err)
+	}
+}
+
 func TestGetPolicyType(t *testing.T) {
 	enforcer := policyEnforcer{}
 	if policyType := enforcer.GetPolicyType(context.Background()); policyType != "regopolicy" {
@@ -26,3 +26,17 @@ const (
 	// ConfigPolicy is the name of the config policy provider.
 	ConfigPolicy = "configpolicy"
 )
+
+// ImageIndexConfig describes how the executor verifies a subject that resolves
+// to an image index.
+type ImageIndexConfig struct {
+	// VerifyManifests enables verifying the referrers of the child manifests
+	// in addition to the referrers of the image index itself.
+	VerifyManifests bool `json:"verifyManifests,omitempty"`
+	// Platforms limits the verified child manifests to the given platforms in
+	// the form of os/arch[/variant]. All child manifests are verified if empty.
+	Platforms []string `json:"platforms,omitempty"`
+	// MatchNodePlatform limits the verified child manifests to the platform
+	// Ratify is running on.
+	MatchNodePlatform bool `json:"matchNodePlatform,omitempty"`
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 
 	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/pkg/common"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore/config"
@@ -52,3 +53,11 @@ type ReferrerStore interface {
 	// GetSubjectDescriptor returns the descriptor for the given subject.
 	GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error)
 }
+
+// ManifestStore is implemented by referrer stores able to return the content of
+// a manifest as stored, which is required to copy artifacts without changing
+// their digests
+type ManifestStore interface {
+	// GetManifestContent returns the raw content of the manifest given by the descriptor
+	GetManifestContent(ctx context.Context, subjectReference common.Reference, manifestDesc oci.Descriptor) ([]byte, error)
+}
@@ -73,7 +73,7 @@ func CreateStoreFromConfig(storeConfig config.StorePluginConfig, configVersion s
 			targetPath := path.Join(pluginBinDir[0], storeNameStr)
 			err = pluginCommon.DownloadPlugin(source, targetPath)
 			if err != nil {
-				return nil, re.ErrorCodeDownloadPluginFailure.WithComponentType(re.ReferrerStore)
+				return nil, re.ErrorCodeDownloadPluginFailure.WithComponentType(re.ReferrerStore).WithError(err)
 			}
 			logrus.Infof("downloaded store plugin %s from %s to %s", storeNameStr, source.Artifact, targetPath)
 		} else {
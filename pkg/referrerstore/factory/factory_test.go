@@ -116,16 +116,19 @@ func TestCreateStoresFromConfig_DynamicPluginStores_ReturnsExpected(t *testing.T
 	featureflag.InitFeatureFlagsFromEnv()
 
 	testCases := []struct {
-		name     string
-		artifact string
+		name         string
+		artifact     string
+		verification map[string]interface{}
 	}{
 		{
-			name:     "image specified by tag",
-			artifact: "wabbitnetworks.azurecr.io/test/sample-store-plugin:v1",
+			name:         "image specified by tag",
+			artifact:     "wabbitnetworks.azurecr.io/test/sample-store-plugin:v1",
+			verification: map[string]interface{}{"skip": true},
 		},
 		{
-			name:     "image specified by digest",
-			artifact: "wabbitnetworks.azurecr.io/test/sample-store-plugin@sha256:96ba9f9636cde32df87d62dcad4e430d055e708b9f173475c5d7468b732d6566",
+			name:         "image specified by digest",
+			artifact:     "wabbitnetworks.azurecr.io/test/sample-store-plugin@sha256:96ba9f9636cde32df87d62dcad4e430d055e708b9f173475c5d7468b732d6566",
+			verification: map[string]interface{}{"digest": "sha256:96ba9f9636cde32df87d62dcad4e430d055e708b9f173475c5d7468b732d6566"},
 		},
 	}
 
@@ -134,7 +137,8 @@ func TestCreateStoresFromConfig_DynamicPluginStores_ReturnsExpected(t *testing.T
 			storeConfig := map[string]interface{}{
 				"name": pluginStoreName,
 				"source": map[string]interface{}{
-					"artifact": tc.artifact,
+					"artifact":     tc.artifact,
+					"verification": tc.verification,
 				},
 			}
 
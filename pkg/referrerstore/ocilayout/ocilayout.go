@@ -0,0 +1,294 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocilayout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	commonutils "github.com/ratify-project/ratify/pkg/common/utils"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+	"github.com/ratify-project/ratify/pkg/referrerstore/factory"
+)
+
+const (
+	storeName = "ocilayout"
+	// defaultMediaType is the media type oras-go returns for content resolved
+	// by digest that is not tagged in the index of the layout
+	defaultMediaType = "application/octet-stream"
+	// maxManifestBytes is the maximum size of a manifest read from the layout
+	maxManifestBytes = 4 * 1024 * 1024
+)
+
+var logOpt = logger.Option{ComponentType: logger.ReferrerStore}
+
+// OCILayoutStoreConf describes the configuration of the OCI layout store
+type OCILayoutStoreConf struct {
+	Name string `json:"name"`
+	// Path is the path of an OCI image layout directory or of an oci-archive
+	// tarball of an OCI image layout
+	Path string `json:"path"`
+}
+
+type ociLayoutStoreFactory struct{}
+
+// ociLayoutStore is a read-only referrer store backed by an OCI image layout
+// on disk. It does not require any network access.
+type ociLayoutStore struct {
+	config    *OCILayoutStoreConf
+	rawConfig config.StoreConfig
+	layout    *ocitarget.ReadOnlyStore
+}
+
+func init() {
+	factory.Register(storeName, &ociLayoutStoreFactory{})
+}
+
+func (s *ociLayoutStoreFactory) Create(version string, storeConfig config.StorePluginConfig) (referrerstore.ReferrerStore, error) {
+	conf := OCILayoutStoreConf{}
+	storeConfigBytes, err := json.Marshal(storeConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, "", re.EmptyLink, err, "", re.HideStackTrace)
+	}
+	if err := json.Unmarshal(storeConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, "", re.EmptyLink, err, "failed to parse oci layout store configuration", re.HideStackTrace)
+	}
+	if conf.Path == "" {
+		return nil, re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail("the path of the OCI layout is required")
+	}
+
+	layout, err := loadLayout(context.Background(), conf.Path)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithError(err).WithComponentType(re.ReferrerStore).WithDetail(fmt.Sprintf("failed to load the OCI layout at %s", conf.Path))
+	}
+
+	return &ociLayoutStore{
+		config:    &conf,
+		rawConfig: config.StoreConfig{Version: version, Store: storeConfig},
+		layout:    layout,
+	}, nil
+}
+
+// loadLayout loads the OCI layout from a directory or from an oci-archive
+// tarball if the path is a file
+func loadLayout(ctx context.Context, path string) (*ocitarget.ReadOnlyStore, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ocitarget.NewFromFS(ctx, os.DirFS(path))
+	}
+	return ocitarget.NewFromTar(ctx, path)
+}
+
+func (store *ociLayoutStore) Name() string {
+	return storeName
+}
+
+func (store *ociLayoutStore) GetConfig() *config.StoreConfig {
+	return &store.rawConfig
+}
+
+// ListReferrers returns the referrers of the subject found in the layout. The
+// manifests of the layout declaring the subject are merged with the referrers
+// index of the layout tagged with the referrers tag schema of the subject.
+func (store *ociLayoutStore) ListReferrers(ctx context.Context, subjectReference common.Reference, _ []string, _ string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	if subjectDesc == nil {
+		var err error
+		if subjectDesc, err = store.GetSubjectDescriptor(ctx, subjectReference); err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+	}
+
+	referrerDescs, err := registry.Referrers(ctx, store.layout, subjectDesc.Descriptor, "")
+	if err != nil && !errors.Is(err, errdef.ErrUnsupported) {
+		return referrerstore.ListReferrersResult{}, re.ErrorCodeListReferrersFailure.WithDetail(fmt.Sprintf("Failed to list the referrers of %s in the OCI layout", subjectDesc.Digest)).WithError(err)
+	}
+	indexDescs, err := store.referrersIndex(ctx, subjectDesc.Digest)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, re.ErrorCodeListReferrersFailure.WithDetail(fmt.Sprintf("Failed to read the referrers index of %s in the OCI layout", subjectDesc.Digest)).WithError(err)
+	}
+
+	seen := make(map[digest.Digest]bool)
+	referrers := []ocispecs.ReferenceDescriptor{}
+	for _, desc := range append(referrerDescs, indexDescs...) {
+		if seen[desc.Digest] {
+			continue
+		}
+		seen[desc.Digest] = true
+		referrers = append(referrers, ocispecs.ReferenceDescriptor{
+			Descriptor:   desc,
+			ArtifactType: desc.ArtifactType,
+		})
+	}
+
+	return referrerstore.ListReferrersResult{Referrers: referrers}, nil
+}
+
+// referrersIndex returns the manifests of the referrers index of the subject
+// tagged in the layout following the referrers tag schema. It returns nil if
+// the layout has no referrers index for the subject.
+func (store *ociLayoutStore) referrersIndex(ctx context.Context, subjectDigest digest.Digest) ([]oci.Descriptor, error) {
+	tag := strings.Replace(subjectDigest.String(), ":", "-", 1)
+	indexDesc, err := store.layout.Resolve(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	indexBytes, err := store.fetch(ctx, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	var index oci.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, err
+	}
+	return index.Manifests, nil
+}
+
+func (store *ociLayoutStore) GetBlobContent(ctx context.Context, _ common.Reference, digest digest.Digest) ([]byte, error) {
+	desc, err := store.layout.Resolve(ctx, digest.String())
+	if err != nil {
+		return nil, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to find the blob %s in the OCI layout", digest)).WithError(err)
+	}
+	blobContent, err := content.FetchAll(ctx, store.layout, desc)
+	if err != nil {
+		return nil, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to read the blob %s from the OCI layout", digest)).WithError(err)
+	}
+	return blobContent, nil
+}
+
+func (store *ociLayoutStore) GetReferenceManifest(ctx context.Context, _ common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	manifestBytes, err := store.fetch(ctx, referenceDesc.Descriptor)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Failed to read the artifact metadata %s from the OCI layout", referenceDesc.Digest)).WithError(err)
+	}
+
+	mediaType := referenceDesc.MediaType
+	if mediaType == "" || mediaType == defaultMediaType {
+		mediaType = manifestMediaType(manifestBytes)
+	}
+
+	referenceManifest := ocispecs.ReferenceManifest{}
+	switch {
+	case mediaType == oci.MediaTypeImageManifest:
+		var imageManifest oci.Manifest
+		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.image.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+		}
+		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
+	case mediaType == ocispecs.MediaTypeArtifactManifest:
+		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.artifact.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+		}
+	case ocispecs.IsImageIndex(mediaType):
+		var index oci.Index
+		if err := json.Unmarshal(manifestBytes, &index); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail(fmt.Sprintf("Failed to parse image index of mediatype `%s`", mediaType)).WithError(err).WithRemediation("Please check if the image index was created correctly.")
+		}
+		referenceManifest = commonutils.OciIndexToReferenceManifest(mediaType, index)
+	default:
+		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Unsupported artifact metadata of media type %s", mediaType)).WithRemediation("Please check if the artifact metadata was created correctly.")
+	}
+
+	return referenceManifest, nil
+}
+
+// GetManifestContent returns the raw content of the manifest from the layout
+func (store *ociLayoutStore) GetManifestContent(ctx context.Context, _ common.Reference, manifestDesc oci.Descriptor) ([]byte, error) {
+	manifestBytes, err := store.fetch(ctx, manifestDesc)
+	if err != nil {
+		return nil, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Failed to read the manifest %s from the OCI layout", manifestDesc.Digest)).WithError(err)
+	}
+	return manifestBytes, nil
+}
+
+// GetSubjectDescriptor resolves the subject by digest, or by the tag of the
+// subject in the index of the layout. The tag is matched against the full
+// reference, the repository and tag, and the tag alone since tools annotate
+// the index of the layout differently.
+func (store *ociLayoutStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	candidates := []string{}
+	if subjectReference.Digest != "" {
+		candidates = append(candidates, subjectReference.Digest.String())
+	} else {
+		candidates = append(candidates, subjectReference.Original)
+		if subjectReference.Tag != "" {
+			candidates = append(candidates, fmt.Sprintf("%s:%s", subjectReference.Path, subjectReference.Tag), subjectReference.Tag)
+		}
+	}
+
+	for _, candidate := range candidates {
+		desc, err := store.layout.Resolve(ctx, candidate)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				continue
+			}
+			return nil, re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("Unable to resolve the reference %s in the OCI layout", subjectReference.Original)).WithError(err)
+		}
+		if desc.MediaType == defaultMediaType {
+			// content resolved by digest only has the default media type, the
+			// media type is read from the manifest instead
+			manifestBytes, err := store.fetch(ctx, desc)
+			if err != nil {
+				return nil, re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("Unable to read the manifest of %s from the OCI layout", subjectReference.Original)).WithError(err)
+			}
+			desc.MediaType = manifestMediaType(manifestBytes)
+		}
+		return &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size}}, nil
+	}
+
+	logger.GetLogger(ctx, logOpt).Debugf("reference %s not found in the OCI layout at %s", subjectReference.Original, store.config.Path)
+	return nil, re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("Unable to find the reference %s in the OCI layout at %s", subjectReference.Original, store.config.Path)).WithRemediation("Please check that the subject is tagged in the index.json of the OCI layout or reference it by digest.")
+}
+
+// fetch reads the manifest of the descriptor from the layout
+func (store *ociLayoutStore) fetch(ctx context.Context, desc oci.Descriptor) ([]byte, error) {
+	if desc.Size > maxManifestBytes {
+		return nil, fmt.Errorf("manifest %s of size %d exceeds the limit of %d bytes", desc.Digest, desc.Size, maxManifestBytes)
+	}
+	return content.FetchAll(ctx, store.layout, desc)
+}
+
+// manifestMediaType returns the media type declared by the manifest
+func manifestMediaType(manifestBytes []byte) string {
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return defaultMediaType
+	}
+	return manifest.MediaType
+}
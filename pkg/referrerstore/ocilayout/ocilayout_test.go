@@ -0,0 +1,297 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocilayout
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/config"
+)
+
+const (
+	signatureArtifactType = "application/vnd.cncf.notary.signature"
+	sbomArtifactType      = "application/spdx+json"
+)
+
+type testLayout struct {
+	path      string
+	subject   oci.Descriptor
+	signature oci.Descriptor
+	sbom      oci.Descriptor
+	blob      []byte
+}
+
+func pushJSON(t *testing.T, store *ocitarget.Store, mediaType string, v interface{}) oci.Descriptor {
+	t.Helper()
+	content, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal content: %v", err)
+	}
+	return pushBytes(t, store, mediaType, content)
+}
+
+func pushBytes(t *testing.T, store *ocitarget.Store, mediaType string, content []byte) oci.Descriptor {
+	t.Helper()
+	desc := oci.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(content), Size: int64(len(content))}
+	if err := store.Push(context.Background(), desc, bytes.NewReader(content)); err != nil {
+		t.Fatalf("failed to push content: %v", err)
+	}
+	return desc
+}
+
+// createTestLayout creates an OCI layout with a subject image tagged v1, a
+// signature declaring the image as subject and an SBOM only listed in the
+// referrers index of the image
+func createTestLayout(t *testing.T) testLayout {
+	t.Helper()
+	ctx := context.Background()
+	layout := testLayout{path: filepath.Join(t.TempDir(), "layout")}
+	store, err := ocitarget.New(layout.path)
+	if err != nil {
+		t.Fatalf("failed to create oci layout: %v", err)
+	}
+
+	emptyConfig := pushBytes(t, store, oci.MediaTypeEmptyJSON, []byte("{}"))
+	layer := pushBytes(t, store, oci.MediaTypeImageLayer, []byte("layer"))
+	layout.subject = pushJSON(t, store, oci.MediaTypeImageManifest, oci.Manifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Config:    emptyConfig,
+		Layers:    []oci.Descriptor{layer},
+	})
+	if err := store.Tag(ctx, layout.subject, "v1"); err != nil {
+		t.Fatalf("failed to tag subject: %v", err)
+	}
+
+	layout.blob = []byte("signature envelope")
+	signatureBlob := pushBytes(t, store, "application/jose+json", layout.blob)
+	layout.signature = pushJSON(t, store, oci.MediaTypeImageManifest, oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: signatureArtifactType,
+		Config:       emptyConfig,
+		Layers:       []oci.Descriptor{signatureBlob},
+		Subject:      &layout.subject,
+	})
+	layout.signature.ArtifactType = signatureArtifactType
+
+	sbomBlob := pushBytes(t, store, sbomArtifactType, []byte("sbom"))
+	layout.sbom = pushJSON(t, store, oci.MediaTypeImageManifest, oci.Manifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: sbomArtifactType,
+		Config:       emptyConfig,
+		Layers:       []oci.Descriptor{sbomBlob},
+	})
+	layout.sbom.ArtifactType = sbomArtifactType
+
+	referrersIndex := pushJSON(t, store, oci.MediaTypeImageIndex, oci.Index{
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: []oci.Descriptor{layout.signature, layout.sbom},
+	})
+	if err := store.Tag(ctx, referrersIndex, strings.Replace(layout.subject.Digest.String(), ":", "-", 1)); err != nil {
+		t.Fatalf("failed to tag referrers index: %v", err)
+	}
+	return layout
+}
+
+// createTestArchive writes the layout directory as an oci-archive tarball
+func createTestArchive(t *testing.T, layoutPath string) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "layout.tar")
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer archive.Close()
+	tw := tar.NewWriter(archive)
+	err = filepath.WalkDir(layoutPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(layoutPath, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(name), Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return archivePath
+}
+
+// testReference returns the reference of the net-monitor image by tag or digest
+func testReference(tag string, dgst digest.Digest) common.Reference {
+	ref := common.Reference{Path: "registry.example.io/net-monitor", Tag: tag, Digest: dgst}
+	if dgst != "" {
+		ref.Original = fmt.Sprintf("%s@%s", ref.Path, dgst)
+	} else {
+		ref.Original = fmt.Sprintf("%s:%s", ref.Path, tag)
+	}
+	return ref
+}
+
+func createTestStore(t *testing.T, path string) referrerstore.ReferrerStore {
+	t.Helper()
+	store, err := (&ociLayoutStoreFactory{}).Create("1.0.0", config.StorePluginConfig{"name": storeName, "path": path})
+	if err != nil {
+		t.Fatalf("failed to create oci layout store: %v", err)
+	}
+	return store
+}
+
+// TestCreate tests the validation of the store configuration
+func TestCreate(t *testing.T) {
+	factory := &ociLayoutStoreFactory{}
+	if _, err := factory.Create("1.0.0", config.StorePluginConfig{"name": storeName}); err == nil {
+		t.Fatalf("expected error for missing path")
+	}
+	if _, err := factory.Create("1.0.0", config.StorePluginConfig{"name": storeName, "path": filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Fatalf("expected error for missing layout")
+	}
+	if _, err := factory.Create("1.0.0", config.StorePluginConfig{"name": storeName, "path": t.TempDir()}); err == nil {
+		t.Fatalf("expected error for directory without oci-layout file")
+	}
+
+	store := createTestStore(t, createTestLayout(t).path)
+	if store.Name() != storeName {
+		t.Fatalf("Name() = %s, want %s", store.Name(), storeName)
+	}
+	if store.GetConfig().Version != "1.0.0" {
+		t.Fatalf("GetConfig() version = %s, want 1.0.0", store.GetConfig().Version)
+	}
+}
+
+// TestGetSubjectDescriptor tests resolving subjects by tag and by digest
+func TestGetSubjectDescriptor(t *testing.T) {
+	layout := createTestLayout(t)
+	store := createTestStore(t, layout.path)
+
+	tests := []struct {
+		name      string
+		reference common.Reference
+		wantErr   bool
+	}{
+		{name: "tag", reference: testReference("v1", "")},
+		{name: "digest", reference: testReference("", layout.subject.Digest)},
+		{name: "missing tag", reference: testReference("v2", ""), wantErr: true},
+		{name: "missing digest", reference: testReference("", digest.FromString("missing")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, err := store.GetSubjectDescriptor(context.Background(), tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSubjectDescriptor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (desc.Digest != layout.subject.Digest || desc.MediaType != oci.MediaTypeImageManifest) {
+				t.Fatalf("GetSubjectDescriptor() = %+v, want %+v", desc.Descriptor, layout.subject)
+			}
+		})
+	}
+}
+
+// TestListReferrers tests that referrers declaring the subject and referrers
+// of the referrers index are listed from both a directory and a tarball
+func TestListReferrers(t *testing.T) {
+	layout := createTestLayout(t)
+	for name, path := range map[string]string{
+		"directory": layout.path,
+		"tarball":   createTestArchive(t, layout.path),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := createTestStore(t, path)
+			ref := testReference("v1", "")
+			result, err := store.ListReferrers(ctx, ref, nil, "", nil)
+			if err != nil {
+				t.Fatalf("ListReferrers() unexpected error: %v", err)
+			}
+			if len(result.Referrers) != 2 {
+				t.Fatalf("ListReferrers() returned %d referrers, want 2: %+v", len(result.Referrers), result.Referrers)
+			}
+			artifactTypes := map[string]ocispecs.ReferenceDescriptor{}
+			for _, referrer := range result.Referrers {
+				artifactTypes[referrer.ArtifactType] = referrer
+			}
+			signature, ok := artifactTypes[signatureArtifactType]
+			if !ok || signature.Digest != layout.signature.Digest {
+				t.Fatalf("ListReferrers() did not return the signature: %+v", result.Referrers)
+			}
+			if sbom, ok := artifactTypes[sbomArtifactType]; !ok || sbom.Digest != layout.sbom.Digest {
+				t.Fatalf("ListReferrers() did not return the sbom of the referrers index: %+v", result.Referrers)
+			}
+
+			manifest, err := store.GetReferenceManifest(ctx, ref, signature)
+			if err != nil {
+				t.Fatalf("GetReferenceManifest() unexpected error: %v", err)
+			}
+			if manifest.ArtifactType != signatureArtifactType || len(manifest.Blobs) != 1 || manifest.Subject == nil || manifest.Subject.Digest != layout.subject.Digest {
+				t.Fatalf("GetReferenceManifest() = %+v", manifest)
+			}
+
+			blob, err := store.GetBlobContent(ctx, ref, manifest.Blobs[0].Digest)
+			if err != nil {
+				t.Fatalf("GetBlobContent() unexpected error: %v", err)
+			}
+			if !bytes.Equal(blob, layout.blob) {
+				t.Fatalf("GetBlobContent() = %s, want %s", blob, layout.blob)
+			}
+			if _, err := store.GetBlobContent(ctx, ref, digest.FromString("missing")); err == nil {
+				t.Fatalf("GetBlobContent() expected error for missing blob")
+			}
+		})
+	}
+}
+
+// TestGetReferenceManifest_UnsupportedMediaType tests that unsupported
+// manifests are rejected
+func TestGetReferenceManifest_UnsupportedMediaType(t *testing.T) {
+	layout := createTestLayout(t)
+	store := createTestStore(t, layout.path)
+	desc := ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{
+		MediaType: "application/vnd.unknown.manifest.v1+json",
+		Digest:    layout.subject.Digest,
+		Size:      layout.subject.Size,
+	}}
+	if _, err := store.GetReferenceManifest(context.Background(), testReference("v1", ""), desc); err == nil {
+		t.Fatalf("GetReferenceManifest() expected error for unsupported media type")
+	}
+}
@@ -30,34 +30,62 @@ import (
 	"oras.land/oras-go/v2/registry"
 )
 
-const CosignArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
-const CosignSignatureTagSuffix = ".sig"
+const (
+	CosignArtifactType            = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	CosignAttestationArtifactType = "application/vnd.dev.cosign.artifact.att.v1+json"
+	CosignSBOMArtifactType        = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+	CosignSignatureTagSuffix      = ".sig"
+	CosignAttestationTagSuffix    = ".att"
+	CosignSBOMTagSuffix           = ".sbom"
+)
+
+// cosignAttachment describes an artifact Cosign attaches to an image with a
+// tag derived from the image digest
+type cosignAttachment struct {
+	tagSuffix    string
+	artifactType string
+	description  string
+}
+
+// cosignAttachments are the tag-based attachments discovered for a subject
+var cosignAttachments = []cosignAttachment{
+	{tagSuffix: CosignSignatureTagSuffix, artifactType: CosignArtifactType, description: "signature"},
+	{tagSuffix: CosignAttestationTagSuffix, artifactType: CosignAttestationArtifactType, description: "attestation"},
+	{tagSuffix: CosignSBOMTagSuffix, artifactType: CosignSBOMArtifactType, description: "SBOM"},
+}
 
+// getCosignReferences returns a reference descriptor for each Cosign
+// signature, attestation and SBOM tag found for the subject
 func getCosignReferences(ctx context.Context, subjectReference common.Reference, repository registry.Repository) (*[]ocispecs.ReferenceDescriptor, error) {
 	var references []ocispecs.ReferenceDescriptor
-	signatureTag, err := attachedImageTag(subjectReference, CosignSignatureTagSuffix)
-	if err != nil {
-		return nil, err
-	}
+	for _, attachment := range cosignAttachments {
+		attachedTag, err := attachedImageTag(subjectReference, attachment.tagSuffix)
+		if err != nil {
+			return nil, err
+		}
 
-	desc, err := repository.Resolve(ctx, signatureTag)
-	if err != nil {
-		if errors.Is(err, errdef.ErrNotFound) {
-			return nil, nil
+		desc, err := repository.Resolve(ctx, attachedTag)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				continue
+			}
+			evictOnError(ctx, err, subjectReference.Original)
+			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to validate existence of Cosign %s of the artifact: %+v", attachment.description, subjectReference)).WithError(err)
 		}
-		evictOnError(ctx, err, subjectReference.Original)
-		return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail(fmt.Sprintf("Failed to validate existence of Cosign signature of the artifact: %+v", subjectReference)).WithError(err)
-	}
 
-	references = append(references, ocispecs.ReferenceDescriptor{
-		ArtifactType: CosignArtifactType,
-		Descriptor: oci.Descriptor{
-			MediaType: desc.MediaType,
-			Digest:    desc.Digest,
-			Size:      desc.Size,
-		},
-	})
+		references = append(references, ocispecs.ReferenceDescriptor{
+			ArtifactType: attachment.artifactType,
+			Descriptor: oci.Descriptor{
+				MediaType: desc.MediaType,
+				Digest:    desc.Digest,
+				Size:      desc.Size,
+			},
+		})
+	}
 
+	if len(references) == 0 {
+		return nil, nil
+	}
 	return &references, nil
 }
 
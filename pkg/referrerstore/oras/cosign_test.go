@@ -92,6 +92,10 @@ func TestGetCosignReferences(t *testing.T) {
 	testSubjectDigest := digest.FromString("test")
 	testCosignSubjectTag := fmt.Sprintf("%s-%s.sig", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex())
 	testCosignImageDigest := digest.FromString("test_cosign")
+	testCosignAttestationTag := fmt.Sprintf("%s-%s.att", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex())
+	testCosignAttestationDigest := digest.FromString("test_cosign_attestation")
+	testCosignSBOMTag := fmt.Sprintf("%s-%s.sbom", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex())
+	testCosignSBOMDigest := digest.FromString("test_cosign_sbom")
 	testcases := []struct {
 		name       string
 		subjectRef common.Reference
@@ -150,6 +154,72 @@ func TestGetCosignReferences(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "cosign signature, attestation and sbom references",
+			subjectRef: common.Reference{
+				Path:   "localhost:5000/net-monitor",
+				Tag:    "v1",
+				Digest: testSubjectDigest,
+			},
+			repository: mocks.TestRepository{
+				ResolveMap: map[string]oci.Descriptor{
+					fmt.Sprintf("localhost:5000/net-monitor:%s", testCosignSubjectTag): {
+						Digest: testCosignImageDigest,
+					},
+					fmt.Sprintf("localhost:5000/net-monitor:%s", testCosignAttestationTag): {
+						Digest: testCosignAttestationDigest,
+					},
+					fmt.Sprintf("localhost:5000/net-monitor:%s", testCosignSBOMTag): {
+						Digest: testCosignSBOMDigest,
+					},
+				},
+			},
+			output: &[]ocispecs.ReferenceDescriptor{
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignImageDigest,
+					},
+					ArtifactType: CosignArtifactType,
+				},
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignAttestationDigest,
+					},
+					ArtifactType: CosignAttestationArtifactType,
+				},
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignSBOMDigest,
+					},
+					ArtifactType: CosignSBOMArtifactType,
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "cosign attestation without signature",
+			subjectRef: common.Reference{
+				Path:   "localhost:5000/net-monitor",
+				Tag:    "v1",
+				Digest: testSubjectDigest,
+			},
+			repository: mocks.TestRepository{
+				ResolveMap: map[string]oci.Descriptor{
+					fmt.Sprintf("localhost:5000/net-monitor:%s", testCosignAttestationTag): {
+						Digest: testCosignAttestationDigest,
+					},
+				},
+			},
+			output: &[]ocispecs.ReferenceDescriptor{
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignAttestationDigest,
+					},
+					ArtifactType: CosignAttestationArtifactType,
+				},
+			},
+			err: nil,
+		},
 		{
 			name: "resolve error non-standard error code",
 			subjectRef: common.Reference{
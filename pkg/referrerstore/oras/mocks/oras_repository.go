@@ -30,7 +30,9 @@ type TestRepository struct {
 	ResolveErr    error
 	ResolveMap    map[string]oci.Descriptor
 	ReferrersList []oci.Descriptor
+	ReferrersErr  error
 	FetchMap      map[digest.Digest]io.ReadCloser
+	ReferenceMap  map[string]BlobPair
 	BlobStoreTest TestBlobStore
 }
 
@@ -55,9 +57,19 @@ func (r TestRepository) Resolve(_ context.Context, reference string) (oci.Descri
 }
 
 func (r TestRepository) Referrers(_ context.Context, _ oci.Descriptor, _ string, fn func(referrers []oci.Descriptor) error) error {
+	if r.ReferrersErr != nil {
+		return r.ReferrersErr
+	}
 	return fn(r.ReferrersList)
 }
 
+func (r TestRepository) FetchReference(_ context.Context, reference string) (oci.Descriptor, io.ReadCloser, error) {
+	if pair, ok := r.ReferenceMap[reference]; ok {
+		return pair.Descriptor, pair.Reader, nil
+	}
+	return oci.Descriptor{}, nil, errdef.ErrNotFound
+}
+
 func (r TestRepository) Fetch(_ context.Context, target oci.Descriptor) (io.ReadCloser, error) {
 	if reader, ok := r.FetchMap[target.Digest]; ok {
 		return reader, nil
@@ -81,6 +81,10 @@ type OrasStoreConf struct { //nolint:revive // ignore linter to have unique type
 	CosignEnabled  bool                            `json:"cosignEnabled,omitempty"`
 	AuthProvider   authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
 	LocalCachePath string                          `json:"localCachePath,omitempty"`
+	// ReferrersMode configures how referrers are discovered per registry host:
+	// api, tagSchema, both-merge or auto. The "*" key applies to registries
+	// that are not listed. Defaults to auto.
+	ReferrersMode map[string]string `json:"referrersMode,omitempty"`
 }
 
 type orasStoreFactory struct{}
@@ -132,6 +136,10 @@ func createBaseStore(version string, storeConfig config.StorePluginConfig) (*ora
 		return nil, re.ErrorCodeConfigInvalid.NewError(re.ReferrerStore, "", re.EmptyLink, err, "failed to parse oras store configuration", re.HideStackTrace)
 	}
 
+	if err := validateReferrersMode(conf.ReferrersMode); err != nil {
+		return nil, err
+	}
+
 	authenticationProvider, err := authprovider.CreateAuthProviderFromConfig(conf.AuthProvider)
 	if err != nil {
 		return nil, re.ErrorCodePluginInitFailure.NewError(re.ReferrerStore, "", re.EmptyLink, err, "failed to create auth provider from configuration", re.HideStackTrace)
@@ -223,12 +231,12 @@ func (store *orasStore) ListReferrers(ctx context.Context, subjectReference comm
 	}
 
 	// find all referrers referencing subject descriptor
-	artifactTypeFilter := ""
-	var referrerDescriptors []oci.Descriptor
-	if err := repository.Referrers(ctx, resolvedSubjectDesc.Descriptor, artifactTypeFilter, func(referrers []oci.Descriptor) error {
-		referrerDescriptors = append(referrerDescriptors, referrers...)
-		return nil
-	}); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+	registryHost := ""
+	if artifactRef, err := registry.ParseReference(subjectReference.Original); err == nil {
+		registryHost = artifactRef.Registry
+	}
+	referrerDescriptors, err := listReferrerDescriptors(ctx, repository, registryHost, getReferrersMode(registryHost, store.config), resolvedSubjectDesc.Descriptor)
+	if err != nil {
 		evictOnError(ctx, err, subjectReference.Original)
 		return referrerstore.ListReferrersResult{}, err
 	}
@@ -311,67 +319,84 @@ func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference com
 }
 
 func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	manifestBytes, err := store.GetManifestContent(ctx, subjectReference, referenceDesc.Descriptor)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+
+	referenceManifest := ocispecs.ReferenceManifest{}
+
+	// marshal manifest bytes into reference manifest descriptor
+	if referenceDesc.Descriptor.MediaType == oci.MediaTypeImageManifest {
+		var imageManifest oci.Manifest
+		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.image.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+		}
+		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
+	} else if referenceDesc.Descriptor.MediaType == ocispecs.MediaTypeArtifactManifest {
+		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.artifact.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+		}
+	} else if ocispecs.IsImageIndex(referenceDesc.Descriptor.MediaType) {
+		var index oci.Index
+		if err := json.Unmarshal(manifestBytes, &index); err != nil {
+			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail(fmt.Sprintf("Failed to parse image index of mediatype `%s`", referenceDesc.Descriptor.MediaType)).WithError(err).WithRemediation("Please check if the image index was created correctly.")
+		}
+		referenceManifest = commonutils.OciIndexToReferenceManifest(referenceDesc.Descriptor.MediaType, index)
+	} else {
+		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Unsupported artifact metadata of media type %s", referenceDesc.Descriptor.MediaType)).WithRemediation("Please check if the artifact metadata was created correctly.")
+	}
+
+	return referenceManifest, nil
+}
+
+// GetManifestContent returns the raw content of the manifest from the local
+// ORAS cache or from the remote repository
+func (store *orasStore) GetManifestContent(ctx context.Context, subjectReference common.Reference, manifestDesc oci.Descriptor) ([]byte, error) {
 	repository, err := store.createRepository(ctx, store, subjectReference)
 	if err != nil {
-		return ocispecs.ReferenceManifest{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
+		return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to connect to the remote registry").WithError(err)
 	}
 	var manifestBytes []byte
 	// check if manifest exists in local ORAS cache
-	isCached, err := store.localCache.Exists(ctx, referenceDesc.Descriptor)
+	isCached, err := store.localCache.Exists(ctx, manifestDesc)
 	if err != nil {
-		logger.GetLogger(ctx, logOpt).Warnf("failed to check if manifest [%s] exists in cache: %v", referenceDesc.Descriptor.Digest, err)
+		logger.GetLogger(ctx, logOpt).Warnf("failed to check if manifest [%s] exists in cache: %v", manifestDesc.Digest, err)
 	}
 	metrics.ReportBlobCacheCount(ctx, isCached)
 
 	if isCached {
-		manifestBytes, err = store.getRawContentFromCache(ctx, referenceDesc.Descriptor)
+		manifestBytes, err = store.getRawContentFromCache(ctx, manifestDesc)
 		if err != nil {
 			isCached = false
-			logger.GetLogger(ctx, logOpt).Warnf("failed to get manifest [%s] from cache: %v", referenceDesc.Descriptor.Digest, err)
+			logger.GetLogger(ctx, logOpt).Warnf("failed to get manifest [%s] from cache: %v", manifestDesc.Digest, err)
 		}
 	}
 
 	if !isCached {
 		// fetch manifest content from repository
-		manifestReader, err := repository.Fetch(ctx, referenceDesc.Descriptor)
+		manifestReader, err := repository.Fetch(ctx, manifestDesc)
 		if err != nil {
 			evictOnError(ctx, err, subjectReference.Original)
-			return ocispecs.ReferenceManifest{}, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to fetch the artifact metadata from the registry").WithError(err)
+			return nil, re.ErrorCodeRepositoryOperationFailure.WithDetail("Failed to fetch the artifact metadata from the registry").WithError(err)
 		}
 
 		manifestBytes, err = io.ReadAll(manifestReader)
 		if err != nil {
-			return ocispecs.ReferenceManifest{}, re.ErrorCodeManifestInvalid.WithDetail("Failed to parse the artifact metadata").WithError(err)
+			return nil, re.ErrorCodeManifestInvalid.WithDetail("Failed to parse the artifact metadata").WithError(err)
 		}
 
 		// push fetched manifest to local ORAS cache
 		// If multiple goroutines try to push the same manifest to the cache, oras-go
 		// may return `ErrAlreadyExists` error. This is expected and can be ignored.
-		orasExistsExpectedError := fmt.Errorf("%s: %s: %w", referenceDesc.Descriptor.Digest, referenceDesc.Descriptor.MediaType, errdef.ErrAlreadyExists)
-		err = store.localCache.Push(ctx, referenceDesc.Descriptor, bytes.NewReader(manifestBytes))
+		orasExistsExpectedError := fmt.Errorf("%s: %s: %w", manifestDesc.Digest, manifestDesc.MediaType, errdef.ErrAlreadyExists)
+		err = store.localCache.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes))
 		if err != nil && err.Error() != orasExistsExpectedError.Error() {
-			logger.GetLogger(ctx, logOpt).Warnf("failed to save manifest [%s] in cache: %v", referenceDesc.Descriptor.Digest, err)
-		}
-	}
-
-	referenceManifest := ocispecs.ReferenceManifest{}
-
-	// marshal manifest bytes into reference manifest descriptor
-	if referenceDesc.Descriptor.MediaType == oci.MediaTypeImageManifest {
-		var imageManifest oci.Manifest
-		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
-			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.image.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
-		}
-		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
-	} else if referenceDesc.Descriptor.MediaType == ocispecs.MediaTypeArtifactManifest {
-		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
-			return ocispecs.ReferenceManifest{}, re.ErrorCodeDataDecodingFailure.WithDetail("Failed to parse artifact metadata of mediatype `application/vnd.oci.artifact.manifest.v1+json`").WithError(err).WithRemediation("Please check if the artifact metadata was created correctly.")
+			logger.GetLogger(ctx, logOpt).Warnf("failed to save manifest [%s] in cache: %v", manifestDesc.Digest, err)
 		}
-	} else {
-		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Unsupported artifact metadata of media type %s", referenceDesc.Descriptor.MediaType)).WithRemediation("Please check if the artifact metadata was created correctly.")
 	}
 
-	return referenceManifest, nil
+	return manifestBytes, nil
 }
 
 func (store *orasStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
@@ -871,6 +871,19 @@ func TestORASCreate_CreateBaseStore_Failure(t *testing.T) {
 		t.Fatalf("expected error creating oras store")
 	}
 }
+
+func TestORASCreate_InvalidReferrersMode(t *testing.T) {
+	conf := config.StorePluginConfig{
+		"name": "oras",
+		"referrersMode": map[string]interface{}{
+			"mirror.example.io": "fallback",
+		},
+	}
+	if _, err := createBaseStore("1.0.0", conf); err == nil {
+		t.Fatalf("expected error creating oras store with invalid referrers mode")
+	}
+}
+
 func TestORASCreate_CacheProvider_Nil(t *testing.T) {
 	conf := config.StorePluginConfig{
 		"name": "oras",
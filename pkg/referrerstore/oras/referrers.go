@@ -0,0 +1,183 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/metrics"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	// ReferrersModeAPI lists referrers with the Referrers API only
+	ReferrersModeAPI = "api"
+	// ReferrersModeTagSchema lists referrers with the referrers tag schema only
+	ReferrersModeTagSchema = "tagSchema"
+	// ReferrersModeMerge lists referrers with both the Referrers API and the
+	// referrers tag schema and merges the results
+	ReferrersModeMerge = "both-merge"
+	// ReferrersModeAuto lists referrers with the Referrers API and falls back
+	// to the referrers tag schema if the API is not supported
+	ReferrersModeAuto = "auto"
+
+	// referrersModeDefaultRegistry is the key of the referrers mode applied to
+	// registries without a specific mode
+	referrersModeDefaultRegistry = "*"
+	// maxReferrersIndexBytes is the maximum size of a referrers tag schema index
+	maxReferrersIndexBytes int64 = 4 * 1024 * 1024
+)
+
+// validateReferrersMode validates the configured referrers mode of each registry
+func validateReferrersMode(referrersMode map[string]string) error {
+	for registryHost, mode := range referrersMode {
+		switch mode {
+		case ReferrersModeAPI, ReferrersModeTagSchema, ReferrersModeMerge, ReferrersModeAuto:
+		default:
+			return re.ErrorCodeConfigInvalid.WithComponentType(re.ReferrerStore).WithDetail(fmt.Sprintf("unsupported referrersMode %s for registry %s", mode, registryHost)).WithRemediation(fmt.Sprintf("Supported referrers modes are %s, %s, %s and %s.", ReferrersModeAPI, ReferrersModeTagSchema, ReferrersModeMerge, ReferrersModeAuto))
+		}
+	}
+	return nil
+}
+
+// getReferrersMode returns the referrers mode configured for the registry
+func getReferrersMode(registryHost string, config *OrasStoreConf) string {
+	if mode, ok := config.ReferrersMode[registryHost]; ok {
+		return mode
+	}
+	if mode, ok := config.ReferrersMode[referrersModeDefaultRegistry]; ok {
+		return mode
+	}
+	return ReferrersModeAuto
+}
+
+// listReferrerDescriptors lists the referrers of the subject with the
+// referrers mode configured for the registry
+func listReferrerDescriptors(ctx context.Context, repository registry.Repository, registryHost string, mode string, subjectDesc oci.Descriptor) ([]oci.Descriptor, error) {
+	switch mode {
+	case ReferrersModeAPI:
+		metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeAPI)
+		return referrersByAPI(ctx, repository, subjectDesc)
+	case ReferrersModeTagSchema:
+		metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeTagSchema)
+		return referrersByTagSchema(ctx, repository, subjectDesc)
+	case ReferrersModeMerge:
+		metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeAPI)
+		apiReferrers, err := referrersByAPI(ctx, repository, subjectDesc)
+		if err != nil {
+			if !errors.Is(err, errdef.ErrUnsupported) {
+				return nil, err
+			}
+			logger.GetLogger(ctx, logOpt).Debugf("referrers API is not supported by registry %s: %v", registryHost, err)
+		}
+		metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeTagSchema)
+		tagSchemaReferrers, err := referrersByTagSchema(ctx, repository, subjectDesc)
+		if err != nil {
+			return nil, err
+		}
+		return mergeReferrers(apiReferrers, tagSchemaReferrers), nil
+	default:
+		referrers, err := referrersByAPI(ctx, repository, subjectDesc)
+		if err == nil {
+			metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeAPI)
+			return referrers, nil
+		}
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			return nil, err
+		}
+		logger.GetLogger(ctx, logOpt).Debugf("referrers API is not supported by registry %s, falling back to the referrers tag schema: %v", registryHost, err)
+		metrics.ReportReferrersPathCount(ctx, registryHost, mode, ReferrersModeTagSchema)
+		return referrersByTagSchema(ctx, repository, subjectDesc)
+	}
+}
+
+// referrersByAPI lists the referrers with the Referrers API. The fallback of
+// the ORAS repository to the tag schema is disabled so that an unsupported API
+// is reported as errdef.ErrUnsupported.
+func referrersByAPI(ctx context.Context, repository registry.Repository, subjectDesc oci.Descriptor) ([]oci.Descriptor, error) {
+	if remoteRepository, ok := repository.(*remote.Repository); ok {
+		if err := remoteRepository.SetReferrersCapability(true); err != nil {
+			logger.GetLogger(ctx, logOpt).Debugf("failed to force the referrers API: %v", err)
+		}
+	}
+	var referrers []oci.Descriptor
+	if err := repository.Referrers(ctx, subjectDesc, "", func(page []oci.Descriptor) error {
+		referrers = append(referrers, page...)
+		return nil
+	}); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+		return nil, err
+	}
+	return referrers, nil
+}
+
+// referrersByTagSchema lists the referrers from the index tagged with the
+// referrers tag schema <alg>-<ref>
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#referrers-tag-schema
+func referrersByTagSchema(ctx context.Context, repository registry.Repository, subjectDesc oci.Descriptor) ([]oci.Descriptor, error) {
+	referrersTag := buildReferrersTag(subjectDesc.Digest)
+	desc, reader, err := repository.FetchReference(ctx, referrersTag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	if desc.Size > maxReferrersIndexBytes {
+		return nil, fmt.Errorf("referrers index of tag %s exceeds the maximum size of %d bytes", referrersTag, maxReferrersIndexBytes)
+	}
+	indexBytes, err := io.ReadAll(io.LimitReader(reader, maxReferrersIndexBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referrers index of tag %s: %w", referrersTag, err)
+	}
+	var index oci.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode referrers index of tag %s: %w", referrersTag, err)
+	}
+	return index.Manifests, nil
+}
+
+// mergeReferrers merges the referrers lists, deduplicating referrers by digest
+func mergeReferrers(lists ...[]oci.Descriptor) []oci.Descriptor {
+	seen := make(map[digest.Digest]bool)
+	merged := []oci.Descriptor{}
+	for _, list := range lists {
+		for _, referrer := range list {
+			if seen[referrer.Digest] {
+				continue
+			}
+			seen[referrer.Digest] = true
+			merged = append(merged, referrer)
+		}
+	}
+	return merged
+}
+
+// buildReferrersTag builds the referrers tag schema of the subject digest
+func buildReferrersTag(subjectDigest digest.Digest) string {
+	return subjectDigest.Algorithm().String() + "-" + subjectDigest.Encoded()
+}
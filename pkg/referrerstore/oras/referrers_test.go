@@ -0,0 +1,194 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/referrerstore/oras/mocks"
+	"oras.land/oras-go/v2/errdef"
+)
+
+var (
+	referrersSubjectDesc = oci.Descriptor{Digest: digest.FromString("subject")}
+	apiOnlyReferrer      = oci.Descriptor{Digest: digest.FromString("api"), ArtifactType: "application/vnd.cncf.notary.signature"}
+	sharedReferrer       = oci.Descriptor{Digest: digest.FromString("shared"), ArtifactType: "application/spdx+json"}
+	tagOnlyReferrer      = oci.Descriptor{Digest: digest.FromString("tag"), ArtifactType: "application/vnd.in-toto+json"}
+)
+
+func newReferrersTestRepository(t *testing.T, referrersErr error, indexBytes []byte) mocks.TestRepository {
+	t.Helper()
+	repo := mocks.TestRepository{
+		ReferrersList: []oci.Descriptor{apiOnlyReferrer, sharedReferrer},
+		ReferrersErr:  referrersErr,
+		ReferenceMap:  map[string]mocks.BlobPair{},
+	}
+	if indexBytes != nil {
+		repo.ReferenceMap[buildReferrersTag(referrersSubjectDesc.Digest)] = mocks.BlobPair{
+			Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageIndex, Digest: digest.FromBytes(indexBytes), Size: int64(len(indexBytes))},
+			Reader:     io.NopCloser(bytes.NewReader(indexBytes)),
+		}
+	}
+	return repo
+}
+
+// TestListReferrerDescriptors tests the referrers discovery of each referrers mode
+func TestListReferrerDescriptors(t *testing.T) {
+	index, err := json.Marshal(oci.Index{
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: []oci.Descriptor{sharedReferrer, tagOnlyReferrer},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal referrers index: %v", err)
+	}
+	unsupportedErr := fmt.Errorf("failed to query referrers API: %w", errdef.ErrUnsupported)
+
+	tests := []struct {
+		name         string
+		mode         string
+		referrersErr error
+		index        []byte
+		want         []oci.Descriptor
+		wantErr      bool
+	}{
+		{
+			name:  "api mode",
+			mode:  ReferrersModeAPI,
+			index: index,
+			want:  []oci.Descriptor{apiOnlyReferrer, sharedReferrer},
+		},
+		{
+			name:         "api mode with unsupported api",
+			mode:         ReferrersModeAPI,
+			referrersErr: unsupportedErr,
+			index:        index,
+			wantErr:      true,
+		},
+		{
+			name:  "tag schema mode",
+			mode:  ReferrersModeTagSchema,
+			index: index,
+			want:  []oci.Descriptor{sharedReferrer, tagOnlyReferrer},
+		},
+		{
+			name: "tag schema mode without referrers tag",
+			mode: ReferrersModeTagSchema,
+			want: nil,
+		},
+		{
+			name:    "tag schema mode with invalid index",
+			mode:    ReferrersModeTagSchema,
+			index:   []byte("invalid"),
+			wantErr: true,
+		},
+		{
+			name:  "merge mode deduplicates by digest",
+			mode:  ReferrersModeMerge,
+			index: index,
+			want:  []oci.Descriptor{apiOnlyReferrer, sharedReferrer, tagOnlyReferrer},
+		},
+		{
+			name:         "merge mode with unsupported api",
+			mode:         ReferrersModeMerge,
+			referrersErr: unsupportedErr,
+			index:        index,
+			want:         []oci.Descriptor{sharedReferrer, tagOnlyReferrer},
+		},
+		{
+			name:         "merge mode with api failure",
+			mode:         ReferrersModeMerge,
+			referrersErr: errors.New("internal server error"),
+			index:        index,
+			wantErr:      true,
+		},
+		{
+			name:  "auto mode with supported api",
+			mode:  ReferrersModeAuto,
+			index: index,
+			want:  []oci.Descriptor{apiOnlyReferrer, sharedReferrer},
+		},
+		{
+			name:         "auto mode falls back to tag schema",
+			mode:         ReferrersModeAuto,
+			referrersErr: unsupportedErr,
+			index:        index,
+			want:         []oci.Descriptor{sharedReferrer, tagOnlyReferrer},
+		},
+		{
+			name:         "auto mode with api failure",
+			mode:         ReferrersModeAuto,
+			referrersErr: errors.New("internal server error"),
+			index:        index,
+			wantErr:      true,
+		},
+		{
+			name:         "not found subject",
+			mode:         ReferrersModeAuto,
+			referrersErr: errdef.ErrNotFound,
+			want:         nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newReferrersTestRepository(t, tt.referrersErr, tt.index)
+			got, err := listReferrerDescriptors(context.Background(), repo, "test.registry.io", tt.mode, referrersSubjectDesc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listReferrerDescriptors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("listReferrerDescriptors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetReferrersMode tests the lookup of the referrers mode of a registry
+func TestGetReferrersMode(t *testing.T) {
+	conf := &OrasStoreConf{
+		ReferrersMode: map[string]string{
+			"mirror.example.io": ReferrersModeTagSchema,
+			"*":                 ReferrersModeMerge,
+		},
+	}
+	if mode := getReferrersMode("mirror.example.io", conf); mode != ReferrersModeTagSchema {
+		t.Fatalf("getReferrersMode() = %s, want %s", mode, ReferrersModeTagSchema)
+	}
+	if mode := getReferrersMode("other.example.io", conf); mode != ReferrersModeMerge {
+		t.Fatalf("getReferrersMode() = %s, want %s", mode, ReferrersModeMerge)
+	}
+	if mode := getReferrersMode("other.example.io", &OrasStoreConf{}); mode != ReferrersModeAuto {
+		t.Fatalf("getReferrersMode() = %s, want %s", mode, ReferrersModeAuto)
+	}
+}
+
+// TestValidateReferrersMode tests the validation of the configured referrers modes
+func TestValidateReferrersMode(t *testing.T) {
+	if err := validateReferrersMode(map[string]string{"*": ReferrersModeAuto, "mirror.example.io": ReferrersModeMerge}); err != nil {
+		t.Fatalf("validateReferrersMode() unexpected error: %v", err)
+	}
+	if err := validateReferrersMode(map[string]string{"mirror.example.io": "fallback"}); err == nil {
+		t.Fatalf("validateReferrersMode() expected error for unsupported mode")
+	}
+}
@@ -46,12 +46,17 @@ func NewStore(version string, storeConfig config.StorePluginConfig, pluginPaths
 		return nil, fmt.Errorf("failed to find store name in the stores config with key %s", "name")
 	}
 
+	policy, err := pluginCommon.ParseExecutionPolicy(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &StorePlugin{
 		name:      fmt.Sprintf("%s", storeName),
 		version:   version,
 		path:      pluginPaths,
 		rawConfig: storeConfig,
-		executor:  &pluginCommon.DefaultExecutor{Stderr: os.Stderr},
+		executor:  &pluginCommon.DefaultExecutor{Stderr: os.Stderr, Policy: policy},
 	}, nil
 }
 
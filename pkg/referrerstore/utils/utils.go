@@ -17,7 +17,9 @@ package utils
 
 import (
 	"context"
+	"fmt"
 
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/ratify-project/ratify/errors"
 	"github.com/ratify-project/ratify/internal/logger"
 	"github.com/ratify-project/ratify/pkg/common"
@@ -40,3 +42,16 @@ func ResolveSubjectDescriptor(ctx context.Context, stores *[]referrerstore.Refer
 
 	return nil, errors.ErrorCodeReferrerStoreFailure.WithDetail("could not resolve descriptor for a subject from any stores").WithComponentType(errors.ReferrerStore)
 }
+
+// GetImageIndexManifests returns the child manifests of the image index from
+// the first store that is able to fetch it.
+func GetImageIndexManifests(ctx context.Context, stores *[]referrerstore.ReferrerStore, subRef common.Reference, indexDesc *ocispecs.SubjectDescriptor) ([]oci.Descriptor, error) {
+	for _, referrerStore := range *stores {
+		manifest, err := referrerStore.GetReferenceManifest(ctx, subRef, ocispecs.ReferenceDescriptor{Descriptor: indexDesc.Descriptor})
+		if err == nil {
+			return manifest.Manifests, nil
+		}
+		logger.GetLogger(ctx, logOpt).Warn(errors.ErrorCodeGetReferenceManifestFailure.NewError(errors.ReferrerStore, referrerStore.Name(), errors.EmptyLink, err, "failed to fetch the image index", errors.HideStackTrace))
+	}
+	return nil, errors.ErrorCodeReferrerStoreFailure.WithDetail(fmt.Sprintf("could not fetch image index %s from any stores", indexDesc.Digest)).WithComponentType(errors.ReferrerStore)
+}
@@ -209,6 +209,21 @@ func TestCreateVerifierFromConfig(t *testing.T) {
 				"name": "test-verifier",
 				"source": map[string]interface{}{
 					"artifact": "invalid",
+					"verification": map[string]interface{}{
+						"skip": true,
+					},
+				},
+			},
+			pluginBinDir:         []string{"test/path"},
+			dynamicPluginEnabled: true,
+			expectedErr:          true,
+		},
+		{
+			name: "plugin source without verification",
+			verifierConfig: config.VerifierConfig{
+				"name": "test-verifier",
+				"source": map[string]interface{}{
+					"artifact": "wabbitnetworks.azurecr.io/test/sample-verifier-plugin:v1",
 				},
 			},
 			pluginBinDir:         []string{"test/path"},
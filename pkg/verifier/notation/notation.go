@@ -77,6 +77,9 @@ type NotationPluginVerifierConfig struct { //nolint:revive // ignore linter to h
 	VerificationCertStores verificationCertStores `json:"verificationCertStores"`
 	// TrustPolicyDoc represents a trustpolicy.json document. Reference: https://pkg.go.dev/github.com/notaryproject/notation-go@v0.12.0-beta.1.0.20221125022016-ab113ebd2a6c/verifier/trustpolicy#Document
 	TrustPolicyDoc trustpolicy.Document `json:"trustPolicyDoc"`
+	// Revocation enables the revocation checking of the code signing and
+	// timestamping certificate chains with CRLs cached by the cache provider.
+	Revocation *RevocationConfig `json:"revocation,omitempty"`
 }
 
 type notationPluginVerifier struct {
@@ -84,6 +87,7 @@ type notationPluginVerifier struct {
 	verifierType     string
 	artifactTypes    []string
 	notationVerifier *notation.Verifier
+	revocation       *revocationChecker
 }
 
 type notationPluginVerifierFactory struct{}
@@ -109,12 +113,18 @@ func (f *notationPluginVerifierFactory) Create(_ string, verifierConfig config.V
 		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Notation Verifier").WithError(err)
 	}
 
+	revocation, err := newRevocationChecker(conf.Revocation)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Notation Verifier").WithError(err)
+	}
+
 	artifactTypes := strings.Split(conf.ArtifactTypes, ",")
 	return &notationPluginVerifier{
 		name:             verifierName,
 		verifierType:     verifierTypeStr,
 		artifactTypes:    artifactTypes,
 		notationVerifier: &verifyService,
+		revocation:       revocation,
 	}, nil
 }
 
@@ -139,7 +149,7 @@ func (v *notationPluginVerifier) Verify(ctx context.Context,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	store referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
-	extensions := make(map[string]string)
+	extensions := make(map[string]interface{})
 
 	subjectDesc, err := store.GetSubjectDescriptor(ctx, subjectReference)
 	if err != nil {
@@ -173,6 +183,16 @@ func (v *notationPluginVerifier) Verify(ctx context.Context,
 	extensions["Issuer"] = cert.Issuer.String()
 	extensions["SN"] = cert.Subject.String()
 
+	if v.revocation != nil {
+		revocationResult := v.revocation.check(ctx, &outcome.EnvelopeContent.SignerInfo)
+		extensions[RevocationExtension] = revocationResult
+		// a failed revocation check is reported as failed result, not as
+		// error, to keep the revocation extension in the report
+		if verifierErr := revocationResult.err(v.revocation.failOpen); verifierErr != nil {
+			return verifier.NewVerifierResult("", v.name, v.verifierType, "", false, verifierErr, extensions), nil
+		}
+	}
+
 	return verifier.NewVerifierResult("", v.name, v.verifierType, "Notation signature verification success", true, nil, extensions), nil
 }
 
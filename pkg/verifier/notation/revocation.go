@@ -0,0 +1,469 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/tspclient-go"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/cache"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	RevocationMethodOCSP string = "ocsp"
+	RevocationMethodCRL  string = "crl"
+
+	RevocationChainCodeSigning  string = "codeSigning"
+	RevocationChainTimestamping string = "timestamping"
+
+	RevocationFailClosed string = "failClosed"
+	RevocationFailOpen   string = "failOpen"
+
+	RevocationStatusGood         string = "good"
+	RevocationStatusRevoked      string = "revoked"
+	RevocationStatusUnknown      string = "unknown"
+	RevocationStatusNonRevokable string = "nonRevokable"
+
+	// RevocationExtension is the key of the revocation result in the verifier result extensions
+	RevocationExtension string = "revocation"
+
+	defaultRevocationTimeout time.Duration = 5 * time.Second
+	defaultCRLMaxCacheTTL    time.Duration = 24 * time.Hour
+	// maxCRLSize is the maximum size of a downloaded CRL
+	maxCRLSize int64 = 32 * 1024 * 1024
+	// maxOCSPResponseSize is the maximum size of an OCSP response
+	maxOCSPResponseSize int64 = 20 * 1024
+)
+
+// RevocationConfig configures the Ratify managed revocation checking of the
+// certificate chains of Notation signatures. The revocation check of the
+// Notation trust policy can be set to skip when this is enabled to avoid
+// checking revocation twice.
+type RevocationConfig struct {
+	// Methods are the revocation checking methods in order of preference.
+	// The next method is used when the status cannot be determined.
+	// Defaults to ocsp then crl.
+	Methods []string `json:"methods,omitempty"`
+	// Chains are the certificate chains checked. Defaults to codeSigning and timestamping.
+	Chains []string `json:"chains,omitempty"`
+	// FailureMode is applied when the revocation status of a certificate
+	// cannot be determined, for example when the CRL endpoint is unreachable.
+	// failClosed fails the verification, failOpen ignores the certificate.
+	// Defaults to failClosed.
+	FailureMode string `json:"failureMode,omitempty"`
+	// Timeout of each OCSP or CRL request. Defaults to 5s.
+	Timeout string `json:"timeout,omitempty"`
+	// CRLMaxCacheTTL is the maximum duration a CRL is cached. CRLs are never
+	// cached beyond their next update. Defaults to 24h.
+	CRLMaxCacheTTL string `json:"crlMaxCacheTTL,omitempty"`
+}
+
+// revocationResult is the revocation status of the certificate chains of a signature
+type revocationResult struct {
+	// Status is the aggregated status of all checked certificates
+	Status       string                        `json:"status"`
+	Certificates []certificateRevocationResult `json:"certificates"`
+}
+
+// certificateRevocationResult is the revocation status of a single certificate
+type certificateRevocationResult struct {
+	Chain        string     `json:"chain"`
+	Subject      string     `json:"subject"`
+	SerialNumber string     `json:"serialNumber"`
+	Status       string     `json:"status"`
+	Method       string     `json:"method,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// crlCacheEntry is the value of a CRL in the cache
+type crlCacheEntry struct {
+	// Raw is the DER encoded CRL
+	Raw []byte `json:"raw"`
+}
+
+type revocationChecker struct {
+	methods        []string
+	chains         []string
+	failOpen       bool
+	crlMaxCacheTTL time.Duration
+	httpClient     *http.Client
+}
+
+// newRevocationChecker validates the revocation configuration and creates
+// the checker. It returns nil if revocation checking is not configured.
+func newRevocationChecker(config *RevocationConfig) (*revocationChecker, error) {
+	if config == nil {
+		return nil, nil
+	}
+	checker := &revocationChecker{
+		methods:        config.Methods,
+		chains:         config.Chains,
+		crlMaxCacheTTL: defaultCRLMaxCacheTTL,
+	}
+	if len(checker.methods) == 0 {
+		checker.methods = []string{RevocationMethodOCSP, RevocationMethodCRL}
+	}
+	for _, method := range checker.methods {
+		if method != RevocationMethodOCSP && method != RevocationMethodCRL {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Unsupported revocation method %s", method)).WithRemediation(fmt.Sprintf("Supported methods are %s and %s.", RevocationMethodOCSP, RevocationMethodCRL))
+		}
+	}
+	if len(checker.chains) == 0 {
+		checker.chains = []string{RevocationChainCodeSigning, RevocationChainTimestamping}
+	}
+	for _, chain := range checker.chains {
+		if chain != RevocationChainCodeSigning && chain != RevocationChainTimestamping {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Unsupported revocation chain %s", chain)).WithRemediation(fmt.Sprintf("Supported chains are %s and %s.", RevocationChainCodeSigning, RevocationChainTimestamping))
+		}
+	}
+	switch config.FailureMode {
+	case "", RevocationFailClosed:
+	case RevocationFailOpen:
+		checker.failOpen = true
+	default:
+		return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Unsupported revocation failureMode %s", config.FailureMode)).WithRemediation(fmt.Sprintf("Supported failure modes are %s and %s.", RevocationFailClosed, RevocationFailOpen))
+	}
+	timeout := defaultRevocationTimeout
+	if config.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(config.Timeout); err != nil || timeout <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid revocation timeout %s", config.Timeout)).WithError(err)
+		}
+	}
+	if config.CRLMaxCacheTTL != "" {
+		ttl, err := time.ParseDuration(config.CRLMaxCacheTTL)
+		if err != nil || ttl <= 0 {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Invalid revocation crlMaxCacheTTL %s", config.CRLMaxCacheTTL)).WithError(err)
+		}
+		checker.crlMaxCacheTTL = ttl
+	}
+	checker.httpClient = &http.Client{Timeout: timeout}
+	return checker, nil
+}
+
+// check returns the revocation status of the configured certificate chains
+// of the signature
+func (c *revocationChecker) check(ctx context.Context, signerInfo *signature.SignerInfo) *revocationResult {
+	result := &revocationResult{Status: RevocationStatusGood, Certificates: []certificateRevocationResult{}}
+	if slices.Contains(c.chains, RevocationChainCodeSigning) {
+		result.add(c.checkChain(ctx, RevocationChainCodeSigning, signerInfo.CertificateChain))
+	}
+	if slices.Contains(c.chains, RevocationChainTimestamping) && len(signerInfo.UnsignedAttributes.TimestampSignature) > 0 {
+		certs, err := timestampCertificates(signerInfo.UnsignedAttributes.TimestampSignature)
+		if err != nil {
+			result.add([]certificateRevocationResult{{Chain: RevocationChainTimestamping, Status: RevocationStatusUnknown, Error: err.Error()}})
+		} else {
+			result.add(c.checkChain(ctx, RevocationChainTimestamping, orderChain(certs)))
+		}
+	}
+	if result.Status == RevocationStatusUnknown && c.failOpen {
+		logger.GetLogger(ctx, logOpt).Warnf("revocation status of the signature certificates could not be determined, ignoring due to %s failure mode", RevocationFailOpen)
+	}
+	return result
+}
+
+// err returns an error if a certificate is revoked, or if the revocation
+// status of a certificate is unknown and the failure mode is failClosed
+func (r *revocationResult) err(failOpen bool) *re.Error {
+	var err re.Error
+	switch r.Status {
+	case RevocationStatusRevoked:
+		err = re.ErrorCodeVerifyReferenceFailure.WithDetail("The certificate chain of the Notation signature contains a revoked certificate").WithRemediation("Please refer to the revocation field of the verifier result extensions for the revoked certificate.")
+	case RevocationStatusUnknown:
+		if failOpen {
+			return nil
+		}
+		err = re.ErrorCodeVerifyReferenceFailure.WithDetail("The revocation status of the certificate chain of the Notation signature could not be determined").WithRemediation(fmt.Sprintf("Please check that the OCSP and CRL endpoints are reachable, or set the revocation failureMode to %s.", RevocationFailOpen))
+	default:
+		return nil
+	}
+	return &err
+}
+
+// add appends the certificate results and updates the aggregated status.
+// revoked takes precedence over unknown which takes precedence over good.
+func (r *revocationResult) add(certificates []certificateRevocationResult) {
+	for _, certificate := range certificates {
+		r.Certificates = append(r.Certificates, certificate)
+		switch {
+		case certificate.Status == RevocationStatusRevoked:
+			r.Status = RevocationStatusRevoked
+		case certificate.Status == RevocationStatusUnknown && r.Status != RevocationStatusRevoked:
+			r.Status = RevocationStatusUnknown
+		}
+	}
+}
+
+// checkChain checks each certificate of the chain ordered from leaf to root
+// against its issuer. The self-signed root is not checked.
+func (c *revocationChecker) checkChain(ctx context.Context, chainName string, chain []*x509.Certificate) []certificateRevocationResult {
+	results := []certificateRevocationResult{}
+	for i := 0; i+1 < len(chain); i++ {
+		cert, issuer := chain[i], chain[i+1]
+		result := certificateRevocationResult{
+			Chain:        chainName,
+			Subject:      cert.Subject.String(),
+			SerialNumber: cert.SerialNumber.String(),
+			Status:       RevocationStatusNonRevokable,
+		}
+		errs := []string{}
+		for _, method := range c.methods {
+			var status certificateRevocationResult
+			var err error
+			switch method {
+			case RevocationMethodOCSP:
+				if len(cert.OCSPServer) == 0 {
+					continue
+				}
+				status, err = c.checkOCSP(ctx, cert, issuer)
+			case RevocationMethodCRL:
+				if len(cert.CRLDistributionPoints) == 0 {
+					continue
+				}
+				status, err = c.checkCRL(ctx, cert, issuer)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", method, err))
+				result.Status = RevocationStatusUnknown
+				continue
+			}
+			result.Status, result.Method, result.RevokedAt = status.Status, method, status.RevokedAt
+			break
+		}
+		if result.Status == RevocationStatusUnknown {
+			result.Error = strings.Join(errs, "; ")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkOCSP queries the OCSP servers of the certificate in order until one responds
+func (c *revocationChecker) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate) (certificateRevocationResult, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return certificateRevocationResult{}, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		response, err := c.postOCSP(ctx, server, request, cert, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch response.Status {
+		case ocsp.Good:
+			return certificateRevocationResult{Status: RevocationStatusGood}, nil
+		case ocsp.Revoked:
+			return certificateRevocationResult{Status: RevocationStatusRevoked, RevokedAt: &response.RevokedAt}, nil
+		default:
+			lastErr = fmt.Errorf("OCSP server %s returned unknown status", server)
+		}
+	}
+	return certificateRevocationResult{}, lastErr
+}
+
+func (c *revocationChecker) postOCSP(ctx context.Context, server string, request []byte, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	if !strings.HasPrefix(strings.ToLower(server), "http") {
+		return nil, fmt.Errorf("unsupported OCSP server %s", server)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/ocsp-request")
+	body, err := c.get(httpRequest, maxOCSPResponseSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OCSP server %s: %w", server, err)
+	}
+	response, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response from OCSP server %s: %w", server, err)
+	}
+	if !response.NextUpdate.IsZero() && time.Now().After(response.NextUpdate) {
+		return nil, fmt.Errorf("expired response from OCSP server %s", server)
+	}
+	return response, nil
+}
+
+// checkCRL looks up the certificate in the CRLs of its distribution points.
+// The status is determined by the first CRL that could be fetched.
+func (c *revocationChecker) checkCRL(ctx context.Context, cert, issuer *x509.Certificate) (certificateRevocationResult, error) {
+	var lastErr error
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := c.fetchCRL(ctx, url, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				revokedAt := entry.RevocationTime
+				return certificateRevocationResult{Status: RevocationStatusRevoked, RevokedAt: &revokedAt}, nil
+			}
+		}
+		return certificateRevocationResult{Status: RevocationStatusGood}, nil
+	}
+	return certificateRevocationResult{}, lastErr
+}
+
+// fetchCRL returns the CRL from the cache or downloads it. Downloaded CRLs are
+// cached until their next update so that they are shared by all replicas
+// using the same cache provider.
+func (c *revocationChecker) fetchCRL(ctx context.Context, url string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	cacheProvider := cache.GetCacheProvider()
+	cacheKey := fmt.Sprintf(cache.CacheKeyCRL, url)
+	if cacheProvider != nil {
+		if value, found := cacheProvider.Get(ctx, cacheKey); found && value != "" {
+			var entry crlCacheEntry
+			if err := json.Unmarshal([]byte(value), &entry); err != nil {
+				logger.GetLogger(ctx, logOpt).Warn(re.ErrorCodeDataDecodingFailure.NewError(re.Cache, "", re.EmptyLink, err, fmt.Sprintf("failed to unmarshal CRL cache value of %s", url), re.HideStackTrace))
+			} else if crl, err := parseCRL(entry.Raw, issuer); err == nil {
+				logger.GetLogger(ctx, logOpt).Debugf("CRL cache hit for %s", url)
+				return crl, nil
+			}
+		}
+	}
+
+	if !strings.HasPrefix(strings.ToLower(url), "http") {
+		return nil, fmt.Errorf("unsupported CRL distribution point %s", url)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.get(request, maxCRLSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download CRL from %s: %w", url, err)
+	}
+	crl, err := parseCRL(raw, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL from %s: %w", url, err)
+	}
+
+	if cacheProvider != nil {
+		ttl := c.crlMaxCacheTTL
+		if !crl.NextUpdate.IsZero() && time.Until(crl.NextUpdate) < ttl {
+			ttl = time.Until(crl.NextUpdate)
+		}
+		if !cacheProvider.SetWithTTL(ctx, cacheKey, crlCacheEntry{Raw: raw}, ttl) {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to cache CRL of %s", url)
+		}
+	}
+	return crl, nil
+}
+
+// get sends the request and returns the response body
+func (c *revocationChecker) get(request *http.Request, maxSize int64) ([]byte, error) {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("response exceeds the maximum size of %d bytes", maxSize)
+	}
+	return body, nil
+}
+
+// parseCRL parses the CRL and validates that it is issued by the issuer and
+// not expired
+func parseCRL(raw []byte, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL is not signed by %s: %w", issuer.Subject, err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return nil, fmt.Errorf("CRL expired at %s", crl.NextUpdate)
+	}
+	return crl, nil
+}
+
+// timestampCertificates returns the certificates of the timestamp token
+func timestampCertificates(timestampSignature []byte) ([]*x509.Certificate, error) {
+	token, err := tspclient.ParseSignedToken(timestampSignature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp token: %w", err)
+	}
+	return token.Certificates, nil
+}
+
+// orderChain orders the certificates from leaf to root by following the
+// issuer of each certificate
+func orderChain(certs []*x509.Certificate) []*x509.Certificate {
+	if len(certs) == 0 {
+		return certs
+	}
+	isIssuer := make(map[int]bool)
+	for i, cert := range certs {
+		for j, candidate := range certs {
+			if i != j && cert.CheckSignatureFrom(candidate) == nil {
+				isIssuer[j] = true
+			}
+		}
+	}
+	leaf := certs[0]
+	for i, cert := range certs {
+		if !isIssuer[i] {
+			leaf = cert
+			break
+		}
+	}
+	chain := []*x509.Certificate{leaf}
+	for len(chain) < len(certs) {
+		current := chain[len(chain)-1]
+		if current.CheckSignatureFrom(current) == nil {
+			break
+		}
+		var next *x509.Certificate
+		for _, candidate := range certs {
+			if candidate != current && current.CheckSignatureFrom(candidate) == nil {
+				next = candidate
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+	}
+	return chain
+}
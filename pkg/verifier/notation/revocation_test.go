@@ -0,0 +1,557 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/cache"
+	e "github.com/ratify-project/ratify/pkg/executor"
+	exConfig "github.com/ratify-project/ratify/pkg/executor/config"
+	ef "github.com/ratify-project/ratify/pkg/executor/core"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/policyprovider/configpolicy"
+	policyTypes "github.com/ratify-project/ratify/pkg/policyprovider/types"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"golang.org/x/crypto/ocsp"
+)
+
+const testCRLCacheType = "crlTestCache"
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// crlTestCache is a map backed cache provider used to verify CRL caching
+type crlTestCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+type crlTestCacheFactory struct{}
+
+func (f *crlTestCacheFactory) Create(_ context.Context, _ string, _ int) (cache.CacheProvider, error) {
+	return &crlTestCache{entries: map[string]string{}}, nil
+}
+
+func (c *crlTestCache) Get(_ context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+func (c *crlTestCache) Set(ctx context.Context, key string, value interface{}) bool {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+func (c *crlTestCache) SetWithTTL(_ context.Context, key string, value interface{}, _ time.Duration) bool {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = string(bytes)
+	return true
+}
+
+func (c *crlTestCache) Delete(_ context.Context, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return true
+}
+
+func init() {
+	cache.Register(testCRLCacheType, &crlTestCacheFactory{})
+}
+
+// newTestCertificate creates a self-signed root CA if issuer is nil, or a leaf
+// certificate issued by issuer otherwise
+func newTestCertificate(t *testing.T, serial int64, issuer *testCertificate, crlURL, ocspURL string) *testCertificate {
+	t.Helper()
+	return createTestCertificate(t, serial, issuer, issuer == nil, crlURL, ocspURL)
+}
+
+func createTestCertificate(t *testing.T, serial int64, issuer *testCertificate, isCA bool, crlURL, ocspURL string) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "ratify test " + big.NewInt(serial).String()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if crlURL != "" {
+		template.CRLDistributionPoints = []string{crlURL}
+	}
+	if ocspURL != "" {
+		template.OCSPServer = []string{ocspURL}
+	}
+	if isCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return &testCertificate{cert: cert, key: key}
+}
+
+// newCRLServer returns a CRL server revoking the given serial numbers and
+// the counter of the requests served
+func newCRLServer(t *testing.T, issuer *testCertificate, revoked ...int64) (*httptest.Server, *int32) {
+	t.Helper()
+	entries := []x509.RevocationListEntry{}
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now().Add(-time.Minute)})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, issuer.cert, issuer.key)
+	if err != nil {
+		t.Fatalf("failed to create CRL: %v", err)
+	}
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write(crl)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// newOCSPServer returns an OCSP server responding with the given status for all certificates
+func newOCSPServer(t *testing.T, issuer *testCertificate, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		response, err := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, issuer.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestRevocationChecker(t *testing.T, config RevocationConfig) *revocationChecker {
+	t.Helper()
+	checker, err := newRevocationChecker(&config)
+	if err != nil {
+		t.Fatalf("newRevocationChecker() unexpected error: %v", err)
+	}
+	return checker
+}
+
+// TestNewRevocationChecker tests the validation of the revocation configuration
+func TestNewRevocationChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *RevocationConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{
+			name:    "revocation not configured",
+			wantNil: true,
+		},
+		{
+			name:   "default configuration",
+			config: &RevocationConfig{},
+		},
+		{
+			name: "valid configuration",
+			config: &RevocationConfig{
+				Methods:        []string{RevocationMethodCRL},
+				Chains:         []string{RevocationChainCodeSigning},
+				FailureMode:    RevocationFailOpen,
+				Timeout:        "2s",
+				CRLMaxCacheTTL: "1h",
+			},
+		},
+		{
+			name:    "unsupported method",
+			config:  &RevocationConfig{Methods: []string{"ldap"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported chain",
+			config:  &RevocationConfig{Chains: []string{"authenticode"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure mode",
+			config:  &RevocationConfig{FailureMode: "ignore"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timeout",
+			config:  &RevocationConfig{Timeout: "-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid crl max cache ttl",
+			config:  &RevocationConfig{CRLMaxCacheTTL: "forever"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := newRevocationChecker(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRevocationChecker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (checker == nil) != tt.wantNil {
+				t.Fatalf("newRevocationChecker() checker = %v, wantNil %v", checker, tt.wantNil)
+			}
+		})
+	}
+}
+
+// TestCheck_CRL tests the revocation checking of the code signing chain with CRLs
+func TestCheck_CRL(t *testing.T) {
+	root := newTestCertificate(t, 1, nil, "", "")
+	crlServer, _ := newCRLServer(t, root, 3)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name        string
+		leaf        *testCertificate
+		failureMode string
+		wantStatus  string
+		wantErr     bool
+	}{
+		{
+			name:       "good certificate",
+			leaf:       newTestCertificate(t, 2, root, crlServer.URL, ""),
+			wantStatus: RevocationStatusGood,
+		},
+		{
+			name:       "revoked certificate",
+			leaf:       newTestCertificate(t, 3, root, crlServer.URL, ""),
+			wantStatus: RevocationStatusRevoked,
+			wantErr:    true,
+		},
+		{
+			name:       "certificate without distribution point",
+			leaf:       newTestCertificate(t, 4, root, "", ""),
+			wantStatus: RevocationStatusGood,
+		},
+		{
+			name:        "unreachable crl fails closed",
+			leaf:        newTestCertificate(t, 5, root, unreachable.URL, ""),
+			failureMode: RevocationFailClosed,
+			wantStatus:  RevocationStatusUnknown,
+			wantErr:     true,
+		},
+		{
+			name:        "unreachable crl fails open",
+			leaf:        newTestCertificate(t, 6, root, unreachable.URL, ""),
+			failureMode: RevocationFailOpen,
+			wantStatus:  RevocationStatusUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := newTestRevocationChecker(t, RevocationConfig{FailureMode: tt.failureMode, Timeout: "1s"})
+			result := checker.check(context.Background(), &signature.SignerInfo{
+				CertificateChain: []*x509.Certificate{tt.leaf.cert, root.cert},
+			})
+			if result.Status != tt.wantStatus {
+				t.Fatalf("check() status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if len(result.Certificates) != 1 {
+				t.Fatalf("check() expected 1 certificate result, got %d", len(result.Certificates))
+			}
+			if err := result.err(checker.failOpen); (err != nil) != tt.wantErr {
+				t.Fatalf("err() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCheck_NonRevokable tests that certificates without revocation
+// information are reported as non revokable
+func TestCheck_NonRevokable(t *testing.T) {
+	root := newTestCertificate(t, 1, nil, "", "")
+	leaf := newTestCertificate(t, 2, root, "", "")
+	checker := newTestRevocationChecker(t, RevocationConfig{})
+	result := checker.check(context.Background(), &signature.SignerInfo{
+		CertificateChain: []*x509.Certificate{leaf.cert, root.cert},
+	})
+	if result.Certificates[0].Status != RevocationStatusNonRevokable {
+		t.Fatalf("check() certificate status = %s, want %s", result.Certificates[0].Status, RevocationStatusNonRevokable)
+	}
+}
+
+// TestCheck_OCSP tests the revocation checking with OCSP and the fallback to CRLs
+func TestCheck_OCSP(t *testing.T) {
+	root := newTestCertificate(t, 1, nil, "", "")
+	crlServer, crlRequests := newCRLServer(t, root, 3)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name       string
+		leaf       *testCertificate
+		wantStatus string
+		wantMethod string
+	}{
+		{
+			name:       "good certificate",
+			leaf:       newTestCertificate(t, 2, root, crlServer.URL, newOCSPServer(t, root, ocsp.Good).URL),
+			wantStatus: RevocationStatusGood,
+			wantMethod: RevocationMethodOCSP,
+		},
+		{
+			name:       "revoked certificate",
+			leaf:       newTestCertificate(t, 4, root, crlServer.URL, newOCSPServer(t, root, ocsp.Revoked).URL),
+			wantStatus: RevocationStatusRevoked,
+			wantMethod: RevocationMethodOCSP,
+		},
+		{
+			name:       "fallback to crl",
+			leaf:       newTestCertificate(t, 3, root, crlServer.URL, unreachable.URL),
+			wantStatus: RevocationStatusRevoked,
+			wantMethod: RevocationMethodCRL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := atomic.LoadInt32(crlRequests)
+			checker := newTestRevocationChecker(t, RevocationConfig{Timeout: "1s"})
+			result := checker.check(context.Background(), &signature.SignerInfo{
+				CertificateChain: []*x509.Certificate{tt.leaf.cert, root.cert},
+			})
+			if result.Status != tt.wantStatus {
+				t.Fatalf("check() status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Certificates[0].Method != tt.wantMethod {
+				t.Fatalf("check() method = %s, want %s", result.Certificates[0].Method, tt.wantMethod)
+			}
+			if tt.wantMethod == RevocationMethodOCSP && atomic.LoadInt32(crlRequests) != before {
+				t.Fatalf("check() expected no CRL download when OCSP succeeds")
+			}
+		})
+	}
+}
+
+// TestFetchCRL_Cache tests that CRLs are downloaded once and shared through the cache provider
+func TestFetchCRL_Cache(t *testing.T) {
+	ctx := context.Background()
+	if _, err := cache.NewCacheProvider(ctx, testCRLCacheType, cache.DefaultCacheName, cache.DefaultCacheSize); err != nil {
+		t.Fatalf("failed to create cache provider: %v", err)
+	}
+	root := newTestCertificate(t, 1, nil, "", "")
+	crlServer, requests := newCRLServer(t, root, 3)
+	leaf := newTestCertificate(t, 2, root, crlServer.URL, "")
+
+	for i := 0; i < 2; i++ {
+		// a new checker per iteration stands in for another replica
+		checker := newTestRevocationChecker(t, RevocationConfig{Methods: []string{RevocationMethodCRL}})
+		result := checker.check(ctx, &signature.SignerInfo{
+			CertificateChain: []*x509.Certificate{leaf.cert, root.cert},
+		})
+		if result.Status != RevocationStatusGood {
+			t.Fatalf("check() status = %s, want %s", result.Status, RevocationStatusGood)
+		}
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("expected CRL to be downloaded once, got %d downloads", got)
+	}
+
+	// a CRL signed by another issuer is not trusted from the cache
+	other := newTestCertificate(t, 10, nil, "", "")
+	checker := newTestRevocationChecker(t, RevocationConfig{Methods: []string{RevocationMethodCRL}})
+	if _, err := checker.fetchCRL(ctx, crlServer.URL, other.cert); err == nil {
+		t.Fatalf("fetchCRL() expected error for CRL of another issuer")
+	}
+}
+
+// TestOrderChain tests ordering of the timestamping certificates from leaf to root
+func TestOrderChain(t *testing.T) {
+	root := newTestCertificate(t, 1, nil, "", "")
+	intermediate := createTestCertificate(t, 2, root, true, "", "")
+	leaf := newTestCertificate(t, 3, intermediate, "", "")
+
+	chain := orderChain([]*x509.Certificate{root.cert, leaf.cert, intermediate.cert})
+	want := []*x509.Certificate{leaf.cert, intermediate.cert, root.cert}
+	if len(chain) != len(want) {
+		t.Fatalf("orderChain() returned %d certificates, want %d", len(chain), len(want))
+	}
+	for i := range want {
+		if !chain[i].Equal(want[i]) {
+			t.Fatalf("orderChain() certificate %d = %s, want %s", i, chain[i].Subject, want[i].Subject)
+		}
+	}
+}
+
+// TestCheck_InvalidTimestamp tests that an invalid timestamp token results in an unknown status
+func TestCheck_InvalidTimestamp(t *testing.T) {
+	checker := newTestRevocationChecker(t, RevocationConfig{Chains: []string{RevocationChainTimestamping}})
+	signerInfo := &signature.SignerInfo{}
+	signerInfo.UnsignedAttributes.TimestampSignature = []byte("invalid")
+	result := checker.check(context.Background(), signerInfo)
+	if result.Status != RevocationStatusUnknown {
+		t.Fatalf("check() status = %s, want %s", result.Status, RevocationStatusUnknown)
+	}
+}
+
+// chainNotationVerifier accepts every signature with the given certificate chain
+type chainNotationVerifier struct {
+	chain []*x509.Certificate
+}
+
+func (v chainNotationVerifier) Verify(_ context.Context, _ oci.Descriptor, _ []byte, _ notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{CertificateChain: v.chain},
+		},
+	}, nil
+}
+
+// TestVerify_RevocationInExecutorReport tests that the revocation extension is
+// kept in the executor report when the signing certificate is revoked
+func TestVerify_RevocationInExecutorReport(t *testing.T) {
+	root := newTestCertificate(t, 1, nil, "", "")
+	crlServer, _ := newCRLServer(t, root, 2)
+	leaf := newTestCertificate(t, 2, root, crlServer.URL, "")
+
+	subjectDigest := digest.FromString("subject")
+	signatureDigest := digest.FromString("signature")
+	envelopeDigest := digest.FromString("envelope")
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDigest: {Descriptor: oci.Descriptor{Digest: subjectDigest}},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {{
+				ArtifactType: mocks.SignatureArtifactType,
+				Descriptor:   oci.Descriptor{Digest: signatureDigest},
+			}},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			signatureDigest: {Blobs: []oci.Descriptor{{Digest: envelopeDigest, MediaType: testMediaType}}},
+		},
+		Blobs: map[digest.Digest][]byte{
+			envelopeDigest: testRefBlob,
+		},
+	}
+
+	var notationVerifier notation.Verifier = chainNotationVerifier{chain: []*x509.Certificate{leaf.cert, root.cert}}
+	notationVerifierPlugin := &notationPluginVerifier{
+		name:             test,
+		verifierType:     verifierType,
+		artifactTypes:    []string{mocks.SignatureArtifactType},
+		notationVerifier: &notationVerifier,
+		revocation:       newTestRevocationChecker(t, RevocationConfig{Timeout: "1s"}),
+	}
+	executor := ef.Executor{
+		Verifiers:      []verifier.ReferenceVerifier{notationVerifierPlugin},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		PolicyEnforcer: configpolicy.PolicyEnforcer{
+			ArtifactTypePolicies: map[string]policyTypes.ArtifactTypeVerifyPolicy{
+				mocks.SignatureArtifactType: policyTypes.AllVerifySuccess,
+			},
+		},
+		Config: &exConfig.ExecutorConfig{},
+	}
+
+	result, err := executor.VerifySubject(context.Background(), e.VerifyParameters{
+		Subject: "localhost:5000/net-monitor@" + subjectDigest.String(),
+	})
+	if err != nil {
+		t.Fatalf("VerifySubject() unexpected error: %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("VerifySubject() expected to fail for a revoked certificate")
+	}
+	if len(result.VerifierReports) != 1 {
+		t.Fatalf("VerifySubject() expected 1 verifier report, got %d", len(result.VerifierReports))
+	}
+	report, ok := result.VerifierReports[0].(verifier.VerifierResult)
+	if !ok {
+		t.Fatalf("VerifySubject() unexpected report type %T", result.VerifierReports[0])
+	}
+	if report.IsSuccess || report.ErrorReason == "" {
+		t.Fatalf("VerifySubject() expected a failed report with error reason, got %+v", report)
+	}
+	revocation, ok := report.Extensions.(map[string]interface{})[RevocationExtension].(*revocationResult)
+	if !ok {
+		t.Fatalf("VerifySubject() expected the %s extension, got %+v", RevocationExtension, report.Extensions)
+	}
+	if revocation.Status != RevocationStatusRevoked {
+		t.Fatalf("VerifySubject() revocation status = %s, want %s", revocation.Status, RevocationStatusRevoked)
+	}
+}
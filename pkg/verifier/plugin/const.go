@@ -17,7 +17,32 @@ package plugin
 
 const (
 	VerifyCommand = "VERIFY"
+	// ServeCommand starts the plugin as a long-lived gRPC server
+	ServeCommand  = "SERVE"
 	CommandEnvKey = "RATIFY_VERIFIER_COMMAND"
 	SubjectEnvKey = "RATIFY_VERIFIER_SUBJECT"
 	VersionEnvKey = "RATIFY_VERIFIER_VERSION"
+	// SocketEnvKey is the unix socket the plugin serves the VerifierPlugin service on
+	SocketEnvKey = "RATIFY_VERIFIER_SOCKET"
+	// OrchestratorSocketEnvKey is the unix socket of the PluginOrchestrator
+	// service used by the plugin to fetch manifests and blobs
+	OrchestratorSocketEnvKey = "RATIFY_ORCHESTRATOR_SOCKET"
+	// StoreMetadataKey is the gRPC metadata key of the store of a
+	// VerifyReference request, passed back as the store plugin name of
+	// PluginOrchestrator requests
+	StoreMetadataKey = "ratify-store"
+	// MaxMessageSize is the maximum size of the gRPC messages exchanged with
+	// plugins served over gRPC. Blobs such as SBOMs and vulnerability reports
+	// are sent in a single message, so the default limit of 4 MiB is raised.
+	MaxMessageSize = 256 * 1024 * 1024
+)
+
+const (
+	// PluginModeKey is the verifier config key selecting how the plugin is invoked
+	PluginModeKey = "pluginMode"
+	// PluginModeExec executes the plugin for every verification
+	PluginModeExec = "exec"
+	// PluginModeGRPC starts the plugin once and verifies over gRPC, falling
+	// back to PluginModeExec if the plugin does not support it
+	PluginModeGRPC = "grpc"
 )
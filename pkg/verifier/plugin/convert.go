@@ -0,0 +1,310 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	commonpb "github.com/ratify-project/ratify/experimental/proto/v1/common"
+	verifierpb "github.com/ratify-project/ratify/experimental/proto/v1/verifier"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/utils"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+// Attributes of the descriptors exchanged with plugins served over gRPC.
+const (
+	attributeSubject      = "subject"
+	attributeDigest       = "digest"
+	attributeTag          = "tag"
+	attributeMediaType    = "mediaType"
+	attributeSize         = "size"
+	attributeArtifactType = "artifactType"
+	// annotations are added as attributes with this prefix
+	attributeAnnotationPrefix = "annotation:"
+)
+
+// ToSubjectDescriptor returns the descriptor of the subject.
+func ToSubjectDescriptor(subjectReference common.Reference) *commonpb.Descriptor {
+	attributes := map[string]string{}
+	if subjectReference.Digest != "" {
+		attributes[attributeDigest] = subjectReference.Digest.String()
+	}
+	if subjectReference.Tag != "" {
+		attributes[attributeTag] = subjectReference.Tag
+	}
+	return newDescriptor(subjectReference.Original, attributes)
+}
+
+// FromSubjectDescriptor returns the subject of the descriptor. The digest
+// attribute is set if the subject was resolved from a tag.
+func FromSubjectDescriptor(descriptor *commonpb.Descriptor) (common.Reference, error) {
+	subjectReference, err := utils.ParseSubjectReference(descriptor.GetRawPath())
+	if err != nil {
+		return common.Reference{}, err
+	}
+	if d, ok := descriptorAttributes(descriptor)[attributeDigest]; ok {
+		if subjectReference.Digest, err = digest.Parse(d); err != nil {
+			return common.Reference{}, fmt.Errorf("invalid digest of subject %s: %w", descriptor.GetRawPath(), err)
+		}
+	}
+	return subjectReference, nil
+}
+
+// ToBlobDescriptor returns the descriptor of a blob in the repository of the
+// subject.
+func ToBlobDescriptor(subjectReference common.Reference, blobDigest digest.Digest) *commonpb.Descriptor {
+	descriptor := toDescriptor(subjectReference, oci.Descriptor{Digest: blobDigest})
+	descriptor.Attributes[0].Values[attributeSubject] = subjectReference.Original
+	return descriptor
+}
+
+// FromBlobDescriptor returns the subject and the digest of a blob descriptor.
+func FromBlobDescriptor(descriptor *commonpb.Descriptor) (common.Reference, digest.Digest, error) {
+	attributes := descriptorAttributes(descriptor)
+	blobDigest, err := digest.Parse(attributes[attributeDigest])
+	if err != nil {
+		return common.Reference{}, "", fmt.Errorf("invalid digest of blob %s: %w", descriptor.GetRawPath(), err)
+	}
+	subject := attributes[attributeSubject]
+	if subject == "" {
+		subject = descriptor.GetRawPath()
+	}
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return common.Reference{}, "", err
+	}
+	return subjectReference, blobDigest, nil
+}
+
+// ToReferrer returns the referrer of the reference descriptor.
+func ToReferrer(subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor) *commonpb.Referrer {
+	return &commonpb.Referrer{
+		ArtifactType: referenceDescriptor.ArtifactType,
+		Descriptor_:  toDescriptor(subjectReference, referenceDescriptor.Descriptor),
+	}
+}
+
+// FromReferrer returns the reference descriptor of the referrer.
+func FromReferrer(referrer *commonpb.Referrer) (ocispecs.ReferenceDescriptor, error) {
+	descriptor, err := fromDescriptor(referrer.GetDescriptor_())
+	if err != nil {
+		return ocispecs.ReferenceDescriptor{}, err
+	}
+	return ocispecs.ReferenceDescriptor{
+		Descriptor:   descriptor,
+		ArtifactType: referrer.GetArtifactType(),
+	}, nil
+}
+
+// ToManifest returns the manifest of a referrer of the subject.
+func ToManifest(subjectReference common.Reference, manifest ocispecs.ReferenceManifest) *commonpb.Manifest {
+	referrer := newDescriptor("", map[string]string{})
+	referrer.Attributes[0].Values[attributeMediaType] = manifest.MediaType
+	addAnnotations(referrer.Attributes[0].Values, manifest.Annotations)
+	result := &commonpb.Manifest{
+		Referrer: &commonpb.Referrer{ArtifactType: manifest.ArtifactType, Descriptor_: referrer},
+		Blobs:    &commonpb.Manifest_Blobs{},
+		Subject:  &commonpb.Manifest_Subjects{},
+	}
+	for _, blob := range manifest.Blobs {
+		result.Blobs.Descriptor_ = append(result.Blobs.Descriptor_, toDescriptor(subjectReference, blob))
+	}
+	if manifest.Subject != nil {
+		result.Subject.Descriptor_ = append(result.Subject.Descriptor_, toDescriptor(subjectReference, *manifest.Subject))
+	}
+	return result
+}
+
+// FromManifest returns the reference manifest of the manifest.
+func FromManifest(manifest *commonpb.Manifest) (ocispecs.ReferenceManifest, error) {
+	attributes := descriptorAttributes(manifest.GetReferrer().GetDescriptor_())
+	result := ocispecs.ReferenceManifest{
+		MediaType:    attributes[attributeMediaType],
+		ArtifactType: manifest.GetReferrer().GetArtifactType(),
+		Annotations:  annotations(attributes),
+		Blobs:        []oci.Descriptor{},
+	}
+	for _, blob := range manifest.GetBlobs().GetDescriptor_() {
+		descriptor, err := fromDescriptor(blob)
+		if err != nil {
+			return ocispecs.ReferenceManifest{}, err
+		}
+		result.Blobs = append(result.Blobs, descriptor)
+	}
+	if subjects := manifest.GetSubject().GetDescriptor_(); len(subjects) > 0 {
+		subject, err := fromDescriptor(subjects[0])
+		if err != nil {
+			return ocispecs.ReferenceManifest{}, err
+		}
+		result.Subject = &subject
+	}
+	return result, nil
+}
+
+// ToVerifyReferenceResponse returns the response of a verifier result. The
+// message, error reason and remediation of the result are returned as the
+// reasons in this order, and the extensions as data of JSON encoded values.
+func ToVerifyReferenceResponse(result *verifier.VerifierResult, subject *commonpb.Descriptor, reference *commonpb.Referrer) (*verifierpb.VerifyReferenceResponse, error) {
+	verifierName := result.VerifierName
+	if verifierName == "" {
+		verifierName = result.Name
+	}
+	response := &verifierpb.VerifyReferenceResponse{
+		VerifierName: verifierName,
+		Subject:      subject.GetRawPath(),
+		Reference:    reference.GetDescriptor_(),
+		Valid:        result.IsSuccess,
+	}
+
+	reasons := []string{result.Message, result.ErrorReason, result.Remediation}
+	for len(reasons) > 0 && reasons[len(reasons)-1] == "" {
+		reasons = reasons[:len(reasons)-1]
+	}
+	response.Reasons = reasons
+
+	if result.Extensions == nil {
+		return response, nil
+	}
+	extensions, err := json.Marshal(result.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extensions: %w", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(extensions, &values); err != nil {
+		return nil, fmt.Errorf("extensions must be a JSON object: %w", err)
+	}
+	data := &verifierpb.VerifyReferenceResponse_ExtensionData{Values: map[string]string{}}
+	for key, value := range values {
+		data.Values[key] = string(value)
+	}
+	response.Data = append(response.Data, data)
+	return response, nil
+}
+
+// FromVerifyReferenceResponse returns the verifier result of the response.
+func FromVerifyReferenceResponse(response *verifierpb.VerifyReferenceResponse, verifierType string) (*verifier.VerifierResult, error) {
+	result := &verifier.VerifierResult{
+		IsSuccess:    response.GetValid(),
+		Name:         response.GetVerifierName(),
+		VerifierName: response.GetVerifierName(),
+		Type:         verifierType,
+		VerifierType: verifierType,
+	}
+	reasons := response.GetReasons()
+	for i, field := range []*string{&result.Message, &result.ErrorReason, &result.Remediation} {
+		if i < len(reasons) {
+			*field = reasons[i]
+		}
+	}
+
+	if len(response.GetData()) == 0 {
+		return result, nil
+	}
+	extensions := map[string]interface{}{}
+	for _, data := range response.GetData() {
+		for key, value := range data.GetValues() {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				return nil, fmt.Errorf("failed to decode extension %s: %w", key, err)
+			}
+			extensions[key] = decoded
+		}
+	}
+	result.Extensions = extensions
+	return result, nil
+}
+
+// toDescriptor returns the descriptor of content in the repository of the
+// subject.
+func toDescriptor(subjectReference common.Reference, descriptor oci.Descriptor) *commonpb.Descriptor {
+	attributes := map[string]string{attributeDigest: descriptor.Digest.String()}
+	if descriptor.MediaType != "" {
+		attributes[attributeMediaType] = descriptor.MediaType
+	}
+	if descriptor.Size != 0 {
+		attributes[attributeSize] = strconv.FormatInt(descriptor.Size, 10)
+	}
+	if descriptor.ArtifactType != "" {
+		attributes[attributeArtifactType] = descriptor.ArtifactType
+	}
+	addAnnotations(attributes, descriptor.Annotations)
+	return newDescriptor(fmt.Sprintf("%s@%s", subjectReference.Path, descriptor.Digest), attributes)
+}
+
+func fromDescriptor(descriptor *commonpb.Descriptor) (oci.Descriptor, error) {
+	attributes := descriptorAttributes(descriptor)
+	result := oci.Descriptor{
+		MediaType:    attributes[attributeMediaType],
+		ArtifactType: attributes[attributeArtifactType],
+		Annotations:  annotations(attributes),
+	}
+	var err error
+	if d, ok := attributes[attributeDigest]; ok {
+		if result.Digest, err = digest.Parse(d); err != nil {
+			return oci.Descriptor{}, fmt.Errorf("invalid digest of descriptor %s: %w", descriptor.GetRawPath(), err)
+		}
+	}
+	if size, ok := attributes[attributeSize]; ok {
+		if result.Size, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return oci.Descriptor{}, fmt.Errorf("invalid size of descriptor %s: %w", descriptor.GetRawPath(), err)
+		}
+	}
+	return result, nil
+}
+
+func newDescriptor(rawPath string, attributes map[string]string) *commonpb.Descriptor {
+	return &commonpb.Descriptor{
+		RawPath:    rawPath,
+		Attributes: []*commonpb.Descriptor_Attributes{{Values: attributes}},
+	}
+}
+
+// descriptorAttributes merges the attributes of the descriptor.
+func descriptorAttributes(descriptor *commonpb.Descriptor) map[string]string {
+	attributes := map[string]string{}
+	for _, values := range descriptor.GetAttributes() {
+		for key, value := range values.GetValues() {
+			attributes[key] = value
+		}
+	}
+	return attributes
+}
+
+func addAnnotations(attributes, annotations map[string]string) {
+	for key, value := range annotations {
+		attributes[attributeAnnotationPrefix+key] = value
+	}
+}
+
+func annotations(attributes map[string]string) map[string]string {
+	var result map[string]string
+	for key, value := range attributes {
+		if annotation, ok := strings.CutPrefix(key, attributeAnnotationPrefix); ok {
+			if result == nil {
+				result = map[string]string{}
+			}
+			result[annotation] = value
+		}
+	}
+	return result
+}
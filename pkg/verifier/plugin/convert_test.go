@@ -0,0 +1,165 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/verifier"
+)
+
+var (
+	testSubjectDigest = digest.FromString("subject")
+	testSubject       = common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Tag:      "v1",
+		Digest:   testSubjectDigest,
+		Original: "localhost:5000/net-monitor:v1",
+	}
+)
+
+func TestSubjectDescriptor_RoundTrip(t *testing.T) {
+	subjectReference, err := FromSubjectDescriptor(ToSubjectDescriptor(testSubject))
+	if err != nil {
+		t.Fatalf("FromSubjectDescriptor() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(subjectReference, testSubject) {
+		t.Fatalf("expected subject %+v, got %+v", testSubject, subjectReference)
+	}
+}
+
+func TestBlobDescriptor_RoundTrip(t *testing.T) {
+	blobDigest := digest.FromString("blob")
+	descriptor := ToBlobDescriptor(testSubject, blobDigest)
+	if descriptor.GetRawPath() != "localhost:5000/net-monitor@"+blobDigest.String() {
+		t.Fatalf("unexpected raw path %s", descriptor.GetRawPath())
+	}
+	subjectReference, d, err := FromBlobDescriptor(descriptor)
+	if err != nil {
+		t.Fatalf("FromBlobDescriptor() unexpected error: %v", err)
+	}
+	if d != blobDigest || subjectReference.Path != testSubject.Path || subjectReference.Original != testSubject.Original {
+		t.Fatalf("unexpected subject %+v and digest %s", subjectReference, d)
+	}
+}
+
+func TestReferrerAndManifest_RoundTrip(t *testing.T) {
+	referenceDescriptor := ocispecs.ReferenceDescriptor{
+		Descriptor: oci.Descriptor{
+			MediaType:   oci.MediaTypeImageManifest,
+			Digest:      digest.FromString("signature"),
+			Size:        1024,
+			Annotations: map[string]string{"dev.cosignproject.cosign/signature": "sig"},
+		},
+		ArtifactType: "application/vnd.cncf.notary.signature",
+	}
+	result, err := FromReferrer(ToReferrer(testSubject, referenceDescriptor))
+	if err != nil {
+		t.Fatalf("FromReferrer() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, referenceDescriptor) {
+		t.Fatalf("expected referrer %+v, got %+v", referenceDescriptor, result)
+	}
+
+	manifest := ocispecs.ReferenceManifest{
+		MediaType:    oci.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.notary.signature",
+		Annotations:  map[string]string{"created": "now"},
+		Blobs: []oci.Descriptor{{
+			MediaType:   "application/jose+json",
+			Digest:      digest.FromString("envelope"),
+			Size:        512,
+			Annotations: map[string]string{"io.cncf.notary.x509chain.thumbprint#S256": "[]"},
+		}},
+		Subject: &oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: testSubjectDigest, Size: 2048},
+	}
+	manifestResult, err := FromManifest(ToManifest(testSubject, manifest))
+	if err != nil {
+		t.Fatalf("FromManifest() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(manifestResult, manifest) {
+		t.Fatalf("expected manifest %+v, got %+v", manifest, manifestResult)
+	}
+}
+
+func TestVerifyReferenceResponse_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name   string
+		result verifier.VerifierResult
+		want   verifier.VerifierResult
+	}{
+		{
+			name: "failure with reasons and extensions",
+			result: verifier.VerifierResult{
+				IsSuccess:    false,
+				Name:         "sbom",
+				VerifierName: "sbom",
+				Message:      "SBOM validation failed",
+				ErrorReason:  "disallowed license",
+				Extensions:   map[string]interface{}{"licenses": []string{"GPL"}, "count": 1, "name": "sbom"},
+			},
+			want: verifier.VerifierResult{
+				IsSuccess:    false,
+				Name:         "sbom",
+				VerifierName: "sbom",
+				Type:         "sbom-type",
+				VerifierType: "sbom-type",
+				Message:      "SBOM validation failed",
+				ErrorReason:  "disallowed license",
+				Extensions:   map[string]interface{}{"licenses": []interface{}{"GPL"}, "count": float64(1), "name": "sbom"},
+			},
+		},
+		{
+			name:   "success without extensions",
+			result: verifier.VerifierResult{IsSuccess: true, Name: "sbom", Message: "SBOM validation passed"},
+			want: verifier.VerifierResult{
+				IsSuccess:    true,
+				Name:         "sbom",
+				VerifierName: "sbom",
+				Type:         "sbom-type",
+				VerifierType: "sbom-type",
+				Message:      "SBOM validation passed",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := ToVerifyReferenceResponse(&tc.result, ToSubjectDescriptor(testSubject), nil)
+			if err != nil {
+				t.Fatalf("ToVerifyReferenceResponse() unexpected error: %v", err)
+			}
+			result, err := FromVerifyReferenceResponse(response, "sbom-type")
+			if err != nil {
+				t.Fatalf("FromVerifyReferenceResponse() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*result, tc.want) {
+				t.Fatalf("expected result %+v, got %+v", tc.want, *result)
+			}
+		})
+	}
+}
+
+func TestToVerifyReferenceResponse_InvalidExtensions(t *testing.T) {
+	if _, err := ToVerifyReferenceResponse(&verifier.VerifierResult{Extensions: []string{"a"}}, nil, nil); err == nil {
+		t.Fatalf("expected error for extensions which are not an object")
+	}
+}
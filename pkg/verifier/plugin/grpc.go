@@ -0,0 +1,291 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	re "github.com/ratify-project/ratify/errors"
+	verifierpb "github.com/ratify-project/ratify/experimental/proto/v1/verifier"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	pluginCommon "github.com/ratify-project/ratify/pkg/common/plugin"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// pluginStartTimeout is the time a plugin is given to serve on its socket
+	pluginStartTimeout = 10 * time.Second
+	socketPollInterval = 50 * time.Millisecond
+	// pluginStartBackoff is the time after which a plugin failing to serve
+	// over gRPC is started again. It doubles with each failure up to
+	// maxPluginStartBackoff, the plugin is executed in the meantime.
+	pluginStartBackoff    = 30 * time.Second
+	maxPluginStartBackoff = 30 * time.Minute
+)
+
+var logOpt = logger.Option{
+	ComponentType: logger.Plugin,
+}
+
+// errServeNotSupported is returned if the plugin cannot be served over gRPC
+// and is executed instead.
+var errServeNotSupported = errors.New("plugin does not support serving over gRPC")
+
+// pluginServer is a verifier plugin started once and served over a unix
+// socket. The plugin exits once its stdin is closed, which also happens if
+// Ratify exits.
+type pluginServer struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *grpc.ClientConn
+	client verifierpb.VerifierPluginClient
+	// exited is closed once the plugin process exits
+	exited chan struct{}
+}
+
+// pluginServerEntry holds the server of a plugin. Its mutex is held while the
+// plugin is started so that callers of other plugins are not blocked.
+type pluginServerEntry struct {
+	mu     sync.Mutex
+	server *pluginServer
+	// failures is the number of consecutive failures to serve the plugin,
+	// which is not started again before retryAt
+	failures int
+	retryAt  time.Time
+}
+
+var (
+	pluginServersMutex sync.Mutex
+	// pluginServers are the plugin servers by path, version and policy
+	pluginServers     = map[string]*pluginServerEntry{}
+	pluginServerCount atomic.Int64
+	// newPluginServer starts the plugin server, it is a variable for mocking
+	// purposes
+	newPluginServer = startPluginServer
+)
+
+// getPluginServer returns the running server of the plugin, starting it if
+// it is not running. It returns errServeNotSupported if the plugin failed to
+// serve, in which case it is not started again until its backoff elapsed.
+// Plugins with different execution policies are served separately.
+func getPluginServer(ctx context.Context, pluginPath, version string, policy *pluginCommon.ExecutionPolicy) (*pluginServer, error) {
+	key := pluginPath + "@" + version
+	if policy != nil {
+		policyBytes, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		key += string(policyBytes)
+	}
+
+	pluginServersMutex.Lock()
+	entry, ok := pluginServers[key]
+	if !ok {
+		entry = &pluginServerEntry{}
+		pluginServers[key] = entry
+	}
+	pluginServersMutex.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.server != nil {
+		select {
+		case <-entry.server.exited:
+			logger.GetLogger(ctx, logOpt).Warnf("plugin %s exited, restarting it", pluginPath)
+			entry.server.conn.Close()
+			entry.server = nil
+		default:
+			return entry.server, nil
+		}
+	}
+	if time.Now().Before(entry.retryAt) {
+		return nil, errServeNotSupported
+	}
+
+	server, err := newPluginServer(ctx, pluginPath, version, policy)
+	if err != nil {
+		backoff := maxPluginStartBackoff
+		if entry.failures < 16 {
+			backoff = min(pluginStartBackoff<<entry.failures, maxPluginStartBackoff)
+		}
+		entry.failures++
+		entry.retryAt = time.Now().Add(backoff)
+		logger.GetLogger(ctx, logOpt).Warnf("failed to serve plugin %s over gRPC, executing it for the next %v instead: %v", pluginPath, backoff, err)
+		return nil, errServeNotSupported
+	}
+	entry.server = server
+	entry.failures = 0
+	entry.retryAt = time.Time{}
+	return server, nil
+}
+
+// startPluginServer starts the plugin with the serve command under the server
+// policy and waits until it serves on its socket.
+func startPluginServer(ctx context.Context, pluginPath, version string, policy *pluginCommon.ExecutionPolicy) (*pluginServer, error) {
+	_, orchestratorSocket, err := getOrchestrator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start plugin orchestrator: %w", err)
+	}
+	socket := filepath.Join(socketDir, fmt.Sprintf("verifier-%d.sock", pluginServerCount.Add(1)))
+
+	cmd, err := policy.ServerPolicy().Command(context.Background(), pluginPath, nil, append(os.Environ(),
+		fmt.Sprintf("%s=%s", CommandEnvKey, ServeCommand),
+		fmt.Sprintf("%s=%s", VersionEnvKey, version),
+		fmt.Sprintf("%s=%s", SocketEnvKey, socket),
+		fmt.Sprintf("%s=%s", OrchestratorSocketEnvKey, orchestratorSocket),
+	))
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	server := &pluginServer{cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		logger.GetLogger(context.Background(), logOpt).Infof("plugin %s exited: %v", pluginPath, err)
+		close(server.exited)
+	}()
+
+	deadline := time.After(pluginStartTimeout)
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			break
+		}
+		select {
+		case <-server.exited:
+			server.stop()
+			return nil, fmt.Errorf("plugin exited before serving on %s", socket)
+		case <-deadline:
+			server.stop()
+			return nil, fmt.Errorf("plugin did not serve on %s within %v", socket, pluginStartTimeout)
+		case <-time.After(socketPollInterval):
+		}
+	}
+
+	server.conn, err = grpc.NewClient("unix://"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxMessageSize), grpc.MaxCallSendMsgSize(MaxMessageSize)))
+	if err != nil {
+		server.stop()
+		return nil, err
+	}
+	server.client = verifierpb.NewVerifierPluginClient(server.conn)
+	logger.GetLogger(ctx, logOpt).Infof("started plugin %s serving on %s", pluginPath, socket)
+	return server, nil
+}
+
+// stop closes the stdin of the plugin and kills it if it does not exit.
+func (s *pluginServer) stop() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.stdin.Close()
+	select {
+	case <-s.exited:
+	case <-time.After(time.Second):
+		_ = s.cmd.Process.Kill()
+		<-s.exited
+	}
+}
+
+// verifyReferenceGRPC verifies the reference with the plugin served over
+// gRPC. Manifests and blobs are fetched by the plugin through the
+// orchestrator with the given store. The timeout of the execution policy
+// applies to each verification.
+func (vp *VerifierPlugin) verifyReferenceGRPC(
+	ctx context.Context,
+	pluginPath string,
+	subjectReference common.Reference,
+	referenceDescriptor ocispecs.ReferenceDescriptor,
+	store referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	server, err := getPluginServer(ctx, pluginPath, vp.version, vp.policy)
+	if err != nil {
+		return nil, err
+	}
+	o, _, err := getOrchestrator()
+	if err != nil {
+		return nil, err
+	}
+	handle, release := o.register(ctx, store)
+	defer release()
+
+	configuration, err := toStruct(vp.rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	request := &verifierpb.VerifyReferenceRequest{
+		Subject:       ToSubjectDescriptor(subjectReference),
+		Reference:     ToReferrer(subjectReference, referenceDescriptor),
+		Configuration: configuration,
+	}
+	requestCtx := ctx
+	if timeout := vp.policy.GetTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		requestCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	response, err := server.client.VerifyReference(metadata.AppendToOutgoingContext(requestCtx, StoreMetadataKey, handle), request)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			return nil, re.ErrorCodePluginTimeout.WithDetail(fmt.Sprintf("plugin %s did not complete within %s", pluginPath, vp.policy.GetTimeout())).WithError(err)
+		}
+		return nil, err
+	}
+
+	verifierType := vp.name
+	if vp.verifierType != "" {
+		verifierType = vp.verifierType
+	}
+	return FromVerifyReferenceResponse(response, verifierType)
+}
+
+// toStruct returns the verifier config as a protobuf struct.
+func toStruct(config map[string]interface{}) (*structpb.Struct, error) {
+	// normalize values to JSON types
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(bytes, &values); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(values)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/experimental/proto/v1/orchestrator"
+	pluginCommon "github.com/ratify-project/ratify/pkg/common/plugin"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	sm "github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewVerifier_PluginMode(t *testing.T) {
+	testCases := []struct {
+		mode     interface{}
+		wantMode string
+		wantErr  bool
+	}{
+		{mode: nil, wantMode: PluginModeExec},
+		{mode: PluginModeExec, wantMode: PluginModeExec},
+		{mode: PluginModeGRPC, wantMode: PluginModeGRPC},
+		{mode: "http", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		verifierConfig := map[string]interface{}{"name": "test-verifier"}
+		if tc.mode != nil {
+			verifierConfig[PluginModeKey] = tc.mode
+		}
+		v, err := NewVerifier("1.0.0", verifierConfig, []string{})
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("NewVerifier() with mode %v error = %v, wantErr %v", tc.mode, err, tc.wantErr)
+		}
+		if err == nil && v.(*VerifierPlugin).mode != tc.wantMode {
+			t.Fatalf("expected mode %s, got %s", tc.wantMode, v.(*VerifierPlugin).mode)
+		}
+	}
+}
+
+func TestVerify_GRPCFallsBackToExec(t *testing.T) {
+	executed := 0
+	verifierPlugin := &VerifierPlugin{
+		name:          testPlugin,
+		artifactTypes: []string{"test-type"},
+		version:       "1.0.0",
+		mode:          PluginModeGRPC,
+		rawConfig:     map[string]interface{}{"name": testPlugin},
+		executor: &TestExecutor{
+			find: func(_ string, _ []string) (string, error) {
+				return filepath.Join(t.TempDir(), testPlugin), nil
+			},
+			execute: func(_ context.Context, _ string, _ []string, _ []byte, _ []string) ([]byte, error) {
+				executed++
+				return []byte(`{"isSuccess":true}`), nil
+			},
+		},
+	}
+
+	for i := 1; i <= 2; i++ {
+		result, err := verifierPlugin.Verify(context.Background(), testSubject, ocispecs.ReferenceDescriptor{ArtifactType: "test-type"}, &sm.TestStore{})
+		if err != nil {
+			t.Fatalf("Verify() unexpected error: %v", err)
+		}
+		if !result.IsSuccess || executed != i {
+			t.Fatalf("expected plugin to be executed %d times with success, got %d times with result %+v", i, executed, result)
+		}
+	}
+}
+
+// mockPluginServers replaces the plugin server start with start and resets
+// the plugin servers
+func mockPluginServers(t *testing.T, start func(pluginPath string) (*pluginServer, error)) {
+	original := newPluginServer
+	t.Cleanup(func() {
+		newPluginServer = original
+		pluginServersMutex.Lock()
+		pluginServers = map[string]*pluginServerEntry{}
+		pluginServersMutex.Unlock()
+	})
+	newPluginServer = func(_ context.Context, pluginPath, _ string, _ *pluginCommon.ExecutionPolicy) (*pluginServer, error) {
+		return start(pluginPath)
+	}
+}
+
+func TestGetPluginServer_StartsPluginsConcurrently(t *testing.T) {
+	slowStarted := make(chan struct{})
+	release := make(chan struct{})
+	mockPluginServers(t, func(pluginPath string) (*pluginServer, error) {
+		if pluginPath == "slow" {
+			close(slowStarted)
+			<-release
+		}
+		return &pluginServer{exited: make(chan struct{})}, nil
+	})
+
+	slowServer := make(chan *pluginServer)
+	go func() {
+		server, _ := getPluginServer(context.Background(), "slow", "1.0.0", nil)
+		slowServer <- server
+	}()
+	<-slowStarted
+
+	// another plugin is served while the slow plugin is starting
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := getPluginServer(context.Background(), "fast", "1.0.0", nil); err != nil {
+			t.Errorf("getPluginServer() unexpected error: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected plugin to be served while another plugin is starting")
+	}
+
+	// callers of the starting plugin wait for it and share its server
+	waiting := make(chan *pluginServer)
+	go func() {
+		server, _ := getPluginServer(context.Background(), "slow", "1.0.0", nil)
+		waiting <- server
+	}()
+	close(release)
+	first, second := <-slowServer, <-waiting
+	if first == nil || first != second {
+		t.Fatalf("expected the plugin to be started once, got servers %p and %p", first, second)
+	}
+}
+
+func TestGetPluginServer_RetriesWithBackoff(t *testing.T) {
+	starts := 0
+	startErr := errors.New("plugin exited before serving")
+	mockPluginServers(t, func(_ string) (*pluginServer, error) {
+		starts++
+		if startErr != nil {
+			return nil, startErr
+		}
+		return &pluginServer{exited: make(chan struct{})}, nil
+	})
+	entry := func() *pluginServerEntry {
+		pluginServersMutex.Lock()
+		defer pluginServersMutex.Unlock()
+		for _, entry := range pluginServers {
+			return entry
+		}
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := getPluginServer(context.Background(), testPlugin, "1.0.0", nil); !errors.Is(err, errServeNotSupported) {
+			t.Fatalf("expected errServeNotSupported, got %v", err)
+		}
+	}
+	if starts != 1 {
+		t.Fatalf("expected the plugin not to be started again before the backoff elapsed, got %d starts", starts)
+	}
+	if backoff := time.Until(entry().retryAt); backoff <= 0 || backoff > pluginStartBackoff {
+		t.Fatalf("expected a backoff of at most %v, got %v", pluginStartBackoff, backoff)
+	}
+
+	// the backoff doubles with each failure
+	entry().retryAt = time.Time{}
+	if _, err := getPluginServer(context.Background(), testPlugin, "1.0.0", nil); !errors.Is(err, errServeNotSupported) {
+		t.Fatalf("expected errServeNotSupported, got %v", err)
+	}
+	if backoff := time.Until(entry().retryAt); starts != 2 || backoff <= pluginStartBackoff {
+		t.Fatalf("expected the plugin to be started again with a longer backoff, got %d starts and a backoff of %v", starts, backoff)
+	}
+
+	// the plugin is served once it starts after the backoff
+	entry().retryAt = time.Time{}
+	startErr = nil
+	server, err := getPluginServer(context.Background(), testPlugin, "1.0.0", nil)
+	if err != nil || server == nil || starts != 3 {
+		t.Fatalf("expected the plugin to be served, got server %v with error %v after %d starts", server, err, starts)
+	}
+	if entry().failures != 0 {
+		t.Fatalf("expected failures to be reset, got %d", entry().failures)
+	}
+}
+
+func TestOrchestrator_GetBlobsAndManifest(t *testing.T) {
+	o, socket, err := getOrchestrator()
+	if err != nil {
+		t.Fatalf("getOrchestrator() unexpected error: %v", err)
+	}
+	if socket == "" {
+		t.Fatalf("expected orchestrator to be served on a socket")
+	}
+
+	referenceDigest := digest.FromString("signature")
+	blobDigest := digest.FromString("envelope")
+	manifest := ocispecs.ReferenceManifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Blobs:     []oci.Descriptor{{MediaType: "application/jose+json", Digest: blobDigest, Size: 8}},
+	}
+	store := &sm.MemoryTestStore{
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{referenceDigest: manifest},
+		Blobs:     map[digest.Digest][]byte{blobDigest: []byte("envelope")},
+	}
+	handle, release := o.register(context.Background(), store)
+
+	blobs, err := o.GetBlobs(context.Background(), &orchestrator.GetBlobsRequest{
+		Artifact:        ToBlobDescriptor(testSubject, blobDigest),
+		StorePluginName: handle,
+	})
+	if err != nil {
+		t.Fatalf("GetBlobs() unexpected error: %v", err)
+	}
+	if len(blobs.GetContent()) != 1 || string(blobs.GetContent()[0]) != "envelope" {
+		t.Fatalf("unexpected blob content %q", blobs.GetContent())
+	}
+
+	response, err := o.GetManifest(context.Background(), &orchestrator.GetManifestRequest{
+		Subject:         ToSubjectDescriptor(testSubject),
+		Referrer:        ToReferrer(testSubject, ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{Digest: referenceDigest}}),
+		StorePluginName: handle,
+	})
+	if err != nil {
+		t.Fatalf("GetManifest() unexpected error: %v", err)
+	}
+	result, err := FromManifest(response.GetManifest())
+	if err != nil || len(result.Blobs) != 1 || result.Blobs[0].Digest != blobDigest {
+		t.Fatalf("unexpected manifest %+v, error %v", result, err)
+	}
+
+	if _, err := o.GetBlobs(context.Background(), &orchestrator.GetBlobsRequest{
+		Artifact:        ToBlobDescriptor(testSubject, digest.FromString("missing")),
+		StorePluginName: handle,
+	}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable for missing blob, got %v", err)
+	}
+
+	release()
+	if _, err := o.GetBlobs(context.Background(), &orchestrator.GetBlobsRequest{
+		Artifact:        ToBlobDescriptor(testSubject, blobDigest),
+		StorePluginName: handle,
+	}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for released store, got %v", err)
+	}
+}
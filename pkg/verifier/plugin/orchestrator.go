@@ -0,0 +1,146 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ratify-project/ratify/experimental/proto/v1/orchestrator"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storeHandle is a store of an in-flight verification of a plugin served
+// over gRPC. Requests of the plugin are served with the context of the
+// verification.
+type storeHandle struct {
+	ctx   context.Context
+	store referrerstore.ReferrerStore
+}
+
+// orchestratorServer serves the PluginOrchestrator service to verifier
+// plugins served over gRPC, so plugins fetch manifests and blobs with the
+// stores, cache and credentials of Ratify.
+type orchestratorServer struct {
+	orchestrator.UnimplementedPluginOrchestratorServer
+
+	handles     sync.Map
+	handleCount atomic.Uint64
+}
+
+var (
+	orchestratorOnce     sync.Once
+	orchestratorInstance *orchestratorServer
+	// socketDir is the directory of the unix sockets of the orchestrator and
+	// the plugins
+	socketDir          string
+	orchestratorSocket string
+	orchestratorErr    error
+)
+
+// getOrchestrator starts the orchestrator on first use and returns it with
+// the socket it is served on.
+func getOrchestrator() (*orchestratorServer, string, error) {
+	orchestratorOnce.Do(func() {
+		socketDir, orchestratorErr = os.MkdirTemp("", "ratify-plugins-")
+		if orchestratorErr != nil {
+			return
+		}
+		orchestratorSocket = filepath.Join(socketDir, "orchestrator.sock")
+		lsnr, err := net.Listen("unix", orchestratorSocket)
+		if err != nil {
+			orchestratorErr = err
+			return
+		}
+		orchestratorInstance = &orchestratorServer{}
+		server := grpc.NewServer(grpc.MaxRecvMsgSize(MaxMessageSize), grpc.MaxSendMsgSize(MaxMessageSize))
+		orchestrator.RegisterPluginOrchestratorServer(server, orchestratorInstance)
+		go func() {
+			if err := server.Serve(lsnr); err != nil {
+				logger.GetLogger(context.Background(), logOpt).Errorf("plugin orchestrator stopped with error: %v", err)
+			}
+		}()
+	})
+	return orchestratorInstance, orchestratorSocket, orchestratorErr
+}
+
+// register returns the handle of the store of a verification, to be released
+// once the verification completes.
+func (o *orchestratorServer) register(ctx context.Context, store referrerstore.ReferrerStore) (string, func()) {
+	handle := fmt.Sprintf("%s-%d", store.Name(), o.handleCount.Add(1))
+	o.handles.Store(handle, storeHandle{ctx: ctx, store: store})
+	return handle, func() { o.handles.Delete(handle) }
+}
+
+func (o *orchestratorServer) lookup(handle string) (storeHandle, error) {
+	value, ok := o.handles.Load(handle)
+	if !ok {
+		return storeHandle{}, status.Errorf(codes.NotFound, "store %s is not part of an in-flight verification", handle)
+	}
+	return value.(storeHandle), nil
+}
+
+// GetBlobs returns the content of the blob of the artifact descriptor.
+func (o *orchestratorServer) GetBlobs(_ context.Context, request *orchestrator.GetBlobsRequest) (*orchestrator.GetBlobsResponse, error) {
+	handle, err := o.lookup(request.GetStorePluginName())
+	if err != nil {
+		return nil, err
+	}
+	subjectReference, blobDigest, err := FromBlobDescriptor(request.GetArtifact())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	content, err := handle.store.GetBlobContent(handle.ctx, subjectReference, blobDigest)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &orchestrator.GetBlobsResponse{
+		Artifact: request.GetArtifact(),
+		Content:  [][]byte{content},
+	}, nil
+}
+
+// GetManifest returns the manifest of the referrer of the subject.
+func (o *orchestratorServer) GetManifest(_ context.Context, request *orchestrator.GetManifestRequest) (*orchestrator.GetManifestResponse, error) {
+	handle, err := o.lookup(request.GetStorePluginName())
+	if err != nil {
+		return nil, err
+	}
+	subjectReference, err := FromSubjectDescriptor(request.GetSubject())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	referenceDescriptor, err := FromReferrer(request.GetReferrer())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	manifest, err := handle.store.GetReferenceManifest(handle.ctx, subjectReference, referenceDescriptor)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &orchestrator.GetManifestResponse{
+		Manifest: ToManifest(subjectReference, manifest),
+	}, nil
+}
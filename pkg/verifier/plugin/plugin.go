@@ -18,6 +18,7 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -40,6 +41,8 @@ type VerifierPlugin struct {
 	artifactTypes    []string
 	nestedReferences []string
 	version          string
+	mode             string
+	policy           *pluginCommon.ExecutionPolicy
 	path             []string
 	rawConfig        config.VerifierConfig
 	executor         pluginCommon.Executor
@@ -70,15 +73,30 @@ func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPat
 		artifactTypes = append(artifactTypes, "*")
 	}
 
+	mode := PluginModeExec
+	if m, ok := verifierConfig[PluginModeKey]; ok {
+		mode = fmt.Sprintf("%s", m)
+		if mode != PluginModeExec && mode != PluginModeGRPC {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("invalid %s %s of verifier %s, supported modes are %s and %s", PluginModeKey, mode, verifierName, PluginModeExec, PluginModeGRPC))
+		}
+	}
+
+	policy, err := pluginCommon.ParseExecutionPolicy(verifierConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &VerifierPlugin{
 		name:             fmt.Sprintf("%s", verifierName),
 		verifierType:     verifierType,
 		version:          version,
+		mode:             mode,
+		policy:           policy,
 		path:             pluginPaths,
 		rawConfig:        verifierConfig,
 		artifactTypes:    artifactTypes,
 		nestedReferences: nestedReferences,
-		executor:         &pluginCommon.DefaultExecutor{Stderr: os.Stderr},
+		executor:         &pluginCommon.DefaultExecutor{Stderr: os.Stderr, Policy: policy},
 	}, nil
 }
 
@@ -103,8 +121,27 @@ func (vp *VerifierPlugin) Verify(ctx context.Context,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	store referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	verifierTypeStr := vp.name
+	if vp.verifierType != "" {
+		verifierTypeStr = vp.verifierType
+	}
+	pluginPath, err := vp.executor.FindInPaths(verifierTypeStr, vp.path)
+	if err != nil {
+		return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodePluginNotFound.NewError(re.Verifier, vp.name, re.EmptyLink, err, nil, re.HideStackTrace)
+	}
+
+	if vp.mode == PluginModeGRPC {
+		vr, err := vp.verifyReferenceGRPC(ctx, pluginPath, subjectReference, referenceDescriptor, store)
+		if err == nil {
+			return *vr, nil
+		}
+		if !errors.Is(err, errServeNotSupported) {
+			return verifier.VerifierResult{IsSuccess: false}, re.ErrorCodeVerifyPluginFailure.NewError(re.Verifier, vp.name, re.EmptyLink, err, nil, re.HideStackTrace)
+		}
+	}
+
 	referrerStoreConfig := store.GetConfig()
-	vr, err := vp.verifyReference(ctx, subjectReference, referenceDescriptor, referrerStoreConfig)
+	vr, err := vp.verifyReference(ctx, pluginPath, subjectReference, referenceDescriptor, referrerStoreConfig)
 	if err != nil {
 		return verifier.VerifierResult{IsSuccess: false}, err
 	}
@@ -114,17 +151,10 @@ func (vp *VerifierPlugin) Verify(ctx context.Context,
 
 func (vp *VerifierPlugin) verifyReference(
 	ctx context.Context,
+	pluginPath string,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	referrerStoreConfig *rc.StoreConfig) (*verifier.VerifierResult, error) {
-	verifierTypeStr := vp.name
-	if vp.verifierType != "" {
-		verifierTypeStr = vp.verifierType
-	}
-	pluginPath, err := vp.executor.FindInPaths(verifierTypeStr, vp.path)
-	if err != nil {
-		return nil, re.ErrorCodePluginNotFound.NewError(re.Verifier, vp.name, re.EmptyLink, err, nil, re.HideStackTrace)
-	}
 
 	pluginArgs := VerifierPluginArgs{
 		Command:          VerifyCommand,
@@ -19,8 +19,10 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ratify-project/ratify/pkg/common"
+	pluginCommon "github.com/ratify-project/ratify/pkg/common/plugin"
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	sm "github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 )
@@ -65,6 +67,26 @@ func TestNewVerifier_Expected(t *testing.T) {
 	}
 }
 
+func TestNewVerifier_ExecutionPolicy(t *testing.T) {
+	verifierConfig := map[string]interface{}{
+		"name":            "test-verifier",
+		"executionPolicy": map[string]interface{}{"timeout": "10s", "allowedEnv": []interface{}{"HOME"}},
+	}
+	verifier, err := NewVerifier("1.0.0", verifierConfig, []string{})
+	if err != nil {
+		t.Fatalf("failed to create plugin verifier %v", err)
+	}
+	vp := verifier.(*VerifierPlugin)
+	if vp.policy.GetTimeout() != 10*time.Second || vp.executor.(*pluginCommon.DefaultExecutor).Policy != vp.policy {
+		t.Fatalf("expected execution policy to be applied to the executor, got %+v", vp.policy)
+	}
+
+	verifierConfig["executionPolicy"] = map[string]interface{}{"timeout": "never"}
+	if _, err := NewVerifier("1.0.0", verifierConfig, []string{}); err == nil {
+		t.Fatalf("expected error for invalid execution policy")
+	}
+}
+
 func TestVerify_IsSuccessTrue_Expected(t *testing.T) {
 	testPlugin := "test-plugin"
 	testExecutor := &TestExecutor{
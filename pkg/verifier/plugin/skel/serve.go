@@ -0,0 +1,198 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/experimental/proto/v1/orchestrator"
+	verifierpb "github.com/ratify-project/ratify/experimental/proto/v1/verifier"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/common/plugin"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	storeConfig "github.com/ratify-project/ratify/pkg/referrerstore/config"
+	"github.com/ratify-project/ratify/pkg/verifier/config"
+	vp "github.com/ratify-project/ratify/pkg/verifier/plugin"
+	"github.com/ratify-project/ratify/pkg/verifier/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// verifierServer serves the VerifierPlugin service with the verify function
+// of the plugin.
+type verifierServer struct {
+	verifierpb.UnimplementedVerifierPluginServer
+
+	version         string
+	verifyReference VerifyReference
+	orchestrator    orchestrator.PluginOrchestratorClient
+}
+
+// serve serves the plugin over gRPC on the socket given by Ratify until stdin
+// is closed.
+func (pc *pcontext) serve(verifyReference VerifyReference, supportedVersions []string) *plugin.Error {
+	version := pc.GetEnviron(vp.VersionEnvKey)
+	if err := validateVersion(version, supportedVersions); err != nil {
+		return err
+	}
+	socket := pc.GetEnviron(vp.SocketEnvKey)
+	orchestratorSocket := pc.GetEnviron(vp.OrchestratorSocketEnvKey)
+	if socket == "" || orchestratorSocket == "" {
+		return plugin.NewError(types.ErrMissingEnvironmentVariables, fmt.Sprintf("missing env variables [%s,%s]", vp.SocketEnvKey, vp.OrchestratorSocketEnvKey), "")
+	}
+
+	conn, err := grpc.NewClient("unix://"+orchestratorSocket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(vp.MaxMessageSize), grpc.MaxCallSendMsgSize(vp.MaxMessageSize)))
+	if err != nil {
+		return plugin.NewError(types.ErrIOFailure, fmt.Sprintf("failed to connect to orchestrator %s", orchestratorSocket), err.Error())
+	}
+	defer conn.Close()
+
+	lsnr, err := net.Listen("unix", socket)
+	if err != nil {
+		return plugin.NewError(types.ErrIOFailure, fmt.Sprintf("failed to listen on %s", socket), err.Error())
+	}
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(vp.MaxMessageSize), grpc.MaxSendMsgSize(vp.MaxMessageSize))
+	verifierpb.RegisterVerifierPluginServer(server, &verifierServer{
+		version:         version,
+		verifyReference: verifyReference,
+		orchestrator:    orchestrator.NewPluginOrchestratorClient(conn),
+	})
+	go func() {
+		// stdin is closed by Ratify to stop the plugin
+		_, _ = io.Copy(io.Discard, pc.Stdin)
+		server.Stop()
+	}()
+	if err := server.Serve(lsnr); err != nil {
+		return plugin.NewError(types.ErrIOFailure, fmt.Sprintf("failed to serve on %s", socket), err.Error())
+	}
+	return nil
+}
+
+// VerifyReference verifies the reference with the verify function of the
+// plugin. The plugin input is the same as if the plugin was executed, except
+// for the store which fetches manifests and blobs through Ratify.
+func (s *verifierServer) VerifyReference(ctx context.Context, request *verifierpb.VerifyReferenceRequest) (*verifierpb.VerifyReferenceResponse, error) {
+	subjectReference, err := vp.FromSubjectDescriptor(request.GetSubject())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	referenceDescriptor, err := vp.FromReferrer(request.GetReference())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	stdinData, err := json.Marshal(config.PluginInputConfig{
+		Config:       request.GetConfiguration().AsMap(),
+		ReferencDesc: referenceDescriptor,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, perr := validateAndGetConfig(stdinData); perr != nil {
+		return nil, status.Error(codes.InvalidArgument, perr.Error())
+	}
+
+	var storeName string
+	if stores := metadata.ValueFromIncomingContext(ctx, vp.StoreMetadataKey); len(stores) > 0 {
+		storeName = stores[0]
+	}
+	cmdArgs := &CmdArgs{
+		Version:    s.version,
+		Subject:    request.GetSubject().GetRawPath(),
+		StdinData:  stdinData,
+		subjectRef: subjectReference,
+	}
+	store := &orchestratorStore{name: storeName, client: s.orchestrator}
+	result, err := s.verifyReference(cmdArgs, subjectReference, referenceDescriptor, store)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, fmt.Sprintf("plugin command %s failed: %v", vp.VerifyCommand, err))
+	}
+	response, err := vp.ToVerifyReferenceResponse(result, request.GetSubject(), request.GetReference())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return response, nil
+}
+
+// orchestratorStore is the store of a plugin served over gRPC. Manifests and
+// blobs are fetched through the PluginOrchestrator service of Ratify, with
+// the store of the verification.
+type orchestratorStore struct {
+	name   string
+	client orchestrator.PluginOrchestratorClient
+}
+
+var _ referrerstore.ReferrerStore = &orchestratorStore{}
+
+func (s *orchestratorStore) Name() string {
+	return s.name
+}
+
+// ListReferrers is not supported by the PluginOrchestrator service.
+func (s *orchestratorStore) ListReferrers(_ context.Context, subjectReference common.Reference, _ []string, _ string, _ *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	return referrerstore.ListReferrersResult{}, re.ErrorCodeListReferrersFailure.WithDetail(fmt.Sprintf("failed to list referrers of %s: listing referrers is not supported by plugins served over gRPC", subjectReference.Original))
+}
+
+func (s *orchestratorStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, blobDigest digest.Digest) ([]byte, error) {
+	response, err := s.client.GetBlobs(ctx, &orchestrator.GetBlobsRequest{
+		Artifact:        vp.ToBlobDescriptor(subjectReference, blobDigest),
+		StorePluginName: s.name,
+	})
+	if err != nil {
+		return nil, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("failed to get blob %s of %s", blobDigest, subjectReference.Original)).WithError(err)
+	}
+	if len(response.GetContent()) == 0 {
+		return nil, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("no content returned for blob %s of %s", blobDigest, subjectReference.Original))
+	}
+	return response.GetContent()[0], nil
+}
+
+func (s *orchestratorStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	response, err := s.client.GetManifest(ctx, &orchestrator.GetManifestRequest{
+		Subject:         vp.ToSubjectDescriptor(subjectReference),
+		Referrer:        vp.ToReferrer(subjectReference, referenceDesc),
+		StorePluginName: s.name,
+	})
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("failed to get manifest %s of %s", referenceDesc.Digest, subjectReference.Original)).WithError(err)
+	}
+	return vp.FromManifest(response.GetManifest())
+}
+
+func (s *orchestratorStore) GetConfig() *storeConfig.StoreConfig {
+	return &storeConfig.StoreConfig{}
+}
+
+// GetSubjectDescriptor returns the descriptor of the subject as resolved by
+// Ratify, which only has the digest set.
+func (s *orchestratorStore) GetSubjectDescriptor(_ context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	if subjectReference.Digest == "" {
+		return nil, re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("subject %s is not resolved to a digest", subjectReference.Original))
+	}
+	return &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{Digest: subjectReference.Digest}}, nil
+}
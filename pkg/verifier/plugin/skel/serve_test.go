@@ -0,0 +1,200 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	sm "github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin"
+)
+
+const serveTestPluginName = "serve-test"
+
+// serveTestVerifyReference succeeds if the single blob of the referrer is
+// "valid" and reports the process of the plugin.
+func serveTestVerifyReference(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	input, err := validateAndGetConfig(args.StdinData)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	subjectDesc, serr := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if serr != nil {
+		return nil, serr
+	}
+	manifest, merr := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
+	if merr != nil {
+		return nil, merr
+	}
+	blob, berr := referrerStore.GetBlobContent(ctx, subjectReference, manifest.Blobs[0].Digest)
+	if berr != nil {
+		return nil, berr
+	}
+	return &verifier.VerifierResult{
+		Name:      fmt.Sprintf("%s", input.Config["name"]),
+		IsSuccess: string(blob) == "valid",
+		Message:   fmt.Sprintf("blob of %s is %s", subjectDesc.Digest, blob),
+		Extensions: map[string]interface{}{
+			"pid":     os.Getpid(),
+			"version": args.Version,
+		},
+	}, nil
+}
+
+func TestServe_VerifyReferenceThroughOrchestrator(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to get test executable: %v", err)
+	}
+	pluginDir := t.TempDir()
+	if err := os.Symlink(executable, filepath.Join(pluginDir, serveTestPluginName)); err != nil {
+		t.Skipf("failed to link test executable as plugin: %v", err)
+	}
+	v, err := plugin.NewVerifier("1.0.0", map[string]interface{}{
+		"name":               serveTestPluginName,
+		plugin.PluginModeKey: plugin.PluginModeGRPC,
+	}, []string{pluginDir})
+	if err != nil {
+		t.Fatalf("NewVerifier() unexpected error: %v", err)
+	}
+
+	subjectDigest := digest.FromString("subject")
+	subjectReference := common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Tag:      "v1",
+		Digest:   subjectDigest,
+		Original: "localhost:5000/net-monitor:v1",
+	}
+	store := &sm.MemoryTestStore{
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{},
+		Blobs:     map[digest.Digest][]byte{},
+	}
+	for _, content := range []string{"valid", "invalid"} {
+		referenceDigest := digest.FromString("manifest-" + content)
+		blobDigest := digest.FromString(content)
+		store.Manifests[referenceDigest] = ocispecs.ReferenceManifest{Blobs: []oci.Descriptor{{Digest: blobDigest}}}
+		store.Blobs[blobDigest] = []byte(content)
+	}
+
+	var pid interface{}
+	for _, content := range []string{"valid", "invalid"} {
+		referenceDescriptor := ocispecs.ReferenceDescriptor{
+			Descriptor:   oci.Descriptor{Digest: digest.FromString("manifest-" + content)},
+			ArtifactType: "test-type",
+		}
+		result, err := v.Verify(context.Background(), subjectReference, referenceDescriptor, store)
+		if err != nil {
+			t.Fatalf("Verify() unexpected error: %v", err)
+		}
+		if result.IsSuccess != (content == "valid") || result.VerifierName != serveTestPluginName ||
+			result.Message != fmt.Sprintf("blob of %s is %s", subjectDigest, content) {
+			t.Fatalf("unexpected result %+v", result)
+		}
+		extensions := result.Extensions.(map[string]interface{})
+		if extensions["version"] != "1.0.0" {
+			t.Fatalf("unexpected extensions %v", extensions)
+		}
+		if pid != nil && extensions["pid"] != pid {
+			t.Fatalf("expected the plugin process to be reused, got pid %v and %v", pid, extensions["pid"])
+		}
+		pid = extensions["pid"]
+	}
+
+	// failures of the plugin are returned as errors
+	_, err = v.Verify(context.Background(), subjectReference, ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{Digest: digest.FromString("missing")}}, store)
+	if err == nil || !strings.Contains(err.Error(), "manifest not found") {
+		t.Fatalf("expected manifest not found error, got %v", err)
+	}
+}
+
+func TestServe_MissingSockets(t *testing.T) {
+	pluginContext := &pcontext{
+		GetEnviron: func(key string) string {
+			return map[string]string{
+				plugin.CommandEnvKey: plugin.ServeCommand,
+				plugin.VersionEnvKey: "1.0.0",
+			}[key]
+		},
+		Stdin:  strings.NewReader(""),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	err := pluginContext.pluginMainCore("", "1.0.0", serveTestVerifyReference, []string{"1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), plugin.SocketEnvKey) {
+		t.Fatalf("expected missing socket error, got %v", err)
+	}
+}
+
+func TestServe_VerifyReferenceLargeBlob(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to get test executable: %v", err)
+	}
+	pluginDir := t.TempDir()
+	if err := os.Symlink(executable, filepath.Join(pluginDir, serveTestPluginName)); err != nil {
+		t.Skipf("failed to link test executable as plugin: %v", err)
+	}
+	v, err := plugin.NewVerifier("1.0.0", map[string]interface{}{
+		"name":               serveTestPluginName,
+		plugin.PluginModeKey: plugin.PluginModeGRPC,
+	}, []string{pluginDir})
+	if err != nil {
+		t.Fatalf("NewVerifier() unexpected error: %v", err)
+	}
+
+	subjectDigest := digest.FromString("subject")
+	subjectReference := common.Reference{
+		Path:     "localhost:5000/net-monitor",
+		Tag:      "v1",
+		Digest:   subjectDigest,
+		Original: "localhost:5000/net-monitor:v1",
+	}
+	// the blob exceeds the default message size of gRPC, and so does the
+	// result quoting it
+	blob := strings.Repeat("a", 5*1024*1024)
+	referenceDigest := digest.FromString("manifest-large")
+	blobDigest := digest.FromString(blob)
+	store := &sm.MemoryTestStore{
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			referenceDigest: {Blobs: []oci.Descriptor{{Digest: blobDigest}}},
+		},
+		Blobs: map[digest.Digest][]byte{blobDigest: []byte(blob)},
+	}
+	referenceDescriptor := ocispecs.ReferenceDescriptor{
+		Descriptor:   oci.Descriptor{Digest: referenceDigest},
+		ArtifactType: "test-type",
+	}
+	result, err := v.Verify(context.Background(), subjectReference, referenceDescriptor, store)
+	if err != nil {
+		t.Fatalf("Verify() unexpected error: %v", err)
+	}
+	if result.Message != fmt.Sprintf("blob of %s is %s", subjectDigest, blob) {
+		t.Fatalf("unexpected result message of %d bytes", len(result.Message))
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/referrerstore"
 	storeConfig "github.com/ratify-project/ratify/pkg/referrerstore/config"
 	"github.com/ratify-project/ratify/pkg/referrerstore/factory"
+	_ "github.com/ratify-project/ratify/pkg/referrerstore/ocilayout" // register oci layout referrer store for offline verification
 	"github.com/ratify-project/ratify/pkg/utils"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/config"
@@ -69,6 +70,10 @@ func PluginMain(name, version string, verifyReference VerifyReference, supported
 }
 
 func (pc *pcontext) pluginMainCore(_, version string, verifyReference VerifyReference, supportedVersions []string) *plugin.Error {
+	if pc.GetEnviron(vp.CommandEnvKey) == vp.ServeCommand {
+		return pc.serve(verifyReference, supportedVersions)
+	}
+
 	cmd, cmdArgs, err := pc.getCmdArgsFromEnv()
 	if err != nil {
 		return err
@@ -45,6 +45,11 @@ const (
 var dirPath string
 
 func TestMain(m *testing.M) {
+	// the test binary is started as a plugin served over gRPC by the serve tests
+	if os.Getenv(plugin.CommandEnvKey) == plugin.ServeCommand {
+		PluginMain(serveTestPluginName, "1.0.0", serveTestVerifyReference, []string{"1.0.0"})
+		os.Exit(0)
+	}
 	setup()
 	code := m.Run()
 	teardown()
@@ -0,0 +1,129 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// InTotoPayloadType is the DSSE payload type of in-toto statements
+	InTotoPayloadType string = "application/vnd.in-toto+json"
+	// StatementTypeV01 and StatementTypeV1 are the supported in-toto statement types
+	StatementTypeV01 string = "https://in-toto.io/Statement/v0.1"
+	StatementTypeV1  string = "https://in-toto.io/Statement/v1"
+)
+
+// Envelope is a DSSE envelope as defined by
+// https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// Statement is an in-toto attestation statement
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Subject is an artifact an in-toto statement is about
+type Subject struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// parseEnvelope parses a DSSE envelope and decodes its payload
+func parseEnvelope(blob []byte) (*Envelope, []byte, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(blob, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse DSSE envelope: %w", err)
+	}
+	if envelope.PayloadType == "" || envelope.Payload == "" {
+		return nil, nil, fmt.Errorf("DSSE envelope is missing payloadType or payload")
+	}
+	payload, err := decodeBase64(envelope.Payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode DSSE envelope payload: %w", err)
+	}
+	return &envelope, payload, nil
+}
+
+// parseStatement parses the in-toto statement in the payload of a DSSE envelope
+func parseStatement(payloadType string, payload []byte) (*Statement, error) {
+	if payloadType != InTotoPayloadType {
+		return nil, fmt.Errorf("unsupported DSSE payload type %s", payloadType)
+	}
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("failed to parse in-toto statement: %w", err)
+	}
+	if statement.Type != StatementTypeV01 && statement.Type != StatementTypeV1 {
+		return nil, fmt.Errorf("unsupported in-toto statement type %s", statement.Type)
+	}
+	if statement.PredicateType == "" {
+		return nil, fmt.Errorf("in-toto statement is missing predicateType")
+	}
+	return &statement, nil
+}
+
+// pae returns the pre-authentication encoding of the payload that is signed
+// by DSSE signatures
+func pae(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// hasSubject returns true if the statement is about the artifact with the given digest
+func (s *Statement) hasSubject(subjectDigest digest.Digest) bool {
+	for _, subject := range s.Subject {
+		if value, ok := subject.Digest[subjectDigest.Algorithm().String()]; ok && strings.EqualFold(value, subjectDigest.Encoded()) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding
+func decodeBase64(value string) ([]byte, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	if decoded, err := base64.RawStdEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	if decoded, err := base64.URLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.RawURLEncoding.DecodeString(value)
+}
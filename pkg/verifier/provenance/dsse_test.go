@@ -0,0 +1,140 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// TestPAE tests the pre-authentication encoding with the example of the DSSE specification
+func TestPAE(t *testing.T) {
+	got := string(pae("http://example.com/HelloWorld", []byte("hello world")))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if got != want {
+		t.Fatalf("pae() = %s, want %s", got, want)
+	}
+}
+
+// TestParseEnvelope tests the parseEnvelope function
+func TestParseEnvelope(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte("{}"))
+	tests := []struct {
+		name    string
+		blob    string
+		wantErr bool
+	}{
+		{
+			name: "valid envelope",
+			blob: `{"payloadType":"application/vnd.in-toto+json","payload":"` + payload + `","signatures":[]}`,
+		},
+		{
+			name: "url safe unpadded payload",
+			blob: `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.RawURLEncoding.EncodeToString([]byte("{}")) + `"}`,
+		},
+		{
+			name:    "invalid json",
+			blob:    "invalid",
+			wantErr: true,
+		},
+		{
+			name:    "missing payload",
+			blob:    `{"payloadType":"application/vnd.in-toto+json"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid payload encoding",
+			blob:    `{"payloadType":"application/vnd.in-toto+json","payload":"!!"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, decoded, err := parseEnvelope([]byte(tt.blob))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(decoded) != "{}" {
+				t.Fatalf("parseEnvelope() payload = %s, want {}", decoded)
+			}
+		})
+	}
+}
+
+// TestParseStatement tests the parseStatement function
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		payloadType string
+		payload     string
+		wantErr     bool
+	}{
+		{
+			name:        "v1 statement",
+			payloadType: InTotoPayloadType,
+			payload:     `{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://slsa.dev/provenance/v1","subject":[],"predicate":{}}`,
+		},
+		{
+			name:        "v0.1 statement",
+			payloadType: InTotoPayloadType,
+			payload:     `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","subject":[],"predicate":{}}`,
+		},
+		{
+			name:        "unsupported payload type",
+			payloadType: "text/plain",
+			payload:     `{}`,
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported statement type",
+			payloadType: InTotoPayloadType,
+			payload:     `{"_type":"https://example.com/Statement","predicateType":"https://slsa.dev/provenance/v1"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "missing predicate type",
+			payloadType: InTotoPayloadType,
+			payload:     `{"_type":"https://in-toto.io/Statement/v1"}`,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseStatement(tt.payloadType, []byte(tt.payload)); (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestHasSubject tests the hasSubject function
+func TestHasSubject(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	statement := Statement{
+		Subject: []Subject{
+			{Name: "other", Digest: map[string]string{"sha256": digest.FromString("other").Encoded()}},
+			{Name: "subject", Digest: map[string]string{"sha256": subjectDigest.Encoded()}},
+		},
+	}
+	if !statement.hasSubject(subjectDigest) {
+		t.Fatalf("hasSubject() expected statement to be about %s", subjectDigest)
+	}
+	if statement.hasSubject(digest.FromString("unknown")) {
+		t.Fatalf("hasSubject() expected statement not to be about unknown digest")
+	}
+}
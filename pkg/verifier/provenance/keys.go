@@ -0,0 +1,157 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"os"
+
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+const fileProviderName string = "file"
+
+// KeyConfig references a public key trusted to sign attestations, either
+// from a key management provider or from a file
+type KeyConfig struct {
+	Provider string `json:"provider,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Version  string `json:"version,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+// PKKey identifies a public key in the verifier result extensions
+type PKKey struct {
+	Provider string `json:"provider,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// validateKeys checks that each key is defined by either a file or a key
+// management provider
+func validateKeys(keys []KeyConfig) error {
+	if len(keys) == 0 {
+		return re.ErrorCodeConfigInvalid.WithDetail("keys parameter is required in the provenance verifier configuration")
+	}
+	for _, keyConfig := range keys {
+		if keyConfig.File == "" && keyConfig.Provider == "" {
+			return re.ErrorCodeConfigInvalid.WithDetail("key management provider name is required when not using file path")
+		}
+		if keyConfig.File != "" && keyConfig.Provider != "" {
+			return re.ErrorCodeConfigInvalid.WithDetail("'provider' and 'file' cannot be configured together")
+		}
+		if keyConfig.Version != "" && keyConfig.Name == "" {
+			return re.ErrorCodeConfigInvalid.WithDetail("key name is required when key version is defined")
+		}
+	}
+	return nil
+}
+
+// loadLocalKeys reads the public keys defined by file path
+func loadLocalKeys(keys []KeyConfig) (map[PKKey]keymanagementprovider.PublicKey, error) {
+	keyMap := make(map[PKKey]keymanagementprovider.PublicKey)
+	for _, keyConfig := range keys {
+		if keyConfig.File == "" {
+			continue
+		}
+		contents, err := os.ReadFile(keyConfig.File)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Failed to load the key from file %s", keyConfig.File)).WithError(err).WithRemediation("Ensure that the key file path is correct and public key is correctly saved.")
+		}
+		pubKey, err := cryptoutils.UnmarshalPEMToPublicKey(contents)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Failed to parse the key from file %s", keyConfig.File)).WithError(err)
+		}
+		keyMap[PKKey{Provider: fileProviderName, Name: keyConfig.File}] = keymanagementprovider.PublicKey{Key: pubKey, ProviderType: fileProviderName}
+	}
+	return keyMap, nil
+}
+
+// getKeys returns the local keys and the keys fetched from the key
+// management providers
+func (v *provenanceVerifier) getKeys(ctx context.Context) (map[PKKey]keymanagementprovider.PublicKey, error) {
+	keyMap := make(map[PKKey]keymanagementprovider.PublicKey)
+	for key, pubKey := range v.localKeys {
+		keyMap[key] = pubKey
+	}
+	for _, keyConfig := range v.config.Keys {
+		if keyConfig.File != "" {
+			continue
+		}
+		kmpResource, err := keymanagementprovider.GetKeysFromMap(ctx, keyConfig.Provider)
+		if err != nil {
+			return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Failed to access key management provider %s", keyConfig.Provider)).WithError(err)
+		}
+		if keyConfig.Name != "" {
+			pubKey, exists := kmpResource[keymanagementprovider.KMPMapKey{Name: keyConfig.Name, Version: keyConfig.Version}]
+			if !exists {
+				return nil, re.ErrorCodeConfigInvalid.WithDetail(fmt.Sprintf("Key %s with version %s not found in key management provider %s", keyConfig.Name, keyConfig.Version, keyConfig.Provider))
+			}
+			keyMap[PKKey{Provider: keyConfig.Provider, Name: keyConfig.Name, Version: keyConfig.Version}] = pubKey
+		} else {
+			for key, pubKey := range kmpResource {
+				keyMap[PKKey{Provider: keyConfig.Provider, Name: key.Name, Version: key.Version}] = pubKey
+			}
+		}
+	}
+	return keyMap, nil
+}
+
+// verifyEnvelopeSignatures returns the keys that verified at least one
+// signature of the envelope
+func verifyEnvelopeSignatures(envelope *Envelope, payload []byte, keysMap map[PKKey]keymanagementprovider.PublicKey) ([]PKKey, error) {
+	message := pae(envelope.PayloadType, payload)
+	verifiedBy := []PKKey{}
+	for mapKey, pubKey := range keysMap {
+		verifier, err := signature.LoadVerifier(pubKey.Key, hashForKey(pubKey.Key))
+		if err != nil {
+			return nil, re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Failed to load public key from provider [%s] name [%s] version [%s]", mapKey.Provider, mapKey.Name, mapKey.Version)).WithError(err)
+		}
+		for _, sig := range envelope.Signatures {
+			sigBytes, err := decodeBase64(sig.Sig)
+			if err != nil {
+				continue
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sigBytes), bytes.NewReader(message)); err == nil {
+				verifiedBy = append(verifiedBy, mapKey)
+				break
+			}
+		}
+	}
+	return verifiedBy, nil
+}
+
+// hashForKey returns the hash function used with the public key. ECDSA keys
+// use the hash matching the curve size, all other keys use SHA256.
+func hashForKey(publicKey crypto.PublicKey) crypto.Hash {
+	if key, ok := publicKey.(*ecdsa.PublicKey); ok {
+		switch key.Curve {
+		case elliptic.P384():
+			return crypto.SHA384
+		case elliptic.P521():
+			return crypto.SHA512
+		}
+	}
+	return crypto.SHA256
+}
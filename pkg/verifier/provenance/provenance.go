@@ -0,0 +1,308 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	re "github.com/ratify-project/ratify/errors"
+	"github.com/ratify-project/ratify/internal/logger"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/config"
+	"github.com/ratify-project/ratify/pkg/verifier/factory"
+	"github.com/ratify-project/ratify/pkg/verifier/types"
+)
+
+const (
+	verifierType string = "provenance"
+
+	// InTotoArtifactType is the artifact type of in-toto attestations attached
+	// as OCI referrers
+	InTotoArtifactType string = "application/vnd.in-toto+json"
+	// DSSEArtifactType is the artifact type of DSSE envelopes attached as OCI
+	// referrers
+	DSSEArtifactType string = "application/vnd.dsse.envelope.v1+json"
+	// CosignAttestationArtifactType is the artifact type of Cosign attestations
+	// discovered with the .att tag
+	CosignAttestationArtifactType string = "application/vnd.dev.cosign.artifact.att.v1+json"
+)
+
+type PluginConfig struct {
+	Name             string      `json:"name"`
+	Type             string      `json:"type,omitempty"`
+	ArtifactTypes    string      `json:"artifactTypes"`
+	NestedReferences []string    `json:"nestedArtifactTypes,omitempty"`
+	Keys             []KeyConfig `json:"keys"`
+	Rules            RulesConfig `json:"rules,omitempty"`
+}
+
+// Extension is the structure for the verifier result extensions
+// contains the verification result of each attestation found
+type Extension struct {
+	Attestations []attestationExtension `json:"attestations"`
+}
+
+// attestationExtension is the verification result of a single provenance
+// attestation. The decoded predicate is exposed so that policies can evaluate
+// fields not covered by the verifier rules.
+type attestationExtension struct {
+	Digest        digest.Digest          `json:"digest"`
+	PredicateType string                 `json:"predicateType"`
+	IsSuccess     bool                   `json:"isSuccess"`
+	Err           string                 `json:"error,omitempty"`
+	VerifiedKeys  []PKKey                `json:"verifiedKeys,omitempty"`
+	Provenance    *provenanceInfo        `json:"provenance,omitempty"`
+	Predicate     map[string]interface{} `json:"predicate,omitempty"`
+}
+
+type provenanceVerifier struct {
+	name             string
+	verifierType     string
+	artifactTypes    []string
+	nestedReferences []string
+	config           *PluginConfig
+	localKeys        map[PKKey]keymanagementprovider.PublicKey
+	rules            *rules
+}
+
+type provenanceVerifierFactory struct{}
+
+var logOpt = logger.Option{
+	ComponentType: logger.Verifier,
+}
+
+// init() registers the provenance verifier with the factory
+func init() {
+	factory.Register(verifierType, &provenanceVerifierFactory{})
+}
+
+// Create creates a new provenance verifier
+func (f *provenanceVerifierFactory) Create(_ string, verifierConfig config.VerifierConfig, _ string, namespace string) (verifier.ReferenceVerifier, error) {
+	logger.GetLogger(context.Background(), logOpt).Debugf("creating provenance verifier with config %v, namespace '%v'", verifierConfig, namespace)
+	conf, err := parseVerifierConfig(verifierConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Failed to create the Provenance Verifier").WithError(err)
+	}
+
+	if err := validateKeys(conf.Keys); err != nil {
+		return nil, err
+	}
+	localKeys, err := loadLocalKeys(conf.Keys)
+	if err != nil {
+		return nil, re.ErrorCodePluginInitFailure.WithDetail("Failed to create the Provenance Verifier").WithError(err)
+	}
+	compiledRules, err := newRules(conf.Rules)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.WithDetail("Invalid rules in the Provenance Verifier configuration").WithError(err)
+	}
+
+	return &provenanceVerifier{
+		name:             conf.Name,
+		verifierType:     conf.Type,
+		artifactTypes:    strings.Split(conf.ArtifactTypes, ","),
+		nestedReferences: conf.NestedReferences,
+		config:           conf,
+		localKeys:        localKeys,
+		rules:            compiledRules,
+	}, nil
+}
+
+// Name returns the name of the provenance verifier
+func (v *provenanceVerifier) Name() string {
+	return v.name
+}
+
+// Type returns 'provenance' as the type of the verifier
+func (v *provenanceVerifier) Type() string {
+	return verifierType
+}
+
+// CanVerify returns true if the referenceDescriptor's artifact type is in the list of artifact types supported by the verifier
+func (v *provenanceVerifier) CanVerify(_ context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	for _, at := range v.artifactTypes {
+		if at == "*" || at == referenceDescriptor.ArtifactType {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify verifies the signatures of the in-toto attestations in the reference
+// and evaluates their SLSA provenance predicates against the configured rules
+func (v *provenanceVerifier) Verify(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	keysMap, err := v.getKeys(ctx)
+	if err != nil {
+		return errorToVerifyResult(v.name, v.verifierType, err), nil
+	}
+
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeGetSubjectDescriptorFailure.WithDetail(fmt.Sprintf("Failed to resolve the subject descriptor of the artifact: %+v", subjectReference)).WithError(err)), nil
+	}
+
+	referenceManifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, referenceDescriptor)
+	if err != nil {
+		return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeGetReferenceManifestFailure.WithDetail(fmt.Sprintf("Failed to get the attestation manifest %s", referenceDescriptor.Digest)).WithError(err)), nil
+	}
+
+	attestations := make([]attestationExtension, 0)
+	hasValidProvenance := false
+	for _, blob := range referenceManifest.Blobs {
+		blobBytes, err := referrerStore.GetBlobContent(ctx, subjectReference, blob.Digest)
+		if err != nil {
+			return errorToVerifyResult(v.name, v.verifierType, re.ErrorCodeGetBlobContentFailure.WithDetail(fmt.Sprintf("Failed to get the attestation with digest %s", blob.Digest)).WithError(err)), nil
+		}
+		attestation, isProvenance := v.verifyAttestation(blob.Digest, blobBytes, keysMap, subjectDesc.Digest)
+		if !isProvenance {
+			logger.GetLogger(ctx, logOpt).Debugf("skipping attestation %s of predicate type %s", blob.Digest, attestation.PredicateType)
+			continue
+		}
+		if attestation.IsSuccess {
+			hasValidProvenance = true
+		}
+		attestations = append(attestations, attestation)
+	}
+
+	if hasValidProvenance {
+		return verifier.NewVerifierResult(
+			"",
+			v.name,
+			v.verifierType,
+			"Verification success. Valid SLSA provenance found. Please refer to extensions field for verifications performed.",
+			true,
+			nil,
+			Extension{Attestations: attestations},
+		), nil
+	}
+
+	reason := "no valid SLSA provenance found"
+	if len(attestations) == 0 {
+		reason = fmt.Sprintf("no SLSA provenance attestation found in %s", referenceDescriptor.Digest)
+	}
+	errorResult := errorToVerifyResult(v.name, v.verifierType, fmt.Errorf("%s", reason))
+	errorResult.Extensions = Extension{Attestations: attestations}
+	return errorResult, nil
+}
+
+// verifyAttestation verifies a single DSSE envelope. It returns false if the
+// envelope does not contain a SLSA provenance.
+func (v *provenanceVerifier) verifyAttestation(blobDigest digest.Digest, blob []byte, keysMap map[PKKey]keymanagementprovider.PublicKey, subjectDigest digest.Digest) (attestationExtension, bool) {
+	attestation := attestationExtension{Digest: blobDigest}
+	envelope, payload, err := parseEnvelope(blob)
+	if err != nil {
+		return attestation, false
+	}
+	statement, err := parseStatement(envelope.PayloadType, payload)
+	if err != nil {
+		return attestation, false
+	}
+	attestation.PredicateType = statement.PredicateType
+	if !isSLSAProvenance(statement.PredicateType) {
+		return attestation, false
+	}
+
+	verifiedKeys, err := verifyEnvelopeSignatures(envelope, payload, keysMap)
+	if err != nil {
+		attestation.Err = err.Error()
+		return attestation, true
+	}
+	if len(verifiedKeys) == 0 {
+		attestation.Err = "no signature of the attestation could be verified with the configured keys"
+		return attestation, true
+	}
+	attestation.VerifiedKeys = verifiedKeys
+
+	if !statement.hasSubject(subjectDigest) {
+		attestation.Err = fmt.Sprintf("attestation is not about the subject %s", subjectDigest)
+		return attestation, true
+	}
+
+	var predicate map[string]interface{}
+	if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+		attestation.Err = fmt.Sprintf("failed to decode predicate: %v", err)
+		return attestation, true
+	}
+	attestation.Predicate = predicate
+
+	info, err := parseProvenance(statement.PredicateType, statement.Predicate)
+	if err != nil {
+		attestation.Err = err.Error()
+		return attestation, true
+	}
+	attestation.Provenance = info
+	if err := v.rules.evaluate(info); err != nil {
+		attestation.Err = err.Error()
+		return attestation, true
+	}
+	attestation.IsSuccess = true
+	return attestation, true
+}
+
+// GetNestedReferences returns the nested reference artifact types configured
+func (v *provenanceVerifier) GetNestedReferences() []string {
+	return v.nestedReferences
+}
+
+// parseVerifierConfig parses the verifier config and returns a PluginConfig
+func parseVerifierConfig(verifierConfig config.VerifierConfig) (*PluginConfig, error) {
+	verifierName, hasName := verifierConfig[types.Name].(string)
+	if !hasName {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, "", re.EmptyLink, nil, "missing name in verifier config", re.HideStackTrace)
+	}
+	conf := PluginConfig{}
+
+	verifierConfigBytes, err := json.Marshal(verifierConfig)
+	if err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, err, nil, re.HideStackTrace)
+	}
+
+	if err := json.Unmarshal(verifierConfigBytes, &conf); err != nil {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, err, fmt.Sprintf("failed to unmarshal to provenance verifier config from: %+v.", verifierConfig), re.HideStackTrace)
+	}
+
+	// if Type is not provided, use the Name as the Type
+	if conf.Type == "" {
+		conf.Type = conf.Name
+	}
+
+	if conf.ArtifactTypes == "" {
+		return nil, re.ErrorCodeConfigInvalid.NewError(re.Verifier, verifierName, re.EmptyLink, nil, "missing artifactTypes in verifier config", re.HideStackTrace)
+	}
+
+	return &conf, nil
+}
+
+// errorToVerifyResult returns a verifier result with the error message and isSuccess set to false
+func errorToVerifyResult(name string, verifierType string, err error) verifier.VerifierResult {
+	verifierErr := re.ErrorCodeVerifyReferenceFailure.WithDetail("Failed to validate the provenance attestation").WithError(err)
+	return verifier.NewVerifierResult(
+		"",
+		name,
+		verifierType,
+		"",
+		false,
+		&verifierErr,
+		nil,
+	)
+}
@@ -0,0 +1,332 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/keymanagementprovider"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier/config"
+)
+
+const testKMPName string = "provenance-test-kmp"
+
+var (
+	testSubjectDigest     = digest.FromString("test_subject")
+	testAttestationDigest = digest.FromString("test_attestation")
+)
+
+// createTestKeyFile writes the public key of the private key to a PEM file
+func createTestKeyFile(t *testing.T, privateKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return path
+}
+
+// createTestEnvelope creates a DSSE envelope of a statement signed by the private key
+func createTestEnvelope(t *testing.T, privateKey *ecdsa.PrivateKey, predicateType string, predicate string, subjectDigest digest.Digest) []byte {
+	t.Helper()
+	statement := Statement{
+		Type:          StatementTypeV1,
+		Subject:       []Subject{{Name: "test", Digest: map[string]string{subjectDigest.Algorithm().String(): subjectDigest.Encoded()}}},
+		PredicateType: predicateType,
+		Predicate:     json.RawMessage(predicate),
+	}
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+	hash := sha256.Sum256(pae(InTotoPayloadType, payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign statement: %v", err)
+	}
+	envelope, err := json.Marshal(Envelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	return envelope
+}
+
+// createTestStore creates a store with an attestation manifest holding the envelopes
+func createTestStore(envelopes ...[]byte) *mocks.MemoryTestStore {
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			testSubjectDigest: {Descriptor: oci.Descriptor{Digest: testSubjectDigest}},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{},
+		Blobs:     map[digest.Digest][]byte{},
+	}
+	manifest := ocispecs.ReferenceManifest{MediaType: oci.MediaTypeImageManifest}
+	for _, envelope := range envelopes {
+		blobDigest := digest.FromBytes(envelope)
+		store.Blobs[blobDigest] = envelope
+		manifest.Blobs = append(manifest.Blobs, oci.Descriptor{MediaType: DSSEArtifactType, Digest: blobDigest})
+	}
+	store.Manifests[testAttestationDigest] = manifest
+	return store
+}
+
+// TestCreate tests the Create function of the provenance verifier
+func TestCreate(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPath := createTestKeyFile(t, privateKey)
+	tests := []struct {
+		name    string
+		config  config.VerifierConfig
+		wantErr bool
+	}{
+		{
+			name: "valid config",
+			config: config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+				"keys":          []KeyConfig{{File: keyPath}, {Provider: testKMPName}},
+				"rules":         RulesConfig{AllowedBuilderIDs: []string{testBuilderID}, MinimumSLSALevel: 1},
+			},
+		},
+		{
+			name: "missing name",
+			config: config.VerifierConfig{
+				"artifactTypes": InTotoArtifactType,
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing artifact types",
+			config: config.VerifierConfig{
+				"name": "test",
+				"keys": []KeyConfig{{File: keyPath}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing keys",
+			config: config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+			},
+			wantErr: true,
+		},
+		{
+			name: "key with file and provider",
+			config: config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+				"keys":          []KeyConfig{{File: keyPath, Provider: testKMPName}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "key file not found",
+			config: config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+				"keys":          []KeyConfig{{File: filepath.Join(t.TempDir(), "missing.pub")}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid rules",
+			config: config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+				"keys":          []KeyConfig{{File: keyPath}},
+				"rules":         RulesConfig{MinimumSLSALevel: 5},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &provenanceVerifierFactory{}
+			if _, err := f.Create("", tt.config, "", ""); (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCanVerify tests the CanVerify function of the provenance verifier
+func TestCanVerify(t *testing.T) {
+	v := &provenanceVerifier{artifactTypes: []string{InTotoArtifactType, CosignAttestationArtifactType}}
+	if !v.CanVerify(context.Background(), ocispecs.ReferenceDescriptor{ArtifactType: CosignAttestationArtifactType}) {
+		t.Fatalf("CanVerify() expected cosign attestations to be verifiable")
+	}
+	if v.CanVerify(context.Background(), ocispecs.ReferenceDescriptor{ArtifactType: "application/vnd.cncf.notary.signature"}) {
+		t.Fatalf("CanVerify() expected notation signatures not to be verifiable")
+	}
+}
+
+// TestVerify tests the Verify function of the provenance verifier
+func TestVerify(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPath := createTestKeyFile(t, privateKey)
+	keymanagementprovider.SaveSecrets(testKMPName, "inline", map[keymanagementprovider.KMPMapKey]crypto.PublicKey{{Name: "key"}: privateKey.Public()}, map[keymanagementprovider.KMPMapKey][]*x509.Certificate{})
+	defer keymanagementprovider.DeleteResourceFromMap(testKMPName)
+
+	allowedRules := RulesConfig{
+		AllowedBuilderIDs:        []string{"https://github.com/slsa-framework/slsa-github-generator/*"},
+		SourceRepositoryPatterns: []string{"^https://github.com/ratify-project/ratify$"},
+	}
+	tests := []struct {
+		name        string
+		keys        []KeyConfig
+		rules       RulesConfig
+		envelopes   [][]byte
+		wantSuccess bool
+		wantCount   int
+	}{
+		{
+			name:        "valid provenance verified with key file",
+			keys:        []KeyConfig{{File: keyPath}},
+			rules:       allowedRules,
+			envelopes:   [][]byte{createTestEnvelope(t, privateKey, SLSAProvenanceV1, testProvenanceV1, testSubjectDigest)},
+			wantSuccess: true,
+			wantCount:   1,
+		},
+		{
+			name:        "valid provenance verified with key management provider",
+			keys:        []KeyConfig{{Provider: testKMPName}},
+			rules:       allowedRules,
+			envelopes:   [][]byte{createTestEnvelope(t, privateKey, SLSAProvenanceV02, testProvenanceV02, testSubjectDigest)},
+			wantSuccess: true,
+			wantCount:   1,
+		},
+		{
+			name:      "provenance signed by untrusted key",
+			keys:      []KeyConfig{{File: keyPath}},
+			envelopes: [][]byte{createTestEnvelope(t, otherKey, SLSAProvenanceV1, testProvenanceV1, testSubjectDigest)},
+			wantCount: 1,
+		},
+		{
+			name:      "provenance of another subject",
+			keys:      []KeyConfig{{File: keyPath}},
+			envelopes: [][]byte{createTestEnvelope(t, privateKey, SLSAProvenanceV1, testProvenanceV1, digest.FromString("other"))},
+			wantCount: 1,
+		},
+		{
+			name:      "provenance violating rules",
+			keys:      []KeyConfig{{File: keyPath}},
+			rules:     RulesConfig{MinimumSLSALevel: 3},
+			envelopes: [][]byte{createTestEnvelope(t, privateKey, SLSAProvenanceV1, testProvenanceV1, testSubjectDigest)},
+			wantCount: 1,
+		},
+		{
+			name:      "no provenance attestation",
+			keys:      []KeyConfig{{File: keyPath}},
+			envelopes: [][]byte{createTestEnvelope(t, privateKey, "https://spdx.dev/Document", `{}`, testSubjectDigest), []byte("invalid")},
+		},
+		{
+			name:  "one valid provenance among other attestations",
+			keys:  []KeyConfig{{File: keyPath}},
+			rules: allowedRules,
+			envelopes: [][]byte{
+				createTestEnvelope(t, privateKey, "https://spdx.dev/Document", `{}`, testSubjectDigest),
+				createTestEnvelope(t, otherKey, SLSAProvenanceV1, testProvenanceV1, testSubjectDigest),
+				createTestEnvelope(t, privateKey, SLSAProvenanceV1, testProvenanceV1, testSubjectDigest),
+			},
+			wantSuccess: true,
+			wantCount:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &provenanceVerifierFactory{}
+			v, err := f.Create("", config.VerifierConfig{
+				"name":          "test",
+				"artifactTypes": InTotoArtifactType,
+				"keys":          tt.keys,
+				"rules":         tt.rules,
+			}, "", "")
+			if err != nil {
+				t.Fatalf("Create() unexpected error: %v", err)
+			}
+			subjectRef := common.Reference{Path: "test", Digest: testSubjectDigest, Original: "test@" + testSubjectDigest.String()}
+			refDesc := ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{Digest: testAttestationDigest}, ArtifactType: InTotoArtifactType}
+			result, err := v.Verify(context.Background(), subjectRef, refDesc, createTestStore(tt.envelopes...))
+			if err != nil {
+				t.Fatalf("Verify() unexpected error: %v", err)
+			}
+			if result.IsSuccess != tt.wantSuccess {
+				t.Fatalf("Verify() success = %t, want %t: %s", result.IsSuccess, tt.wantSuccess, result.ErrorReason)
+			}
+			extension, ok := result.Extensions.(Extension)
+			if !ok {
+				t.Fatalf("Verify() unexpected extensions type %T", result.Extensions)
+			}
+			if len(extension.Attestations) != tt.wantCount {
+				t.Fatalf("Verify() attestations = %d, want %d", len(extension.Attestations), tt.wantCount)
+			}
+			if tt.wantSuccess {
+				attestation := extension.Attestations[len(extension.Attestations)-1]
+				if attestation.Provenance == nil || attestation.Provenance.BuilderID != testBuilderID || attestation.Predicate == nil {
+					t.Fatalf("Verify() expected the decoded predicate in the extensions, got %+v", attestation)
+				}
+			}
+		})
+	}
+}
+
+// TestVerify_SubjectNotFound tests that an unresolvable subject fails verification
+func TestVerify_SubjectNotFound(t *testing.T) {
+	v := &provenanceVerifier{name: "test", config: &PluginConfig{}}
+	result, err := v.Verify(context.Background(), common.Reference{Digest: digest.FromString("unknown")}, ocispecs.ReferenceDescriptor{}, createTestStore())
+	if err != nil {
+		t.Fatalf("Verify() unexpected error: %v", err)
+	}
+	if result.IsSuccess {
+		t.Fatalf("Verify() expected failure for unknown subject")
+	}
+}
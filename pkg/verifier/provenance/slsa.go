@@ -0,0 +1,247 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SLSAProvenanceV02 and SLSAProvenanceV1 are the supported SLSA provenance predicate types
+	SLSAProvenanceV02 string = "https://slsa.dev/provenance/v0.2"
+	SLSAProvenanceV1  string = "https://slsa.dev/provenance/v1"
+
+	// defaultSLSALevel is the SLSA build level of a signed provenance from a
+	// builder without a configured level
+	defaultSLSALevel int = 1
+	maxSLSALevel     int = 3
+)
+
+// RulesConfig holds the rules a SLSA provenance must satisfy
+type RulesConfig struct {
+	// AllowedBuilderIDs are the trusted builder ids. An entry ending with '*'
+	// matches all builder ids with the same prefix.
+	AllowedBuilderIDs []string `json:"allowedBuilderIDs,omitempty"`
+	// SourceRepositoryPatterns are regular expressions of the trusted source
+	// repositories. A pattern must match the whole build source repository.
+	SourceRepositoryPatterns []string `json:"sourceRepositoryPatterns,omitempty"`
+	// MinimumSLSALevel is the minimum SLSA build level of the provenance
+	MinimumSLSALevel int `json:"minimumSLSALevel,omitempty"`
+	// BuilderLevels are the SLSA build levels attained by the builders
+	BuilderLevels []BuilderLevel `json:"builderLevels,omitempty"`
+}
+
+// BuilderLevel is the SLSA build level attained by the builders matching the id
+type BuilderLevel struct {
+	ID    string `json:"id"`
+	Level int    `json:"level"`
+}
+
+// rules are the compiled RulesConfig
+type rules struct {
+	config             RulesConfig
+	sourceRepositories []*regexp.Regexp
+}
+
+// provenanceInfo is the information of a SLSA provenance the rules are evaluated against
+type provenanceInfo struct {
+	BuilderID          string   `json:"builderID"`
+	BuildType          string   `json:"buildType,omitempty"`
+	SourceRepositories []string `json:"sourceRepositories,omitempty"`
+	SLSALevel          int      `json:"slsaLevel"`
+}
+
+type provenanceV02 struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		ConfigSource struct {
+			URI string `json:"uri"`
+		} `json:"configSource"`
+	} `json:"invocation"`
+}
+
+type provenanceV1 struct {
+	BuildDefinition struct {
+		BuildType          string                 `json:"buildType"`
+		ExternalParameters map[string]interface{} `json:"externalParameters"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+	} `json:"runDetails"`
+}
+
+// newRules validates and compiles the rules
+func newRules(config RulesConfig) (*rules, error) {
+	if config.MinimumSLSALevel < 0 || config.MinimumSLSALevel > maxSLSALevel {
+		return nil, fmt.Errorf("minimumSLSALevel must be between 0 and %d", maxSLSALevel)
+	}
+	for _, builderLevel := range config.BuilderLevels {
+		if builderLevel.ID == "" {
+			return nil, fmt.Errorf("id is required in builderLevels")
+		}
+		if builderLevel.Level < 0 || builderLevel.Level > maxSLSALevel {
+			return nil, fmt.Errorf("level of builder %s must be between 0 and %d", builderLevel.ID, maxSLSALevel)
+		}
+	}
+	compiled := &rules{config: config}
+	for _, pattern := range config.SourceRepositoryPatterns {
+		// anchor the pattern so that it cannot match a substring of an untrusted repository
+		expression, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid source repository pattern %s: %w", pattern, err)
+		}
+		compiled.sourceRepositories = append(compiled.sourceRepositories, expression)
+	}
+	return compiled, nil
+}
+
+// isSLSAProvenance returns true if the predicate type is a supported SLSA provenance
+func isSLSAProvenance(predicateType string) bool {
+	return predicateType == SLSAProvenanceV02 || predicateType == SLSAProvenanceV1
+}
+
+// parseProvenance extracts the builder and the build source from a SLSA
+// provenance predicate. Materials and resolved dependencies are not build
+// sources and are ignored.
+func parseProvenance(predicateType string, predicate []byte) (*provenanceInfo, error) {
+	info := &provenanceInfo{}
+	switch predicateType {
+	case SLSAProvenanceV02:
+		var p provenanceV02
+		if err := json.Unmarshal(predicate, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse SLSA v0.2 provenance: %w", err)
+		}
+		info.BuilderID = p.Builder.ID
+		info.BuildType = p.BuildType
+		info.SourceRepositories = appendSource(info.SourceRepositories, p.Invocation.ConfigSource.URI)
+	case SLSAProvenanceV1:
+		var p provenanceV1
+		if err := json.Unmarshal(predicate, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse SLSA v1 provenance: %w", err)
+		}
+		info.BuilderID = p.RunDetails.Builder.ID
+		info.BuildType = p.BuildDefinition.BuildType
+		for _, source := range externalParameterSources(p.BuildDefinition.ExternalParameters) {
+			info.SourceRepositories = appendSource(info.SourceRepositories, source)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported predicate type %s", predicateType)
+	}
+	if info.BuilderID == "" {
+		return nil, fmt.Errorf("builder id not found in provenance")
+	}
+	return info, nil
+}
+
+// externalParameterSources returns the source repositories declared in the
+// external parameters of the common SLSA v1 build types
+func externalParameterSources(parameters map[string]interface{}) []string {
+	sources := []string{}
+	// GitHub Actions workflow build type
+	if workflow, ok := parameters["workflow"].(map[string]interface{}); ok {
+		if repository, ok := workflow["repository"].(string); ok {
+			sources = append(sources, repository)
+		}
+	}
+	switch source := parameters["source"].(type) {
+	case string:
+		sources = append(sources, source)
+	case map[string]interface{}:
+		if uri, ok := source["uri"].(string); ok {
+			sources = append(sources, uri)
+		}
+	}
+	return sources
+}
+
+// appendSource appends the source uri and its repository without the git+
+// scheme prefix and the @ revision suffix
+func appendSource(sources []string, uri string) []string {
+	if uri == "" {
+		return sources
+	}
+	sources = append(sources, uri)
+	repository := strings.TrimPrefix(uri, "git+")
+	if index := strings.LastIndex(repository, "@"); index > strings.Index(repository, "://")+2 {
+		repository = repository[:index]
+	}
+	if repository != uri {
+		sources = append(sources, repository)
+	}
+	return sources
+}
+
+// evaluate checks the provenance against the rules and sets its SLSA level
+func (r *rules) evaluate(info *provenanceInfo) error {
+	if len(r.config.AllowedBuilderIDs) > 0 && !matchesBuilderID(r.config.AllowedBuilderIDs, info.BuilderID) {
+		return fmt.Errorf("builder %s is not allowed", info.BuilderID)
+	}
+
+	if len(r.sourceRepositories) > 0 && !r.matchesSourceRepository(info.SourceRepositories) {
+		return fmt.Errorf("source repositories %v do not match any of the allowed patterns %v", info.SourceRepositories, r.config.SourceRepositoryPatterns)
+	}
+
+	info.SLSALevel = r.builderLevel(info.BuilderID)
+	if info.SLSALevel < r.config.MinimumSLSALevel {
+		return fmt.Errorf("SLSA build level %d of builder %s is lower than the minimum level %d", info.SLSALevel, info.BuilderID, r.config.MinimumSLSALevel)
+	}
+	return nil
+}
+
+// builderLevel returns the highest level configured for the builder
+func (r *rules) builderLevel(builderID string) int {
+	level := defaultSLSALevel
+	for _, builderLevel := range r.config.BuilderLevels {
+		if matchesBuilderID([]string{builderLevel.ID}, builderID) && builderLevel.Level > level {
+			level = builderLevel.Level
+		}
+	}
+	return level
+}
+
+func (r *rules) matchesSourceRepository(sources []string) bool {
+	for _, source := range sources {
+		for _, expression := range r.sourceRepositories {
+			if expression.MatchString(source) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesBuilderID returns true if the builder id equals one of the allowed
+// ids or starts with the prefix of an allowed id ending with '*'
+func matchesBuilderID(allowed []string, builderID string) bool {
+	for _, id := range allowed {
+		if prefix, ok := strings.CutSuffix(id, "*"); ok {
+			if strings.HasPrefix(builderID, prefix) {
+				return true
+			}
+		} else if id == builderID {
+			return true
+		}
+	}
+	return false
+}
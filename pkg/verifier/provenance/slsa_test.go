@@ -0,0 +1,290 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	testBuilderID string = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v1.9.0"
+
+	testProvenanceV02 string = `{
+		"builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v1.9.0"},
+		"buildType": "https://github.com/slsa-framework/slsa-github-generator/container@v1",
+		"invocation": {
+			"configSource": {
+				"uri": "git+https://github.com/ratify-project/ratify@refs/heads/main",
+				"entryPoint": ".github/workflows/publish.yml"
+			}
+		},
+		"materials": [
+			{"uri": "git+https://github.com/ratify-project/ratify@refs/heads/main"}
+		]
+	}`
+
+	testProvenanceV1 string = `{
+		"buildDefinition": {
+			"buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+			"externalParameters": {
+				"workflow": {
+					"ref": "refs/heads/main",
+					"repository": "https://github.com/ratify-project/ratify",
+					"path": ".github/workflows/publish.yml"
+				}
+			},
+			"resolvedDependencies": [
+				{"uri": "git+https://github.com/ratify-project/ratify@refs/heads/main"}
+			]
+		},
+		"runDetails": {
+			"builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v1.9.0"}
+		}
+	}`
+)
+
+// TestParseProvenance tests the parseProvenance function
+func TestParseProvenance(t *testing.T) {
+	tests := []struct {
+		name          string
+		predicateType string
+		predicate     string
+		wantSource    string
+		wantErr       bool
+	}{
+		{
+			name:          "SLSA v0.2 provenance",
+			predicateType: SLSAProvenanceV02,
+			predicate:     testProvenanceV02,
+			wantSource:    "https://github.com/ratify-project/ratify",
+		},
+		{
+			name:          "SLSA v1 provenance",
+			predicateType: SLSAProvenanceV1,
+			predicate:     testProvenanceV1,
+			wantSource:    "https://github.com/ratify-project/ratify",
+		},
+		{
+			name:          "missing builder id",
+			predicateType: SLSAProvenanceV1,
+			predicate:     `{"buildDefinition": {}}`,
+			wantErr:       true,
+		},
+		{
+			name:          "invalid predicate",
+			predicateType: SLSAProvenanceV02,
+			predicate:     `[]`,
+			wantErr:       true,
+		},
+		{
+			name:          "unsupported predicate type",
+			predicateType: "https://spdx.dev/Document",
+			predicate:     `{}`,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseProvenance(tt.predicateType, []byte(tt.predicate))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProvenance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if info.BuilderID != testBuilderID {
+				t.Fatalf("parseProvenance() builder id = %s, want %s", info.BuilderID, testBuilderID)
+			}
+			if !slices.Contains(info.SourceRepositories, tt.wantSource) {
+				t.Fatalf("parseProvenance() source repositories = %v, want %s", info.SourceRepositories, tt.wantSource)
+			}
+		})
+	}
+}
+
+// TestParseProvenance_IgnoresDependencies tests that materials and resolved
+// dependencies are not reported as build sources
+func TestParseProvenance_IgnoresDependencies(t *testing.T) {
+	tests := []struct {
+		name          string
+		predicateType string
+		predicate     string
+	}{
+		{
+			name:          "SLSA v0.2 material",
+			predicateType: SLSAProvenanceV02,
+			predicate: `{
+				"builder": {"id": "` + testBuilderID + `"},
+				"invocation": {"configSource": {"uri": "git+https://github.com/attacker/repo@refs/heads/main"}},
+				"materials": [{"uri": "git+https://github.com/ratify-project/ratify@refs/heads/main"}]
+			}`,
+		},
+		{
+			name:          "SLSA v1 resolved dependency",
+			predicateType: SLSAProvenanceV1,
+			predicate: `{
+				"buildDefinition": {
+					"externalParameters": {"workflow": {"repository": "https://github.com/attacker/repo"}},
+					"resolvedDependencies": [{"uri": "git+https://github.com/ratify-project/ratify@refs/heads/main"}]
+				},
+				"runDetails": {"builder": {"id": "` + testBuilderID + `"}}
+			}`,
+		},
+	}
+	r, err := newRules(RulesConfig{SourceRepositoryPatterns: []string{"https://github.com/ratify-project/.*"}})
+	if err != nil {
+		t.Fatalf("newRules() unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseProvenance(tt.predicateType, []byte(tt.predicate))
+			if err != nil {
+				t.Fatalf("parseProvenance() unexpected error: %v", err)
+			}
+			if slices.Contains(info.SourceRepositories, "https://github.com/ratify-project/ratify") {
+				t.Fatalf("parseProvenance() source repositories = %v, want dependency excluded", info.SourceRepositories)
+			}
+			if err := r.evaluate(info); err == nil {
+				t.Fatalf("evaluate() expected error for untrusted build source with trusted dependency")
+			}
+		})
+	}
+}
+
+// TestNewRules tests the validation of the rules configuration
+func TestNewRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RulesConfig
+		wantErr bool
+	}{
+		{
+			name: "valid rules",
+			config: RulesConfig{
+				AllowedBuilderIDs:        []string{"https://github.com/slsa-framework/*"},
+				SourceRepositoryPatterns: []string{"^https://github.com/ratify-project/.*$"},
+				MinimumSLSALevel:         3,
+				BuilderLevels:            []BuilderLevel{{ID: "https://github.com/slsa-framework/*", Level: 3}},
+			},
+		},
+		{
+			name:    "invalid source repository pattern",
+			config:  RulesConfig{SourceRepositoryPatterns: []string{"("}},
+			wantErr: true,
+		},
+		{
+			name:    "minimum level out of range",
+			config:  RulesConfig{MinimumSLSALevel: 4},
+			wantErr: true,
+		},
+		{
+			name:    "builder level without id",
+			config:  RulesConfig{BuilderLevels: []BuilderLevel{{Level: 2}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRules(tt.config); (err != nil) != tt.wantErr {
+				t.Fatalf("newRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestEvaluate tests the evaluation of the rules against a provenance
+func TestEvaluate(t *testing.T) {
+	info := provenanceInfo{
+		BuilderID:          testBuilderID,
+		SourceRepositories: []string{"git+https://github.com/ratify-project/ratify@refs/heads/main", "https://github.com/ratify-project/ratify"},
+	}
+	tests := []struct {
+		name      string
+		config    RulesConfig
+		wantLevel int
+		wantErr   bool
+	}{
+		{
+			name:      "no rules",
+			wantLevel: defaultSLSALevel,
+		},
+		{
+			name:      "allowed builder id",
+			config:    RulesConfig{AllowedBuilderIDs: []string{testBuilderID}},
+			wantLevel: defaultSLSALevel,
+		},
+		{
+			name:      "allowed builder id prefix",
+			config:    RulesConfig{AllowedBuilderIDs: []string{"https://github.com/slsa-framework/slsa-github-generator/*"}},
+			wantLevel: defaultSLSALevel,
+		},
+		{
+			name:    "builder id not allowed",
+			config:  RulesConfig{AllowedBuilderIDs: []string{"https://cloudbuild.googleapis.com/GoogleHostedWorker"}},
+			wantErr: true,
+		},
+		{
+			name:      "source repository allowed",
+			config:    RulesConfig{SourceRepositoryPatterns: []string{"^https://github.com/ratify-project/ratify$"}},
+			wantLevel: defaultSLSALevel,
+		},
+		{
+			name:    "source repository not allowed",
+			config:  RulesConfig{SourceRepositoryPatterns: []string{"^https://github.com/other/.*$"}},
+			wantErr: true,
+		},
+		{
+			name:    "source repository pattern matches substring only",
+			config:  RulesConfig{SourceRepositoryPatterns: []string{"github.com/ratify-project/rat"}},
+			wantErr: true,
+		},
+		{
+			name:      "unanchored source repository pattern matches whole repository",
+			config:    RulesConfig{SourceRepositoryPatterns: []string{"https://github.com/ratify-project/.*"}},
+			wantLevel: defaultSLSALevel,
+		},
+		{
+			name: "minimum level attained by builder",
+			config: RulesConfig{
+				MinimumSLSALevel: 3,
+				BuilderLevels:    []BuilderLevel{{ID: "https://github.com/slsa-framework/slsa-github-generator/*", Level: 3}},
+			},
+			wantLevel: 3,
+		},
+		{
+			name:    "minimum level not attained",
+			config:  RulesConfig{MinimumSLSALevel: 2},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRules(tt.config)
+			if err != nil {
+				t.Fatalf("newRules() unexpected error: %v", err)
+			}
+			got := info
+			err = r.evaluate(&got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.SLSALevel != tt.wantLevel {
+				t.Fatalf("evaluate() level = %d, want %d", got.SLSALevel, tt.wantLevel)
+			}
+		})
+	}
+}
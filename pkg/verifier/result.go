@@ -17,6 +17,13 @@ package verifier
 
 import "github.com/ratify-project/ratify/errors"
 
+// NestedSubjectsExtension is the key of the result extensions listing the
+// referrers of the subject the result relies on, e.g. the VEX documents used
+// by a vulnerability report verifier. The executor verifies each of them as a
+// nested subject and fails the result unless their verification succeeds with
+// at least one successfully verified referrer, such as a signature.
+const NestedSubjectsExtension = "nestedSubjects"
+
 // VerifierResult describes the result of verifying a reference manifest for a subject.
 // Note: This struct is used to represent the result of verification in v0.
 type VerifierResult struct { //nolint:revive // ignore linter to have unique type name
@@ -34,7 +34,6 @@ import (
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
 	jsonLoader "github.com/spdx/tools-golang/json"
-	"github.com/spdx/tools-golang/spdx"
 	"github.com/spdx/tools-golang/spdx/v2/v2_3"
 )
 
@@ -51,10 +50,16 @@ type PluginInputConfig struct {
 }
 
 const (
-	SpdxJSONMediaType string = "application/spdx+json"
-	CreationInfo      string = "creationInfo"
-	LicenseViolation  string = "licenseViolations"
-	PackageViolation  string = "packageViolations"
+	SpdxJSONMediaType      string = "application/spdx+json"
+	CycloneDXJSONMediaType string = "application/vnd.cyclonedx+json"
+	CycloneDXXMLMediaType  string = "application/vnd.cyclonedx+xml"
+	// CosignSBOMArtifactType is the artifact type of SBOMs attached by Cosign
+	// with the .sbom tag. The format is given by the media type of the layer.
+	CosignSBOMArtifactType string = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+	CreationInfo           string = "creationInfo"
+	Metadata               string = "metadata"
+	LicenseViolation       string = "licenseViolations"
+	PackageViolation       string = "packageViolations"
 )
 
 func main() {
@@ -68,6 +73,15 @@ func parseInput(stdin []byte) (*PluginConfig, error) {
 		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
 	}
 
+	for _, item := range conf.Config.DisallowedPackages {
+		if len(item.PURL) == 0 {
+			continue
+		}
+		if _, err := utils.ParsePackageURL(item.PURL); err != nil {
+			return nil, fmt.Errorf("failed to parse disallowed package: %w", err)
+		}
+	}
+
 	return &conf.Config, nil
 }
 
@@ -105,11 +119,20 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 			return &result, nil
 		}
 
-		switch artifactType {
+		mediaType := artifactType
+		if artifactType == CosignSBOMArtifactType {
+			mediaType = cosignSBOMMediaType(blobDesc.MediaType)
+		}
+
+		switch mediaType {
 		case SpdxJSONMediaType:
 			return processSpdxJSONMediaType(input.Name, verifierType, refBlob, input.DisallowedLicenses, input.DisallowedPackages), nil
+		case CycloneDXJSONMediaType:
+			return processCycloneDXMediaType(input.Name, verifierType, refBlob, utils.ReadCycloneDXJSON, input.DisallowedLicenses, input.DisallowedPackages), nil
+		case CycloneDXXMLMediaType:
+			return processCycloneDXMediaType(input.Name, verifierType, refBlob, utils.ReadCycloneDXXML, input.DisallowedLicenses, input.DisallowedPackages), nil
 		default:
-			storeErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Unsupported artifactType: %s", artifactType))
+			storeErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Unsupported artifactType: %s", mediaType))
 			result := verifier.NewVerifierResult("", input.Name, verifierType, "Failed to process SBOM blobs.", false, &storeErr, nil)
 			return &result, nil
 		}
@@ -118,30 +141,52 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 	return &result, nil
 }
 
+// cosignSBOMMediaType returns the SBOM media type of a layer attached by
+// Cosign. Cosign uses its own media type for SPDX JSON documents.
+func cosignSBOMMediaType(layerMediaType string) string {
+	switch layerMediaType {
+	case "spdx+json", "text/spdx+json":
+		return SpdxJSONMediaType
+	default:
+		return layerMediaType
+	}
+}
+
 // getViolations returns the package and license violations based on the deny list
-func getViolations(spdxDoc *spdx.Document, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) ([]utils.PackageLicense, []utils.PackageLicense) {
-	packageLicenses := utils.GetPackageLicenses(*spdxDoc)
+func getViolations(packageLicenses []utils.PackageLicense, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) ([]utils.PackageLicense, []utils.PackageLicense) {
 	// load disallowed packageInfo into a map for easier existence check
-	packageMap, packageNameMap := loadDisallowedPackagesMap(disallowedPackages)
+	packageMap, packageNameMap, packageURLs := loadDisallowedPackagesMap(disallowedPackages)
 
 	// detect violation
-	licenseViolation, packageViolation := filterDisallowedPackages(packageLicenses, disallowedLicenses, packageMap, packageNameMap)
+	licenseViolation, packageViolation := filterDisallowedPackages(packageLicenses, disallowedLicenses, packageMap, packageNameMap, packageURLs)
 	return packageViolation, licenseViolation
 }
 
 // load disallowed packageInfo, and disallowed packageName into a map for easier existence check
-func loadDisallowedPackagesMap(packages []utils.PackageInfo) (map[utils.PackageInfo]struct{}, map[string]struct{}) {
+// disallowed packages identified by purl are returned separately as they are
+// matched by the parsed package URL
+func loadDisallowedPackagesMap(packages []utils.PackageInfo) (map[utils.PackageInfo]struct{}, map[string]struct{}, []utils.PackageURL) {
 	packagesInfo := map[utils.PackageInfo]struct{}{}
 	packagesName := map[string]struct{}{}
+	var packageURLs []utils.PackageURL
 
 	for _, item := range packages {
+		if len(item.PURL) != 0 {
+			purl, err := utils.ParsePackageURL(item.PURL)
+			if err != nil {
+				// purls are validated when parsing the input
+				continue
+			}
+			packageURLs = append(packageURLs, purl)
+			continue
+		}
 		// if the deny list item has no specific version, add to separate map
 		if len(item.Version) == 0 {
 			packagesName[item.Name] = struct{}{}
 		}
 		packagesInfo[item] = struct{}{}
 	}
-	return packagesInfo, packagesName
+	return packagesInfo, packagesName, packageURLs
 }
 
 // parse through the spdx blob and returns the verifier result
@@ -149,45 +194,62 @@ func processSpdxJSONMediaType(name string, verifierType string, refBlob []byte,
 	var err error
 	var spdxDoc *v2_3.Document
 	if spdxDoc, err = jsonLoader.Read(bytes.NewReader(refBlob)); spdxDoc != nil && err == nil {
-		if len(disallowedLicenses) != 0 || len(disallowedPackages) != 0 {
-			packageViolation, licenseViolation := getViolations(spdxDoc, disallowedLicenses, disallowedPackages)
+		return getVerifierResult(name, verifierType, utils.GetPackageLicenses(*spdxDoc), CreationInfo, spdxDoc.CreationInfo, disallowedLicenses, disallowedPackages)
+	}
+	verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", name)).WithError(err)
+	result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, nil)
+	return &result
+}
 
-			var extensionData = make(map[string]interface{})
-			extensionData[CreationInfo] = spdxDoc.CreationInfo
-			if len(licenseViolation) != 0 {
-				extensionData[LicenseViolation] = licenseViolation
-			}
+// parse through the CycloneDX blob with the given reader and returns the verifier result
+func processCycloneDXMediaType(name string, verifierType string, refBlob []byte, read func([]byte) (*utils.CycloneDXBOM, error), disallowedLicenses []string, disallowedPackages []utils.PackageInfo) *verifier.VerifierResult {
+	bom, err := read(refBlob)
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", name)).WithError(err)
+		result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, nil)
+		return &result
+	}
+	return getVerifierResult(name, verifierType, utils.GetCycloneDXPackageLicenses(*bom), Metadata, bom.Metadata, disallowedLicenses, disallowedPackages)
+}
 
-			if len(packageViolation) != 0 {
-				extensionData[PackageViolation] = packageViolation
-			}
+// getVerifierResult checks the packages of the SBOM against the deny lists.
+// The document info is added to the extensions under infoKey.
+func getVerifierResult(name string, verifierType string, packageLicenses []utils.PackageLicense, infoKey string, info interface{}, disallowedLicenses []string, disallowedPackages []utils.PackageInfo) *verifier.VerifierResult {
+	if len(disallowedLicenses) != 0 || len(disallowedPackages) != 0 {
+		packageViolation, licenseViolation := getViolations(packageLicenses, disallowedLicenses, disallowedPackages)
 
-			if len(licenseViolation) != 0 || len(packageViolation) != 0 {
-				sbomErr := errors.ErrorCodeVerifyPluginFailure.WithDetail("License or package violation found.").WithRemediation("Please review extensions data for license and package violation found.")
-				result := verifier.NewVerifierResult("", name, verifierType, "SBOM validation failed", false, &sbomErr, extensionData)
-				return &result
-			}
+		var extensionData = make(map[string]interface{})
+		extensionData[infoKey] = info
+		if len(licenseViolation) != 0 {
+			extensionData[LicenseViolation] = licenseViolation
 		}
 
-		result := verifier.NewVerifierResult(
-			"",
-			name,
-			verifierType,
-			"SBOM verification success. No license or package violation found.",
-			true,
-			nil,
-			map[string]interface{}{CreationInfo: spdxDoc.CreationInfo},
-		)
-		return &result
+		if len(packageViolation) != 0 {
+			extensionData[PackageViolation] = packageViolation
+		}
+
+		if len(licenseViolation) != 0 || len(packageViolation) != 0 {
+			sbomErr := errors.ErrorCodeVerifyPluginFailure.WithDetail("License or package violation found.").WithRemediation("Please review extensions data for license and package violation found.")
+			result := verifier.NewVerifierResult("", name, verifierType, "SBOM validation failed", false, &sbomErr, extensionData)
+			return &result
+		}
 	}
-	verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("failed to verify artifact: %s", name)).WithError(err)
-	result := verifier.NewVerifierResult("", name, verifierType, "", false, &verifierErr, nil)
+
+	result := verifier.NewVerifierResult(
+		"",
+		name,
+		verifierType,
+		"SBOM verification success. No license or package violation found.",
+		true,
+		nil,
+		map[string]interface{}{infoKey: info},
+	)
 	return &result
 }
 
 // iterate through all package info and check against the deny list
 // return the violation packages
-func filterDisallowedPackages(packageLicenses []utils.PackageLicense, disallowedLicense []string, disallowedPackage map[utils.PackageInfo]struct{}, disallowedPackageName map[string]struct{}) ([]utils.PackageLicense, []utils.PackageLicense) {
+func filterDisallowedPackages(packageLicenses []utils.PackageLicense, disallowedLicense []string, disallowedPackage map[utils.PackageInfo]struct{}, disallowedPackageName map[string]struct{}, disallowedPackageURLs []utils.PackageURL) ([]utils.PackageLicense, []utils.PackageLicense) {
 	var violationLicense []utils.PackageLicense
 	var violationPackage []utils.PackageLicense
 
@@ -199,20 +261,44 @@ func filterDisallowedPackages(packageLicenses []utils.PackageLicense, disallowed
 			}
 		}
 
-		current := utils.PackageInfo{
-			Name:    packageInfo.Name,
-			Version: packageInfo.Version,
-		}
-
-		// check if this package is in the deny list by package name
-		if _, ok := disallowedPackageName[current.Name]; ok {
+		// a package matching several deny list entries is reported once
+		if isDisallowedPackage(packageInfo, disallowedPackage, disallowedPackageName, disallowedPackageURLs) {
 			violationPackage = append(violationPackage, packageInfo)
 		}
+	}
+	return violationLicense, violationPackage
+}
 
-		//  check if this package is in the deny list by matching name and version
-		if _, ok := disallowedPackage[current]; ok {
-			violationPackage = append(violationPackage, packageInfo)
+// isDisallowedPackage returns true if the package is in the deny list by
+// package name, by name and version or by purl
+func isDisallowedPackage(packageInfo utils.PackageLicense, disallowedPackage map[utils.PackageInfo]struct{}, disallowedPackageName map[string]struct{}, disallowedPackageURLs []utils.PackageURL) bool {
+	current := utils.PackageInfo{
+		Name:    packageInfo.Name,
+		Version: packageInfo.Version,
+	}
+	if _, ok := disallowedPackageName[current.Name]; ok {
+		return true
+	}
+	if _, ok := disallowedPackage[current]; ok {
+		return true
+	}
+	return matchesPackageURL(packageInfo.PURL, disallowedPackageURLs)
+}
+
+// matchesPackageURL returns true if the purl of the package matches any of the
+// disallowed package URLs
+func matchesPackageURL(purl string, disallowedPackageURLs []utils.PackageURL) bool {
+	if len(purl) == 0 || len(disallowedPackageURLs) == 0 {
+		return false
+	}
+	packageURL, err := utils.ParsePackageURL(purl)
+	if err != nil {
+		return false
+	}
+	for _, disallowed := range disallowedPackageURLs {
+		if packageURL.Matches(disallowed) {
+			return true
 		}
 	}
-	return violationLicense, violationPackage
+	return false
 }
@@ -194,6 +194,54 @@ func TestVerifyReference(t *testing.T) {
 				errorReason: "unexpected end of JSON input",
 			},
 		},
+		{
+			name: "cosign sbom uses layer mediaType",
+			args: args{
+				stdinData: `{"config":{"name":"sbom","type":"sbom"}}`,
+				referenceManifest: ocispecs.ReferenceManifest{
+					Blobs: []oci.Descriptor{
+						{
+							MediaType: "spdx+json",
+							Digest:    blobDigest,
+						},
+					},
+				},
+				refDesc: ocispecs.ReferenceDescriptor{
+					Descriptor: oci.Descriptor{
+						Digest: manifestDigest,
+					},
+					ArtifactType: CosignSBOMArtifactType,
+				},
+			},
+			want: want{
+				message:     "failed to verify artifact: sbom",
+				errorReason: "unexpected end of JSON input",
+			},
+		},
+		{
+			name: "cosign sbom with unsupported layer mediaType",
+			args: args{
+				stdinData: `{"config":{"name":"sbom","type":"sbom"}}`,
+				referenceManifest: ocispecs.ReferenceManifest{
+					Blobs: []oci.Descriptor{
+						{
+							MediaType: mediaType,
+							Digest:    blobDigest,
+						},
+					},
+				},
+				refDesc: ocispecs.ReferenceDescriptor{
+					Descriptor: oci.Descriptor{
+						Digest: manifestDigest,
+					},
+					ArtifactType: CosignSBOMArtifactType,
+				},
+			},
+			want: want{
+				message:     "Failed to process SBOM blobs.",
+				errorReason: "Unsupported artifactType: application/vnd.syft+json",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -381,3 +429,116 @@ func AssertEquals(expected []utils.PackageLicense, actual []utils.PackageLicense
 		}
 	}
 }
+
+func TestProcessCycloneDXMediaType(t *testing.T) {
+	busybox := utils.PackageLicense{
+		Name:    "busybox",
+		Version: "1.36.1-r2",
+		License: "GPL-2.0-only",
+		PURL:    "pkg:apk/alpine/busybox@1.36.1-r2?arch=x86_64&distro=alpine-3.18.4",
+	}
+	libcUtils := utils.PackageLicense{
+		Name:    "libc-utils",
+		Version: "0.7.2-r5",
+		License: "BSD-2-Clause AND BSD-3-Clause",
+		PURL:    "pkg:apk/alpine/libc-utils@0.7.2-r5?arch=x86_64&distro=alpine-3.18.4",
+	}
+	zlib := utils.PackageLicense{
+		Name:    "zlib",
+		Version: "1.2.13-r1",
+		License: "Zlib",
+		PURL:    "pkg:apk/alpine/zlib@1.2.13-r1",
+	}
+
+	cases := []struct {
+		description               string
+		file                      string
+		read                      func([]byte) (*utils.CycloneDXBOM, error)
+		disallowedLicenses        []string
+		disallowedPackages        []utils.PackageInfo
+		expectedLicenseViolations []utils.PackageLicense
+		expectedPackageViolations []utils.PackageLicense
+	}{
+		{
+			description: "no deny list",
+			file:        "bom.cdx.json",
+			read:        utils.ReadCycloneDXJSON,
+		},
+		{
+			description:               "license expression violation",
+			file:                      "bom.cdx.json",
+			read:                      utils.ReadCycloneDXJSON,
+			disallowedLicenses:        []string{"BSD-3-Clause", "zlib"},
+			expectedLicenseViolations: []utils.PackageLicense{libcUtils, zlib},
+		},
+		{
+			description:               "package violation by name and purl",
+			file:                      "bom.cdx.xml",
+			read:                      utils.ReadCycloneDXXML,
+			disallowedPackages:        []utils.PackageInfo{{Name: "zlib", Version: "1.2.13-r1"}, {PURL: "pkg:apk/alpine/busybox"}},
+			expectedPackageViolations: []utils.PackageLicense{busybox, zlib},
+		},
+		{
+			description:               "package matching name and purl reported once",
+			file:                      "bom.cdx.json",
+			read:                      utils.ReadCycloneDXJSON,
+			disallowedPackages:        []utils.PackageInfo{{Name: "zlib"}, {PURL: "pkg:apk/alpine/zlib"}},
+			expectedPackageViolations: []utils.PackageLicense{zlib},
+		},
+		{
+			description:        "purl version not matched",
+			file:               "bom.cdx.xml",
+			read:               utils.ReadCycloneDXXML,
+			disallowedPackages: []utils.PackageInfo{{PURL: "pkg:apk/alpine/busybox@1.36.1-r3"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("error reading %s", filepath.Join("testdata", tc.file))
+			}
+			report := processCycloneDXMediaType("test", "", b, tc.read, tc.disallowedLicenses, tc.disallowedPackages)
+
+			if report.IsSuccess != (len(tc.expectedPackageViolations) == 0 && len(tc.expectedLicenseViolations) == 0) {
+				t.Fatalf("Test %s failed. Unexpected IsSuccess: %v", tc.description, report.IsSuccess)
+			}
+
+			extensionData := report.Extensions.(map[string]interface{})
+			if extensionData[Metadata] == nil {
+				t.Fatalf("Test %s failed. Expected metadata in extensions", tc.description)
+			}
+
+			if len(tc.expectedPackageViolations) != 0 {
+				AssertEquals(tc.expectedPackageViolations, extensionData[PackageViolation].([]utils.PackageLicense), tc.description, t)
+			}
+
+			if len(tc.expectedLicenseViolations) != 0 {
+				AssertEquals(tc.expectedLicenseViolations, extensionData[LicenseViolation].([]utils.PackageLicense), tc.description, t)
+			}
+		})
+	}
+}
+
+func TestProcessInvalidCycloneDXMediaType(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "bom.json"))
+	if err != nil {
+		t.Fatalf("error reading %s", filepath.Join("testdata", "bom.json"))
+	}
+	report := processCycloneDXMediaType("test", "", b, utils.ReadCycloneDXJSON, nil, nil)
+
+	if report.IsSuccess {
+		t.Fatalf("expected to fail reading an SPDX document as CycloneDX")
+	}
+	if report.ErrorReason != "JSON document does not contain bomFormat CycloneDX" {
+		t.Fatalf("expected error reason: %s, got: %s", "JSON document does not contain bomFormat CycloneDX", report.ErrorReason)
+	}
+}
+
+func TestParseInput_InvalidPackageURL(t *testing.T) {
+	_, err := parseInput([]byte(`{"config":{"name":"sbom","disallowedPackages":[{"purl":"apk/alpine/busybox"}]}}`))
+	if err == nil {
+		t.Fatalf("expected error for invalid purl")
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+const cycloneDXBOMFormat = "CycloneDX"
+
+// CycloneDXBOM contains the subset of a CycloneDX BOM used by the verifier.
+// The same type is used to decode both the JSON and XML encodings.
+type CycloneDXBOM struct {
+	XMLName     xml.Name             `json:"-" xml:"bom"`
+	BOMFormat   string               `json:"bomFormat,omitempty" xml:"-"`
+	SpecVersion string               `json:"specVersion,omitempty" xml:"-"`
+	Version     int                  `json:"version,omitempty" xml:"version,attr"`
+	Metadata    *CycloneDXMetadata   `json:"metadata,omitempty" xml:"metadata"`
+	Components  []CycloneDXComponent `json:"components,omitempty" xml:"components>component"`
+}
+
+// CycloneDXMetadata is the metadata of a CycloneDX BOM.
+type CycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp,omitempty" xml:"timestamp"`
+	Component *CycloneDXComponent `json:"component,omitempty" xml:"component"`
+}
+
+// CycloneDXComponent is a component of a CycloneDX BOM. Components can be
+// nested.
+type CycloneDXComponent struct {
+	Type       string               `json:"type,omitempty" xml:"type,attr"`
+	Name       string               `json:"name" xml:"name"`
+	Group      string               `json:"group,omitempty" xml:"group"`
+	Version    string               `json:"version,omitempty" xml:"version"`
+	PURL       string               `json:"purl,omitempty" xml:"purl"`
+	Licenses   CycloneDXLicenses    `json:"licenses,omitempty" xml:"licenses"`
+	Components []CycloneDXComponent `json:"components,omitempty" xml:"components>component"`
+}
+
+// CycloneDXLicenses is the license choice of a component, either a list of
+// licenses or a single SPDX license expression.
+type CycloneDXLicenses []CycloneDXLicenseChoice
+
+// CycloneDXLicenseChoice is a license or a license expression.
+type CycloneDXLicenseChoice struct {
+	License    *CycloneDXLicense `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+}
+
+// CycloneDXLicense is a license identified by its SPDX ID or its name.
+type CycloneDXLicense struct {
+	ID   string `json:"id,omitempty" xml:"id"`
+	Name string `json:"name,omitempty" xml:"name"`
+}
+
+// UnmarshalXML decodes the licenses element of the XML encoding where
+// licenses and expressions are child elements instead of objects.
+func (l *CycloneDXLicenses) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var licenses struct {
+		Licenses    []CycloneDXLicense `xml:"license"`
+		Expressions []string           `xml:"expression"`
+	}
+	if err := d.DecodeElement(&licenses, &start); err != nil {
+		return err
+	}
+	for i := range licenses.Licenses {
+		*l = append(*l, CycloneDXLicenseChoice{License: &licenses.Licenses[i]})
+	}
+	for _, expression := range licenses.Expressions {
+		*l = append(*l, CycloneDXLicenseChoice{Expression: expression})
+	}
+	return nil
+}
+
+// ReadCycloneDXJSON decodes a CycloneDX BOM in JSON format.
+func ReadCycloneDXJSON(content []byte) (*CycloneDXBOM, error) {
+	bom := CycloneDXBOM{}
+	if err := json.Unmarshal(content, &bom); err != nil {
+		return nil, err
+	}
+	if bom.BOMFormat != cycloneDXBOMFormat {
+		return nil, errors.New("JSON document does not contain bomFormat CycloneDX")
+	}
+	return &bom, nil
+}
+
+// ReadCycloneDXXML decodes a CycloneDX BOM in XML format.
+func ReadCycloneDXXML(content []byte) (*CycloneDXBOM, error) {
+	bom := CycloneDXBOM{}
+	if err := xml.Unmarshal(content, &bom); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(bom.XMLName.Space, "http://cyclonedx.org/schema/bom/") {
+		return nil, errors.New("XML document does not use the CycloneDX bom namespace")
+	}
+	return &bom, nil
+}
+
+// GetCycloneDXPackageLicenses returns the packageLicense array of all
+// components in the BOM including nested components.
+func GetCycloneDXPackageLicenses(bom CycloneDXBOM) []PackageLicense {
+	output := []PackageLicense{}
+	var walk func(components []CycloneDXComponent)
+	walk = func(components []CycloneDXComponent) {
+		for _, c := range components {
+			output = append(output, PackageLicense{
+				Name:    c.Name,
+				Version: c.Version,
+				License: c.Licenses.Expression(),
+				PURL:    c.PURL,
+			})
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+	return output
+}
+
+// Expression returns the licenses as a single SPDX license expression so that
+// the licenses can be matched the same way as SPDX documents. Multiple
+// licenses are combined with AND as all of them apply to the component.
+func (l CycloneDXLicenses) Expression() string {
+	var parts []string
+	for _, choice := range l {
+		switch {
+		case choice.Expression != "":
+			parts = append(parts, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			parts = append(parts, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			parts = append(parts, choice.License.Name)
+		}
+	}
+	if len(parts) > 1 {
+		for i, part := range parts {
+			if strings.ContainsAny(part, " ") {
+				parts[i] = "(" + part + ")"
+			}
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
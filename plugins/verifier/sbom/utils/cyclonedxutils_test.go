@@ -0,0 +1,124 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetCycloneDXPackageLicenses(t *testing.T) {
+	expected := []PackageLicense{
+		{Name: "busybox", Version: "1.36.1-r2", License: "GPL-2.0-only", PURL: "pkg:apk/alpine/busybox@1.36.1-r2?arch=x86_64&distro=alpine-3.18.4"},
+		{Name: "libcrypto3", Version: "3.1.3-r0", License: "Apache-2.0", PURL: "pkg:apk/alpine/libcrypto3@3.1.3-r0?arch=x86_64&distro=alpine-3.18.4"},
+		{Name: "libc-utils", Version: "0.7.2-r5", License: "BSD-2-Clause AND BSD-3-Clause", PURL: "pkg:apk/alpine/libc-utils@0.7.2-r5?arch=x86_64&distro=alpine-3.18.4"},
+		{Name: "github.com/sirupsen/logrus", Version: "v1.9.3", License: "MIT", PURL: "pkg:golang/github.com/sirupsen/logrus@v1.9.3"},
+		{Name: "zlib", Version: "1.2.13-r1", License: "Zlib", PURL: "pkg:apk/alpine/zlib@1.2.13-r1"},
+	}
+
+	tests := []struct {
+		name string
+		file string
+		read func([]byte) (*CycloneDXBOM, error)
+	}{
+		{
+			name: "json",
+			file: "bom.cdx.json",
+			read: ReadCycloneDXJSON,
+		},
+		{
+			name: "xml",
+			file: "bom.cdx.xml",
+			read: ReadCycloneDXXML,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("../testdata", tt.file))
+			if err != nil {
+				t.Fatalf("error reading %s", tt.file)
+			}
+			bom, err := tt.read(b)
+			if err != nil {
+				t.Fatalf("failed to read bom: %v", err)
+			}
+			if bom.Metadata == nil || bom.Metadata.Timestamp != "2024-05-01T10:00:00Z" {
+				t.Fatalf("unexpected metadata: %+v", bom.Metadata)
+			}
+
+			result := GetCycloneDXPackageLicenses(*bom)
+			if len(result) != len(expected) {
+				t.Fatalf("unexpected packages count, expected %d, got %d", len(expected), len(result))
+			}
+			for i := range expected {
+				if result[i] != expected[i] {
+					t.Fatalf("expected package %+v, got %+v", expected[i], result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadCycloneDX_InvalidDocument(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("../testdata", "syftbom.spdx.json"))
+	if err != nil {
+		t.Fatalf("error reading syftbom.spdx.json")
+	}
+	if _, err := ReadCycloneDXJSON(b); err == nil {
+		t.Fatalf("expected error reading an SPDX document as CycloneDX")
+	}
+	if _, err := ReadCycloneDXXML([]byte(`<bom xmlns="http://example.com/bom"></bom>`)); err == nil {
+		t.Fatalf("expected error reading an XML document without the CycloneDX namespace")
+	}
+}
+
+func TestCycloneDXLicensesExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		licenses CycloneDXLicenses
+		expected string
+	}{
+		{
+			name:     "no license",
+			expected: "",
+		},
+		{
+			name:     "license id",
+			licenses: CycloneDXLicenses{{License: &CycloneDXLicense{ID: "MIT"}}},
+			expected: "MIT",
+		},
+		{
+			name:     "multiple licenses",
+			licenses: CycloneDXLicenses{{License: &CycloneDXLicense{ID: "MIT"}}, {License: &CycloneDXLicense{Name: "Custom"}}},
+			expected: "MIT AND Custom",
+		},
+		{
+			name:     "expression combined with license",
+			licenses: CycloneDXLicenses{{Expression: "MIT OR Apache-2.0"}, {License: &CycloneDXLicense{ID: "BSD-3-Clause"}}},
+			expected: "(MIT OR Apache-2.0) AND BSD-3-Clause",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.licenses.Expression(); result != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
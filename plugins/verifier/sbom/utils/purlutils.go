@@ -0,0 +1,90 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const purlScheme = "pkg:"
+
+// PackageURL is the parsed form of a package URL (purl), e.g.
+// pkg:golang/github.com/sirupsen/logrus@v1.9.3. Qualifiers and subpath are
+// not used for matching and are dropped.
+type PackageURL struct {
+	Type      string
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// ParsePackageURL parses a package URL following
+// https://github.com/package-url/purl-spec
+func ParsePackageURL(purl string) (PackageURL, error) {
+	if !strings.HasPrefix(strings.ToLower(purl), purlScheme) {
+		return PackageURL{}, fmt.Errorf("purl %s does not start with %s", purl, purlScheme)
+	}
+	remainder := strings.TrimLeft(purl[len(purlScheme):], "/")
+	if i := strings.Index(remainder, "#"); i >= 0 {
+		remainder = remainder[:i]
+	}
+	if i := strings.Index(remainder, "?"); i >= 0 {
+		remainder = remainder[:i]
+	}
+
+	var version string
+	if i := strings.LastIndex(remainder, "@"); i >= 0 {
+		version = remainder[i+1:]
+		remainder = remainder[:i]
+	}
+
+	segments := strings.Split(strings.Trim(remainder, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" || segments[len(segments)-1] == "" {
+		return PackageURL{}, fmt.Errorf("purl %s must contain a type and a name", purl)
+	}
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid purl %s: %w", purl, err)
+		}
+		segments[i] = unescaped
+	}
+	unescapedVersion, err := url.PathUnescape(version)
+	if err != nil {
+		return PackageURL{}, fmt.Errorf("invalid purl %s: %w", purl, err)
+	}
+
+	return PackageURL{
+		Type:      strings.ToLower(segments[0]),
+		Namespace: strings.Join(segments[1:len(segments)-1], "/"),
+		Name:      segments[len(segments)-1],
+		Version:   unescapedVersion,
+	}, nil
+}
+
+// Matches returns true if the package URL refers to the same package as the
+// disallowed package URL. A disallowed package URL without version matches
+// all versions of the package.
+func (p PackageURL) Matches(disallowed PackageURL) bool {
+	if p.Type != disallowed.Type ||
+		!strings.EqualFold(p.Namespace, disallowed.Namespace) ||
+		!strings.EqualFold(p.Name, disallowed.Name) {
+		return false
+	}
+	return disallowed.Version == "" || p.Version == disallowed.Version
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import "testing"
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		purl      string
+		expected  PackageURL
+		expectErr bool
+	}{
+		{
+			name:     "with namespace, version and qualifiers",
+			purl:     "pkg:apk/alpine/busybox@1.36.1-r2?arch=x86_64&distro=alpine-3.18.4",
+			expected: PackageURL{Type: "apk", Namespace: "alpine", Name: "busybox", Version: "1.36.1-r2"},
+		},
+		{
+			name:     "multi segment namespace",
+			purl:     "pkg:golang/github.com/sirupsen/logrus@v1.9.3#subpath",
+			expected: PackageURL{Type: "golang", Namespace: "github.com/sirupsen", Name: "logrus", Version: "v1.9.3"},
+		},
+		{
+			name:     "encoded namespace without version",
+			purl:     "pkg:npm/%40angular/core",
+			expected: PackageURL{Type: "npm", Namespace: "@angular", Name: "core"},
+		},
+		{
+			name:     "no namespace",
+			purl:     "pkg:PyPI/django@1.11.1",
+			expected: PackageURL{Type: "pypi", Name: "django", Version: "1.11.1"},
+		},
+		{
+			name:      "missing scheme",
+			purl:      "apk/alpine/busybox",
+			expectErr: true,
+		},
+		{
+			name:      "missing name",
+			purl:      "pkg:apk",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParsePackageURL(tt.purl)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if result != tt.expected {
+				t.Fatalf("expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPackageURLMatches(t *testing.T) {
+	packageURL := PackageURL{Type: "apk", Namespace: "alpine", Name: "busybox", Version: "1.36.1-r2"}
+	tests := []struct {
+		name       string
+		disallowed PackageURL
+		expected   bool
+	}{
+		{
+			name:       "same version",
+			disallowed: PackageURL{Type: "apk", Namespace: "alpine", Name: "busybox", Version: "1.36.1-r2"},
+			expected:   true,
+		},
+		{
+			name:       "any version",
+			disallowed: PackageURL{Type: "apk", Namespace: "alpine", Name: "busybox"},
+			expected:   true,
+		},
+		{
+			name:       "different version",
+			disallowed: PackageURL{Type: "apk", Namespace: "alpine", Name: "busybox", Version: "1.36.1-r3"},
+			expected:   false,
+		},
+		{
+			name:       "different type",
+			disallowed: PackageURL{Type: "deb", Namespace: "alpine", Name: "busybox"},
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := packageURL.Matches(tt.disallowed); result != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -29,11 +29,22 @@ func GetPackageLicenses(doc spdx.Document) []PackageLicense {
 			Name:    p.PackageName,
 			Version: p.PackageVersion,
 			License: p.PackageLicenseConcluded,
+			PURL:    getPackageURL(p),
 		})
 	}
 	return output
 }
 
+// getPackageURL returns the purl of the package from its external references
+func getPackageURL(p *spdx.Package) string {
+	for _, ref := range p.PackageExternalReferences {
+		if ref != nil && ref.RefType == spdx.PackageManagerPURL {
+			return ref.Locator
+		}
+	}
+	return ""
+}
+
 // returns true if the licenseExpression contains the disallowed license
 // this implements a whole word match
 func ContainsLicense(spdxLicenseExpression string, disallowed string) bool {
@@ -27,10 +27,13 @@ package utils
 // Name: alpine-baselayout
 // Version: 3.4.0-r0
 // License: GPL-2.0-only (maps to licenseConcluded)
+// For CycloneDX documents, License is the license expression built from the
+// licenses of the component and PURL is the package URL of the component.
 type PackageLicense struct {
 	Name    string
 	Version string
 	License string
+	PURL    string `json:",omitempty"`
 }
 
 // Internal types that stores extracted Name and Version of package
@@ -44,8 +47,12 @@ type PackageLicense struct {
 // This will translate to a PackageInfo obj with the following fields:
 // Name: alpine-baselayout
 // Version: 3.4.0-r0
+// PURL can be set instead of Name to match packages by package URL, e.g.
+// pkg:apk/alpine/libcrypto3@3.0.7-r2. Packages of all versions are matched if
+// the package URL has no version.
 
 type PackageInfo struct {
 	Name    string `json:"name,omitempty"`
 	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
 }
@@ -0,0 +1,270 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const csafScannerName = "csaf"
+
+type csafDocument struct {
+	Document        csafDocumentMeta    `json:"document"`
+	ProductTree     csafProductTree     `json:"product_tree"`
+	Vulnerabilities []csafVulnerability `json:"vulnerabilities"`
+}
+
+type csafDocumentMeta struct {
+	Category    string       `json:"category"`
+	CSAFVersion string       `json:"csaf_version"`
+	Tracking    csafTracking `json:"tracking"`
+}
+
+type csafTracking struct {
+	ID string `json:"id"`
+}
+
+type csafProductTree struct {
+	Branches         []csafBranch       `json:"branches"`
+	FullProductNames []csafProduct      `json:"full_product_names"`
+	Relationships    []csafRelationship `json:"relationships"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch `json:"branches"`
+	Product  *csafProduct `json:"product"`
+}
+
+type csafProduct struct {
+	Name                        string                   `json:"name"`
+	ProductID                   string                   `json:"product_id"`
+	ProductIdentificationHelper csafIdentificationHelper `json:"product_identification_helper"`
+}
+
+type csafIdentificationHelper struct {
+	PURL string `json:"purl"`
+}
+
+type csafRelationship struct {
+	FullProductName           csafProduct `json:"full_product_name"`
+	ProductReference          string      `json:"product_reference"`
+	RelatesToProductReference string      `json:"relates_to_product_reference"`
+}
+
+type csafVulnerability struct {
+	CVE           string              `json:"cve"`
+	IDs           []csafID            `json:"ids"`
+	ProductStatus map[string][]string `json:"product_status"`
+	Scores        []csafScore         `json:"scores"`
+	Flags         []csafFlag          `json:"flags"`
+	Remediations  []csafRemediation   `json:"remediations"`
+}
+
+type csafID struct {
+	Text string `json:"text"`
+}
+
+type csafScore struct {
+	Products []string  `json:"products"`
+	CVSSV3   *csafCVSS `json:"cvss_v3"`
+	CVSSV2   *csafCVSS `json:"cvss_v2"`
+}
+
+type csafCVSS struct {
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity"`
+}
+
+type csafFlag struct {
+	Label      string   `json:"label"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+type csafRemediation struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// csafProductInfo is the resolved product of a product id. Component is set
+// for products that are a component of another product.
+type csafProductInfo struct {
+	name      string
+	product   string
+	component string
+}
+
+// csafProductStatuses maps the product status groups of CSAF to the status of
+// findings.
+var csafProductStatuses = []struct {
+	group  string
+	status string
+}{
+	{group: "known_affected", status: StatusAffected},
+	{group: "first_affected", status: StatusAffected},
+	{group: "last_affected", status: StatusAffected},
+	{group: "known_not_affected", status: StatusNotAffected},
+	{group: "fixed", status: StatusFixed},
+	{group: "first_fixed", status: StatusFixed},
+	{group: "under_investigation", status: StatusUnderInvestigation},
+}
+
+// ParseCSAF parses a CSAF 2.0 document. A finding is returned for each product
+// listed in the product status of each vulnerability.
+func ParseCSAF(content []byte) (*Report, error) {
+	doc := csafDocument{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Document.CSAFVersion == "" {
+		return nil, errors.New("JSON document does not contain document.csaf_version field of a CSAF document")
+	}
+	products := doc.ProductTree.products()
+
+	findings := []Finding{}
+	for i, vuln := range doc.Vulnerabilities {
+		id := vuln.CVE
+		var aliases []string
+		for _, other := range vuln.IDs {
+			if id == "" {
+				id = other.Text
+				continue
+			}
+			aliases = append(aliases, other.Text)
+		}
+		if id == "" {
+			return nil, fmt.Errorf("vulnerability %d of CSAF document does not contain a cve or an id", i)
+		}
+		statementID := fmt.Sprintf("%s#vulnerabilities[%d]", doc.Document.Tracking.ID, i)
+
+		for _, productStatus := range csafProductStatuses {
+			for _, productID := range vuln.ProductStatus[productStatus.group] {
+				product, ok := products[productID]
+				if !ok {
+					product = csafProductInfo{name: productID, product: productID}
+				}
+				cvss, severity := vuln.score(productID)
+				findings = append(findings, Finding{
+					ID:            id,
+					Aliases:       aliases,
+					Severity:      normalizeSeverity(severity, cvss),
+					CVSS:          cvss,
+					FixedVersion:  vuln.vendorFix(productID),
+					PackageName:   product.name,
+					PackageURL:    product.component,
+					Product:       product.product,
+					Status:        productStatus.status,
+					Justification: vuln.flag(productID),
+					Statement:     statementID,
+				})
+			}
+		}
+	}
+	return &Report{Scanner: csafScannerName, Findings: findings}, nil
+}
+
+// products returns the products of the product tree by product id.
+func (tree csafProductTree) products() map[string]csafProductInfo {
+	products := map[string]csafProductInfo{}
+	addProduct := func(p csafProduct) {
+		id := p.ProductIdentificationHelper.PURL
+		if id == "" {
+			id = p.ProductID
+		}
+		products[p.ProductID] = csafProductInfo{name: p.Name, product: id}
+	}
+	var walk func(branches []csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, branch := range branches {
+			if branch.Product != nil {
+				addProduct(*branch.Product)
+			}
+			walk(branch.Branches)
+		}
+	}
+	walk(tree.Branches)
+	for _, p := range tree.FullProductNames {
+		addProduct(p)
+	}
+	for _, relationship := range tree.Relationships {
+		component := products[relationship.ProductReference]
+		parent := products[relationship.RelatesToProductReference]
+		products[relationship.FullProductName.ProductID] = csafProductInfo{
+			name:      component.name,
+			product:   parent.product,
+			component: component.product,
+		}
+	}
+	for id, product := range products {
+		// a standalone product identified by a purl is a package
+		if product.component == "" && strings.HasPrefix(product.product, purlPrefix) {
+			product.component = product.product
+			products[id] = product
+		}
+	}
+	return products
+}
+
+// score returns the highest CVSS score of the product.
+func (vuln csafVulnerability) score(productID string) (float64, string) {
+	var cvss float64
+	var severity string
+	for _, score := range vuln.Scores {
+		if !contains(score.Products, productID) {
+			continue
+		}
+		for _, s := range []*csafCVSS{score.CVSSV3, score.CVSSV2} {
+			if s != nil && s.BaseScore > cvss {
+				cvss = s.BaseScore
+				severity = s.BaseSeverity
+			}
+		}
+	}
+	return cvss, severity
+}
+
+// vendorFix returns the details of the vendor fix of the product.
+func (vuln csafVulnerability) vendorFix(productID string) string {
+	for _, remediation := range vuln.Remediations {
+		if remediation.Category == "vendor_fix" && contains(remediation.ProductIDs, productID) {
+			return remediation.Details
+		}
+	}
+	return ""
+}
+
+// flag returns the label of the flag set for the product, which is the
+// justification of a not affected status.
+func (vuln csafVulnerability) flag(productID string) string {
+	for _, flag := range vuln.Flags {
+		if contains(flag.ProductIDs, productID) {
+			return flag.Label
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	imagePURL     = "pkg:oci/alpine@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1"
+	libcryptoPURL = "pkg:apk/alpine/libcrypto3@3.1.0-r4"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		parse    func([]byte) (*Report, error)
+		expected Report
+	}{
+		{
+			name:  "trivy",
+			file:  "trivy.json",
+			parse: ParseTrivy,
+			expected: Report{
+				Scanner: "trivy",
+				Findings: []Finding{
+					{
+						ID:             "CVE-2023-2650",
+						Severity:       "medium",
+						CVSS:           6.5,
+						FixedVersion:   "3.1.1-r0",
+						PackageName:    "libcrypto3",
+						PackageVersion: "3.1.0-r4",
+						PackageURL:     "pkg:apk/alpine/libcrypto3@3.1.0-r4?arch=x86_64&distro=3.18.0",
+						Status:         StatusAffected,
+					},
+					{
+						ID:             "CVE-2023-5363",
+						Severity:       "high",
+						CVSS:           7.5,
+						FixedVersion:   "3.1.4-r0",
+						PackageName:    "libssl3",
+						PackageVersion: "3.1.0-r4",
+						PackageURL:     "pkg:apk/alpine/libssl3@3.1.0-r4?arch=x86_64&distro=3.18.0",
+						Status:         StatusAffected,
+					},
+				},
+			},
+		},
+		{
+			name:  "grype",
+			file:  "grype.json",
+			parse: ParseGrype,
+			expected: Report{
+				Scanner: "grype",
+				Findings: []Finding{
+					{
+						ID:             "GHSA-m425-mq94-257g",
+						Aliases:        []string{"CVE-2023-44487"},
+						Severity:       "high",
+						CVSS:           7.5,
+						FixedVersion:   "1.56.3",
+						PackageName:    "google.golang.org/grpc",
+						PackageVersion: "v1.56.2",
+						PackageURL:     "pkg:golang/google.golang.org/grpc@v1.56.2",
+						Status:         StatusAffected,
+					},
+					{
+						ID:             "CVE-2023-5678",
+						Severity:       "medium",
+						CVSS:           5.3,
+						PackageName:    "libcrypto3",
+						PackageVersion: "3.1.0-r4",
+						PackageURL:     "pkg:apk/alpine/libcrypto3@3.1.0-r4?arch=x86_64&distro=alpine-3.18.0",
+						Status:         StatusAffected,
+					},
+				},
+			},
+		},
+		{
+			name:  "openvex",
+			file:  "openvex.json",
+			parse: ParseOpenVEX,
+			expected: Report{
+				Scanner: "openvex",
+				Findings: []Finding{
+					{
+						ID:            "CVE-2023-2650",
+						Aliases:       []string{"GHSA-gqxg-9vcw-4w8f"},
+						Severity:      SeverityUnknown,
+						PackageName:   libcryptoPURL,
+						PackageURL:    libcryptoPURL,
+						Product:       imagePURL,
+						Status:        StatusNotAffected,
+						Justification: "vulnerable_code_not_in_execute_path",
+						Statement:     "https://openvex.dev/docs/public/vex-2e67563e#statements[0]",
+					},
+					{
+						ID:        "CVE-2023-5363",
+						Severity:  SeverityUnknown,
+						Product:   imagePURL,
+						Status:    StatusAffected,
+						Statement: "https://openvex.dev/docs/public/vex-2e67563e#statement-2",
+					},
+				},
+			},
+		},
+		{
+			name:  "openvex v0.0.1",
+			file:  "openvex_v0.0.1.json",
+			parse: ParseOpenVEX,
+			expected: Report{
+				Scanner: "openvex",
+				Findings: []Finding{
+					{
+						ID:          "CVE-2023-2650",
+						Severity:    SeverityUnknown,
+						PackageName: libcryptoPURL,
+						PackageURL:  libcryptoPURL,
+						Product:     imagePURL,
+						Status:      StatusFixed,
+						Statement:   "https://openvex.dev/docs/public/vex-0001#statements[0]",
+					},
+				},
+			},
+		},
+		{
+			name:  "csaf",
+			file:  "csaf.json",
+			parse: ParseCSAF,
+			expected: Report{
+				Scanner: "csaf",
+				Findings: []Finding{
+					{
+						ID:            "CVE-2023-2650",
+						Severity:      SeverityUnknown,
+						PackageName:   "libcrypto3 3.1.0-r4",
+						PackageURL:    libcryptoPURL,
+						Product:       "pkg:oci/example@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1",
+						Status:        StatusNotAffected,
+						Justification: "vulnerable_code_not_in_execute_path",
+						Statement:     "2024-EVD-UC-01-NA-001#vulnerabilities[0]",
+					},
+					{
+						ID:           "CVE-2023-5363",
+						Severity:     "high",
+						CVSS:         7.5,
+						FixedVersion: "Upgrade to 3.1.4-r0",
+						PackageName:  "libcrypto3 3.1.0-r4",
+						PackageURL:   libcryptoPURL,
+						Product:      libcryptoPURL,
+						Status:       StatusAffected,
+						Statement:    "2024-EVD-UC-01-NA-001#vulnerabilities[1]",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("error reading %s", tt.file)
+			}
+			report, err := tt.parse(b)
+			if err != nil {
+				t.Fatalf("failed to parse report: %v", err)
+			}
+			if !reflect.DeepEqual(*report, tt.expected) {
+				t.Fatalf("expected report %+v, got %+v", tt.expected, *report)
+			}
+		})
+	}
+}
+
+func TestParse_WrongFormat(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "csaf.json"))
+	if err != nil {
+		t.Fatalf("error reading csaf.json")
+	}
+	parsers := map[string]func([]byte) (*Report, error){
+		"trivy":   ParseTrivy,
+		"grype":   ParseGrype,
+		"openvex": ParseOpenVEX,
+	}
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parse(b); err == nil {
+				t.Fatalf("expected error parsing a CSAF document as %s", name)
+			}
+		})
+	}
+	if _, err := ParseCSAF([]byte(`{"matches":[]}`)); err == nil {
+		t.Fatalf("expected error parsing a grype report as CSAF")
+	}
+	if _, err := ParseTrivy([]byte(`invalid`)); err == nil {
+		t.Fatalf("expected error parsing invalid json")
+	}
+}
+
+func TestFindingHasID(t *testing.T) {
+	finding := Finding{ID: "GHSA-m425-mq94-257g", Aliases: []string{"CVE-2023-44487"}}
+	if !finding.HasID("cve-2023-44487") {
+		t.Fatalf("expected alias to match")
+	}
+	if !finding.HasID("GHSA-M425-MQ94-257G") {
+		t.Fatalf("expected id to match")
+	}
+	if finding.HasID("CVE-2023-0001") {
+		t.Fatalf("expected unrelated id not to match")
+	}
+}
+
+func TestNormalizeSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+		cvss     float64
+		expected string
+	}{
+		{severity: "CRITICAL", expected: "critical"},
+		{severity: "Unknown", cvss: 9.8, expected: "critical"},
+		{cvss: 7.0, expected: "high"},
+		{cvss: 4.0, expected: "medium"},
+		{cvss: 0.1, expected: "low"},
+		{expected: SeverityUnknown},
+	}
+	for _, tt := range tests {
+		if result := normalizeSeverity(tt.severity, tt.cvss); result != tt.expected {
+			t.Fatalf("normalizeSeverity(%q, %v) = %s, want %s", tt.severity, tt.cvss, result, tt.expected)
+		}
+	}
+}
+
+func TestSamePackage(t *testing.T) {
+	tests := []struct {
+		purl1    string
+		purl2    string
+		expected bool
+	}{
+		{purl1: libcryptoPURL, purl2: "pkg:apk/alpine/libcrypto3@3.1.0-r4?arch=x86_64&distro=3.18.0", expected: true},
+		{purl1: "pkg:apk/alpine/libcrypto3", purl2: libcryptoPURL, expected: true},
+		{purl1: "pkg:apk/alpine/libcrypto3@3.1.1-r0", purl2: libcryptoPURL, expected: false},
+		{purl1: "pkg:apk/alpine/libssl3@3.1.0-r4", purl2: libcryptoPURL, expected: false},
+		{purl1: "libcrypto3", purl2: libcryptoPURL, expected: false},
+	}
+	for _, tt := range tests {
+		if result := SamePackage(tt.purl1, tt.purl2); result != tt.expected {
+			t.Fatalf("SamePackage(%s, %s) = %v, want %v", tt.purl1, tt.purl2, result, tt.expected)
+		}
+	}
+}
+
+func TestPackageURLNameAndVersion(t *testing.T) {
+	if name := PackageURLName("pkg:golang/github.com/sirupsen/logrus@v1.9.3"); name != "logrus" {
+		t.Fatalf("unexpected name %s", name)
+	}
+	if version := PackageURLVersion("pkg:oci/alpine@sha256%3A82d1e9d7?repository_url=docker.io"); version != "sha256:82d1e9d7" {
+		t.Fatalf("unexpected version %s", version)
+	}
+	if name := PackageURLName("invalid"); name != "" {
+		t.Fatalf("unexpected name %s", name)
+	}
+}
+
+func TestPackageURLQualifier(t *testing.T) {
+	purl := "pkg:oci/alpine@sha256%3A82d1e9d7?arch=amd64&repository_url=registry.io%2Flibrary%2Falpine#sub"
+	if repository := PackageURLQualifier(purl, "repository_url"); repository != "registry.io/library/alpine" {
+		t.Fatalf("unexpected repository_url %s", repository)
+	}
+	if tag := PackageURLQualifier(purl, "tag"); tag != "" {
+		t.Fatalf("unexpected tag %s", tag)
+	}
+	if repository := PackageURLQualifier("invalid?repository_url=registry.io", "repository_url"); repository != "" {
+		t.Fatalf("unexpected repository_url %s", repository)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const grypeScannerName = "grype"
+
+type grypeReport struct {
+	Matches    *[]grypeMatch   `json:"matches"`
+	Descriptor grypeDescriptor `json:"descriptor"`
+}
+
+type grypeDescriptor struct {
+	Name string `json:"name"`
+}
+
+type grypeMatch struct {
+	Vulnerability          grypeVulnerability   `json:"vulnerability"`
+	RelatedVulnerabilities []grypeVulnerability `json:"relatedVulnerabilities"`
+	Artifact               grypeArtifact        `json:"artifact"`
+}
+
+type grypeVulnerability struct {
+	ID       string      `json:"id"`
+	Severity string      `json:"severity"`
+	CVSS     []grypeCVSS `json:"cvss"`
+	Fix      grypeFix    `json:"fix"`
+}
+
+type grypeCVSS struct {
+	Version string           `json:"version"`
+	Metrics grypeCVSSMetrics `json:"metrics"`
+}
+
+type grypeCVSSMetrics struct {
+	BaseScore float64 `json:"baseScore"`
+}
+
+type grypeFix struct {
+	Versions []string `json:"versions"`
+	State    string   `json:"state"`
+}
+
+type grypeArtifact struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl"`
+}
+
+// ParseGrype parses a report generated by grype with the json format.
+func ParseGrype(content []byte) (*Report, error) {
+	report := grypeReport{}
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, err
+	}
+	if report.Matches == nil {
+		return nil, errors.New("JSON document does not contain matches field of a grype report")
+	}
+
+	findings := []Finding{}
+	for _, match := range *report.Matches {
+		vuln := match.Vulnerability
+		cvss := grypeCVSSScore(vuln.CVSS)
+		severity := vuln.Severity
+		var aliases []string
+		for _, related := range match.RelatedVulnerabilities {
+			if related.ID != "" && !strings.EqualFold(related.ID, vuln.ID) {
+				aliases = append(aliases, related.ID)
+			}
+			// the score is often only provided by the related nvd record
+			if cvss == 0 {
+				cvss = grypeCVSSScore(related.CVSS)
+			}
+			if severity == "" || strings.EqualFold(severity, SeverityUnknown) {
+				severity = related.Severity
+			}
+		}
+		findings = append(findings, Finding{
+			ID:             vuln.ID,
+			Aliases:        aliases,
+			Severity:       normalizeSeverity(severity, cvss),
+			CVSS:           cvss,
+			FixedVersion:   strings.Join(vuln.Fix.Versions, ","),
+			PackageName:    match.Artifact.Name,
+			PackageVersion: match.Artifact.Version,
+			PackageURL:     match.Artifact.PURL,
+			Status:         StatusAffected,
+		})
+	}
+
+	scanner := strings.ToLower(report.Descriptor.Name)
+	if scanner == "" {
+		scanner = grypeScannerName
+	}
+	return &Report{Scanner: scanner, Findings: findings}, nil
+}
+
+// grypeCVSSScore returns the highest CVSS v3 base score, falling back to the
+// highest score of other CVSS versions.
+func grypeCVSSScore(scores []grypeCVSS) float64 {
+	var v3Score, otherScore float64
+	for _, score := range scores {
+		if strings.HasPrefix(score.Version, "3") {
+			if score.Metrics.BaseScore > v3Score {
+				v3Score = score.Metrics.BaseScore
+			}
+		} else if score.Metrics.BaseScore > otherScore {
+			otherScore = score.Metrics.BaseScore
+		}
+	}
+	if v3Score > 0 {
+		return v3Score
+	}
+	return otherScore
+}
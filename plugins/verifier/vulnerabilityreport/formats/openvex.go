@@ -0,0 +1,155 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	openVEXScannerName   = "openvex"
+	openVEXContextPrefix = "https://openvex.dev/ns"
+	purlPrefix           = "pkg:"
+)
+
+// openVEXDocument supports both the v0.0.1 and the v0.2.0 OpenVEX documents.
+// v0.0.1 uses plain strings for vulnerabilities, products and subcomponents
+// where v0.2.0 uses objects.
+type openVEXDocument struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	ID            string               `json:"@id"`
+	Vulnerability openVEXVulnerability `json:"vulnerability"`
+	Products      []openVEXComponent   `json:"products"`
+	Subcomponents []openVEXComponent   `json:"subcomponents"`
+	Status        string               `json:"status"`
+	Justification string               `json:"justification"`
+}
+
+type openVEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type openVEXComponent struct {
+	ID            string             `json:"@id"`
+	Identifiers   map[string]string  `json:"identifiers"`
+	Subcomponents []openVEXComponent `json:"subcomponents"`
+}
+
+// UnmarshalJSON accepts both the v0.0.1 string and the v0.2.0 object form.
+func (v *openVEXVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		v.Name = name
+		return nil
+	}
+	type vulnerability openVEXVulnerability
+	return json.Unmarshal(data, (*vulnerability)(v))
+}
+
+// UnmarshalJSON accepts both the v0.0.1 string and the v0.2.0 object form.
+func (c *openVEXComponent) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		c.ID = id
+		return nil
+	}
+	type component openVEXComponent
+	return json.Unmarshal(data, (*component)(c))
+}
+
+// purl returns the package URL of the component if any.
+func (c openVEXComponent) purl() string {
+	if purl, ok := c.Identifiers["purl"]; ok {
+		return purl
+	}
+	if strings.HasPrefix(c.ID, purlPrefix) {
+		return c.ID
+	}
+	return ""
+}
+
+// ParseOpenVEX parses an OpenVEX document. A finding is returned for each
+// product, or for each subcomponent of a product, of each statement.
+func ParseOpenVEX(content []byte) (*Report, error) {
+	doc := openVEXDocument{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.Context, openVEXContextPrefix) {
+		return nil, errors.New("JSON document does not contain the @context field of an OpenVEX document")
+	}
+
+	findings := []Finding{}
+	for i, statement := range doc.Statements {
+		if statement.Vulnerability.Name == "" {
+			return nil, fmt.Errorf("statement %d of OpenVEX document does not contain a vulnerability", i)
+		}
+		statementID := statement.ID
+		if statementID == "" {
+			statementID = fmt.Sprintf("%s#statements[%d]", doc.ID, i)
+		}
+		newFinding := func(product string, subcomponent openVEXComponent) Finding {
+			return Finding{
+				ID:            statement.Vulnerability.Name,
+				Aliases:       statement.Vulnerability.Aliases,
+				Severity:      SeverityUnknown,
+				PackageName:   subcomponent.ID,
+				PackageURL:    subcomponent.purl(),
+				Product:       product,
+				Status:        normalizeStatus(statement.Status),
+				Justification: statement.Justification,
+				Statement:     statementID,
+			}
+		}
+
+		products := statement.Products
+		if len(products) == 0 {
+			// the statement applies to any product
+			products = []openVEXComponent{{}}
+		}
+		for _, product := range products {
+			productID := product.purl()
+			if productID == "" {
+				productID = product.ID
+			}
+			// v0.0.1 documents declare the subcomponents on the statement
+			subcomponents := make([]openVEXComponent, 0, len(product.Subcomponents)+len(statement.Subcomponents))
+			subcomponents = append(subcomponents, product.Subcomponents...)
+			subcomponents = append(subcomponents, statement.Subcomponents...)
+			if len(subcomponents) == 0 {
+				findings = append(findings, newFinding(productID, openVEXComponent{}))
+				continue
+			}
+			for _, subcomponent := range subcomponents {
+				findings = append(findings, newFinding(productID, subcomponent))
+			}
+		}
+	}
+	return &Report{Scanner: openVEXScannerName, Findings: findings}, nil
+}
+
+func normalizeStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
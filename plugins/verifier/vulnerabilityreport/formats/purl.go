@@ -0,0 +1,99 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SamePackage returns true if both package URLs refer to the same package.
+// Qualifiers and subpath are ignored and versions are only compared if both
+// package URLs have a version.
+func SamePackage(purl1, purl2 string) bool {
+	name1, version1, ok1 := splitPackageURL(purl1)
+	name2, version2, ok2 := splitPackageURL(purl2)
+	if !ok1 || !ok2 || !strings.EqualFold(name1, name2) {
+		return false
+	}
+	return version1 == "" || version2 == "" || version1 == version2
+}
+
+// PackageURLName returns the name of the package of the package URL.
+func PackageURLName(purl string) string {
+	name, _, ok := splitPackageURL(purl)
+	if !ok {
+		return ""
+	}
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// PackageURLVersion returns the unescaped version of the package URL.
+func PackageURLVersion(purl string) string {
+	_, version, _ := splitPackageURL(purl)
+	return version
+}
+
+// PackageURLQualifier returns the unescaped value of the qualifier of the
+// package URL, empty if the package URL does not have the qualifier.
+func PackageURLQualifier(purl string, key string) string {
+	if !strings.HasPrefix(strings.ToLower(purl), purlPrefix) {
+		return ""
+	}
+	i := strings.Index(purl, "?")
+	if i < 0 {
+		return ""
+	}
+	qualifiers := purl[i+1:]
+	if j := strings.Index(qualifiers, "#"); j >= 0 {
+		qualifiers = qualifiers[:j]
+	}
+	for _, qualifier := range strings.Split(qualifiers, "&") {
+		name, value, ok := strings.Cut(qualifier, "=")
+		if !ok || !strings.EqualFold(name, key) {
+			continue
+		}
+		if value, err := url.QueryUnescape(value); err == nil {
+			return value
+		}
+	}
+	return ""
+}
+
+// splitPackageURL returns the type, namespace and name of the package URL
+// without the scheme, and the version of the package.
+func splitPackageURL(purl string) (string, string, bool) {
+	if !strings.HasPrefix(strings.ToLower(purl), purlPrefix) {
+		return "", "", false
+	}
+	remainder := strings.TrimLeft(purl[len(purlPrefix):], "/")
+	if i := strings.IndexAny(remainder, "?#"); i >= 0 {
+		remainder = remainder[:i]
+	}
+	var version string
+	if i := strings.LastIndex(remainder, "@"); i >= 0 {
+		version = remainder[i+1:]
+		remainder = remainder[:i]
+	}
+	name, err := url.PathUnescape(strings.Trim(remainder, "/"))
+	if err != nil {
+		return "", "", false
+	}
+	if version, err = url.PathUnescape(version); err != nil {
+		return "", "", false
+	}
+	return name, version, name != ""
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package formats
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const trivyScannerName = "trivy"
+
+type trivyReport struct {
+	SchemaVersion int           `json:"SchemaVersion"`
+	Results       []trivyResult `json:"Results"`
+}
+
+type trivyResult struct {
+	Target          string               `json:"Target"`
+	Vulnerabilities []trivyVulnerability `json:"Vulnerabilities"`
+}
+
+type trivyVulnerability struct {
+	VulnerabilityID  string               `json:"VulnerabilityID"`
+	PkgName          string               `json:"PkgName"`
+	InstalledVersion string               `json:"InstalledVersion"`
+	FixedVersion     string               `json:"FixedVersion"`
+	Severity         string               `json:"Severity"`
+	SeveritySource   string               `json:"SeveritySource"`
+	PkgIdentifier    trivyPkgIdentifier   `json:"PkgIdentifier"`
+	CVSS             map[string]trivyCVSS `json:"CVSS"`
+}
+
+type trivyPkgIdentifier struct {
+	PURL string `json:"PURL"`
+}
+
+type trivyCVSS struct {
+	V2Score float64 `json:"V2Score"`
+	V3Score float64 `json:"V3Score"`
+}
+
+// ParseTrivy parses a report generated by trivy with the json format.
+func ParseTrivy(content []byte) (*Report, error) {
+	report := trivyReport{}
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, err
+	}
+	if report.SchemaVersion == 0 {
+		return nil, errors.New("JSON document does not contain SchemaVersion field of a trivy report")
+	}
+
+	findings := []Finding{}
+	for _, result := range report.Results {
+		for _, vuln := range result.Vulnerabilities {
+			cvss := trivyCVSSScore(vuln)
+			findings = append(findings, Finding{
+				ID:             vuln.VulnerabilityID,
+				Severity:       normalizeSeverity(vuln.Severity, cvss),
+				CVSS:           cvss,
+				FixedVersion:   vuln.FixedVersion,
+				PackageName:    vuln.PkgName,
+				PackageVersion: vuln.InstalledVersion,
+				PackageURL:     vuln.PkgIdentifier.PURL,
+				Status:         StatusAffected,
+			})
+		}
+	}
+	return &Report{Scanner: trivyScannerName, Findings: findings}, nil
+}
+
+// trivyCVSSScore returns the CVSS score of the vendor used as severity source,
+// falling back to nvd and then to the highest score of any vendor.
+func trivyCVSSScore(vuln trivyVulnerability) float64 {
+	for _, source := range []string{vuln.SeveritySource, "nvd"} {
+		if cvss, ok := vuln.CVSS[source]; ok {
+			if score := cvssScore(cvss); score > 0 {
+				return score
+			}
+		}
+	}
+	var score float64
+	for _, cvss := range vuln.CVSS {
+		if s := cvssScore(cvss); s > score {
+			score = s
+		}
+	}
+	return score
+}
+
+func cvssScore(cvss trivyCVSS) float64 {
+	if cvss.V3Score > 0 {
+		return cvss.V3Score
+	}
+	return cvss.V2Score
+}
@@ -0,0 +1,110 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package formats parses vulnerability reports and VEX documents of different
+// formats into a common list of findings.
+package formats
+
+import "strings"
+
+const (
+	// StatusAffected is the status of a vulnerability found by a scanner or
+	// declared as affecting a product by a VEX document.
+	StatusAffected = "affected"
+	// StatusNotAffected is the status of a vulnerability declared as not
+	// affecting a product by a VEX document.
+	StatusNotAffected = "not_affected"
+	// StatusFixed is the status of a vulnerability declared as fixed in a
+	// product by a VEX document.
+	StatusFixed = "fixed"
+	// StatusUnderInvestigation is the status of a vulnerability that is not
+	// yet known to affect a product.
+	StatusUnderInvestigation = "under_investigation"
+
+	// SeverityUnknown is used when a finding has neither a severity nor a CVSS
+	// score.
+	SeverityUnknown = "unknown"
+)
+
+// Report is the normalized form of a vulnerability report or VEX document.
+type Report struct {
+	// Scanner is the name of the tool or format that produced the report.
+	Scanner  string    `json:"scanner"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is a vulnerability reported for a package.
+type Finding struct {
+	// ID is the vulnerability identifier, e.g. CVE-2023-1234 or GHSA-xxxx.
+	ID string `json:"id"`
+	// Aliases are other identifiers of the same vulnerability.
+	Aliases []string `json:"aliases,omitempty"`
+	// Severity is the lower case severity, e.g. critical, high, medium, low.
+	Severity string `json:"severity"`
+	// CVSS is the CVSS base score, 0 if not available.
+	CVSS           float64 `json:"cvss,omitempty"`
+	FixedVersion   string  `json:"fixedVersion,omitempty"`
+	PackageName    string  `json:"packageName,omitempty"`
+	PackageVersion string  `json:"packageVersion,omitempty"`
+	PackageURL     string  `json:"purl,omitempty"`
+	// Product is the product a VEX statement applies to, e.g. the purl of the
+	// image. PackageURL is the subcomponent of the product if any.
+	Product string `json:"product,omitempty"`
+	// Status is one of StatusAffected, StatusNotAffected, StatusFixed and
+	// StatusUnderInvestigation.
+	Status string `json:"status"`
+	// Justification is the reason given by a VEX document for the status.
+	Justification string `json:"justification,omitempty"`
+	// Statement identifies the VEX statement the finding was read from.
+	Statement string `json:"statement,omitempty"`
+}
+
+// HasID returns true if the id or any of the aliases of the finding matches
+// the given vulnerability id, ignoring case.
+func (f Finding) HasID(id string) bool {
+	if strings.EqualFold(f.ID, id) {
+		return true
+	}
+	for _, alias := range f.Aliases {
+		if strings.EqualFold(alias, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// IDs returns the id and the aliases of the finding.
+func (f Finding) IDs() []string {
+	return append([]string{f.ID}, f.Aliases...)
+}
+
+// normalizeSeverity returns the lower case severity. If no severity is given,
+// the severity is derived from the CVSS v3 base score.
+func normalizeSeverity(severity string, cvss float64) string {
+	if severity = strings.ToLower(strings.TrimSpace(severity)); severity != "" && severity != SeverityUnknown {
+		return severity
+	}
+	switch {
+	case cvss >= 9.0:
+		return "critical"
+	case cvss >= 7.0:
+		return "high"
+	case cvss >= 4.0:
+		return "medium"
+	case cvss > 0:
+		return "low"
+	}
+	return SeverityUnknown
+}
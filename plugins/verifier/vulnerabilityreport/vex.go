@@ -0,0 +1,357 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/formats"
+)
+
+const (
+	NotationSignatureArtifactType string = "application/vnd.cncf.notary.signature"
+	CosignSignatureArtifactType   string = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	VEXSuppressions               string = "vexSuppressions"
+	VEXDocuments                  string = "vexDocuments"
+
+	ociPackageURLPrefix    = "pkg:oci/"
+	repositoryURLQualifier = "repository_url"
+)
+
+// VEXConfig configures the suppression of findings declared as not affecting
+// the subject by VEX documents attached to the subject
+type VEXConfig struct {
+	// ArtifactTypes of the VEX documents to discover. Defaults to OpenVEX.
+	ArtifactTypes []string `json:"artifactTypes,omitempty"`
+	// SignatureArtifactTypes of the signatures a VEX document must have to be
+	// used. Defaults to notation and cosign signatures. The VEX documents used
+	// to suppress findings are listed as nested subjects of the result, so the
+	// executor verifies their referrers with the configured verifiers and
+	// fails the result unless a notation or cosign signature of each of them
+	// verifies. Reports of other referrers of a VEX document are not counted.
+	SignatureArtifactTypes []string `json:"signatureArtifactTypes,omitempty"`
+	// AllowUnsigned allows VEX documents without signature to suppress findings
+	AllowUnsigned bool `json:"allowUnsigned,omitempty"`
+}
+
+// vexResult holds the VEX statements and documents loaded for the subject
+type vexResult struct {
+	statements []vexStatement
+	documents  []vexDocument
+	// referrers are the descriptors of the used VEX documents by digest
+	referrers map[digest.Digest]ocispecs.ReferenceDescriptor
+	// nestedVerification is true if the VEX documents used must pass nested
+	// verification
+	nestedVerification bool
+}
+
+// vexStatement is a statement of a VEX document suppressing a finding
+type vexStatement struct {
+	formats.Finding
+	// Document is the digest of the VEX document
+	Document digest.Digest
+	// Pinned is true if the product of the statement is pinned to the digest
+	// of the subject
+	Pinned bool
+}
+
+// vexSuppression records a finding suppressed by a VEX statement
+type vexSuppression struct {
+	ID            string `json:"id"`
+	Package       string `json:"package,omitempty"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
+	Statement     string `json:"statement"`
+	Document      string `json:"document"`
+}
+
+// vexDocument records a VEX document discovered for the subject
+type vexDocument struct {
+	Digest string `json:"digest"`
+	Used   bool   `json:"used"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// loadVEXStatements discovers the VEX documents attached to the subject and
+// returns their statements suppressing findings of the subject
+func loadVEXStatements(ctx context.Context, input *PluginConfig, subjectReference common.Reference, referrerStore referrerstore.ReferrerStore) (*vexResult, error) {
+	config := input.VEX
+	artifactTypes := config.ArtifactTypes
+	if len(artifactTypes) == 0 {
+		artifactTypes = []string{OpenVEXArtifactType}
+	}
+	subjectDesc, err := referrerStore.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve subject %s: %w", subjectReference, err)
+	}
+	vexReferrers, err := listReferrers(ctx, referrerStore, subjectReference, subjectDesc, artifactTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VEX documents of subject %s: %w", subjectReference, err)
+	}
+
+	vex := &vexResult{
+		statements:         []vexStatement{},
+		documents:          []vexDocument{},
+		referrers:          map[digest.Digest]ocispecs.ReferenceDescriptor{},
+		nestedVerification: !config.AllowUnsigned,
+	}
+	for _, vexReferrer := range vexReferrers {
+		document := vexDocument{Digest: vexReferrer.Digest.String()}
+		if !config.AllowUnsigned {
+			signed, err := hasSignature(ctx, config, referrerStore, subjectReference, vexReferrer)
+			if err != nil {
+				return nil, err
+			}
+			if !signed {
+				document.Reason = "VEX document is not signed"
+				vex.documents = append(vex.documents, document)
+				continue
+			}
+		}
+
+		parse, ok := input.reportParser(vexReferrer.ArtifactType)
+		if !ok {
+			document.Reason = fmt.Sprintf("unsupported VEX artifact type %s", vexReferrer.ArtifactType)
+			vex.documents = append(vex.documents, document)
+			continue
+		}
+		report, err := readVEXDocument(ctx, referrerStore, subjectReference, vexReferrer, parse)
+		if err != nil {
+			document.Reason = err.Error()
+			vex.documents = append(vex.documents, document)
+			continue
+		}
+		for _, finding := range report.Findings {
+			if (finding.Status == formats.StatusNotAffected || finding.Status == formats.StatusFixed) && appliesToSubject(finding, subjectReference, subjectDesc.Digest) {
+				vex.statements = append(vex.statements, vexStatement{
+					Finding:  finding,
+					Document: vexReferrer.Digest,
+					Pinned:   pinnedToSubject(finding, subjectDesc.Digest),
+				})
+			}
+		}
+		document.Used = true
+		vex.documents = append(vex.documents, document)
+		vex.referrers[vexReferrer.Digest] = vexReferrer
+	}
+	return vex, nil
+}
+
+// listReferrers returns all referrers of the subject with the given artifact types
+func listReferrers(ctx context.Context, referrerStore referrerstore.ReferrerStore, subjectReference common.Reference, subjectDesc *ocispecs.SubjectDescriptor, artifactTypes []string) ([]ocispecs.ReferenceDescriptor, error) {
+	referrers := []ocispecs.ReferenceDescriptor{}
+	var continuationToken string
+	for {
+		result, err := referrerStore.ListReferrers(ctx, subjectReference, artifactTypes, continuationToken, subjectDesc)
+		if err != nil {
+			return nil, err
+		}
+		// not all stores filter by artifact types
+		for _, referrer := range result.Referrers {
+			for _, artifactType := range artifactTypes {
+				if referrer.ArtifactType == artifactType {
+					referrers = append(referrers, referrer)
+					break
+				}
+			}
+		}
+		continuationToken = result.NextToken
+		if continuationToken == "" {
+			return referrers, nil
+		}
+	}
+}
+
+// hasSignature returns true if the VEX document has at least one signature.
+// The signature is not verified by the plugin: it only skips unsigned VEX
+// documents, the executor verifies the signatures of the used documents.
+func hasSignature(ctx context.Context, config *VEXConfig, referrerStore referrerstore.ReferrerStore, subjectReference common.Reference, vexReferrer ocispecs.ReferenceDescriptor) (bool, error) {
+	signatureArtifactTypes := config.SignatureArtifactTypes
+	if len(signatureArtifactTypes) == 0 {
+		signatureArtifactTypes = []string{NotationSignatureArtifactType, CosignSignatureArtifactType}
+	}
+	vexReference := common.Reference{
+		Path:     subjectReference.Path,
+		Digest:   vexReferrer.Digest,
+		Original: fmt.Sprintf("%s@%s", subjectReference.Path, vexReferrer.Digest),
+	}
+	signatures, err := listReferrers(ctx, referrerStore, vexReference, &ocispecs.SubjectDescriptor{Descriptor: vexReferrer.Descriptor}, signatureArtifactTypes)
+	if err != nil {
+		return false, fmt.Errorf("failed to list signatures of VEX document %s: %w", vexReferrer.Digest, err)
+	}
+	return len(signatures) > 0, nil
+}
+
+// readVEXDocument fetches and parses the VEX document
+func readVEXDocument(ctx context.Context, referrerStore referrerstore.ReferrerStore, subjectReference common.Reference, vexReferrer ocispecs.ReferenceDescriptor, parse func([]byte) (*formats.Report, error)) (*formats.Report, error) {
+	manifest, err := referrerStore.GetReferenceManifest(ctx, subjectReference, vexReferrer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch VEX document manifest: %w", err)
+	}
+	if len(manifest.Blobs) == 0 {
+		return nil, fmt.Errorf("no layers found in VEX document manifest")
+	}
+	blob, err := referrerStore.GetBlobContent(ctx, subjectReference, manifest.Blobs[0].Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch VEX document: %w", err)
+	}
+	return parse(blob)
+}
+
+// appliesToSubject returns true if the product of the statement identifies the
+// subject, either by the image digest or, for products without digest, by the
+// repository_url qualifier naming the repository of the subject
+func appliesToSubject(statement formats.Finding, subjectReference common.Reference, subjectDigest digest.Digest) bool {
+	if !strings.HasPrefix(statement.Product, ociPackageURLPrefix) {
+		return false
+	}
+	if _, err := digest.Parse(formats.PackageURLVersion(statement.Product)); err == nil {
+		return pinnedToSubject(statement, subjectDigest)
+	}
+	repository := formats.PackageURLQualifier(statement.Product, repositoryURLQualifier)
+	if _, withoutScheme, ok := strings.Cut(repository, "://"); ok {
+		repository = withoutScheme
+	}
+	repository = strings.TrimSuffix(repository, "/")
+	return repository != "" && strings.EqualFold(repository, subjectReference.Path)
+}
+
+// pinnedToSubject returns true if the product of the statement is pinned to
+// the digest of the subject
+func pinnedToSubject(statement formats.Finding, subjectDigest digest.Digest) bool {
+	return strings.HasPrefix(statement.Product, ociPackageURLPrefix) && digest.Digest(formats.PackageURLVersion(statement.Product)) == subjectDigest
+}
+
+// packageMatches returns true if the statement applies to the package of the
+// finding. A finding of an unknown package is never suppressed, and a statement
+// without package applies to all packages only if it is pinned to the subject.
+func packageMatches(statement vexStatement, finding formats.Finding) bool {
+	if finding.PackageURL == "" && finding.PackageName == "" {
+		return false
+	}
+	if statement.PackageURL == "" {
+		return statement.Pinned
+	}
+	if finding.PackageURL != "" {
+		return formats.SamePackage(statement.PackageURL, finding.PackageURL)
+	}
+	if !strings.EqualFold(formats.PackageURLName(statement.PackageURL), finding.PackageName) {
+		return false
+	}
+	version := formats.PackageURLVersion(statement.PackageURL)
+	return version == "" || finding.PackageVersion == "" || version == finding.PackageVersion
+}
+
+// suppressFindings removes the findings suppressed by the VEX statements
+func suppressFindings(findings []formats.Finding, statements []vexStatement) ([]formats.Finding, []vexSuppression) {
+	remaining := []formats.Finding{}
+	suppressions := []vexSuppression{}
+	for _, finding := range findings {
+		statement, ok := findStatement(statements, finding.IDs(), func(s vexStatement) bool {
+			return packageMatches(s, finding)
+		})
+		if !ok {
+			remaining = append(remaining, finding)
+			continue
+		}
+		pkg := finding.PackageURL
+		if pkg == "" {
+			pkg = finding.PackageName
+		}
+		suppressions = append(suppressions, newVEXSuppression(finding.ID, pkg, statement))
+	}
+	return remaining, suppressions
+}
+
+// findStatement returns the first statement with one of the ids accepted by match
+func findStatement(statements []vexStatement, ids []string, match func(vexStatement) bool) (vexStatement, bool) {
+	for _, statement := range statements {
+		for _, id := range ids {
+			if statement.HasID(id) && match(statement) {
+				return statement, true
+			}
+		}
+	}
+	return vexStatement{}, false
+}
+
+func newVEXSuppression(id string, pkg string, statement vexStatement) vexSuppression {
+	return vexSuppression{
+		ID:            id,
+		Package:       pkg,
+		Status:        statement.Status,
+		Justification: statement.Justification,
+		Statement:     statement.Statement,
+		Document:      statement.Document.String(),
+	}
+}
+
+// withVEXExtensions adds the VEX suppressions and documents to the extensions
+// of the verifier result
+func withVEXExtensions(result *verifier.VerifierResult, vex *vexResult, suppressions []vexSuppression) *verifier.VerifierResult {
+	if result == nil || vex == nil {
+		return result
+	}
+	extensions, ok := result.Extensions.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	if len(suppressions) > 0 {
+		extensions[VEXSuppressions] = suppressions
+	}
+	if len(vex.documents) > 0 {
+		extensions[VEXDocuments] = vex.documents
+	}
+	if nestedSubjects := vex.nestedSubjects(suppressions); len(nestedSubjects) > 0 {
+		extensions[verifier.NestedSubjectsExtension] = nestedSubjects
+	}
+	return result
+}
+
+// nestedSubjects returns the VEX documents which suppressed findings if they
+// must pass nested verification
+func (vex *vexResult) nestedSubjects(suppressions []vexSuppression) []ocispecs.ReferenceDescriptor {
+	nestedSubjects := []ocispecs.ReferenceDescriptor{}
+	if !vex.nestedVerification {
+		return nestedSubjects
+	}
+	added := map[string]bool{}
+	for _, suppression := range suppressions {
+		if added[suppression.Document] {
+			continue
+		}
+		added[suppression.Document] = true
+		if referrer, ok := vex.referrers[digest.Digest(suppression.Document)]; ok {
+			nestedSubjects = append(nestedSubjects, referrer)
+		}
+	}
+	return nestedSubjects
+}
+
+// getStatements returns the statements of the VEX result, nil if VEX is not configured
+func (vex *vexResult) getStatements() []vexStatement {
+	if vex == nil {
+		return nil
+	}
+	return vex.statements
+}
@@ -0,0 +1,520 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/owenrumney/go-sarif/v2/sarif"
+	"github.com/ratify-project/ratify/pkg/common"
+	"github.com/ratify-project/ratify/pkg/ocispecs"
+	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
+	"github.com/ratify-project/ratify/pkg/verifier"
+	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/formats"
+)
+
+// vexSubjectDigest is the product digest of the statements in testdata/openvex.json
+const vexSubjectDigest = digest.Digest("sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1")
+
+const sampleVEXDocument string = `{
+	"@context": "https://openvex.dev/ns/v0.2.0",
+	"@id": "https://openvex.dev/docs/public/vex-busybox",
+	"author": "Ratify",
+	"timestamp": "2024-05-01T10:00:00Z",
+	"version": 1,
+	"statements": [
+		{
+			"vulnerability": {
+				"name": "CVE-2022-48174"
+			},
+			"products": [
+				{
+					"@id": "pkg:oci/alpine@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1",
+					"subcomponents": [
+						{
+							"@id": "pkg:apk/alpine/busybox@1.36.1-r0"
+						}
+					]
+				}
+			],
+			"status": "not_affected",
+			"justification": "vulnerable_code_not_present"
+		}
+	]
+}`
+
+// newVEXTestStore creates a store with a VEX document attached to the subject
+// and optionally signed
+func newVEXTestStore(t *testing.T, subjectDigest digest.Digest, vexDocument []byte, signed bool) *mocks.MemoryTestStore {
+	t.Helper()
+	vexManifestDigest := digest.FromString("vex_manifest")
+	vexBlobDigest := digest.FromBytes(vexDocument)
+	reportManifestDigest := digest.FromString("report_manifest")
+	store := &mocks.MemoryTestStore{
+		Subjects: map[digest.Digest]*ocispecs.SubjectDescriptor{
+			subjectDigest: {Descriptor: oci.Descriptor{Digest: subjectDigest}},
+		},
+		Referrers: map[digest.Digest][]ocispecs.ReferenceDescriptor{
+			subjectDigest: {
+				{Descriptor: oci.Descriptor{Digest: reportManifestDigest}, ArtifactType: TrivyArtifactType},
+				{Descriptor: oci.Descriptor{Digest: vexManifestDigest}, ArtifactType: OpenVEXArtifactType},
+			},
+		},
+		Manifests: map[digest.Digest]ocispecs.ReferenceManifest{
+			vexManifestDigest: {Blobs: []oci.Descriptor{{Digest: vexBlobDigest}}},
+		},
+		Blobs: map[digest.Digest][]byte{vexBlobDigest: vexDocument},
+	}
+	if signed {
+		store.Referrers[vexManifestDigest] = []ocispecs.ReferenceDescriptor{
+			{Descriptor: oci.Descriptor{Digest: digest.FromString("signature")}, ArtifactType: NotationSignatureArtifactType},
+		}
+	}
+	return store
+}
+
+// TestLoadVEXStatements tests the loadVEXStatements function
+func TestLoadVEXStatements(t *testing.T) {
+	vexDocument, err := os.ReadFile(filepath.Join("formats", "testdata", "openvex.json"))
+	if err != nil {
+		t.Fatalf("failed to read VEX document: %v", err)
+	}
+	tests := []struct {
+		name               string
+		config             VEXConfig
+		subjectDigest      digest.Digest
+		document           []byte
+		signed             bool
+		wantStatements     int
+		wantUsed           bool
+		wantDocumentReason string
+	}{
+		{
+			name:           "signed VEX document",
+			subjectDigest:  vexSubjectDigest,
+			document:       vexDocument,
+			signed:         true,
+			wantStatements: 1,
+			wantUsed:       true,
+		},
+		{
+			name:               "unsigned VEX document",
+			subjectDigest:      vexSubjectDigest,
+			document:           vexDocument,
+			wantDocumentReason: "VEX document is not signed",
+		},
+		{
+			name:           "unsigned VEX document allowed",
+			config:         VEXConfig{AllowUnsigned: true},
+			subjectDigest:  vexSubjectDigest,
+			document:       vexDocument,
+			wantStatements: 1,
+			wantUsed:       true,
+		},
+		{
+			name:          "VEX document for another image",
+			subjectDigest: digest.FromString("other_subject"),
+			document:      vexDocument,
+			signed:        true,
+			wantUsed:      true,
+		},
+		{
+			name:               "signature of unsupported type",
+			config:             VEXConfig{SignatureArtifactTypes: []string{CosignSignatureArtifactType}},
+			subjectDigest:      vexSubjectDigest,
+			document:           vexDocument,
+			signed:             true,
+			wantDocumentReason: "VEX document is not signed",
+		},
+		{
+			name:               "invalid VEX document",
+			subjectDigest:      vexSubjectDigest,
+			document:           []byte("invalid"),
+			signed:             true,
+			wantDocumentReason: "invalid character 'i' looking for beginning of value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newVEXTestStore(t, tt.subjectDigest, tt.document, tt.signed)
+			subjectRef := common.Reference{Path: "test_subject_path", Digest: tt.subjectDigest}
+			vex, err := loadVEXStatements(context.Background(), &PluginConfig{VEX: &tt.config}, subjectRef, store)
+			if err != nil {
+				t.Fatalf("loadVEXStatements() unexpected error: %v", err)
+			}
+			if len(vex.statements) != tt.wantStatements {
+				t.Fatalf("loadVEXStatements() statements = %d, want %d", len(vex.statements), tt.wantStatements)
+			}
+			if len(vex.documents) != 1 {
+				t.Fatalf("loadVEXStatements() documents = %d, want 1", len(vex.documents))
+			}
+			if vex.documents[0].Used != tt.wantUsed {
+				t.Fatalf("loadVEXStatements() document used = %t, want %t", vex.documents[0].Used, tt.wantUsed)
+			}
+			if vex.documents[0].Reason != tt.wantDocumentReason {
+				t.Fatalf("loadVEXStatements() document reason = %s, want %s", vex.documents[0].Reason, tt.wantDocumentReason)
+			}
+		})
+	}
+}
+
+// TestLoadVEXStatements_SubjectNotFound tests that an unresolvable subject is an error
+func TestLoadVEXStatements_SubjectNotFound(t *testing.T) {
+	store := &mocks.MemoryTestStore{}
+	subjectRef := common.Reference{Path: "test_subject_path", Digest: vexSubjectDigest}
+	if _, err := loadVEXStatements(context.Background(), &PluginConfig{VEX: &VEXConfig{}}, subjectRef, store); err == nil {
+		t.Fatalf("loadVEXStatements() expected error for unknown subject")
+	}
+}
+
+// TestAppliesToSubject tests the appliesToSubject function
+func TestAppliesToSubject(t *testing.T) {
+	subjectRef := common.Reference{Path: "registry.io/library/alpine", Digest: vexSubjectDigest}
+	tests := []struct {
+		name    string
+		product string
+		want    bool
+	}{
+		{
+			name:    "pinned to the subject digest",
+			product: "pkg:oci/alpine@sha256%3A82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1",
+			want:    true,
+		},
+		{
+			name:    "pinned to another digest",
+			product: "pkg:oci/alpine@" + digest.FromString("other_subject").String() + "?repository_url=registry.io/library/alpine",
+		},
+		{
+			name:    "repository of the subject",
+			product: "pkg:oci/alpine?repository_url=https://registry.io/library/alpine&tag=3.18",
+			want:    true,
+		},
+		{
+			name:    "other repository",
+			product: "pkg:oci/alpine?repository_url=registry.io/other/alpine",
+		},
+		{
+			name:    "image name without repository",
+			product: "pkg:oci/alpine",
+		},
+		{
+			name:    "not an image",
+			product: "pkg:apk/alpine/libcrypto3@3.1.0-r4",
+		},
+		{
+			name: "no product",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appliesToSubject(formats.Finding{Product: tt.product}, subjectRef, vexSubjectDigest); got != tt.want {
+				t.Fatalf("appliesToSubject() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSuppressFindings tests the suppressFindings function
+func TestSuppressFindings(t *testing.T) {
+	statements := []vexStatement{
+		{
+			Finding: formats.Finding{
+				ID:         "CVE-2023-2650",
+				Aliases:    []string{"GHSA-gqxg-9vcw-4w8f"},
+				PackageURL: "pkg:apk/alpine/libcrypto3@3.1.0-r4",
+				Status:     formats.StatusNotAffected,
+			},
+			Document: digest.FromString("vex"),
+		},
+		{
+			Finding: formats.Finding{
+				ID:     "CVE-2023-5363",
+				Status: formats.StatusNotAffected,
+			},
+			Document: digest.FromString("vex"),
+			Pinned:   true,
+		},
+		{
+			Finding: formats.Finding{
+				ID:     "CVE-2023-5678",
+				Status: formats.StatusNotAffected,
+			},
+			Document: digest.FromString("vex"),
+		},
+	}
+	tests := []struct {
+		name           string
+		finding        formats.Finding
+		wantSuppressed bool
+	}{
+		{
+			name:           "matching id and package",
+			finding:        formats.Finding{ID: "CVE-2023-2650", PackageURL: "pkg:apk/alpine/libcrypto3@3.1.0-r4?arch=x86_64"},
+			wantSuppressed: true,
+		},
+		{
+			name:           "matching alias",
+			finding:        formats.Finding{ID: "GHSA-gqxg-9vcw-4w8f", PackageURL: "pkg:apk/alpine/libcrypto3@3.1.0-r4"},
+			wantSuppressed: true,
+		},
+		{
+			name:           "matching id and package name",
+			finding:        formats.Finding{ID: "CVE-2023-2650", PackageName: "libcrypto3", PackageVersion: "3.1.0-r4"},
+			wantSuppressed: true,
+		},
+		{
+			name:    "matching package name of other version",
+			finding: formats.Finding{ID: "CVE-2023-2650", PackageName: "libcrypto3", PackageVersion: "3.1.1-r0"},
+		},
+		{
+			name:    "matching id of unknown package",
+			finding: formats.Finding{ID: "CVE-2023-2650"},
+		},
+		{
+			name:    "other package",
+			finding: formats.Finding{ID: "CVE-2023-2650", PackageURL: "pkg:apk/alpine/libssl3@3.1.0-r4"},
+		},
+		{
+			name:    "other package name",
+			finding: formats.Finding{ID: "CVE-2023-2650", PackageName: "libssl3"},
+		},
+		{
+			name:    "other vulnerability",
+			finding: formats.Finding{ID: "CVE-2023-1234", PackageURL: "pkg:apk/alpine/libcrypto3@3.1.0-r4"},
+		},
+		{
+			name:           "statement pinned to the subject without package",
+			finding:        formats.Finding{ID: "CVE-2023-5363", PackageURL: "pkg:apk/alpine/libssl3@3.1.0-r4"},
+			wantSuppressed: true,
+		},
+		{
+			name:    "statement pinned to the subject without package for unknown package",
+			finding: formats.Finding{ID: "CVE-2023-5363"},
+		},
+		{
+			name:    "statement for the subject repository without package",
+			finding: formats.Finding{ID: "CVE-2023-5678", PackageURL: "pkg:apk/alpine/libssl3@3.1.0-r4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining, suppressions := suppressFindings([]formats.Finding{tt.finding}, statements)
+			if tt.wantSuppressed && (len(remaining) != 0 || len(suppressions) != 1) {
+				t.Fatalf("suppressFindings() expected finding to be suppressed")
+			}
+			if !tt.wantSuppressed && (len(remaining) != 1 || len(suppressions) != 0) {
+				t.Fatalf("suppressFindings() expected finding not to be suppressed")
+			}
+		})
+	}
+}
+
+// TestSuppressFindings_Sarif tests the suppression of the findings of a sarif
+// report
+func TestSuppressFindings_Sarif(t *testing.T) {
+	report, err := formats.ParseOpenVEX([]byte(sampleVEXDocument))
+	if err != nil {
+		t.Fatalf("failed to parse VEX document: %v", err)
+	}
+	statements := []vexStatement{{Finding: report.Findings[0], Document: digest.FromString("vex")}}
+
+	sarifReport, err := sarif.FromBytes([]byte(sampleSarifReport))
+	if err != nil {
+		t.Fatalf("failed to parse sarif report: %v", err)
+	}
+	findings, failure := sarifReportFindings(&PluginConfig{}, "test_verifier", "", GrypeScannerName, sarifReport, time.Now())
+	if failure != nil {
+		t.Fatalf("sarifReportFindings() unexpected failure: %+v", failure)
+	}
+	remaining, suppressions := suppressFindings(findings, statements)
+	if len(suppressions) != 1 || suppressions[0].ID != "CVE-2022-48174" || suppressions[0].Package != "busybox" {
+		t.Fatalf("suppressFindings() suppressions = %+v, want CVE-2022-48174", suppressions)
+	}
+	if len(remaining) != 0 {
+		t.Fatalf("suppressFindings() expected no remaining findings")
+	}
+}
+
+// TestSuppressFindings_SarifPackage tests that the findings of sarif results
+// are only suppressed by statements of their package
+func TestSuppressFindings_SarifPackage(t *testing.T) {
+	trivyMessage := "Package: busybox\nInstalled Version: 1.36.1-r0\nVulnerability CVE-2022-48174\nSeverity: CRITICAL\nFixed Version: 1.36.1-r1"
+	tests := []struct {
+		name           string
+		ruleID         string
+		message        string
+		packageURL     string
+		wantSuppressed bool
+	}{
+		{
+			name:           "trivy result of the package",
+			ruleID:         "CVE-2022-48174",
+			message:        trivyMessage,
+			packageURL:     "pkg:apk/alpine/busybox@1.36.1-r0",
+			wantSuppressed: true,
+		},
+		{
+			name:       "trivy result of another package",
+			ruleID:     "CVE-2022-48174",
+			message:    trivyMessage,
+			packageURL: "pkg:apk/alpine/ssl_client@1.36.1-r0",
+		},
+		{
+			name:       "trivy result of another version",
+			ruleID:     "CVE-2022-48174",
+			message:    trivyMessage,
+			packageURL: "pkg:apk/alpine/busybox@1.36.1-r2",
+		},
+		{
+			name:       "result of unknown package",
+			ruleID:     "CVE-2022-48174",
+			message:    "Vulnerability CVE-2022-48174",
+			packageURL: "pkg:apk/alpine/busybox@1.36.1-r0",
+		},
+		{
+			name:       "grype result of another package",
+			ruleID:     "CVE-2022-48174-ssl_client",
+			message:    "The path /lib/apk/db/installed reports ssl_client at version 1.36.1-r0  which is a vulnerable (apk) package installed in the container",
+			packageURL: "pkg:apk/alpine/busybox@1.36.1-r0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := []vexStatement{{
+				Finding:  formats.Finding{ID: "CVE-2022-48174", PackageURL: tt.packageURL, Status: formats.StatusNotAffected},
+				Document: digest.FromString("vex"),
+			}}
+			sarifReport := &sarif.Report{Runs: []*sarif.Run{{
+				Tool:    sarif.Tool{Driver: &sarif.ToolComponent{Name: TrivyScannerName}},
+				Results: []*sarif.Result{sarif.NewRuleResult(tt.ruleID).WithMessage(sarif.NewTextMessage(tt.message))},
+			}}}
+			findings, failure := sarifReportFindings(&PluginConfig{}, "test_verifier", "", TrivyScannerName, sarifReport, time.Now())
+			if failure != nil {
+				t.Fatalf("sarifReportFindings() unexpected failure: %+v", failure)
+			}
+			remaining, suppressions := suppressFindings(findings, statements)
+			if suppressed := len(suppressions) == 1 && len(remaining) == 0; suppressed != tt.wantSuppressed {
+				t.Fatalf("suppressFindings() suppressed = %t, want %t", suppressed, tt.wantSuppressed)
+			}
+		})
+	}
+}
+
+// TestVerifyReference_VEX tests that findings are suppressed by VEX documents
+// attached to the subject
+func TestVerifyReference_VEX(t *testing.T) {
+	trivyReport, err := os.ReadFile(filepath.Join("formats", "testdata", "trivy.json"))
+	if err != nil {
+		t.Fatalf("failed to read trivy report: %v", err)
+	}
+	vexDocument, err := os.ReadFile(filepath.Join("formats", "testdata", "openvex.json"))
+	if err != nil {
+		t.Fatalf("failed to read VEX document: %v", err)
+	}
+	tests := []struct {
+		name            string
+		stdinData       string
+		signed          bool
+		wantSuccess     bool
+		wantSuppression bool
+		wantNested      bool
+	}{
+		{
+			name:        "denied CVE without VEX",
+			stdinData:   `{"config":{"name": "vulnerabilityreport", "denylistCVEs": ["CVE-2023-2650"]}}`,
+			signed:      true,
+			wantSuccess: false,
+		},
+		{
+			name:            "denied CVE suppressed by signed VEX",
+			stdinData:       `{"config":{"name": "vulnerabilityreport", "denylistCVEs": ["CVE-2023-2650"], "vex": {}}}`,
+			signed:          true,
+			wantSuccess:     true,
+			wantSuppression: true,
+			wantNested:      true,
+		},
+		{
+			name:            "denied CVE suppressed by unsigned VEX allowed",
+			stdinData:       `{"config":{"name": "vulnerabilityreport", "denylistCVEs": ["CVE-2023-2650"], "vex": {"allowUnsigned": true}}}`,
+			wantSuccess:     true,
+			wantSuppression: true,
+		},
+		{
+			name:        "denied CVE not suppressed by unsigned VEX",
+			stdinData:   `{"config":{"name": "vulnerabilityreport", "denylistCVEs": ["CVE-2023-2650"], "vex": {}}}`,
+			wantSuccess: false,
+		},
+		{
+			name:            "affected CVE not suppressed by VEX",
+			stdinData:       `{"config":{"name": "vulnerabilityreport", "denylistCVEs": ["CVE-2023-5363"], "vex": {}}}`,
+			signed:          true,
+			wantSuccess:     false,
+			wantSuppression: true,
+			wantNested:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newVEXTestStore(t, vexSubjectDigest, vexDocument, tt.signed)
+			reportManifestDigest := digest.FromString("report_manifest")
+			reportBlobDigest := digest.FromBytes(trivyReport)
+			store.Manifests[reportManifestDigest] = ocispecs.ReferenceManifest{Blobs: []oci.Descriptor{{Digest: reportBlobDigest}}}
+			store.Blobs[reportBlobDigest] = trivyReport
+
+			cmdArgs := skel.CmdArgs{
+				Version:   "1.0.0",
+				Subject:   "test_subject",
+				StdinData: []byte(tt.stdinData),
+			}
+			subjectRef := common.Reference{Path: "test_subject_path", Digest: vexSubjectDigest, Original: "test_subject"}
+			refDesc := ocispecs.ReferenceDescriptor{
+				Descriptor: oci.Descriptor{
+					Digest: reportManifestDigest,
+					Annotations: map[string]string{
+						DefaultCreatedAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+				ArtifactType: TrivyArtifactType,
+			}
+			result, err := VerifyReference(&cmdArgs, subjectRef, refDesc, store)
+			if err != nil {
+				t.Fatalf("VerifyReference() unexpected error: %v", err)
+			}
+			if result.IsSuccess != tt.wantSuccess {
+				t.Fatalf("VerifyReference() success = %t, want %t: %s %s", result.IsSuccess, tt.wantSuccess, result.Message, result.ErrorReason)
+			}
+			extensions, _ := json.Marshal(result.Extensions)
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(extensions, &decoded); err != nil {
+				t.Fatalf("failed to decode extensions: %v", err)
+			}
+			if _, ok := decoded[VEXSuppressions]; ok != tt.wantSuppression {
+				t.Fatalf("VerifyReference() extensions = %s, want suppressions %t", extensions, tt.wantSuppression)
+			}
+			if _, ok := decoded[verifier.NestedSubjectsExtension]; ok != tt.wantNested {
+				t.Fatalf("VerifyReference() extensions = %s, want nested subjects %t", extensions, tt.wantNested)
+			}
+		})
+	}
+}
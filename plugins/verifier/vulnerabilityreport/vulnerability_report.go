@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	_ "github.com/ratify-project/ratify/pkg/referrerstore/oras"
 	"github.com/ratify-project/ratify/pkg/verifier"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/formats"
 	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/schemavalidation"
 )
 
@@ -41,15 +43,48 @@ import (
 var embeddedFS embed.FS
 
 const (
-	SarifArtifactType        string = "application/sarif+json"
+	SarifArtifactType string = "application/sarif+json"
+	// The scanners and VEX tools do not define artifact types for reports in
+	// their native formats. The following artifact types are Ratify conventions,
+	// reports attached with other artifact types are mapped to their format
+	// with the reportFormats config.
+	TrivyArtifactType        string = "application/trivy+json"
+	GrypeArtifactType        string = "application/grype+json"
+	OpenVEXArtifactType      string = "application/openvex+json"
+	CSAFArtifactType         string = "application/csaf+json"
+	TrivyFormat              string = "trivy"
+	GrypeFormat              string = "grype"
+	OpenVEXFormat            string = "openvex"
+	CSAFFormat               string = "csaf"
 	SarifOfflineFilePath     string = "schemavalidation/schemas/sarif-2.1.0.json"
 	TrivyScannerName         string = "trivy"
 	GrypeScannerName         string = "grype"
 	CreatedAnnotation        string = "createdAt"
 	DefaultCreatedAnnotation string = imagespec.AnnotationCreated
 	SeverityRegex                   = `Severity:\s*(\w+)`
+	TrivyPackageRegex               = `Package:\s*(\S+)\s+Installed Version:\s*(\S+)`
+	GrypePackageRegex               = `reports (\S+) at version (\S+)`
+	SecuritySeverityProperty string = "security-severity"
 )
 
+// reportParsers are the parsers of the report formats that are normalized into
+// findings instead of being validated against a json schema
+var reportParsers = map[string]func([]byte) (*formats.Report, error){
+	TrivyFormat:   formats.ParseTrivy,
+	GrypeFormat:   formats.ParseGrype,
+	OpenVEXFormat: formats.ParseOpenVEX,
+	CSAFFormat:    formats.ParseCSAF,
+}
+
+// defaultReportFormats are the formats of the reports by the artifact types
+// used by convention
+var defaultReportFormats = map[string]string{
+	TrivyArtifactType:   TrivyFormat,
+	GrypeArtifactType:   GrypeFormat,
+	OpenVEXArtifactType: OpenVEXFormat,
+	CSAFArtifactType:    CSAFFormat,
+}
+
 type PluginConfig struct {
 	Name                  string   `json:"name"`
 	Type                  string   `json:"type"`
@@ -59,6 +94,16 @@ type PluginConfig struct {
 	DisallowedSeverities  []string `json:"disallowedSeverities,omitempty"`
 	Passthrough           bool     `json:"passthrough,omitempty"`
 	DenylistCVEs          []string `json:"denylistCVEs,omitempty"`
+	// MaximumCVSS fails the verification if any finding has a CVSS base score
+	// above the threshold
+	MaximumCVSS *float64 `json:"maximumCVSS,omitempty"`
+	// VEX enables the suppression of findings based on the VEX documents
+	// attached to the subject
+	VEX *VEXConfig `json:"vex,omitempty"`
+	// ReportFormats maps the artifact types of reports to their format, one
+	// of trivy, grype, openvex or csaf, in addition to the artifact types
+	// used by convention
+	ReportFormats map[string]string `json:"reportFormats,omitempty"`
 }
 
 type PluginInputConfig struct {
@@ -75,10 +120,28 @@ func parseInput(stdin []byte) (*PluginConfig, error) {
 	if err := json.Unmarshal(stdin, &conf); err != nil {
 		return nil, fmt.Errorf("failed to parse stdin for the input: %w", err)
 	}
+	for artifactType, format := range conf.Config.ReportFormats {
+		if _, ok := reportParsers[format]; !ok {
+			return nil, fmt.Errorf("unsupported format %s of artifact type %s, supported formats are %s, %s, %s and %s", format, artifactType, TrivyFormat, GrypeFormat, OpenVEXFormat, CSAFFormat)
+		}
+	}
 
 	return &conf.Config, nil
 }
 
+// reportParser returns the parser of the report format of the artifact type
+func (c *PluginConfig) reportParser(artifactType string) (func([]byte) (*formats.Report, error), bool) {
+	format, ok := c.ReportFormats[artifactType]
+	if !ok {
+		format, ok = defaultReportFormats[artifactType]
+	}
+	if !ok {
+		return nil, false
+	}
+	parse, ok := reportParsers[format]
+	return parse, ok
+}
+
 func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
 	input, err := parseInput(args.StdinData)
 	if err != nil {
@@ -196,23 +259,48 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 		return &result, nil
 	}
 
-	// validate json schema
-	if err := verifyJSONSchema(referenceDescriptor.ArtifactType, refBlob, input.SchemaURL); err != nil {
-		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Schema validation failed for digest:[%s],artifact type:[%s].", blobDesc.Digest, referenceDescriptor.ArtifactType)).WithError(err)
-		result := verifier.NewVerifierResult(
-			"",
-			input.Name,
-			verifierType,
-			"",
-			false,
-			&verifierErr,
-			map[string]interface{}{CreatedAnnotation: createdTime},
-		)
-		return &result, nil
+	// validate json schema of the reports which are not parsed into findings
+	parse, isParsed := input.reportParser(referenceDescriptor.ArtifactType)
+	if !isParsed {
+		if err := verifyJSONSchema(referenceDescriptor.ArtifactType, refBlob, input.SchemaURL); err != nil {
+			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail(fmt.Sprintf("Schema validation failed for digest:[%s],artifact type:[%s].", blobDesc.Digest, referenceDescriptor.ArtifactType)).WithError(err)
+			result := verifier.NewVerifierResult(
+				"",
+				input.Name,
+				verifierType,
+				"",
+				false,
+				&verifierErr,
+				map[string]interface{}{CreatedAnnotation: createdTime},
+			)
+			return &result, nil
+		}
+	}
+
+	// load the VEX documents of the subject unless the report is a VEX document
+	var vex *vexResult
+	if input.VEX != nil && !isVEXArtifactType(input.VEX, referenceDescriptor.ArtifactType) {
+		vex, err = loadVEXStatements(ctx, input, subjectReference, referrerStore)
+		if err != nil {
+			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to load VEX documents.").WithError(err)
+			result := verifier.NewVerifierResult(
+				"",
+				input.Name,
+				verifierType,
+				"",
+				false,
+				&verifierErr,
+				map[string]interface{}{CreatedAnnotation: createdTime},
+			)
+			return &result, nil
+		}
 	}
 
 	if referenceDescriptor.ArtifactType == SarifArtifactType {
-		return processSarifReport(input, input.Name, verifierType, refBlob, createdTime)
+		return processSarifReport(input, input.Name, verifierType, refBlob, createdTime, vex)
+	}
+	if isParsed {
+		return processReport(input, input.Name, verifierType, parse, refBlob, createdTime, vex)
 	}
 
 	result := verifier.NewVerifierResult(
@@ -229,7 +317,8 @@ func VerifyReference(args *skel.CmdArgs, subjectReference common.Reference, refe
 
 // verifyJSONSchema validates the json schema of the report
 // if schemaURL is empty, it will use the offline schema embedded in binary
-// currently only support for sarif reports
+// currently only support for sarif reports, the other supported report formats
+// are validated when they are parsed
 func verifyJSONSchema(artifactType string, refBlob []byte, schemaURL string) error {
 	if artifactType == SarifArtifactType {
 		// decide online or offline schema type
@@ -246,7 +335,8 @@ func verifyJSONSchema(artifactType string, refBlob []byte, schemaURL string) err
 }
 
 // processSarifReport processes the sarif report running individual validations as configured
-func processSarifReport(input *PluginConfig, verifierName string, verifierType string, blob []byte, createdTime time.Time) (*verifier.VerifierResult, error) {
+// on the findings normalized from its results
+func processSarifReport(input *PluginConfig, verifierName string, verifierType string, blob []byte, createdTime time.Time, vex *vexResult) (*verifier.VerifierResult, error) {
 	sarifReport, err := sarif.FromBytes(blob)
 	if err != nil {
 		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to parse sarif report.").WithError(err)
@@ -274,23 +364,74 @@ func processSarifReport(input *PluginConfig, verifierName string, verifierType s
 		)
 		return &result, nil
 	}
+	return processSarifResults(input, verifierName, verifierType, sarifReport, createdTime, vex)
+}
+
+// processSarifResults normalizes the results of the first run of the sarif
+// report into findings and validates them like the findings of the other
+// report formats, so that both yield the same result for the same
+// vulnerabilities
+func processSarifResults(input *PluginConfig, verifierName string, verifierType string, sarifReport *sarif.Report, createdTime time.Time, vex *vexResult) (*verifier.VerifierResult, error) {
 	scannerName := strings.ToLower(sarifReport.Runs[0].Tool.Driver.Name)
+	findings, failure := sarifReportFindings(input, verifierName, verifierType, scannerName, sarifReport, createdTime)
+	if failure != nil {
+		return failure, nil
+	}
+	findings, suppressions := suppressFindings(findings, vex.getStatements())
+	return validateFindings(input, verifierName, verifierType, scannerName, findings, createdTime, vex, suppressions)
+}
+
+// processReport processes the reports normalized into findings running
+// individual validations as configured. Only findings affecting the subject
+// are validated, findings suppressed by the VEX statements are removed before
+// validation.
+func processReport(input *PluginConfig, verifierName string, verifierType string, parse func([]byte) (*formats.Report, error), blob []byte, createdTime time.Time, vex *vexResult) (*verifier.VerifierResult, error) {
+	report, err := parse(blob)
+	if err != nil {
+		verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to parse vulnerability report.").WithError(err)
+		result := verifier.NewVerifierResult(
+			"",
+			verifierName,
+			verifierType,
+			"",
+			false,
+			&verifierErr,
+			map[string]interface{}{CreatedAnnotation: createdTime},
+		)
+		return &result, nil
+	}
+	findings, suppressions := suppressFindings(affectedFindings(report.Findings), vex.getStatements())
+	return validateFindings(input, verifierName, verifierType, report.Scanner, findings, createdTime, vex, suppressions)
+}
+
+// validateFindings runs the validations configured on the findings remaining
+// after the VEX suppressions
+func validateFindings(input *PluginConfig, verifierName string, verifierType string, scannerName string, findings []formats.Finding, createdTime time.Time, vex *vexResult, suppressions []vexSuppression) (*verifier.VerifierResult, error) {
 	if len(input.DenylistCVEs) > 0 {
-		verifierReport, err := verifyDenyListCVEs(input.Name, verifierType, scannerName, sarifReport, input.DenylistCVEs, createdTime)
+		verifierReport, err := verifyFindingsDenyListCVEs(verifierName, verifierType, scannerName, findings, input.DenylistCVEs, createdTime)
 		if err != nil {
 			return nil, err
 		}
 		if !verifierReport.IsSuccess {
-			return verifierReport, nil
+			return withVEXExtensions(verifierReport, vex, suppressions), nil
 		}
 	}
 	if len(input.DisallowedSeverities) > 0 {
-		verifierReport, err := verifyDisallowedSeverities(input.Name, verifierType, scannerName, sarifReport, input.DisallowedSeverities, createdTime)
+		verifierReport, err := verifyFindingsDisallowedSeverities(verifierName, verifierType, scannerName, findings, input.DisallowedSeverities, createdTime)
+		if err != nil {
+			return nil, err
+		}
+		if !verifierReport.IsSuccess {
+			return withVEXExtensions(verifierReport, vex, suppressions), nil
+		}
+	}
+	if input.MaximumCVSS != nil {
+		verifierReport, err := verifyMaximumCVSS(verifierName, verifierType, scannerName, findings, *input.MaximumCVSS, createdTime)
 		if err != nil {
 			return nil, err
 		}
 		if !verifierReport.IsSuccess {
-			return verifierReport, nil
+			return withVEXExtensions(verifierReport, vex, suppressions), nil
 		}
 	}
 
@@ -306,46 +447,43 @@ func processSarifReport(input *PluginConfig, verifierName string, verifierType s
 			"scanner":         scannerName,
 		},
 	)
-	return &result, nil
+	return withVEXExtensions(&result, vex, suppressions), nil
 }
 
-// verifyDenyListCVEs verifies that the report does not contain any deny-listed CVEs
-func verifyDenyListCVEs(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, denylistCVEs []string, createdTime time.Time) (*verifier.VerifierResult, error) {
-	denylistCVESet := make(map[string]bool)
-	denylistViolations := []string{}
-
-	// convert denylistCVEs to a set for easy lookup
-	for _, cve := range denylistCVEs {
-		denylistCVESet[strings.ToLower(cve)] = false
+// isVEXArtifactType returns true if the artifact type is a VEX document
+func isVEXArtifactType(config *VEXConfig, artifactType string) bool {
+	if artifactType == OpenVEXArtifactType || artifactType == CSAFArtifactType {
+		return true
 	}
-
-	// iterate over the results and check which cves are deny-listed
-	for _, result := range sarifReport.Runs[0].Results {
-		if result.RuleID == nil || *result.RuleID == "" {
-			verifierResult := verifier.NewVerifierResult(
-				"",
-				verifierName,
-				verifierType,
-				fmt.Sprintf("Rule id not found for result:[%v].", result),
-				false,
-				nil,
-				map[string]interface{}{
-					"scanner":         scannerName,
-					CreatedAnnotation: createdTime,
-				},
-			)
-			return &verifierResult, nil
+	for _, vexArtifactType := range config.ArtifactTypes {
+		if artifactType == vexArtifactType {
+			return true
 		}
-		ruleIDLower := strings.ToLower(*result.RuleID)
-		if _, ok := denylistCVESet[ruleIDLower]; ok {
-			denylistCVESet[ruleIDLower] = true
+	}
+	return false
+}
+
+// affectedFindings returns the findings with the affected status
+func affectedFindings(findings []formats.Finding) []formats.Finding {
+	affected := []formats.Finding{}
+	for _, finding := range findings {
+		if finding.Status == formats.StatusAffected {
+			affected = append(affected, finding)
 		}
 	}
+	return affected
+}
 
-	// iterate over the denylistCVESet and add the deny-listed cves to the list of violations
-	for cve, isDenylisted := range denylistCVESet {
-		if isDenylisted {
-			denylistViolations = append(denylistViolations, cve)
+// verifyFindingsDenyListCVEs verifies that the findings do not contain any deny-listed CVEs
+// a finding matches a deny-listed CVE by its id or any of its aliases
+func verifyFindingsDenyListCVEs(verifierName string, verifierType string, scannerName string, findings []formats.Finding, denylistCVEs []string, createdTime time.Time) (*verifier.VerifierResult, error) {
+	denylistViolations := []string{}
+	for _, cve := range denylistCVEs {
+		for _, finding := range findings {
+			if finding.HasID(cve) {
+				denylistViolations = append(denylistViolations, strings.ToLower(cve))
+				break
+			}
 		}
 	}
 
@@ -382,73 +520,18 @@ func verifyDenyListCVEs(verifierName string, verifierType string, scannerName st
 	return &result, nil
 }
 
-// verifyDisallowedSeverities verifies that the report does not contain any disallowed severity levels
-func verifyDisallowedSeverities(verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, disallowedSeverities []string, createdTime time.Time) (*verifier.VerifierResult, error) {
-	ruleMap := make(map[string]*sarif.ReportingDescriptor)
-	violatingRules := make(map[string]string)
-	// create a map of rule id to rule for easy lookup
-	for _, rule := range sarifReport.Runs[0].Tool.Driver.Rules {
-		ruleMap[rule.ID] = rule
-	}
-	// iterate over the results and check if the severity is disallowed
-	for _, result := range sarifReport.Runs[0].Results {
-		if result.RuleID == nil || *result.RuleID == "" {
-			verifierResult := verifier.NewVerifierResult(
-				"",
-				verifierName,
-				verifierType,
-				fmt.Sprintf("Rule id not found for result:[%v].", result),
-				false,
-				nil,
-				map[string]interface{}{
-					"scanner":         scannerName,
-					CreatedAnnotation: createdTime,
-				},
-			)
-			return &verifierResult, nil
-		}
-		rule, ok := ruleMap[*result.RuleID]
-		if !ok {
-			verifierResult := verifier.NewVerifierResult(
-				"",
-				verifierName,
-				verifierType,
-				fmt.Sprintf("Rule not found for result:[%v].", result),
-				false,
-				nil,
-				map[string]interface{}{
-					"scanner":         scannerName,
-					CreatedAnnotation: createdTime,
-				},
-			)
-			return &verifierResult, nil
-		}
-		severity, err := extractSeverity(scannerName, *rule)
-		if err != nil {
-			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to extract severity.").WithError(err)
-			verifierResult := verifier.NewVerifierResult(
-				"",
-				verifierName,
-				verifierType,
-				"",
-				false,
-				&verifierErr,
-				map[string]interface{}{
-					"scanner":         scannerName,
-					CreatedAnnotation: createdTime,
-				},
-			)
-			return &verifierResult, nil
-		}
-		// check if the severity is disallowed and add it to the map of violating CVE IDs
+// verifyFindingsDisallowedSeverities verifies that the findings do not contain any disallowed severity levels
+func verifyFindingsDisallowedSeverities(verifierName string, verifierType string, scannerName string, findings []formats.Finding, disallowedSeverities []string, createdTime time.Time) (*verifier.VerifierResult, error) {
+	violatingFindings := make(map[string]string)
+	for _, finding := range findings {
 		for _, disallowed := range disallowedSeverities {
-			if strings.EqualFold(severity, disallowed) {
-				violatingRules[rule.ID] = severity
+			if strings.EqualFold(finding.Severity, disallowed) {
+				violatingFindings[finding.ID] = finding.Severity
 			}
 		}
 	}
-	// if there are violating rules, return them as custom extension field
-	if len(violatingRules) > 0 {
+
+	if len(violatingFindings) > 0 {
 		result := verifier.NewVerifierResult(
 			"",
 			verifierName,
@@ -459,7 +542,7 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 			map[string]interface{}{
 				"scanner":              scannerName,
 				"disallowedSeverities": disallowedSeverities,
-				"severityViolations":   violatingRules,
+				"severityViolations":   violatingFindings,
 				CreatedAnnotation:      createdTime,
 			},
 		)
@@ -480,6 +563,140 @@ func verifyDisallowedSeverities(verifierName string, verifierType string, scanne
 	return &result, nil
 }
 
+// verifyMaximumCVSS verifies that no finding has a CVSS base score above the maximum
+// findings without CVSS score are not considered
+func verifyMaximumCVSS(verifierName string, verifierType string, scannerName string, findings []formats.Finding, maximumCVSS float64, createdTime time.Time) (*verifier.VerifierResult, error) {
+	violatingFindings := make(map[string]float64)
+	for _, finding := range findings {
+		if finding.CVSS > maximumCVSS && finding.CVSS > violatingFindings[finding.ID] {
+			violatingFindings[finding.ID] = finding.CVSS
+		}
+	}
+
+	if len(violatingFindings) > 0 {
+		result := verifier.NewVerifierResult(
+			"",
+			verifierName,
+			verifierType,
+			"Found CVSS scores above maximum. See extensions field for details.",
+			false,
+			nil,
+			map[string]interface{}{
+				"scanner":         scannerName,
+				"maximumCVSS":     maximumCVSS,
+				"cvssViolations":  violatingFindings,
+				CreatedAnnotation: createdTime,
+			},
+		)
+		return &result, nil
+	}
+	result := verifier.NewVerifierResult(
+		"",
+		verifierName,
+		verifierType,
+		"Validation succeeded",
+		true,
+		nil,
+		map[string]interface{}{
+			"scanner":         scannerName,
+			CreatedAnnotation: createdTime,
+		},
+	)
+	return &result, nil
+}
+
+// sarifReportFindings converts the results of the sarif report into findings.
+// The package of a result is parsed from the result message of trivy or grype.
+// Grype suffixes the rule id with the package name, the vulnerability id
+// without the suffix is the id of the finding and the rule id an alias. The
+// CVSS score is read from the security-severity property of the rule which is
+// set by both trivy and grype, the severity from the rule help text. Results
+// without rule id, or without rule and severity if severities are disallowed,
+// fail the verification as the configured validations cannot be applied.
+func sarifReportFindings(input *PluginConfig, verifierName string, verifierType string, scannerName string, sarifReport *sarif.Report, createdTime time.Time) ([]formats.Finding, *verifier.VerifierResult) {
+	failure := func(message string, verifierErr *re.Error) *verifier.VerifierResult {
+		result := verifier.NewVerifierResult(
+			"",
+			verifierName,
+			verifierType,
+			message,
+			false,
+			verifierErr,
+			map[string]interface{}{
+				"scanner":         scannerName,
+				CreatedAnnotation: createdTime,
+			},
+		)
+		return &result
+	}
+	findings := []formats.Finding{}
+	ruleMap := make(map[string]*sarif.ReportingDescriptor)
+	for _, rule := range sarifReport.Runs[0].Tool.Driver.Rules {
+		ruleMap[rule.ID] = rule
+	}
+	for _, result := range sarifReport.Runs[0].Results {
+		if result.RuleID == nil || *result.RuleID == "" {
+			if len(input.DenylistCVEs) > 0 || len(input.DisallowedSeverities) > 0 {
+				return nil, failure(fmt.Sprintf("Rule id not found for result:[%v].", result), nil)
+			}
+			continue
+		}
+		finding := formats.Finding{
+			ID:     *result.RuleID,
+			Status: formats.StatusAffected,
+		}
+		finding.PackageName, finding.PackageVersion = sarifResultPackage(result)
+		if id, ok := cutSuffixFold(finding.ID, "-"+finding.PackageName); finding.PackageName != "" && ok {
+			finding.Aliases = []string{finding.ID}
+			finding.ID = id
+		}
+		rule, ok := ruleMap[*result.RuleID]
+		if !ok {
+			if len(input.DisallowedSeverities) > 0 {
+				return nil, failure(fmt.Sprintf("Rule not found for result:[%v].", result), nil)
+			}
+			findings = append(findings, finding)
+			continue
+		}
+		if rule.Properties != nil {
+			if score, ok := rule.Properties[SecuritySeverityProperty].(string); ok {
+				finding.CVSS, _ = strconv.ParseFloat(score, 64)
+			}
+		}
+		severity, err := extractSeverity(scannerName, *rule)
+		if err != nil && len(input.DisallowedSeverities) > 0 {
+			verifierErr := re.ErrorCodeVerifyPluginFailure.WithDetail("Failed to extract severity.").WithError(err)
+			return nil, failure("", &verifierErr)
+		}
+		finding.Severity = severity
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// sarifResultPackage returns the name and version of the package of the sarif
+// result parsed from the result message of trivy or grype, empty if unknown
+func sarifResultPackage(result *sarif.Result) (string, string) {
+	if result.Message.Text == nil {
+		return "", ""
+	}
+	for _, packageRegex := range []string{TrivyPackageRegex, GrypePackageRegex} {
+		if match := regexp.MustCompile(packageRegex).FindStringSubmatch(*result.Message.Text); len(match) == 3 {
+			return match[1], match[2]
+		}
+	}
+	return "", ""
+}
+
+// cutSuffixFold returns s without the suffix, ignoring case, and true if s
+// ends with the suffix
+func cutSuffixFold(s, suffix string) (string, bool) {
+	if len(s) <= len(suffix) || !strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
+
 // extractSeverity extracts the severity from the rule help text using regex
 // relies on the help text being in the format "Severity: <severity>"
 // currently only supports trivy and grype scanners
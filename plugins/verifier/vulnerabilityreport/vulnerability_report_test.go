@@ -17,6 +17,9 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -27,6 +30,7 @@ import (
 	"github.com/ratify-project/ratify/pkg/ocispecs"
 	"github.com/ratify-project/ratify/pkg/referrerstore/mocks"
 	"github.com/ratify-project/ratify/pkg/verifier/plugin/skel"
+	"github.com/ratify-project/ratify/plugins/verifier/vulnerabilityreport/formats"
 )
 
 const sampleSarifReport string = `{
@@ -371,11 +375,25 @@ func TestProcessSarifReport(t *testing.T) {
 			},
 		},
 		{
-			name: "disallowed severity CVE found",
+			name: "deny list CVE found by vulnerability id of grype rule",
 			args: args{
 				input: PluginConfig{
 					Name:         "test_verifier",
 					DenylistCVEs: []string{"CVE-2022-48174"},
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Found denied CVEs. See extensions field for details.",
+				err:     nil,
+			},
+		},
+		{
+			name: "disallowed severity CVE found",
+			args: args{
+				input: PluginConfig{
+					Name:         "test_verifier",
+					DenylistCVEs: []string{"CVE-2021-1234"},
 					DisallowedSeverities: []string{
 						"critical",
 					},
@@ -392,7 +410,7 @@ func TestProcessSarifReport(t *testing.T) {
 			args: args{
 				input: PluginConfig{
 					Name:         "test_verifier",
-					DenylistCVEs: []string{"CVE-2022-48174"},
+					DenylistCVEs: []string{"CVE-2021-1234"},
 					DisallowedSeverities: []string{
 						"high",
 					},
@@ -404,10 +422,38 @@ func TestProcessSarifReport(t *testing.T) {
 				err:     nil,
 			},
 		},
+		{
+			name: "CVSS score above maximum found",
+			args: args{
+				input: PluginConfig{
+					Name:        "test_verifier",
+					MaximumCVSS: floatPtr(9.0),
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Found CVSS scores above maximum. See extensions field for details.",
+				err:     nil,
+			},
+		},
+		{
+			name: "CVSS score within maximum",
+			args: args{
+				input: PluginConfig{
+					Name:        "test_verifier",
+					MaximumCVSS: floatPtr(9.8),
+				},
+				blobContent: sampleSarifReport,
+			},
+			want: want{
+				message: "Validation succeeded",
+				err:     nil,
+			},
+		},
 	}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			verifierReport, err := processSarifReport(&tests[i].args.input, "sample_verifier", "", []byte(tt.args.blobContent), time.Now())
+			verifierReport, err := processSarifReport(&tests[i].args.input, "sample_verifier", "", []byte(tt.args.blobContent), time.Now(), nil)
 			if err != nil && err.Error() != tt.want.err.Error() {
 				t.Errorf("processSarifReport() error = %v, wantErr %v", err, tt.want.err)
 				return
@@ -423,8 +469,9 @@ func TestProcessSarifReport(t *testing.T) {
 	}
 }
 
-// TestVerifyDenyListCVEs tests the verifyDenyListCVEs function
-func TestVerifyDenyListCVEs(t *testing.T) {
+// TestProcessSarifResults_DenyListCVEs tests the deny list validation of the
+// sarif results
+func TestProcessSarifResults_DenyListCVEs(t *testing.T) {
 	validRuleID := "CVE-2021-1234"
 	type args struct {
 		denyListCVEs []string
@@ -534,22 +581,23 @@ func TestVerifyDenyListCVEs(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			verifierReport, err := verifyDenyListCVEs("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.denyListCVEs, time.Now())
+			verifierReport, err := processSarifResults(&PluginConfig{DenylistCVEs: tt.args.denyListCVEs}, "test_verifier", "", &tests[i].args.sarifReport, time.Now(), nil)
 			if err != nil && err.Error() != tt.want.err.Error() {
-				t.Fatalf("verifyDenyListCVEs() error = %v, wantErr %v", err, tt.want.err)
+				t.Fatalf("processSarifResults() error = %v, wantErr %v", err, tt.want.err)
 			}
 			if verifierReport.Message != tt.want.message {
-				t.Fatalf("verifyDenyListCVEs() verifier report message = %s, want %s", verifierReport.Message, tt.want.message)
+				t.Fatalf("processSarifResults() verifier report message = %s, want %s", verifierReport.Message, tt.want.message)
 			}
 			if verifierReport.ErrorReason != tt.want.errorReason {
-				t.Fatalf("verifyDenyListCVEs() verifier report error reaon = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
+				t.Fatalf("processSarifResults() verifier report error reaon = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
 			}
 		})
 	}
 }
 
-// TestVerifyDisallowedSeverities tests the verifyDisallowedSeverities function
-func TestVerifyDisallowedSeverities(t *testing.T) {
+// TestProcessSarifResults_DisallowedSeverities tests the disallowed severities
+// validation of the sarif results
+func TestProcessSarifResults_DisallowedSeverities(t *testing.T) {
 	validSeverityText := "Severity: HIGH"
 	invalidSeverityText := "invalid severity text"
 	validRuleID := "RULEID"
@@ -745,17 +793,17 @@ func TestVerifyDisallowedSeverities(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			verifierReport, err := verifyDisallowedSeverities("test_verifier", "", TrivyScannerName, &tests[i].args.sarifReport, tt.args.disallowedSeverities, time.Now())
+			verifierReport, err := processSarifResults(&PluginConfig{DisallowedSeverities: tt.args.disallowedSeverities}, "test_verifier", "", &tests[i].args.sarifReport, time.Now(), nil)
 			if err != nil && err.Error() != tt.want.err.Error() {
-				t.Fatalf("verifyDisallowedSeverities() error = %v, wantErr %v", err, tt.want.err)
+				t.Fatalf("processSarifResults() error = %v, wantErr %v", err, tt.want.err)
 				return
 			}
 			if verifierReport.Message != tt.want.message {
-				t.Fatalf("verifyDisallowedSeverities() verifier report message = %s, want %s", verifierReport.Message, tt.want.message)
+				t.Fatalf("processSarifResults() verifier report message = %s, want %s", verifierReport.Message, tt.want.message)
 				return
 			}
 			if verifierReport.ErrorReason != tt.want.errorReason {
-				t.Fatalf("verifyDisalowedServerities() verifier report error reason = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
+				t.Fatalf("processSarifResults() verifier report error reason = %s, want = %s", verifierReport.ErrorReason, tt.want.errorReason)
 			}
 		})
 	}
@@ -1003,3 +1051,195 @@ func TestValidateMaxiumAge(t *testing.T) {
 		})
 	}
 }
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// TestProcessReport tests the processReport function with the report formats
+// normalized into findings
+func TestProcessReport(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		artifactType    string
+		input           PluginConfig
+		message         string
+		errorReason     string
+		violationsKey   string
+		expectedScanner string
+	}{
+		{
+			name:         "invalid report",
+			file:         "csaf.json",
+			artifactType: TrivyArtifactType,
+			message:      "Failed to parse vulnerability report.",
+			errorReason:  "JSON document does not contain SchemaVersion field of a trivy report",
+		},
+		{
+			name:            "trivy validation succeeded",
+			file:            "trivy.json",
+			artifactType:    TrivyArtifactType,
+			input:           PluginConfig{DenylistCVEs: []string{"CVE-2023-0001"}, DisallowedSeverities: []string{"critical"}, MaximumCVSS: floatPtr(7.5)},
+			message:         "Validation succeeded",
+			expectedScanner: "trivy",
+		},
+		{
+			name:          "trivy disallowed severity found",
+			file:          "trivy.json",
+			artifactType:  TrivyArtifactType,
+			input:         PluginConfig{DisallowedSeverities: []string{"HIGH"}},
+			message:       "Found disallowed severities. See extensions field for details.",
+			violationsKey: "severityViolations",
+		},
+		{
+			name:          "grype denied CVE found by alias",
+			file:          "grype.json",
+			artifactType:  GrypeArtifactType,
+			input:         PluginConfig{DenylistCVEs: []string{"CVE-2023-44487"}},
+			message:       "Found denied CVEs. See extensions field for details.",
+			violationsKey: "cveViolations",
+		},
+		{
+			name:          "grype CVSS score above maximum found",
+			file:          "grype.json",
+			artifactType:  GrypeArtifactType,
+			input:         PluginConfig{MaximumCVSS: floatPtr(7.0)},
+			message:       "Found CVSS scores above maximum. See extensions field for details.",
+			violationsKey: "cvssViolations",
+		},
+		{
+			name:            "openvex not affected statements are ignored",
+			file:            "openvex.json",
+			artifactType:    OpenVEXArtifactType,
+			input:           PluginConfig{DenylistCVEs: []string{"CVE-2023-2650"}},
+			message:         "Validation succeeded",
+			expectedScanner: "openvex",
+		},
+		{
+			name:          "openvex affected statement denied",
+			file:          "openvex.json",
+			artifactType:  OpenVEXArtifactType,
+			input:         PluginConfig{DenylistCVEs: []string{"CVE-2023-5363"}},
+			message:       "Found denied CVEs. See extensions field for details.",
+			violationsKey: "cveViolations",
+		},
+		{
+			name:          "csaf disallowed severity found",
+			file:          "csaf.json",
+			artifactType:  CSAFArtifactType,
+			input:         PluginConfig{DisallowedSeverities: []string{"high"}},
+			message:       "Found disallowed severities. See extensions field for details.",
+			violationsKey: "severityViolations",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := os.ReadFile(filepath.Join("formats", "testdata", tt.file))
+			if err != nil {
+				t.Fatalf("error reading %s", tt.file)
+			}
+			verifierReport, err := processReport(&tt.input, "test_verifier", "", reportParsers[defaultReportFormats[tt.artifactType]], blob, time.Now(), nil)
+			if err != nil {
+				t.Fatalf("processReport() unexpected error = %v", err)
+			}
+			if verifierReport.Message != tt.message {
+				t.Fatalf("processReport() verifier report message = %s, want %s", verifierReport.Message, tt.message)
+			}
+			if verifierReport.ErrorReason != tt.errorReason {
+				t.Fatalf("processReport() verifier report error reason = %s, want %s", verifierReport.ErrorReason, tt.errorReason)
+			}
+			extensions := verifierReport.Extensions.(map[string]interface{})
+			if tt.violationsKey != "" {
+				if _, ok := extensions[tt.violationsKey]; !ok || verifierReport.IsSuccess {
+					t.Fatalf("processReport() expected extension %s in failed report", tt.violationsKey)
+				}
+			}
+			if tt.expectedScanner != "" && extensions["scanner"] != tt.expectedScanner {
+				t.Fatalf("processReport() scanner = %v, want %s", extensions["scanner"], tt.expectedScanner)
+			}
+		})
+	}
+}
+
+// TestReportParser tests the reportParser function with report formats
+// mapped by the config
+func TestReportParser(t *testing.T) {
+	input, err := parseInput([]byte(`{"config":{"name":"vulnerabilityreport","reportFormats":{"application/vnd.example.trivy+json":"trivy"}}}`))
+	if err != nil {
+		t.Fatalf("parseInput() unexpected error = %v", err)
+	}
+	for _, artifactType := range []string{"application/vnd.example.trivy+json", TrivyArtifactType, CSAFArtifactType} {
+		if _, ok := input.reportParser(artifactType); !ok {
+			t.Fatalf("reportParser() expected parser of artifact type %s", artifactType)
+		}
+	}
+	if _, ok := input.reportParser(SarifArtifactType); ok {
+		t.Fatalf("reportParser() expected no parser of artifact type %s", SarifArtifactType)
+	}
+
+	if _, err := parseInput([]byte(`{"config":{"name":"vulnerabilityreport","reportFormats":{"application/vnd.example+json":"cyclonedx"}}}`)); err == nil {
+		t.Fatalf("parseInput() expected error for unsupported report format")
+	}
+}
+
+// TestSarifReportFindings tests the sarifReportFindings function
+func TestSarifReportFindings(t *testing.T) {
+	sarifReport, err := sarif.FromString(sampleSarifReport)
+	if err != nil {
+		t.Fatalf("failed to parse sarif report: %v", err)
+	}
+	findings, failure := sarifReportFindings(&PluginConfig{}, "test_verifier", "", GrypeScannerName, sarifReport, time.Now())
+	if failure != nil {
+		t.Fatalf("sarifReportFindings() unexpected failure: %+v", failure)
+	}
+	expected := formats.Finding{
+		ID:             "CVE-2022-48174",
+		Aliases:        []string{"CVE-2022-48174-busybox"},
+		Severity:       "critical",
+		CVSS:           9.8,
+		PackageName:    "busybox",
+		PackageVersion: "1.36.1-r0",
+		Status:         formats.StatusAffected,
+	}
+	if len(findings) != 1 || !reflect.DeepEqual(findings[0], expected) {
+		t.Fatalf("sarifReportFindings() = %+v, want %+v", findings, expected)
+	}
+}
+
+// TestProcessSarifResults_MatchesNormalizedReport tests that a sarif report
+// and the native report of the same scan yield the same result
+func TestProcessSarifResults_MatchesNormalizedReport(t *testing.T) {
+	sarifReport, err := sarif.FromString(sampleSarifReport)
+	if err != nil {
+		t.Fatalf("failed to parse sarif report: %v", err)
+	}
+	nativeReport := func([]byte) (*formats.Report, error) {
+		return &formats.Report{Scanner: GrypeScannerName, Findings: []formats.Finding{{
+			ID:             "CVE-2022-48174",
+			Severity:       "critical",
+			CVSS:           9.8,
+			PackageName:    "busybox",
+			PackageVersion: "1.36.1-r0",
+			Status:         formats.StatusAffected,
+		}}}, nil
+	}
+	for _, input := range []PluginConfig{
+		{DenylistCVEs: []string{"CVE-2022-48174"}},
+		{DisallowedSeverities: []string{"critical"}},
+		{MaximumCVSS: floatPtr(9.0)},
+		{DenylistCVEs: []string{"CVE-2021-1234"}, DisallowedSeverities: []string{"high"}},
+	} {
+		sarifResult, err := processSarifResults(&input, "test_verifier", "", sarifReport, time.Now(), nil)
+		if err != nil {
+			t.Fatalf("processSarifResults() unexpected error = %v", err)
+		}
+		nativeResult, err := processReport(&input, "test_verifier", "", nativeReport, nil, time.Now(), nil)
+		if err != nil {
+			t.Fatalf("processReport() unexpected error = %v", err)
+		}
+		if sarifResult.IsSuccess != nativeResult.IsSuccess || sarifResult.Message != nativeResult.Message {
+			t.Fatalf("sarif result %+v differs from native result %+v for config %+v", sarifResult, nativeResult, input)
+		}
+	}
+}